@@ -0,0 +1,438 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package addressing allocates IP addresses to the links and nodes of a
+// topology, computes static routes along shortest paths, and renders both as
+// vendor configuration, so that a topology has working reachability without
+// running a routing protocol.
+//
+//	plan, err := addressing.Allocate(topo, addressing.DefaultPools)
+//	...
+//	// Every loopback is reachable from every node.
+//	plan.RouteAll()
+//	// Everything else is reachable through the egress node.
+//	plan.Route("egress", netip.MustParsePrefix("0.0.0.0/0"))
+//	if err := plan.Configure(topo); err != nil {
+//		...
+//	}
+package addressing
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"regexp"
+
+	gwpb "github.com/openconfig/kne/proto/gateway"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+// defaultAdvertise is the prefix advertised by GATEWAY nodes advertising
+// none.
+var defaultAdvertise = netip.MustParsePrefix("0.0.0.0/0")
+
+// Pools are the prefixes addresses are allocated from.  A pool that is not
+// valid, such as the zero netip.Prefix, is not used, so for example a Pools
+// with only Link4 set results in IPv4 links and no loopbacks.
+type Pools struct {
+	// Link4 and Link6 are divided into one /31 and one /127 per link.
+	Link4, Link6 netip.Prefix
+	// Loopback4 and Loopback6 provide one /32 and one /128 per node.
+	Loopback4, Loopback6 netip.Prefix
+}
+
+// DefaultPools are the pools used by kne when none are specified.
+var DefaultPools = Pools{
+	Link4:     netip.MustParsePrefix("192.168.0.0/16"),
+	Link6:     netip.MustParsePrefix("2001:db8:0:1::/64"),
+	Loopback4: netip.MustParsePrefix("10.255.0.0/16"),
+	Loopback6: netip.MustParsePrefix("2001:db8:0:ff::/64"),
+}
+
+// A Plan is the addresses and static routes of the nodes of a topology.
+type Plan struct {
+	// Nodes are the nodes of the topology, in topology order.
+	Nodes []*Node
+
+	nodes map[string]*Node
+}
+
+// A Node is a node of a topology and its addresses and routes.
+type Node struct {
+	Name   string
+	Vendor tpb.Vendor
+	// Loopback4 and Loopback6 are the loopback addresses of the node, with
+	// a /32 and /128 prefix length.  They are not valid if not allocated.
+	Loopback4, Loopback6 netip.Prefix
+	// Interfaces are the interfaces of the node on links, in link order.
+	Interfaces []*Interface
+	// Routes are the static routes of the node, in the order they were
+	// added.
+	Routes []*Route
+	// Advertise are the prefixes routed to the node by RouteAll besides its
+	// loopbacks.  They are the advertised prefixes of a GATEWAY node,
+	// 0.0.0.0/0 if it advertises none.
+	Advertise []netip.Prefix
+}
+
+// An Interface is one end of a link.
+type Interface struct {
+	// Key is the key of the interface in the node's interface map, such as
+	// eth1.
+	Key string
+	// Name is the vendor name of the interface, such as ethernet-1/1.  It is
+	// the name of the interface in the topology, if set, or else derived from
+	// Key for the vendor.
+	Name string
+	// Addr4 and Addr6 are the addresses of the interface with their prefix
+	// length.  They are not valid if not allocated.
+	Addr4, Addr6 netip.Prefix
+	// Peer and PeerKey are the node and interface key at the other end of
+	// the link.
+	Peer, PeerKey string
+	// PeerAddr4 and PeerAddr6 are the addresses at the other end of the
+	// link.
+	PeerAddr4, PeerAddr6 netip.Addr
+}
+
+// A Route is a static route.
+type Route struct {
+	Prefix  netip.Prefix
+	NextHop netip.Addr
+	// Interface is the interface the next hop is reached through.
+	Interface *Interface
+}
+
+// Routes4 returns the IPv4 routes of n.
+func (n *Node) Routes4() []*Route {
+	return n.routes(true)
+}
+
+// Routes6 returns the IPv6 routes of n.
+func (n *Node) Routes6() []*Route {
+	return n.routes(false)
+}
+
+func (n *Node) routes(v4 bool) []*Route {
+	var routes []*Route
+	for _, r := range n.Routes {
+		if r.Prefix.Addr().Is4() == v4 {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// IPv6 reports whether n has any IPv6 address.
+func (n *Node) IPv6() bool {
+	if n.Loopback6.IsValid() {
+		return true
+	}
+	for _, intf := range n.Interfaces {
+		if intf.Addr6.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// Interface returns the interface of n with key, or nil.
+func (n *Node) Interface(key string) *Interface {
+	for _, intf := range n.Interfaces {
+		if intf.Key == key {
+			return intf
+		}
+	}
+	return nil
+}
+
+// Node returns the named node of p, or nil.
+func (p *Plan) Node(name string) *Node {
+	return p.nodes[name]
+}
+
+// Allocate returns a Plan allocating addresses from pools to the links and
+// nodes of t.  The n'th link is given the n'th point-to-point subnet of each
+// link pool, the A end getting the lower address.  The n'th node is given the
+// n+1'th address of each loopback pool, so the first address of the pool is
+// not used.  The Plan has no routes.
+func Allocate(t *tpb.Topology, pools Pools) (*Plan, error) {
+	p := &Plan{
+		nodes: map[string]*Node{},
+	}
+	pbs := map[string]*tpb.Node{}
+	for i, pb := range t.GetNodes() {
+		if pb.GetName() == "" {
+			return nil, fmt.Errorf("node %d has no name", i)
+		}
+		if _, ok := p.nodes[pb.GetName()]; ok {
+			return nil, fmt.Errorf("duplicate node %q", pb.GetName())
+		}
+		n := &Node{Name: pb.GetName(), Vendor: pb.GetVendor()}
+		var err error
+		if n.Loopback4, err = allocate(pools.Loopback4, 32, i+1); err != nil {
+			return nil, fmt.Errorf("node %q: %w", n.Name, err)
+		}
+		if n.Loopback6, err = allocate(pools.Loopback6, 128, i+1); err != nil {
+			return nil, fmt.Errorf("node %q: %w", n.Name, err)
+		}
+		if n.Vendor == tpb.Vendor_GATEWAY {
+			if n.Advertise, err = advertised(pb); err != nil {
+				return nil, fmt.Errorf("node %q: %w", n.Name, err)
+			}
+		}
+		p.Nodes = append(p.Nodes, n)
+		p.nodes[n.Name] = n
+		pbs[n.Name] = pb
+	}
+	used := map[string]bool{}
+	for i, l := range t.GetLinks() {
+		ends := [2]struct{ node, key string }{{l.GetANode(), l.GetAInt()}, {l.GetZNode(), l.GetZInt()}}
+		var intfs [2]*Interface
+		for j, e := range ends {
+			if _, ok := p.nodes[e.node]; !ok {
+				return nil, fmt.Errorf("link %d: node %q not found", i, e.node)
+			}
+			if e.key == "" {
+				return nil, fmt.Errorf("link %d: node %q has no interface", i, e.node)
+			}
+			if used[e.node+":"+e.key] {
+				return nil, fmt.Errorf("link %d: interface %q of node %q is on more than one link", i, e.key, e.node)
+			}
+			used[e.node+":"+e.key] = true
+			intfs[j] = &Interface{
+				Key:  e.key,
+				Name: InterfaceName(pbs[e.node], e.key),
+			}
+		}
+		subnet4, err := allocate(pools.Link4, 31, i)
+		if err != nil {
+			return nil, fmt.Errorf("link %d: %w", i, err)
+		}
+		subnet6, err := allocate(pools.Link6, 127, i)
+		if err != nil {
+			return nil, fmt.Errorf("link %d: %w", i, err)
+		}
+		for j, intf := range intfs {
+			peer := intfs[1-j]
+			intf.Peer, intf.PeerKey = ends[1-j].node, peer.Key
+			if subnet4.IsValid() {
+				intf.Addr4 = netip.PrefixFrom(nth(subnet4.Addr(), j), 31)
+				intf.PeerAddr4 = nth(subnet4.Addr(), 1-j)
+			}
+			if subnet6.IsValid() {
+				intf.Addr6 = netip.PrefixFrom(nth(subnet6.Addr(), j), 127)
+				intf.PeerAddr6 = nth(subnet6.Addr(), 1-j)
+			}
+			n := p.nodes[ends[j].node]
+			n.Interfaces = append(n.Interfaces, intf)
+		}
+	}
+	return p, nil
+}
+
+// advertised returns the prefixes advertised by the GATEWAY node pb.
+func advertised(pb *tpb.Node) ([]netip.Prefix, error) {
+	cfg, err := gatewayConfig(pb)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.GetAdvertise()) == 0 {
+		return []netip.Prefix{defaultAdvertise}, nil
+	}
+	var prefixes []netip.Prefix
+	for _, s := range cfg.GetAdvertise() {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("advertise: %w", err)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// gatewayConfig returns the GatewayConfig in the vendor data of pb, which is
+// empty if pb has no vendor data.
+func gatewayConfig(pb *tpb.Node) (*gwpb.GatewayConfig, error) {
+	cfg := &gwpb.GatewayConfig{}
+	if vd := pb.GetConfig().GetVendorData(); vd != nil {
+		if err := vd.UnmarshalTo(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// allocate returns the i'th subnet with prefix length bits of pool, or the
+// zero netip.Prefix if pool is not valid.
+func allocate(pool netip.Prefix, bits, i int) (netip.Prefix, error) {
+	if !pool.IsValid() {
+		return netip.Prefix{}, nil
+	}
+	if bits < pool.Bits() || bits > pool.Addr().BitLen() {
+		return netip.Prefix{}, fmt.Errorf("pool %s cannot be divided into /%d subnets", pool, bits)
+	}
+	if size := bits - pool.Bits(); size < 63 && int64(i) >= int64(1)<<size {
+		return netip.Prefix{}, fmt.Errorf("pool %s exhausted", pool)
+	}
+	shift := pool.Addr().BitLen() - bits
+	a := new(big.Int).SetBytes(pool.Masked().Addr().AsSlice())
+	a.Add(a, new(big.Int).Lsh(big.NewInt(int64(i)), uint(shift)))
+	b := make([]byte, pool.Addr().BitLen()/8)
+	a.FillBytes(b)
+	addr, _ := netip.AddrFromSlice(b)
+	return netip.PrefixFrom(addr, bits), nil
+}
+
+// nth returns the address i after a, with i being 0 or 1.
+func nth(a netip.Addr, i int) netip.Addr {
+	if i == 1 {
+		return a.Next()
+	}
+	return a
+}
+
+var (
+	nokiaRE  = regexp.MustCompile(`^e(\d+)-(\d+)$`)
+	aristaRE = regexp.MustCompile(`^eth(\d+)$`)
+)
+
+// InterfaceName returns the name of the interface with key of node n: the
+// name set in the topology, or else the vendor name of the interface.  Keys
+// are used as is for vendors where the mapping is not known.
+func InterfaceName(n *tpb.Node, key string) string {
+	if name := n.GetInterfaces()[key].GetName(); name != "" {
+		return name
+	}
+	switch n.GetVendor() {
+	case tpb.Vendor_NOKIA:
+		if m := nokiaRE.FindStringSubmatch(key); m != nil {
+			return fmt.Sprintf("ethernet-%s/%s", m[1], m[2])
+		}
+	case tpb.Vendor_ARISTA:
+		if m := aristaRE.FindStringSubmatch(key); m != nil {
+			return "Ethernet" + m[1]
+		}
+	}
+	return key
+}
+
+// Route adds to each node a static route to each of prefixes along a
+// shortest path, in hops, to the node dst.  With no prefixes the loopbacks
+// of dst are routed.  Routes are only added for the address families of the
+// interfaces on the path, and an existing route to the same prefix is
+// replaced.  Nodes that cannot reach dst are given no routes.  Among paths of
+// the same length the one through the links earliest in the topology is
+// used.
+func (p *Plan) Route(dst string, prefixes ...netip.Prefix) error {
+	d, ok := p.nodes[dst]
+	if !ok {
+		return fmt.Errorf("node %q not found", dst)
+	}
+	if len(prefixes) == 0 {
+		for _, lo := range []netip.Prefix{d.Loopback4, d.Loopback6} {
+			if lo.IsValid() {
+				prefixes = append(prefixes, lo)
+			}
+		}
+	}
+	for name, via := range p.nextHops(dst) {
+		n := p.nodes[name]
+		for _, prefix := range prefixes {
+			r := &Route{Prefix: prefix.Masked(), Interface: via}
+			if prefix.Addr().Is4() {
+				r.NextHop = via.PeerAddr4
+			} else {
+				r.NextHop = via.PeerAddr6
+			}
+			if r.NextHop.IsValid() {
+				n.addRoute(r)
+			}
+		}
+	}
+	return nil
+}
+
+// RouteAll adds the routes needed for the loopbacks of every node to be
+// reachable from every other node, and then routes the advertised prefixes
+// of each node to it.
+func (p *Plan) RouteAll() {
+	for _, n := range p.Nodes {
+		// The node exists, so Route cannot fail.
+		p.Route(n.Name)
+	}
+	for _, n := range p.Nodes {
+		if len(n.Advertise) > 0 {
+			p.Route(n.Name, n.Advertise...)
+		}
+	}
+}
+
+// Path returns the names of the nodes on a shortest path from src to dst, as
+// used by Route, starting with src and ending with dst.  It returns nil if
+// there is no path.
+func (p *Plan) Path(src, dst string) []string {
+	if _, ok := p.nodes[dst]; !ok {
+		return nil
+	}
+	if src == dst {
+		return []string{dst}
+	}
+	via := p.nextHops(dst)
+	path := []string{src}
+	for name := src; name != dst; {
+		intf, ok := via[name]
+		if !ok {
+			return nil
+		}
+		name = intf.Peer
+		path = append(path, name)
+	}
+	return path
+}
+
+// nextHops returns the interface each node reaching dst uses as the first
+// hop of a shortest path to dst.  It is a breadth first search from dst.
+func (p *Plan) nextHops(dst string) map[string]*Interface {
+	via := map[string]*Interface{}
+	seen := map[string]bool{dst: true}
+	for queue := []string{dst}; len(queue) > 0; queue = queue[1:] {
+		for _, intf := range p.nodes[queue[0]].Interfaces {
+			if seen[intf.Peer] {
+				continue
+			}
+			seen[intf.Peer] = true
+			// The interface of the peer facing back toward dst.
+			for _, back := range p.nodes[intf.Peer].Interfaces {
+				if back.Peer == queue[0] && back.PeerKey == intf.Key {
+					via[intf.Peer] = back
+					break
+				}
+			}
+			queue = append(queue, intf.Peer)
+		}
+	}
+	return via
+}
+
+// addRoute adds r to n, replacing any route to the same prefix.
+func (n *Node) addRoute(r *Route) {
+	for i, old := range n.Routes {
+		if old.Prefix == r.Prefix {
+			n.Routes[i] = r
+			return
+		}
+	}
+	n.Routes = append(n.Routes, r)
+}
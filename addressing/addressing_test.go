@@ -0,0 +1,293 @@
+package addressing
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	gwpb "github.com/openconfig/kne/proto/gateway"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// square returns a topology of four nodes in a square, r1-r2-r3-r4-r1.
+func square() *tpb.Topology {
+	return &tpb.Topology{
+		Name: "square",
+		Nodes: []*tpb.Node{
+			{Name: "r1", Vendor: tpb.Vendor_NOKIA},
+			{Name: "r2", Vendor: tpb.Vendor_ARISTA},
+			{Name: "r3", Vendor: tpb.Vendor_JUNIPER, Interfaces: map[string]*tpb.Interface{
+				"eth1": {Name: "et-0/0/0"},
+				"eth2": {Name: "et-0/0/1"},
+			}},
+			{Name: "r4", Vendor: tpb.Vendor_HOST},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "e1-1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r2", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+			{ANode: "r3", AInt: "eth2", ZNode: "r4", ZInt: "eth1"},
+			{ANode: "r4", AInt: "eth2", ZNode: "r1", ZInt: "e1-2"},
+		},
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	p, err := Allocate(square(), DefaultPools)
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	if got, want := len(p.Nodes), 4; got != want {
+		t.Fatalf("Allocate() got %d nodes, want %d", got, want)
+	}
+	want := &Node{
+		Name:      "r1",
+		Vendor:    tpb.Vendor_NOKIA,
+		Loopback4: netip.MustParsePrefix("10.255.0.1/32"),
+		Loopback6: netip.MustParsePrefix("2001:db8:0:ff::1/128"),
+		Interfaces: []*Interface{{
+			Key:       "e1-1",
+			Name:      "ethernet-1/1",
+			Addr4:     netip.MustParsePrefix("192.168.0.0/31"),
+			Addr6:     netip.MustParsePrefix("2001:db8:0:1::/127"),
+			Peer:      "r2",
+			PeerKey:   "eth1",
+			PeerAddr4: netip.MustParseAddr("192.168.0.1"),
+			PeerAddr6: netip.MustParseAddr("2001:db8:0:1::1"),
+		}, {
+			Key:       "e1-2",
+			Name:      "ethernet-1/2",
+			Addr4:     netip.MustParsePrefix("192.168.0.7/31"),
+			Addr6:     netip.MustParsePrefix("2001:db8:0:1::7/127"),
+			Peer:      "r4",
+			PeerKey:   "eth2",
+			PeerAddr4: netip.MustParseAddr("192.168.0.6"),
+			PeerAddr6: netip.MustParseAddr("2001:db8:0:1::6"),
+		}},
+	}
+	if s := cmp.Diff(want, p.Node("r1"), cmp.Comparer(func(a, b netip.Prefix) bool { return a == b }), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); s != "" {
+		t.Errorf("Allocate() unexpected node r1 (-want +got):\n%s", s)
+	}
+	if got, want := p.Node("r2").Interface("eth1").Name, "Ethernet1"; got != want {
+		t.Errorf("Allocate() got r2 eth1 name %q, want %q", got, want)
+	}
+	if got, want := p.Node("r3").Interface("eth2").Name, "et-0/0/1"; got != want {
+		t.Errorf("Allocate() got r3 eth2 name %q, want %q", got, want)
+	}
+	if got, want := p.Node("r4").Interface("eth1").Name, "eth1"; got != want {
+		t.Errorf("Allocate() got r4 eth1 name %q, want %q", got, want)
+	}
+}
+
+func TestAllocateErrors(t *testing.T) {
+	tests := []struct {
+		desc  string
+		topo  *tpb.Topology
+		pools Pools
+		want  string
+	}{{
+		desc:  "only ipv4 links",
+		topo:  square(),
+		pools: Pools{Link4: netip.MustParsePrefix("10.0.0.0/24")},
+	}, {
+		desc:  "link pool exhausted",
+		topo:  square(),
+		pools: Pools{Link4: netip.MustParsePrefix("10.0.0.0/30")},
+		want:  "link 2: pool 10.0.0.0/30 exhausted",
+	}, {
+		desc:  "loopback pool exhausted",
+		topo:  square(),
+		pools: Pools{Loopback4: netip.MustParsePrefix("10.0.0.0/31")},
+		want:  `node "r2": pool 10.0.0.0/31 exhausted`,
+	}, {
+		desc:  "pool too small",
+		topo:  square(),
+		pools: Pools{Link6: netip.MustParsePrefix("2001:db8::/128")},
+		want:  "cannot be divided into /127 subnets",
+	}, {
+		desc: "unknown node",
+		topo: &tpb.Topology{
+			Nodes: []*tpb.Node{{Name: "r1"}},
+			Links: []*tpb.Link{{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"}},
+		},
+		pools: DefaultPools,
+		want:  `link 0: node "r2" not found`,
+	}, {
+		desc: "interface on two links",
+		topo: &tpb.Topology{
+			Nodes: []*tpb.Node{{Name: "r1"}, {Name: "r2"}, {Name: "r3"}},
+			Links: []*tpb.Link{
+				{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"},
+				{ANode: "r1", AInt: "eth1", ZNode: "r3", ZInt: "eth1"},
+			},
+		},
+		pools: DefaultPools,
+		want:  `link 1: interface "eth1" of node "r1" is on more than one link`,
+	}, {
+		desc:  "duplicate node",
+		topo:  &tpb.Topology{Nodes: []*tpb.Node{{Name: "r1"}, {Name: "r1"}}},
+		pools: DefaultPools,
+		want:  `duplicate node "r1"`,
+	}, {
+		desc:  "bad advertised prefix",
+		topo:  &tpb.Topology{Nodes: []*tpb.Node{gatewayNode("gw", &gwpb.GatewayConfig{Advertise: []string{"cluster"}})}},
+		pools: DefaultPools,
+		want:  `node "gw": advertise: netip.ParsePrefix("cluster")`,
+	}, {
+		desc: "gateway vendor data of wrong type",
+		topo: &tpb.Topology{Nodes: []*tpb.Node{{
+			Name:   "gw",
+			Vendor: tpb.Vendor_GATEWAY,
+			Config: &tpb.Config{VendorData: mustAny(&gwpb.Address{})},
+		}}},
+		pools: DefaultPools,
+		want:  `node "gw"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Allocate(tt.topo, tt.pools)
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Fatalf("Allocate() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func mustAny(m proto.Message) *anypb.Any {
+	a, err := anypb.New(m)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// gatewayNode returns a GATEWAY node with cfg in its vendor data.
+func gatewayNode(name string, cfg *gwpb.GatewayConfig) *tpb.Node {
+	return &tpb.Node{Name: name, Vendor: tpb.Vendor_GATEWAY, Config: &tpb.Config{VendorData: mustAny(cfg)}}
+}
+
+func TestRouteAllGateway(t *testing.T) {
+	topo := square()
+	topo.Nodes = append(topo.Nodes,
+		gatewayNode("gw", &gwpb.GatewayConfig{Advertise: []string{"10.96.1.0/12"}}),
+		&tpb.Node{Name: "gw2", Vendor: tpb.Vendor_GATEWAY},
+	)
+	topo.Links = append(topo.Links,
+		&tpb.Link{ANode: "r3", AInt: "eth3", ZNode: "gw", ZInt: "eth1"},
+		&tpb.Link{ANode: "r1", AInt: "e1-3", ZNode: "gw2", ZInt: "eth1"},
+	)
+	p, err := Allocate(topo, Pools{Link4: DefaultPools.Link4})
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	if s := cmp.Diff([]netip.Prefix{netip.MustParsePrefix("10.96.0.0/12")}, p.Node("gw").Advertise, cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); s != "" {
+		t.Errorf("Allocate() unexpected advertised prefixes of gw (-want +got):\n%s", s)
+	}
+	p.RouteAll()
+	want := map[string]map[string]string{
+		"r1":  {"10.96.0.0/12": "192.168.0.1", "0.0.0.0/0": "192.168.0.11"},
+		"r2":  {"10.96.0.0/12": "192.168.0.3", "0.0.0.0/0": "192.168.0.0"},
+		"r3":  {"10.96.0.0/12": "192.168.0.9", "0.0.0.0/0": "192.168.0.2"},
+		"r4":  {"10.96.0.0/12": "192.168.0.4", "0.0.0.0/0": "192.168.0.7"},
+		"gw":  {"0.0.0.0/0": "192.168.0.8"},
+		"gw2": {"10.96.0.0/12": "192.168.0.10"},
+	}
+	for name, want := range want {
+		got := map[string]string{}
+		for _, r := range p.Node(name).Routes {
+			got[r.Prefix.String()] = r.NextHop.String()
+		}
+		for prefix, nh := range want {
+			if got[prefix] != nh {
+				t.Errorf("RouteAll() routed %s of %s via %q, want %q", prefix, name, got[prefix], nh)
+			}
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	p, err := Allocate(square(), DefaultPools)
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	if err := p.Route("r3"); err != nil {
+		t.Fatalf("Route() failed: %v", err)
+	}
+	if err := p.Route("r1", netip.MustParsePrefix("0.0.0.0/0")); err != nil {
+		t.Fatalf("Route() failed: %v", err)
+	}
+	if err := p.Route("r5"); err == nil {
+		t.Errorf("Route() of unknown node succeeded")
+	}
+
+	type route struct{ Prefix, NextHop, Interface string }
+	routes := func(n *Node) []route {
+		var rs []route
+		for _, r := range n.Routes {
+			rs = append(rs, route{r.Prefix.String(), r.NextHop.String(), r.Interface.Key})
+		}
+		return rs
+	}
+	want := map[string][]route{
+		// r1 is two hops from r3 both ways, the path through the first
+		// link is used.
+		"r1": {
+			{"10.255.0.3/32", "192.168.0.1", "e1-1"},
+			{"2001:db8:0:ff::3/128", "2001:db8:0:1::1", "e1-1"},
+		},
+		"r2": {
+			{"10.255.0.3/32", "192.168.0.3", "eth2"},
+			{"2001:db8:0:ff::3/128", "2001:db8:0:1::3", "eth2"},
+			{"0.0.0.0/0", "192.168.0.0", "eth1"},
+		},
+		"r3": {
+			{"0.0.0.0/0", "192.168.0.2", "eth1"},
+		},
+		"r4": {
+			{"10.255.0.3/32", "192.168.0.4", "eth1"},
+			{"2001:db8:0:ff::3/128", "2001:db8:0:1::4", "eth1"},
+			{"0.0.0.0/0", "192.168.0.7", "eth2"},
+		},
+	}
+	for name, want := range want {
+		if s := cmp.Diff(want, routes(p.Node(name))); s != "" {
+			t.Errorf("Route() unexpected routes of %s (-want +got):\n%s", name, s)
+		}
+	}
+
+	// Routing the same prefix again replaces the route.
+	if err := p.Route("r4", netip.MustParsePrefix("0.0.0.0/0")); err != nil {
+		t.Fatalf("Route() failed: %v", err)
+	}
+	want["r1"] = append(want["r1"], route{"0.0.0.0/0", "192.168.0.6", "e1-2"})
+	if s := cmp.Diff(want["r1"], routes(p.Node("r1"))); s != "" {
+		t.Errorf("Route() unexpected routes of r1 (-want +got):\n%s", s)
+	}
+}
+
+func TestPath(t *testing.T) {
+	topo := square()
+	topo.Nodes = append(topo.Nodes, &tpb.Node{Name: "r5"})
+	p, err := Allocate(topo, DefaultPools)
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	tests := []struct {
+		src, dst string
+		want     []string
+	}{
+		{"r1", "r3", []string{"r1", "r2", "r3"}},
+		{"r4", "r2", []string{"r4", "r1", "r2"}},
+		{"r2", "r1", []string{"r2", "r1"}},
+		{"r1", "r1", []string{"r1"}},
+		{"r1", "r5", nil},
+		{"r1", "r6", nil},
+	}
+	for _, tt := range tests {
+		if s := cmp.Diff(tt.want, p.Path(tt.src, tt.dst)); s != "" {
+			t.Errorf("Path(%q, %q) unexpected path (-want +got):\n%s", tt.src, tt.dst, s)
+		}
+	}
+}
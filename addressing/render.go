@@ -0,0 +1,176 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addressing
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"text/template"
+
+	gwpb "github.com/openconfig/kne/proto/gateway"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var funcs = template.FuncMap{
+	// addr returns the address of a prefix.
+	"addr": func(p netip.Prefix) netip.Addr { return p.Addr() },
+	// mask returns the prefix length of an IPv4 prefix as a dotted netmask.
+	"mask": func(p netip.Prefix) string { return net.IP(net.CIDRMask(p.Bits(), 32)).String() },
+	// group returns the name of the next hop group of the route to a prefix.
+	"group": GroupName,
+}
+
+// GroupName returns the name of the next hop group of the static route to
+// prefix p, for vendors whose static routes point at a named group.  Faults
+// replacing the route use the same name, so they remove the group with it.
+func GroupName(p netip.Prefix) string {
+	return "kne-" + strings.NewReplacer(".", "-", ":", "-", "/", "_").Replace(p.String())
+}
+
+// Templates are the templates used to render the configuration of nodes of
+// each vendor.  Each template is executed with a *Node.  Templates may be
+// added or replaced before calling Render.
+var Templates = map[tpb.Vendor]*template.Template{
+	tpb.Vendor_ARISTA:  mustParse("eos.tmpl"),
+	tpb.Vendor_CISCO:   mustParse("xr.tmpl"),
+	tpb.Vendor_FRR:     mustParse("frr.tmpl"),
+	tpb.Vendor_HOST:    mustParse("linux.tmpl"),
+	tpb.Vendor_JUNIPER: mustParse("junos.tmpl"),
+	tpb.Vendor_NOKIA:   mustParse("srlinux.tmpl"),
+}
+
+func mustParse(name string) *template.Template {
+	return template.Must(template.New(name).Funcs(funcs).ParseFS(templateFS, "templates/"+name))
+}
+
+// Render returns the configuration of the named node, rendered with the
+// template for its vendor.
+func (p *Plan) Render(name string) ([]byte, error) {
+	n, ok := p.nodes[name]
+	if !ok {
+		return nil, fmt.Errorf("node %q not found", name)
+	}
+	t, ok := Templates[n.Vendor]
+	if !ok {
+		return nil, fmt.Errorf("node %q: no template for vendor %v", name, n.Vendor)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, n); err != nil {
+		return nil, fmt.Errorf("node %q: %w", name, err)
+	}
+	// Templates put a newline before each line.
+	return bytes.TrimLeft(b.Bytes(), "\n"), nil
+}
+
+// Configure appends the rendered configuration of each node of p to the
+// config data of the node in t.  Nodes whose configuration is in a file, or
+// whose vendor has no template, are not supported.  Nodes with no
+// interfaces, loopbacks or routes are left unchanged.  GATEWAY nodes are
+// configured by adding their addresses and routes to the GatewayConfig in
+// their vendor data instead.  The config data of HOST nodes is only mounted
+// in the pod, so HOST nodes without a command are given one running it as a
+// shell script before sleeping; HOST nodes with a command must run it
+// themselves.
+func (p *Plan) Configure(t *tpb.Topology) error {
+	for _, pb := range t.GetNodes() {
+		n, ok := p.nodes[pb.GetName()]
+		if !ok || (len(n.Interfaces) == 0 && len(n.Routes) == 0 && !n.Loopback4.IsValid() && !n.Loopback6.IsValid()) {
+			continue
+		}
+		if n.Vendor == tpb.Vendor_GATEWAY {
+			if err := configureGateway(pb, n); err != nil {
+				return fmt.Errorf("node %q: %w", n.Name, err)
+			}
+			continue
+		}
+		if _, ok := pb.GetConfig().GetConfigData().(*tpb.Config_File); ok {
+			return fmt.Errorf("node %q: cannot add to config file %q", n.Name, pb.GetConfig().GetFile())
+		}
+		b, err := p.Render(n.Name)
+		if err != nil {
+			return err
+		}
+		if pb.Config == nil {
+			pb.Config = &tpb.Config{}
+		}
+		data := append([]byte(nil), pb.GetConfig().GetData()...)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		pb.Config.ConfigData = &tpb.Config_Data{Data: append(data, b...)}
+		if n.Vendor == tpb.Vendor_HOST {
+			configureHost(pb)
+		}
+	}
+	return nil
+}
+
+// configureHost sets the command of the HOST node pb, if not set, to run its
+// config file as a shell script and then sleep.  The config file defaults to
+// the one the host node mounts.  Errors of the script, such as adding an
+// address that already exists when the container restarts, are ignored.
+func configureHost(pb *tpb.Node) {
+	if len(pb.GetConfig().GetCommand()) != 0 {
+		return
+	}
+	if pb.Config.ConfigPath == "" {
+		pb.Config.ConfigPath = "/etc"
+	}
+	if pb.Config.ConfigFile == "" {
+		pb.Config.ConfigFile = "config"
+	}
+	pb.Config.Command = []string{"/bin/sh", "-c", fmt.Sprintf("sh %s/%s; exec sleep 2000000000000", pb.Config.ConfigPath, pb.Config.ConfigFile)}
+}
+
+// configureGateway adds the addresses and routes of the GATEWAY node n to
+// the GatewayConfig in the vendor data of pb.  The loopbacks of n are put on
+// the interface lo.
+func configureGateway(pb *tpb.Node, n *Node) error {
+	cfg, err := gatewayConfig(pb)
+	if err != nil {
+		return err
+	}
+	add := func(intf string, prefixes ...netip.Prefix) {
+		for _, p := range prefixes {
+			if p.IsValid() {
+				cfg.Addresses = append(cfg.Addresses, &gwpb.Address{Interface: intf, Prefix: p.String()})
+			}
+		}
+	}
+	add("lo", n.Loopback4, n.Loopback6)
+	for _, intf := range n.Interfaces {
+		add(intf.Key, intf.Addr4, intf.Addr6)
+	}
+	for _, r := range n.Routes {
+		cfg.Routes = append(cfg.Routes, &gwpb.Route{Prefix: r.Prefix.String(), NextHop: r.NextHop.String()})
+	}
+	vd, err := anypb.New(cfg)
+	if err != nil {
+		return err
+	}
+	if pb.Config == nil {
+		pb.Config = &tpb.Config{}
+	}
+	pb.Config.VendorData = vd
+	return nil
+}
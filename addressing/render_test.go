@@ -0,0 +1,158 @@
+package addressing
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	gwpb "github.com/openconfig/kne/proto/gateway"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestRender(t *testing.T) {
+	topo := square()
+	// A router of every vendor with a template is connected to r1.
+	for i, n := range []*tpb.Node{
+		{Name: "xr", Vendor: tpb.Vendor_CISCO, Interfaces: map[string]*tpb.Interface{"eth1": {Name: "GigabitEthernet0/0/0/0"}}},
+		{Name: "frr", Vendor: tpb.Vendor_FRR},
+	} {
+		topo.Nodes = append(topo.Nodes, n)
+		topo.Links = append(topo.Links, &tpb.Link{ANode: n.Name, AInt: "eth1", ZNode: "r1", ZInt: fmt.Sprintf("e1-%d", i+3)})
+	}
+	p, err := Allocate(topo, DefaultPools)
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	p.RouteAll()
+	for _, name := range []string{"r1", "r2", "r3", "r4", "xr", "frr"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := p.Render(name)
+			if err != nil {
+				t.Fatalf("Render() failed: %v", err)
+			}
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := cmp.Diff(string(want), string(got)); s != "" {
+				t.Errorf("Render() unexpected config (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	topo := &tpb.Topology{Nodes: []*tpb.Node{{Name: "lemming", Vendor: tpb.Vendor_OPENCONFIG}}}
+	p, err := Allocate(topo, DefaultPools)
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	_, err = p.Render("lemming")
+	if s := errdiff.Substring(err, "no template for vendor OPENCONFIG"); s != "" {
+		t.Errorf("Render() unexpected error: %s", s)
+	}
+	_, err = p.Render("r1")
+	if s := errdiff.Substring(err, `node "r1" not found`); s != "" {
+		t.Errorf("Render() unexpected error: %s", s)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	topo := &tpb.Topology{
+		Nodes: []*tpb.Node{
+			{Name: "h1", Vendor: tpb.Vendor_HOST, Config: &tpb.Config{ConfigData: &tpb.Config_Data{Data: []byte("echo hello")}}},
+			{Name: "h2", Vendor: tpb.Vendor_HOST, Config: &tpb.Config{Command: []string{"/init"}}},
+			{Name: "h3", Vendor: tpb.Vendor_HOST},
+		},
+		Links: []*tpb.Link{{ANode: "h1", AInt: "eth1", ZNode: "h2", ZInt: "eth1"}},
+	}
+	p, err := Allocate(topo, Pools{Link4: DefaultPools.Link4})
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	if err := p.Configure(topo); err != nil {
+		t.Fatalf("Configure() failed: %v", err)
+	}
+	want := `echo hello
+sysctl -w net.ipv4.ip_forward=1 net.ipv6.conf.all.forwarding=1
+ip link set eth1 up
+ip addr add 192.168.0.0/31 dev eth1
+`
+	if s := cmp.Diff(want, string(topo.Nodes[0].GetConfig().GetData())); s != "" {
+		t.Errorf("Configure() unexpected config of h1 (-want +got):\n%s", s)
+	}
+	if got, want := topo.Nodes[0].GetConfig().GetCommand(), []string{"/bin/sh", "-c", "sh /etc/config; exec sleep 2000000000000"}; !cmp.Equal(got, want) {
+		t.Errorf("Configure() set command of h1 to %q, want %q", got, want)
+	}
+	if topo.Nodes[1].GetConfig().GetData() == nil {
+		t.Errorf("Configure() did not configure h2")
+	}
+	if got, want := topo.Nodes[1].GetConfig().GetCommand(), []string{"/init"}; !cmp.Equal(got, want) {
+		t.Errorf("Configure() replaced command of h2 with %q", got)
+	}
+	if topo.Nodes[2].GetConfig() != nil {
+		t.Errorf("Configure() configured h3 with no interfaces: %v", topo.Nodes[2].GetConfig())
+	}
+
+	topo.Nodes[1].Config.ConfigData = &tpb.Config_File{File: "h2.cfg"}
+	err = p.Configure(topo)
+	if s := errdiff.Substring(err, `node "h2": cannot add to config file "h2.cfg"`); s != "" {
+		t.Errorf("Configure() unexpected error: %s", s)
+	}
+}
+
+func TestConfigureGateway(t *testing.T) {
+	topo := &tpb.Topology{
+		Nodes: []*tpb.Node{
+			{Name: "h1", Vendor: tpb.Vendor_HOST},
+			gatewayNode("gw", &gwpb.GatewayConfig{
+				Addresses:         []*gwpb.Address{{Interface: "eth2", Prefix: "172.16.0.1/24"}},
+				Advertise:         []string{"10.96.0.0/12"},
+				ExternalInterface: "net1",
+			}),
+		},
+		Links: []*tpb.Link{{ANode: "h1", AInt: "eth1", ZNode: "gw", ZInt: "eth1"}},
+	}
+	p, err := Allocate(topo, Pools{Link4: DefaultPools.Link4, Loopback4: DefaultPools.Loopback4})
+	if err != nil {
+		t.Fatalf("Allocate() failed: %v", err)
+	}
+	p.RouteAll()
+	if err := p.Configure(topo); err != nil {
+		t.Fatalf("Configure() failed: %v", err)
+	}
+	got := &gwpb.GatewayConfig{}
+	if err := topo.Nodes[1].GetConfig().GetVendorData().UnmarshalTo(got); err != nil {
+		t.Fatalf("Configure() set bad vendor data of gw: %v", err)
+	}
+	want := &gwpb.GatewayConfig{
+		Addresses: []*gwpb.Address{
+			{Interface: "eth2", Prefix: "172.16.0.1/24"},
+			{Interface: "lo", Prefix: "10.255.0.2/32"},
+			{Interface: "eth1", Prefix: "192.168.0.1/31"},
+		},
+		Routes:            []*gwpb.Route{{Prefix: "10.255.0.1/32", NextHop: "192.168.0.0"}},
+		Advertise:         []string{"10.96.0.0/12"},
+		ExternalInterface: "net1",
+	}
+	if s := cmp.Diff(want, got, protocmp.Transform()); s != "" {
+		t.Errorf("Configure() unexpected config of gw (-want +got):\n%s", s)
+	}
+	if s := string(topo.Nodes[0].GetConfig().GetData()); !strings.Contains(s, "ip route replace 10.96.0.0/12 via 192.168.0.1 dev eth1") {
+		t.Errorf("Configure() did not route the advertised prefix of gw on h1:\n%s", s)
+	}
+}
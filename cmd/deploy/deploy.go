@@ -14,17 +14,36 @@
 package deploy
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/openconfig/kne/deploy"
 	"github.com/openconfig/kne/load"
+	"github.com/openconfig/kne/topo"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 	log "k8s.io/klog/v2"
 )
 
-var progress bool
+var (
+	progress     bool
+	resume       bool
+	keepCluster  bool
+	statusOutput string
+
+	preloadImages bool
+	topologies    []string
+	archiveDir    string
+
+	overlays    []string
+	printConfig bool
+)
 
 func New() *cobra.Command {
 	deployCmd := &cobra.Command{
@@ -33,9 +52,57 @@ func New() *cobra.Command {
 		RunE:  deployFn,
 	}
 	deployCmd.Flags().BoolVar(&progress, "progress", false, "Display progress of container bringup")
+	deployCmd.Flags().BoolVar(&resume, "resume", false, "Reuse an existing cluster and skip components that are already installed and healthy")
+	deployCmd.Flags().BoolVar(&preloadImages, "preload-images", false, "Load the images of the deployed components into the kind cluster from the local docker daemon instead of pulling them")
+	deployCmd.Flags().StringSliceVar(&topologies, "topology", nil, "Topology whose node images are also preloaded, implies --preload-images")
+	deployCmd.Flags().StringVar(&archiveDir, "image-archive-dir", "", "Directory of image archives to preload images missing from the local docker daemon")
+	deployCmd.Flags().StringSliceVar(&overlays, "overlay", nil, "Deployment yaml merged on top of the deployment yaml, may be repeated")
+	deployCmd.Flags().BoolVar(&printConfig, "print", false, "Print the deployment yaml after includes, overlays and environment variables are resolved")
+	statusCmd := &cobra.Command{
+		Use:   "status [deployment yaml]",
+		Short: "Show the status of the deployed components.",
+		Long: `Status reports the state of each component installed in the cluster: the
+cluster version and nodes, the ingress, the CNI and the vendor controllers.
+Without a deployment yaml all the known components are checked.`,
+		RunE: statusFn,
+	}
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", "table", "Output format, table or json")
+	statusCmd.Flags().StringSliceVar(&overlays, "overlay", nil, "Deployment yaml merged on top of the deployment yaml, may be repeated")
+	deployCmd.AddCommand(statusCmd)
+	validateCmd := &cobra.Command{
+		Use:   "validate <deployment yaml>",
+		Short: "Validate a deployment yaml without deploying it.",
+		Long: `Validate decodes a deployment yaml, checks that every file it references
+exists and is valid YAML, and runs the checks of each component.  Every error
+is reported with the YAML path of the value in error.  Nothing is deployed.`,
+		RunE: validateFn,
+	}
+	validateCmd.Flags().StringSliceVar(&overlays, "overlay", nil, "Deployment yaml merged on top of the deployment yaml, may be repeated")
+	deployCmd.AddCommand(validateCmd)
+	deployCmd.AddCommand(&cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of deployment yaml files.",
+		RunE:  schemaFn,
+	})
 	return deployCmd
 }
 
+// NewTeardown returns the teardown command, which removes everything created
+// by deploy.
+func NewTeardown() *cobra.Command {
+	teardownCmd := &cobra.Command{
+		Use:   "teardown <deployment yaml>",
+		Short: "Teardown cluster.",
+		Long: `Teardown removes the controllers, CNI and ingress of a deployment, in
+the reverse order they were deployed, and then deletes the cluster.  Use
+--keep-cluster to reset a shared cluster without deleting it.`,
+		RunE: teardownFn,
+	}
+	teardownCmd.Flags().BoolVar(&keepCluster, "keep-cluster", false, "Remove the deployed components but do not delete the cluster")
+	teardownCmd.Flags().StringSliceVar(&overlays, "overlay", nil, "Deployment yaml merged on top of the deployment yaml, may be repeated")
+	return teardownCmd
+}
+
 type ClusterSpec struct {
 	Kind string    `yaml:"kind"`
 	Spec yaml.Node `yaml:"spec"`
@@ -63,14 +130,19 @@ type DeploymentConfig struct {
 	Controllers []*ControllerSpec `yaml:"controllers"`
 }
 
-// newDeployment reads in a deployment config file and returns a
-// deploy.Deployment or an error.  If the testing flag is true the no errors
-// will be reported for missing files.
+// newDeployment reads in a deployment config file, with the overlays from the
+// command line merged on top, and returns a deploy.Deployment or an error.  If
+// the testing flag is true the no errors will be reported for missing files.
 func newDeployment(cfgPath string, testing bool) (*deploy.Deployment, error) {
-	c, err := load.NewConfig(cfgPath, &DeploymentConfig{})
+	c, err := load.NewConfig(cfgPath, &DeploymentConfig{}, overlays...)
 	if err != nil {
 		return nil, err
 	}
+	return decodeDeployment(c, testing)
+}
+
+// decodeDeployment returns the deploy.Deployment of the loaded config c.
+func decodeDeployment(c *load.Config, testing bool) (*deploy.Deployment, error) {
 	c.IgnoreMissingFiles = testing
 
 	cfg := deploy.Deployment{
@@ -94,6 +166,46 @@ func newDeployment(cfgPath string, testing bool) (*deploy.Deployment, error) {
 	return &cfg, nil
 }
 
+// validateFn reports all the errors in the deployment yaml.
+func validateFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing args", cmd.Use)
+	}
+	c, err := load.NewConfig(args[0], &DeploymentConfig{}, overlays...)
+	if err != nil {
+		return err
+	}
+	errs := c.Validate(&deploy.Deployment{})
+	cfg := c.Config.(*DeploymentConfig)
+	if cfg.Cluster.Kind == "" {
+		errs = append(errs, fmt.Errorf("cluster: not specified"))
+	}
+	if cfg.Ingress.Kind == "" {
+		errs = append(errs, fmt.Errorf("ingress: not specified"))
+	}
+	if cfg.CNI.Kind == "" {
+		errs = append(errs, fmt.Errorf("cni: not specified"))
+	}
+	for _, err := range errs {
+		fmt.Fprintln(cmd.OutOrStdout(), err)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s: %d errors", args[0], len(errs))
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s: valid\n", args[0])
+	return nil
+}
+
+// schemaFn prints the JSON Schema of deployment yaml files.
+func schemaFn(cmd *cobra.Command, args []string) error {
+	b, err := load.Schema(&DeploymentConfig{})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(b))
+	return nil
+}
+
 func deployFn(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
 		return fmt.Errorf("%s: missing args", cmd.Use)
@@ -105,13 +217,145 @@ func deployFn(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	d, err := newDeployment(args[0], false)
+	c, err := load.NewConfig(args[0], &DeploymentConfig{}, overlays...)
+	if err != nil {
+		return err
+	}
+	if printConfig {
+		fmt.Fprint(cmd.OutOrStdout(), string(c.Data))
+	}
+	d, err := decodeDeployment(c, false)
 	if err != nil {
 		return err
 	}
+	d.Resume = resume
+	if err := setPreloadImages(d); err != nil {
+		return err
+	}
 	if err := d.Deploy(cmd.Context(), kubecfg); err != nil {
 		return err
 	}
 	log.Infof("Deployment complete, ready for topology")
 	return nil
 }
+
+// setPreloadImages sets up d to preload the images of its components and of
+// the topologies from the command line.
+func setPreloadImages(d *deploy.Deployment) error {
+	d.PreloadImages = preloadImages || len(topologies) != 0
+	d.ImageArchiveDir = archiveDir
+	for _, path := range topologies {
+		pb, err := topo.Load(path)
+		if err != nil {
+			return fmt.Errorf("failed to load topology %q: %w", path, err)
+		}
+		images, err := topo.Images(pb)
+		if err != nil {
+			return fmt.Errorf("failed to find images of topology %q: %w", path, err)
+		}
+		d.Images = append(d.Images, images...)
+	}
+	return nil
+}
+
+func teardownFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing args", cmd.Use)
+	}
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return fmt.Errorf("install kubectl before running teardown: %v", err)
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	d, err := newDeployment(args[0], false)
+	if err != nil {
+		return err
+	}
+	d.KeepCluster = keepCluster
+	if err := d.Delete(cmd.Context(), kubecfg); err != nil {
+		return err
+	}
+	log.Infof("Teardown complete")
+	return nil
+}
+
+// defaultDeployment returns a deployment of an external cluster with every
+// known ingress, CNI and controller, used to inspect a cluster without a
+// deployment yaml.
+func defaultDeployment() *deploy.Deployment {
+	return &deploy.Deployment{
+		Cluster: &deploy.ExternalSpec{},
+		Ingress: &deploy.MetalLBSpec{},
+		CNI:     &deploy.MeshnetSpec{},
+		Controllers: []deploy.Controller{
+			&deploy.IxiaTGSpec{},
+			&deploy.SRLinuxSpec{},
+			&deploy.CEOSLabSpec{},
+			&deploy.LemmingSpec{},
+		},
+	}
+}
+
+// deploymentStatus is a stub for testing.
+var deploymentStatus = func(ctx context.Context, d *deploy.Deployment, kubecfg string) ([]*deploy.ComponentStatus, error) {
+	return d.Status(ctx, kubecfg)
+}
+
+func statusFn(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%s: too many args", cmd.Use)
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	d := defaultDeployment()
+	if len(args) == 1 {
+		if d, err = newDeployment(args[0], false); err != nil {
+			return err
+		}
+	}
+	statuses, err := deploymentStatus(cmd.Context(), d, kubecfg)
+	if err != nil {
+		return err
+	}
+	switch statusOutput {
+	case "json":
+		b, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(b))
+		return nil
+	case "table":
+		return writeStatusTable(cmd.OutOrStdout(), statuses)
+	default:
+		return fmt.Errorf("%s: unknown output format %q", cmd.Use, statusOutput)
+	}
+}
+
+// writeStatusTable writes statuses to w as a table with one row per
+// component.
+func writeStatusTable(w io.Writer, statuses []*deploy.ComponentStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tKIND\tINSTALLED\tHEALTHY\tVERSION\tDETAILS")
+	for _, s := range statuses {
+		var keys []string
+		for k := range s.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var details []string
+		for _, k := range keys {
+			details = append(details, fmt.Sprintf("%s=%s", k, s.Details[k]))
+		}
+		version := s.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%t\t%s\t%s\n", s.Component, s.Kind, s.Installed, s.Healthy, version, strings.Join(details, "; "))
+	}
+	return tw.Flush()
+}
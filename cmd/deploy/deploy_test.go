@@ -14,11 +14,16 @@
 package deploy
 
 import (
+	"context"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/deploy"
 )
 
 var (
@@ -196,3 +201,228 @@ func TestNewDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTeardown(t *testing.T) {
+	c := NewTeardown()
+	if !strings.HasPrefix(c.Use, "teardown") {
+		t.Fatalf("unexpected command object: got %q, want \"teardown\"", c.Use)
+	}
+	if c.Flags().Lookup("keep-cluster") == nil {
+		t.Fatalf("teardown command missing --keep-cluster flag")
+	}
+	if c.Flags().Lookup("overlay") == nil {
+		t.Fatalf("teardown command missing --overlay flag")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	statuses := []*deploy.ComponentStatus{{
+		Component: "cluster",
+		Kind:      "Kind",
+		Installed: true,
+		Healthy:   true,
+		Version:   "v1.25.3",
+		Details:   map[string]string{"name": "kne", "image": "kindest/node:v1.25.3"},
+	}, {
+		Component: "controller",
+		Kind:      "CEOSLab",
+	}}
+	origStatus := deploymentStatus
+	defer func() {
+		deploymentStatus = origStatus
+	}()
+	var gotDeployment *deploy.Deployment
+	deploymentStatus = func(_ context.Context, d *deploy.Deployment, _ string) ([]*deploy.ComponentStatus, error) {
+		gotDeployment = d
+		return statuses, nil
+	}
+	overlay := filepath.Join(t.TempDir(), "overlay.yaml")
+	if err := os.WriteFile(overlay, []byte("cluster:\n  kind: External\n  spec:\n    network: kne\n"), 0600); err != nil {
+		t.Fatalf("failed to write overlay: %v", err)
+	}
+	tests := []struct {
+		desc        string
+		args        []string
+		wantOut     []string
+		wantErr     string
+		wantDefault bool
+	}{{
+		desc: "table",
+		args: []string{"status"},
+		wantOut: []string{
+			"COMPONENT   KIND     INSTALLED  HEALTHY  VERSION  DETAILS",
+			"cluster     Kind     true       true     v1.25.3  image=kindest/node:v1.25.3; name=kne",
+			"controller  CEOSLab  false      false    -",
+		},
+		wantDefault: true,
+	}, {
+		desc:        "json",
+		args:        []string{"status", "-o", "json"},
+		wantOut:     []string{`"component": "cluster"`, `"kind": "CEOSLab"`},
+		wantDefault: true,
+	}, {
+		desc:    "deployment yaml",
+		args:    []string{"status", "-o", "table", "testdata/kind-deployment.yaml"},
+		wantOut: []string{"COMPONENT"},
+	}, {
+		desc:        "overlay",
+		args:        []string{"status", "testdata/kind-deployment.yaml", "--overlay", overlay},
+		wantOut:     []string{"COMPONENT"},
+		wantDefault: true,
+	}, {
+		desc:    "bad output",
+		args:    []string{"status", "-o", "yaml"},
+		wantErr: "unknown output format",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotDeployment = nil
+			c := New()
+			c.PersistentFlags().String("kubecfg", "", "")
+			var out strings.Builder
+			c.SetOut(&out)
+			c.SilenceErrors = true
+			c.SetArgs(tt.args)
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+			if _, ok := gotDeployment.Cluster.(*deploy.ExternalSpec); ok != tt.wantDefault {
+				t.Errorf("got cluster %T, default deployment %v", gotDeployment.Cluster, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		args    []string
+		wantOut []string
+		wantErr string
+	}{{
+		desc:    "valid",
+		args:    []string{"validate", "testdata/kind-deployment.yaml"},
+		wantOut: []string{"testdata/kind-deployment.yaml: valid"},
+	}, {
+		desc: "invalid",
+		args: []string{"validate", "testdata/invalid-deployment.yaml"},
+		wantOut: []string{
+			"cluster.spec.config: stat ",
+			`cluster.spec: invalid ip family "ipv5"`,
+			"ingress.spec: line 11: field ipcount not found in type deploy.MetalLBSpec",
+			"controllers[1].kind: kind CEOSLAB not supported",
+			"cni: not specified",
+		},
+		wantErr: "5 errors",
+	}, {
+		desc:    "missing file",
+		args:    []string{"validate", "testdata/missing.yaml"},
+		wantErr: "no such file",
+	}, {
+		desc:    "missing args",
+		args:    []string{"validate"},
+		wantErr: "missing args",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := New()
+			var out strings.Builder
+			c.SetOut(&out)
+			c.SilenceErrors = true
+			c.SilenceUsage = true
+			c.SetArgs(tt.args)
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	c := New()
+	var out strings.Builder
+	c.SetOut(&out)
+	c.SetArgs([]string{"schema"})
+	if err := c.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("schema failed: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(out.String()), &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+	for _, want := range []string{`"Kind"`, `"MetalLB"`, `"Meshnet"`, `"CEOSLab"`, `"x-kne": "yaml"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("schema missing %s", want)
+		}
+	}
+}
+
+func TestSetPreloadImages(t *testing.T) {
+	defer func() {
+		preloadImages = false
+		topologies = nil
+		archiveDir = ""
+	}()
+	tests := []struct {
+		desc        string
+		preload     bool
+		topologies  []string
+		wantPreload bool
+		wantImages  []string
+		wantErr     string
+	}{{
+		desc: "no preload",
+	}, {
+		desc:        "preload",
+		preload:     true,
+		wantPreload: true,
+	}, {
+		desc:        "topology",
+		topologies:  []string{"../../examples/host/3node-host.pb.txt"},
+		wantPreload: true,
+		wantImages:  []string{"alpine:latest", "us-west1-docker.pkg.dev/kne-external/kne/networkop/init-wait:ga"},
+	}, {
+		desc:       "missing topology",
+		topologies: []string{"missing.pb.txt"},
+		wantErr:    "failed to load topology",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			preloadImages = tt.preload
+			topologies = tt.topologies
+			archiveDir = "archives"
+			d := &deploy.Deployment{}
+			err := setPreloadImages(d)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if d.PreloadImages != tt.wantPreload {
+				t.Errorf("got PreloadImages %v, want %v", d.PreloadImages, tt.wantPreload)
+			}
+			if d.ImageArchiveDir != "archives" {
+				t.Errorf("got ImageArchiveDir %q, want %q", d.ImageArchiveDir, "archives")
+			}
+			if s := cmp.Diff(tt.wantImages, d.Images); s != "" {
+				t.Errorf("Images unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package experiment implements the kne experiment command.
+package experiment
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/openconfig/kne/experiment"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	output string
+	dryRun bool
+)
+
+// newTopology is replaced in tests.
+var newTopology = func(t *tpb.Topology, opts ...topo.Option) (experiment.Topology, error) {
+	return topo.New(t, opts...)
+}
+
+// New returns the experiment command.
+func New() *cobra.Command {
+	runCmd := &cobra.Command{
+		Use:   "run <experiment>",
+		Short: "Run the cases of an experiment on random topologies.",
+		Long: `Run runs each case of an experiment yaml in turn: it generates a random
+topology from the seed of the case, configures its addresses and routes and
+places its faults, creates the topology, traces the paths to the destinations
+before and after applying the faults, collects telemetry and deletes the
+topology.  The files and result of each case are written to a directory named
+by its ID in the output directory, and every result is appended to
+results.jsonl.  A summary of the cases is printed at the end.`,
+		RunE: runFn,
+	}
+	runCmd.Flags().StringVarP(&output, "output", "o", output, "directory to write the results to (out/<experiment name> if not set)")
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "generate the files of the cases but do not create their topologies")
+	listCmd := &cobra.Command{
+		Use:   "list <experiment>",
+		Short: "List the cases of an experiment and the faults placed in them.",
+		RunE:  listFn,
+	}
+	experimentCmd := &cobra.Command{
+		Use:   "experiment",
+		Short: "Batches of fault experiments on random topologies.",
+	}
+	experimentCmd.AddCommand(runCmd)
+	experimentCmd.AddCommand(listCmd)
+	return experimentCmd
+}
+
+func runFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing args", cmd.Use)
+	}
+	s, err := experiment.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	dir := output
+	if dir == "" {
+		dir = filepath.Join("out", s.Name)
+	}
+	r := &experiment.Runner{
+		Spec: s,
+		Dir:  dir,
+		New: func(t *tpb.Topology) (experiment.Topology, error) {
+			return newTopology(t, topo.WithKubecfg(kubecfg))
+		},
+		DryRun: dryRun,
+	}
+	results, err := r.Run(cmd.Context(), s.Cases())
+	if err := experiment.WriteSummary(cmd.OutOrStdout(), results); err != nil {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d of %d cases failed, see %s", cmd.Use, failed, len(results), filepath.Join(dir, experiment.ResultsFile))
+	}
+	return nil
+}
+
+func listFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing args", cmd.Use)
+	}
+	s, err := experiment.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tNODES\tLINKS\tSEED\tDESTINATIONS\tFAULTS")
+	for _, p := range s.Cases() {
+		if _, err := s.Generate(p); err != nil {
+			fmt.Fprintf(w, "%s\t%d\t-\t%d\t-\terror: %v\n", p.ID, p.Nodes, p.Seed, err)
+			continue
+		}
+		var faults []string
+		for _, f := range p.Faults {
+			faults = append(faults, f.String())
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\n", p.ID, p.Nodes, p.Links, p.Seed, strings.Join(p.Destinations, ","), dash(strings.Join(faults, "; ")))
+	}
+	return w.Flush()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
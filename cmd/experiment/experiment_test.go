@@ -0,0 +1,137 @@
+package experiment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/h-fam/errdiff"
+	kexperiment "github.com/openconfig/kne/experiment"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo"
+)
+
+type fakeTopology struct {
+	topo      *tpb.Topology
+	createErr error
+}
+
+func (f *fakeTopology) Create(context.Context, time.Duration) error { return f.createErr }
+func (f *fakeTopology) Delete(context.Context) error                { return nil }
+
+func (f *fakeTopology) Show(context.Context) (*cpb.ShowTopologyResponse, error) {
+	return &cpb.ShowTopologyResponse{Topology: f.topo}, nil
+}
+
+func (f *fakeTopology) ConfigPush(context.Context, string, io.Reader) error { return nil }
+
+func (f *fakeTopology) Exec(context.Context, string, []string, io.Reader, io.Writer, io.Writer) error {
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		desc      string
+		args      []string
+		createErr error
+		want      string
+		wantFiles []string
+		wantErr   string
+	}{{
+		desc: "run",
+		args: []string{"testdata/small.yaml"},
+		// The fake topology has no gNMI services, so the paths are unknown.
+		want:      `(?m)^n4-p0.2-s1-all-f1-on-path +4 +\d+ +1 +r0,r1,r2,r3 +blackhole on r\d 10\.255\.0\.\d/32 +0 +0 +0 +12 +-$`,
+		wantFiles: []string{kexperiment.ResultsFile, "n4-p0.2-s1-all-f0/" + kexperiment.TracesFile},
+	}, {
+		desc:      "dry run",
+		args:      []string{"testdata/small.yaml", "--dry-run"},
+		want:      `(?m)^Cases: 2, failed: 0$`,
+		wantFiles: []string{"n4-p0.2-s1-all-f1-on-path/" + kexperiment.TopologyFile},
+	}, {
+		desc:      "failed",
+		args:      []string{"testdata/small.yaml"},
+		createErr: fmt.Errorf("no cluster"),
+		want:      `(?m)^n4-p0.2-s1-all-f0 .*create: no cluster$`,
+		wantErr:   "2 of 2 cases failed",
+	}, {
+		desc:    "missing file",
+		args:    []string{"testdata/missing.yaml"},
+		wantErr: "no such file",
+	}, {
+		desc:    "no args",
+		wantErr: "missing args",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			origNewTopology, origDryRun := newTopology, dryRun
+			defer func() {
+				newTopology, dryRun = origNewTopology, origDryRun
+			}()
+			newTopology = func(t *tpb.Topology, _ ...topo.Option) (kexperiment.Topology, error) {
+				return &fakeTopology{topo: t, createErr: tt.createErr}, nil
+			}
+			dir := t.TempDir()
+			c := New()
+			c.PersistentFlags().String("kubecfg", "", "")
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetErr(&out)
+			c.SetArgs(append([]string{"run", "-o", dir}, tt.args...))
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("run unexpected error: %s", s)
+			}
+			if tt.want != "" && !regexp.MustCompile(tt.want).Match(out.Bytes()) {
+				t.Errorf("run got output %q, want match of %q", out.String(), tt.want)
+			}
+			for _, f := range tt.wantFiles {
+				if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+					t.Errorf("run did not write %s: %v", f, err)
+				}
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		desc    string
+		args    []string
+		want    string
+		wantErr string
+	}{{
+		desc: "list",
+		args: []string{"testdata/small.yaml"},
+		want: `(?s)^CASE +NODES +LINKS +SEED +DESTINATIONS +FAULTS\nn4-p0.2-s1-all-f0 +4 +\d+ +1 +r0,r1,r2,r3 +-\nn4-p0.2-s1-all-f1-on-path +4 +\d+ +1 +r0,r1,r2,r3 +blackhole on r\d 10\.255\.0\.\d/32\n$`,
+	}, {
+		desc:    "missing file",
+		args:    []string{"testdata/missing.yaml"},
+		wantErr: "no such file",
+	}, {
+		desc:    "no args",
+		wantErr: "missing args",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := New()
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetArgs(append([]string{"list"}, tt.args...))
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("list unexpected error: %s", s)
+			}
+			if tt.want != "" && !regexp.MustCompile(tt.want).Match(out.Bytes()) {
+				t.Errorf("list got output %q, want match of %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pipeline implements the kne pipeline command.
+package pipeline
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openconfig/kne/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// executable returns the kne binary run by pipeline steps.  It is replaced
+// in tests.
+var executable = os.Executable
+
+// New returns the pipeline command.
+func New() *cobra.Command {
+	runCmd := &cobra.Command{
+		Use:   "run <pipeline>",
+		Short: "Run the steps of a pipeline and report their results.",
+		Long: `Run runs the steps of a pipeline yaml in order: deploying the cluster,
+building and loading images, creating topologies, applying manifests, running
+commands and waiting for nodes, pods, services or resources to be ready.  A
+failed step stops the pipeline.  A summary of the steps is printed at the
+end.`,
+		RunE: runFn,
+	}
+	pipelineCmd := &cobra.Command{
+		Use:   "pipeline",
+		Short: "End to end lab pipelines.",
+	}
+	pipelineCmd.AddCommand(runCmd)
+	return pipelineCmd
+}
+
+func runFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing args", cmd.Use)
+	}
+	p, err := pipeline.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	kne, err := executable()
+	if err != nil {
+		kne = "kne"
+	}
+	r := &pipeline.Runner{
+		Kubecfg: kubecfg,
+		KNE:     kne,
+		Stdout:  cmd.OutOrStdout(),
+		Stderr:  cmd.ErrOrStderr(),
+	}
+	results, err := r.Run(cmd.Context(), p)
+	fmt.Fprintln(cmd.OutOrStdout())
+	if err := pipeline.WriteSummary(cmd.OutOrStdout(), results); err != nil {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	return nil
+}
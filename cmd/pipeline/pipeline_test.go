@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/exec"
+	"github.com/openconfig/kne/exec/fake"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		desc    string
+		args    []string
+		resps   []fake.Response
+		want    string
+		wantErr string
+	}{{
+		desc: "passed",
+		args: []string{"testdata/build.yaml"},
+		resps: []fake.Response{
+			{Cmd: "docker", Args: []string{"build", "-t", "egress:latest", "./egress"}},
+			{Cmd: "kind", Args: []string{"load", "docker-image", "egress:latest", "--name", "kne"}},
+			{Cmd: "go", Args: []string{"test", "./tests"}},
+		},
+		want: `(?m)^tests +run +passed`,
+	}, {
+		desc: "failed",
+		args: []string{"testdata/build.yaml"},
+		resps: []fake.Response{
+			{Cmd: "docker", Args: []string{"build", "-t", "egress:latest", "./egress"}},
+			{Cmd: "kind", Args: []string{"load", "docker-image", "egress:latest", "--name", "kne"}, Err: "no cluster kne"},
+		},
+		want:    `(?m)^load egress:latest +load_image +failed .*no cluster kne\ntests +run +skipped`,
+		wantErr: `step "load egress:latest": no cluster kne`,
+	}, {
+		desc:    "missing file",
+		args:    []string{"testdata/missing.yaml"},
+		wantErr: "no such file",
+	}, {
+		desc:    "no args",
+		wantErr: "missing args",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cmds := fake.Commands(tt.resps)
+			cmds.Strict = true
+			origCommandContext, origExecutable := exec.CommandContext, executable
+			defer func() {
+				exec.CommandContext, executable = origCommandContext, origExecutable
+			}()
+			exec.CommandContext = cmds.CommandContext
+			executable = func() (string, error) { return "kne", nil }
+
+			c := New()
+			c.PersistentFlags().String("kubecfg", "", "")
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetErr(&out)
+			c.SetArgs(append([]string{"run"}, tt.args...))
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("run unexpected error: %s", s)
+			}
+			if err := cmds.Done(); err != nil {
+				t.Errorf("run commands: %v", err)
+			}
+			if tt.want == "" {
+				return
+			}
+			if !regexp.MustCompile(tt.want).Match(out.Bytes()) {
+				t.Errorf("run got output %q, want match of %q", out.String(), tt.want)
+			}
+		})
+	}
+}
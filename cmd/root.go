@@ -23,7 +23,15 @@ import (
 
 	"github.com/kr/pretty"
 	"github.com/openconfig/kne/cmd/deploy"
+	"github.com/openconfig/kne/cmd/experiment"
+	"github.com/openconfig/kne/cmd/pipeline"
+	"github.com/openconfig/kne/cmd/scenario"
+	"github.com/openconfig/kne/cmd/telemetry"
 	"github.com/openconfig/kne/cmd/topology"
+	"github.com/openconfig/kne/cmd/trace"
+	kdeploy "github.com/openconfig/kne/deploy"
+	kexec "github.com/openconfig/kne/exec"
+	"github.com/openconfig/kne/metrics"
 	"github.com/openconfig/kne/topo"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/util/homedir"
@@ -31,9 +39,14 @@ import (
 )
 
 var (
-	kubecfg string
-	dryrun  bool
-	timeout time.Duration
+	kubecfg     string
+	dryrun      bool
+	timeout     time.Duration
+	metricsAddr string
+	preloadKind string
+	archiveDir  string
+	logDir      string
+	recorder    *kexec.Recorder
 
 	rootCmd = &cobra.Command{
 		Use:   "kne",
@@ -41,13 +54,22 @@ var (
 		Long: `Kubernetes Network Emulation CLI.  Works with meshnet to create
 layer 2 topology used by containers to layout networks in a k8s
 environment.`,
-		SilenceUsage: true,
+		SilenceUsage:      true,
+		PersistentPreRunE: preRun,
 	}
 )
 
 // ExecuteContext executes the root command.
 func ExecuteContext(ctx context.Context) error {
-	return rootCmd.ExecuteContext(ctx)
+	err := rootCmd.ExecuteContext(ctx)
+	if recorder != nil {
+		kexec.SetRecorder(nil)
+		if cerr := recorder.Close(); cerr != nil {
+			log.Warningf("Failed to close command log: %v", cerr)
+		}
+		log.Infof("External commands recorded in %s", recorder.Dir())
+	}
+	return err
 }
 
 func defaultKubeCfg() string {
@@ -63,13 +85,23 @@ func defaultKubeCfg() string {
 func init() {
 	rootCmd.SetOut(os.Stdout)
 	rootCmd.PersistentFlags().StringVar(&kubecfg, "kubecfg", defaultKubeCfg(), "kubeconfig file")
+	rootCmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "If set, serve Prometheus metrics on this address (e.g. :9090) while the command runs")
+	rootCmd.PersistentFlags().StringVar(&logDir, "log-dir", "", "If set, record the external commands run, and their output, in a new directory for this run under this directory")
 	createCmd.Flags().BoolVar(&dryrun, "dryrun", false, "Generate topology but do not push to k8s")
 	createCmd.Flags().DurationVar(&timeout, "timeout", 0, "Timeout for pod status enquiry")
+	createCmd.Flags().StringVar(&preloadKind, "preload-kind", "", "If set, load the images of the topology into this kind cluster before creating it")
+	createCmd.Flags().StringVar(&archiveDir, "image-archive-dir", "", "Directory of image archives to preload images missing from the local docker daemon")
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(topology.New())
 	rootCmd.AddCommand(deploy.New())
+	rootCmd.AddCommand(deploy.NewTeardown())
+	rootCmd.AddCommand(scenario.New())
+	rootCmd.AddCommand(trace.New())
+	rootCmd.AddCommand(telemetry.New())
+	rootCmd.AddCommand(pipeline.New())
+	rootCmd.AddCommand(experiment.New())
 }
 
 var (
@@ -96,6 +128,48 @@ var (
 	}
 )
 
+// preRun sets up the metrics server and the command recording for the
+// command being run.
+func preRun(cmd *cobra.Command, args []string) error {
+	if err := serveMetrics(cmd, args); err != nil {
+		return err
+	}
+	return recordCommands()
+}
+
+// recordCommands records the external commands run, such as kind, kubectl and
+// docker, in a new directory under --log-dir, if set.
+func recordCommands() error {
+	if logDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create log dir: %w", err)
+	}
+	dir, err := os.MkdirTemp(logDir, time.Now().Format("kne-20060102-150405-"))
+	if err != nil {
+		return fmt.Errorf("failed to create log dir: %w", err)
+	}
+	if recorder, err = kexec.NewRecorder(dir); err != nil {
+		return fmt.Errorf("failed to record commands: %w", err)
+	}
+	kexec.SetRecorder(recorder)
+	log.Infof("Recording external commands in %s", dir)
+	return nil
+}
+
+// serveMetrics serves Prometheus metrics for the lifetime of the command if
+// --metrics-addr is set.
+func serveMetrics(cmd *cobra.Command, _ []string) error {
+	if metricsAddr == "" {
+		return nil
+	}
+	if err := metrics.Serve(cmd.Context(), metricsAddr); err != nil {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+	return nil
+}
+
 func validateTopology(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("%s: topology must be provided", cmd.Use)
@@ -128,6 +202,16 @@ func createFn(cmd *cobra.Command, args []string) error {
 	if dryrun {
 		return nil
 	}
+	if preloadKind != "" {
+		k := &kdeploy.KindSpec{Name: preloadKind}
+		r, err := k.PreloadImages(cmd.Context(), tm.Images(), archiveDir)
+		if r != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Topology images:\n%s", r)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Use, err)
+		}
+	}
 	return tm.Create(cmd.Context(), timeout)
 }
 
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+
+	kexec "github.com/openconfig/kne/exec"
 )
 
 func TestGetKubeCfg(t *testing.T) {
@@ -55,3 +58,33 @@ func TestGetKubeCfg(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordCommands(t *testing.T) {
+	defer func() {
+		logDir, recorder = "", nil
+		kexec.SetRecorder(nil)
+	}()
+	logDir = t.TempDir()
+	if err := recordCommands(); err != nil {
+		t.Fatalf("recordCommands() failed: %v", err)
+	}
+	if recorder == nil {
+		t.Fatalf("recordCommands() did not set the recorder")
+	}
+	if got := filepath.Dir(recorder.Dir()); got != logDir {
+		t.Errorf("recordCommands() recording in %s, want a directory in %s", recorder.Dir(), logDir)
+	}
+	if err := kexec.Command("true").Run(); err != nil {
+		t.Fatalf("true failed: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	recs, err := kexec.ReadIndex(recorder.Dir())
+	if err != nil {
+		t.Fatalf("ReadIndex() failed: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Args[0] != "true" {
+		t.Errorf("ReadIndex() got %v, want a record of true", recs)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scenario implements the kne scenario command.
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+	"github.com/openconfig/kne/topo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRun     bool
+	skipChecks bool
+)
+
+// newTarget returns the target the scenarios are applied to.  It is replaced
+// in tests.
+var newTarget = func(topopb *tpb.Topology, opts ...topo.Option) (scenario.Target, error) {
+	return topo.New(topopb, opts...)
+}
+
+// New returns the scenario command.
+func New() *cobra.Command {
+	applyCmd := &cobra.Command{
+		Use:   "apply <topology> <scenario>",
+		Short: "Apply the faults of a scenario to a running topology.",
+		RunE:  applyFn,
+	}
+	revertCmd := &cobra.Command{
+		Use:   "revert <topology> <scenario>",
+		Short: "Revert the faults of a scenario applied to a running topology.",
+		RunE:  revertFn,
+	}
+	for _, c := range []*cobra.Command{applyCmd, revertCmd} {
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "print the configuration changes but do not make them")
+		c.Flags().BoolVar(&skipChecks, "skip-checks", false, "do not run the checks of the scenario")
+	}
+	listCmd := &cobra.Command{
+		Use:   "list [<scenario>]",
+		Short: "List the kinds of faults, or the faults of a scenario.",
+		RunE:  listFn,
+	}
+	scenarioCmd := &cobra.Command{
+		Use:   "scenario",
+		Short: "Fault injection scenarios.",
+	}
+	scenarioCmd.AddCommand(applyCmd)
+	scenarioCmd.AddCommand(revertCmd)
+	scenarioCmd.AddCommand(listCmd)
+	return scenarioCmd
+}
+
+func applyFn(cmd *cobra.Command, args []string) error {
+	return run(cmd, args, scenario.Apply, func(p *scenario.Program) []*scenario.Action { return p.Apply })
+}
+
+func revertFn(cmd *cobra.Command, args []string) error {
+	return run(cmd, args, scenario.Revert, func(p *scenario.Program) []*scenario.Action { return p.Revert })
+}
+
+type runner func(ctx context.Context, target scenario.Target, s *scenario.Scenario, t *tpb.Topology) error
+
+func run(cmd *cobra.Command, args []string, fn runner, actions func(*scenario.Program) []*scenario.Action) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := scenario.Load(args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if skipChecks {
+		s.Checks = scenario.Checks{}
+	}
+	if dryRun {
+		p, err := scenario.Compile(s, topopb)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Use, err)
+		}
+		printActions(cmd.OutOrStdout(), actions(p))
+		return nil
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	target, err := newTarget(topopb, topo.WithKubecfg(kubecfg))
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if err := fn(cmd.Context(), target, s, topopb); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	return nil
+}
+
+func printActions(w io.Writer, actions []*scenario.Action) {
+	for _, a := range actions {
+		fmt.Fprintf(w, "# %s (%v)\n%s", a.Node, a.Vendor, a.Config)
+		if a.Wait > 0 {
+			fmt.Fprintf(w, "# wait %v\n", a.Wait)
+		}
+	}
+}
+
+func listFn(cmd *cobra.Command, args []string) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	switch len(args) {
+	case 0:
+		fmt.Fprintln(w, "KIND\tDESCRIPTION")
+		for _, k := range scenario.Kinds {
+			fmt.Fprintf(w, "%s\t%s\n", k, k.Description())
+		}
+	case 1:
+		s, err := scenario.Load(args[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Use, err)
+		}
+		fmt.Fprintln(w, "KIND\tNODE\tINTERFACE\tPREFIX\tPARAMETERS")
+		for _, f := range s.Faults {
+			var params []string
+			if f.Via != "" {
+				params = append(params, "via="+f.Via)
+			}
+			if f.MTU != 0 {
+				params = append(params, fmt.Sprintf("mtu=%d", f.MTU))
+			}
+			if f.Count != 0 {
+				params = append(params, fmt.Sprintf("count=%d interval=%v", f.Count, f.Interval))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Kind, f.Node, dash(f.Interface), dash(f.Prefix), dash(strings.Join(params, " ")))
+		}
+	default:
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	return w.Flush()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
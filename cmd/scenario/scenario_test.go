@@ -0,0 +1,164 @@
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+	"github.com/openconfig/kne/topo"
+)
+
+// fakeTarget records the commands run on it.  Commands in fail fail.
+type fakeTarget struct {
+	cmds []string
+	fail map[string]bool
+}
+
+func (f *fakeTarget) ConfigPush(_ context.Context, node string, _ io.Reader) error {
+	return fmt.Errorf("unexpected push to %s", node)
+}
+
+func (f *fakeTarget) Exec(_ context.Context, node string, cmd []string, _ io.Reader, _, _ io.Writer) error {
+	line := strings.TrimSpace(strings.Join(cmd, " "))
+	f.cmds = append(f.cmds, node+": "+line)
+	if f.fail[line] {
+		return fmt.Errorf("exit code 1")
+	}
+	return nil
+}
+
+func TestApplyRevert(t *testing.T) {
+	tests := []struct {
+		desc     string
+		args     []string
+		target   *fakeTarget
+		want     string
+		wantCmds []string
+		wantErr  string
+	}{{
+		desc:    "apply",
+		args:    []string{"apply", "../testdata/line.pb.txt", "testdata/down.yaml"},
+		target:  &fakeTarget{fail: map[string]bool{}},
+		wantErr: "post check failed after applying",
+		wantCmds: []string{
+			"r1: ping -c1 10.255.0.2",
+			"r1: sh -c ip link set eth1 down",
+			"r2: sh -c ip link set eth1 down",
+			"r2: sh -c ip route replace blackhole 10.0.0.0/8",
+			"r1: ping -c1 10.255.0.2",
+		},
+	}, {
+		desc:   "apply skip checks",
+		args:   []string{"apply", "--skip-checks", "../testdata/line.pb.txt", "testdata/down.yaml"},
+		target: &fakeTarget{},
+		wantCmds: []string{
+			"r1: sh -c ip link set eth1 down",
+			"r2: sh -c ip link set eth1 down",
+			"r2: sh -c ip route replace blackhole 10.0.0.0/8",
+		},
+	}, {
+		desc:   "revert",
+		args:   []string{"revert", "--skip-checks", "../testdata/line.pb.txt", "testdata/down.yaml"},
+		target: &fakeTarget{},
+		wantCmds: []string{
+			"r2: sh -c ip route del blackhole 10.0.0.0/8",
+			"r1: sh -c ip link set eth1 up",
+			"r2: sh -c ip link set eth1 up",
+		},
+	}, {
+		desc:   "dry run",
+		args:   []string{"apply", "--dry-run", "../testdata/line.pb.txt", "testdata/down.yaml"},
+		target: &fakeTarget{},
+		want: `# r1 (HOST)
+ip link set eth1 down
+# r2 (HOST)
+ip link set eth1 down
+# r2 (HOST)
+ip route replace blackhole 10.0.0.0/8
+`,
+	}, {
+		desc:    "missing scenario",
+		args:    []string{"apply", "../testdata/line.pb.txt"},
+		target:  &fakeTarget{},
+		wantErr: "invalid args",
+	}, {
+		desc:    "bad scenario",
+		args:    []string{"apply", "../testdata/line.pb.txt", "testdata/missing.yaml"},
+		target:  &fakeTarget{},
+		wantErr: "no such file",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			origNewTarget := newTarget
+			newTarget = func(_ *tpb.Topology, _ ...topo.Option) (scenario.Target, error) {
+				return tt.target, nil
+			}
+			defer func() {
+				newTarget = origNewTarget
+				dryRun, skipChecks = false, false
+			}()
+			c := New()
+			c.PersistentFlags().String("kubecfg", "", "")
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetArgs(tt.args)
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.wantCmds, tt.target.cmds); s != "" {
+				t.Errorf("unexpected commands (-want +got):\n%s", s)
+			}
+			if tt.want != "" {
+				if s := cmp.Diff(tt.want, out.String()); s != "" {
+					t.Errorf("unexpected output (-want +got):\n%s", s)
+				}
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		desc    string
+		args    []string
+		want    []string
+		wantErr string
+	}{{
+		desc: "kinds",
+		args: []string{"list"},
+		want: []string{"KIND", "routing-loop", "interface-flap"},
+	}, {
+		desc: "faults",
+		args: []string{"list", "testdata/down.yaml"},
+		want: []string{"link-down  r1    eth1", "blackhole  r2    -          10.0.0.0/8"},
+	}, {
+		desc:    "too many args",
+		args:    []string{"list", "a", "b"},
+		wantErr: "invalid args",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := New()
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetArgs(tt.args)
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("output does not contain %q:\n%s", w, out.String())
+				}
+			}
+		})
+	}
+}
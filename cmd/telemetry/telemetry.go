@@ -0,0 +1,124 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry implements the kne telemetry command.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/telemetry"
+	"github.com/openconfig/kne/topo"
+	"github.com/spf13/cobra"
+	log "k8s.io/klog/v2"
+)
+
+var (
+	opts = telemetry.Options{
+		Interval: telemetry.DefaultInterval,
+	}
+	format = "jsonl"
+	output string
+)
+
+// TopologyManager is the part of *topo.Manager used to collect telemetry.
+type TopologyManager interface {
+	Show(ctx context.Context) (*cpb.ShowTopologyResponse, error)
+}
+
+// newTopologyManager and collect are replaced in tests.
+var (
+	newTopologyManager = func(topopb *tpb.Topology, opts ...topo.Option) (TopologyManager, error) {
+		return topo.New(topopb, opts...)
+	}
+	collect = telemetry.Collect
+)
+
+// New returns the telemetry command.
+func New() *cobra.Command {
+	collectCmd := &cobra.Command{
+		Use:   "collect <topology>",
+		Short: "Collect telemetry from all nodes of a topology into a time series.",
+		Long: `Collect telemetry from all nodes of a topology into a time series.
+
+The paths are subscribed to in sample mode on the gNMI service of each node.
+Each sample is written with its timestamp, node, path and value, and the
+change since the previous sample for integer values such as counters.`,
+		RunE: collectFn,
+	}
+	collectCmd.Flags().StringSliceVar(&opts.Paths, "paths", opts.Paths, "gNMI paths to collect, such as /interfaces/interface[name=*]/state/counters")
+	collectCmd.Flags().DurationVar(&opts.Interval, "interval", opts.Interval, "sample interval")
+	collectCmd.Flags().DurationVar(&opts.Duration, "duration", opts.Duration, "how long to collect for (until interrupted if 0)")
+	collectCmd.Flags().StringVar(&opts.Username, "username", opts.Username, "gNMI username (no credentials are sent if not set)")
+	collectCmd.Flags().StringVar(&opts.Password, "password", opts.Password, "gNMI password")
+	collectCmd.Flags().StringVar(&format, "format", format, fmt.Sprintf("output format, one of %q", telemetry.Formats))
+	collectCmd.Flags().StringVarP(&output, "output", "o", output, "file to write the samples to (stdout if not set)")
+	telemetryCmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Telemetry commands.",
+	}
+	telemetryCmd.AddCommand(collectCmd)
+	return telemetryCmd
+}
+
+func collectFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing topology", cmd.Use)
+	}
+	if len(opts.Paths) == 0 {
+		return fmt.Errorf("%s: --paths must be provided", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	var out io.Writer = cmd.OutOrStdout()
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Use, err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Warningf("Failed to close %s: %v", output, err)
+			}
+		}()
+		out = f
+	}
+	w, err := telemetry.NewWriter(out, format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	tm, err := newTopologyManager(topopb, topo.WithKubecfg(s))
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	ts, err := tm.Show(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if err := collect(cmd.Context(), ts.GetTopology(), &opts, w); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	return nil
+}
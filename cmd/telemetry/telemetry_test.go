@@ -0,0 +1,122 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/telemetry"
+	"github.com/openconfig/kne/topo"
+)
+
+type fakeTopologyManager struct{}
+
+func (f *fakeTopologyManager) Show(_ context.Context) (*cpb.ShowTopologyResponse, error) {
+	return &cpb.ShowTopologyResponse{Topology: &tpb.Topology{Name: "shown"}}, nil
+}
+
+func TestCollect(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "out.csv")
+	tests := []struct {
+		desc     string
+		args     []string
+		want     string
+		wantFile string
+		wantOpts telemetry.Options
+		wantErr  string
+	}{{
+		desc: "jsonl",
+		args: []string{"collect", "../testdata/line.pb.txt", "--paths", "/a,/b", "--duration", "1m"},
+		want: `{"time":"2023-05-01T12:00:00Z","node":"r1","path":"/a","value":"1"}
+`,
+		wantOpts: telemetry.Options{
+			Paths:    []string{"/a", "/b"},
+			Interval: telemetry.DefaultInterval,
+			Duration: time.Minute,
+		},
+	}, {
+		desc: "csv file",
+		args: []string{"collect", "../testdata/line.pb.txt", "--paths", "/a", "--format", "csv", "-o", outFile, "--interval", "1s", "--username", "u", "--password", "p"},
+		wantFile: `time,node,path,value,delta
+2023-05-01T12:00:00Z,r1,/a,1,
+`,
+		wantOpts: telemetry.Options{
+			Paths:    []string{"/a"},
+			Interval: time.Second,
+			Username: "u",
+			Password: "p",
+		},
+	}, {
+		desc:    "no paths",
+		args:    []string{"collect", "../testdata/line.pb.txt"},
+		wantErr: "--paths must be provided",
+	}, {
+		desc:    "bad format",
+		args:    []string{"collect", "../testdata/line.pb.txt", "--paths", "/a", "--format", "xml"},
+		wantErr: `unknown format "xml"`,
+	}, {
+		desc:    "missing topology",
+		args:    []string{"collect"},
+		wantErr: "missing topology",
+	}}
+	origNewTopologyManager, origCollect := newTopologyManager, collect
+	defer func() {
+		newTopologyManager, collect = origNewTopologyManager, origCollect
+	}()
+	newTopologyManager = func(*tpb.Topology, ...topo.Option) (TopologyManager, error) {
+		return &fakeTopologyManager{}, nil
+	}
+	origOpts, origFormat, origOutput := opts, format, output
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			defer func() {
+				opts, format, output = origOpts, origFormat, origOutput
+			}()
+			var gotOpts telemetry.Options
+			collect = func(_ context.Context, topo *tpb.Topology, o *telemetry.Options, w telemetry.Writer) error {
+				if topo.GetName() != "shown" {
+					t.Errorf("collect() got topology %q, want the shown topology", topo.GetName())
+				}
+				gotOpts = *o
+				if err := w.Write(&telemetry.Sample{Time: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), Node: "r1", Path: "/a", Value: "1"}); err != nil {
+					return err
+				}
+				return w.Flush()
+			}
+			c := New()
+			c.PersistentFlags().String("kubecfg", "", "")
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetArgs(tt.args)
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if s := cmp.Diff(tt.wantOpts, gotOpts); s != "" {
+				t.Errorf("unexpected options (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.want, out.String()); s != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", s)
+			}
+			if tt.wantFile != "" {
+				b, err := os.ReadFile(outFile)
+				if err != nil {
+					t.Fatalf("failed to read output: %v", err)
+				}
+				if s := cmp.Diff(tt.wantFile, string(b)); s != "" {
+					t.Errorf("unexpected output file (-want +got):\n%s", s)
+				}
+			}
+		})
+	}
+}
@@ -16,13 +16,17 @@ package topology
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"text/tabwriter"
 
 	"github.com/openconfig/gnmi/errlist"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/reachability"
+	"github.com/openconfig/kne/testbed"
 	"github.com/openconfig/kne/topo"
 	"github.com/openconfig/kne/topo/node"
 	"github.com/spf13/cobra"
@@ -53,18 +57,45 @@ func New() *cobra.Command {
 		Short: "push or generate certs for nodes in topology",
 		RunE:  certFn,
 	}
+	forwardCmd := &cobra.Command{
+		Use:   "forward <topology> [<device>...]",
+		Short: "forward local ports to the services of devices (all devices if none provided) until interrupted",
+		RunE:  forwardFn,
+	}
+	forwardCmd.Flags().StringVar(&forwardAddress, "address", forwardAddress, "local address to listen on")
 	resetCfgCmd := &cobra.Command{
 		Use:   "reset <topology> <device>",
 		Short: "reset configuration of device to vendor default (if device not provide reset all nodes)",
 		RunE:  resetCfgFn,
 	}
+	reachabilityCmd := &cobra.Command{
+		Use:   "reachability <topology>",
+		Short: "probe the reachability of destinations, by default the loopbacks of all devices, from each device and check the results against a spec",
+		RunE:  reachabilityFn,
+	}
+	reachabilityCmd.Flags().StringVar(&reachabilitySpec, "spec", reachabilitySpec, "YAML file of the probes and their expected results (default: ping every device, expecting every device to be reachable)")
+	reachabilityCmd.Flags().StringSliceVar(&reachabilitySources, "sources", reachabilitySources, "devices to probe from, overriding those of the spec")
+	reachabilityCmd.Flags().StringVar(&reachabilityFormat, "format", reachabilityFormat, "output format, table or json")
+	testbedCmd := &cobra.Command{
+		Use:   "testbed <topology>",
+		Short: "generate an Ondatra testbed, and optionally a KNE binding config, from a topology",
+		RunE:  testbedFn,
+	}
+	testbedCmd.Flags().StringVarP(&testbedOutput, "output", "o", testbedOutput, "file to write the testbed to (default: stdout)")
+	testbedCmd.Flags().StringVar(&testbedBinding, "binding", testbedBinding, "file to write the KNE binding config to")
+	testbedCmd.Flags().StringVar(&testbedUsername, "username", testbedUsername, "default device username of the binding config")
+	testbedCmd.Flags().StringVar(&testbedPassword, "password", testbedPassword, "default device password of the binding config")
+	testbedCmd.Flags().BoolVar(&testbedSkipReset, "skip-reset", testbedSkipReset, "skip resetting devices when reserving them in the binding config")
 	topoCmd := &cobra.Command{
 		Use:   "topology",
 		Short: "Topology commands.",
 	}
 	topoCmd.AddCommand(certCmd)
+	topoCmd.AddCommand(forwardCmd)
 	topoCmd.AddCommand(pushCmd)
+	topoCmd.AddCommand(reachabilityCmd)
 	topoCmd.AddCommand(serviceCmd)
+	topoCmd.AddCommand(testbedCmd)
 	topoCmd.AddCommand(watchCmd)
 	resetCfgCmd.Flags().BoolVar(&skipReset, "skip", skipReset, "skip nodes if they are not resetable")
 	resetCfgCmd.Flags().BoolVar(&pushConfig, "push", pushConfig, "additionally push orginal topology configuration")
@@ -73,9 +104,20 @@ func New() *cobra.Command {
 }
 
 var (
-	skipReset  bool
-	pushConfig bool
-	opts       []topo.Option
+	skipReset      bool
+	pushConfig     bool
+	forwardAddress = "localhost"
+	opts           []topo.Option
+
+	reachabilitySpec    string
+	reachabilitySources []string
+	reachabilityFormat  = "table"
+
+	testbedOutput    string
+	testbedBinding   string
+	testbedUsername  string
+	testbedPassword  string
+	testbedSkipReset bool
 )
 
 func fileRelative(p string) (string, error) {
@@ -242,8 +284,13 @@ var newTopologyManager = func(topopb *tpb.Topology, opts ...topo.Option) (Topolo
 	return topo.New(topopb, opts...)
 }
 
+var newExecer = func(topopb *tpb.Topology, opts ...topo.Option) (reachability.Target, error) {
+	return topo.New(topopb, opts...)
+}
+
 type TopologyManager interface {
 	Show(ctx context.Context) (*cpb.ShowTopologyResponse, error)
+	Forward(ctx context.Context, address string, nodes ...string) ([]*topo.Forward, error)
 }
 
 func serviceFn(cmd *cobra.Command, args []string) error {
@@ -271,3 +318,143 @@ func serviceFn(cmd *cobra.Command, args []string) error {
 	fmt.Fprintln(cmd.OutOrStdout(), prototext.Format(ts.Topology))
 	return nil
 }
+
+func forwardFn(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s: missing topology", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	tOpts := append(opts, topo.WithKubecfg(s))
+	tm, err := newTopologyManager(topopb, tOpts...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	fwds, err := tm.Forward(ctx, forwardAddress, args[1:]...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSERVICE\tLOCAL\tPORT")
+	for _, f := range fwds {
+		service := f.Service
+		if service == "" {
+			service = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", f.Node, service, f.Local, f.Port)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func reachabilityFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing topology", cmd.Use)
+	}
+	if reachabilityFormat != "table" && reachabilityFormat != "json" {
+		return fmt.Errorf("%s: unknown format %q", cmd.Use, reachabilityFormat)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	spec := &reachability.Spec{}
+	if reachabilitySpec != "" {
+		if spec, err = reachability.Load(reachabilitySpec); err != nil {
+			return fmt.Errorf("%s: %w", cmd.Use, err)
+		}
+	}
+	if len(reachabilitySources) > 0 {
+		spec.Sources = reachabilitySources
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	tOpts := append(opts, topo.WithKubecfg(s))
+	target, err := newExecer(topopb, tOpts...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	results, err := reachability.Run(cmd.Context(), target, topopb, spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if reachabilityFormat == "json" {
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(b))
+	} else if err := reachability.WriteMatrix(cmd.OutOrStdout(), results); err != nil {
+		return err
+	}
+	if m := reachability.Mismatches(results); len(m) > 0 {
+		return fmt.Errorf("%s: %d of %d probes did not match expectations", cmd.Use, len(m), len(results))
+	}
+	return nil
+}
+
+func testbedFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: missing topology", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	tb, err := testbed.Generate(topopb)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	b, err := testbed.Marshal(tb)
+	if err != nil {
+		return err
+	}
+	if testbedOutput == "" {
+		fmt.Fprint(cmd.OutOrStdout(), string(b))
+	} else if err := os.WriteFile(testbedOutput, b, 0o644); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if testbedBinding == "" {
+		return nil
+	}
+	topoPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	binding := &testbed.Binding{
+		Topology:  topoPath,
+		Kubecfg:   kubecfg,
+		SkipReset: testbedSkipReset,
+	}
+	if testbedUsername != "" || testbedPassword != "" {
+		binding.Credentials = &testbed.Credentials{
+			Username: testbedUsername,
+			Password: testbedPassword,
+		}
+	}
+	b, err = binding.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(testbedBinding, b, 0o644); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	return nil
+}
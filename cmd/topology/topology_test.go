@@ -8,15 +8,19 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/h-fam/errdiff"
 	tfake "github.com/networkop/meshnet-cni/api/clientset/v1beta1/fake"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/reachability"
 	"github.com/openconfig/kne/topo"
 	"github.com/openconfig/kne/topo/node"
+	opb "github.com/openconfig/ondatra/proto"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/testing/protocmp"
 	kfake "k8s.io/client-go/kubernetes/fake"
@@ -308,8 +312,14 @@ links: {
 )
 
 type fakeTopologyManager struct {
-	topo    *tpb.Topology
-	showErr error
+	topo       *tpb.Topology
+	showErr    error
+	forwards   []*topo.Forward
+	forwardErr error
+	// cancel is called by Forward to stop the forward command.
+	cancel      func()
+	gotAddress  string
+	gotForwards []string
 }
 
 func (f *fakeTopologyManager) Show(_ context.Context) (*cpb.ShowTopologyResponse, error) {
@@ -322,6 +332,15 @@ func (f *fakeTopologyManager) Show(_ context.Context) (*cpb.ShowTopologyResponse
 	}, nil
 }
 
+func (f *fakeTopologyManager) Forward(_ context.Context, address string, nodes ...string) ([]*topo.Forward, error) {
+	f.gotAddress = address
+	f.gotForwards = nodes
+	if f.cancel != nil {
+		f.cancel()
+	}
+	return f.forwards, f.forwardErr
+}
+
 func TestService(t *testing.T) {
 	validProto := &tpb.Topology{}
 	if err := prototext.Unmarshal([]byte(validPbTxt), validProto); err != nil {
@@ -464,3 +483,259 @@ func TestPush(t *testing.T) {
 		})
 	}
 }
+
+func TestForward(t *testing.T) {
+	forwards := []*topo.Forward{
+		{Node: "r1", Service: "ssh", Local: "localhost:40022", Port: 22},
+		{Node: "r2", Local: "localhost:49339", Port: 9339},
+	}
+	tests := []struct {
+		desc        string
+		args        []string
+		topoManager *fakeTopologyManager
+		wantOut     []string
+		wantAddress string
+		wantNodes   []string
+		wantErr     string
+	}{{
+		desc:    "no args",
+		args:    []string{"forward"},
+		wantErr: "missing topology",
+	}, {
+		desc:        "all nodes",
+		args:        []string{"forward", "testdata/valid_topo.pb.txt"},
+		topoManager: &fakeTopologyManager{forwards: forwards},
+		wantOut: []string{
+			"NODE  SERVICE  LOCAL            PORT",
+			"r1    ssh      localhost:40022  22",
+			"r2    -        localhost:49339  9339",
+		},
+		wantAddress: "localhost",
+	}, {
+		desc:        "named nodes and address",
+		args:        []string{"forward", "--address", "0.0.0.0", "testdata/valid_topo.pb.txt", "r1", "r2"},
+		topoManager: &fakeTopologyManager{forwards: forwards},
+		wantOut:     []string{"NODE"},
+		wantAddress: "0.0.0.0",
+		wantNodes:   []string{"r1", "r2"},
+	}, {
+		desc:        "forward fails",
+		args:        []string{"forward", "testdata/valid_topo.pb.txt"},
+		topoManager: &fakeTopologyManager{forwardErr: fmt.Errorf("some error")},
+		wantErr:     "some error",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			origNewTopologyManager := newTopologyManager
+			newTopologyManager = func(_ *tpb.Topology, _ ...topo.Option) (TopologyManager, error) {
+				return tt.topoManager, nil
+			}
+			defer func() {
+				newTopologyManager = origNewTopologyManager
+				forwardAddress = "localhost"
+			}()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.topoManager != nil {
+				tt.topoManager.cancel = cancel
+			}
+			fCmd := New()
+			fCmd.PersistentFlags().String("kubecfg", "", "")
+			buf := &bytes.Buffer{}
+			fCmd.SetOut(buf)
+			fCmd.SetArgs(tt.args)
+			err := fCmd.ExecuteContext(ctx)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("forwardCmd failed: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+			if tt.topoManager.gotAddress != tt.wantAddress {
+				t.Errorf("Forward() got address %q, want %q", tt.topoManager.gotAddress, tt.wantAddress)
+			}
+			if s := cmp.Diff(tt.wantNodes, tt.topoManager.gotForwards, cmpopts.EquateEmpty()); s != "" {
+				t.Errorf("Forward() unexpected nodes diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+// fakeExecer replies to every ping with out and err.
+type fakeExecer struct {
+	out     string
+	err     error
+	gotCmds [][]string
+}
+
+func (f *fakeExecer) Exec(_ context.Context, node string, cmd []string, _ io.Reader, stdout, _ io.Writer) error {
+	f.gotCmds = append(f.gotCmds, append([]string{node}, cmd...))
+	stdout.Write([]byte(f.out))
+	return f.err
+}
+
+func TestReachability(t *testing.T) {
+	const reachable = "3 packets transmitted, 3 received, 0% packet loss\nrtt min/avg/max/mdev = 1.0/2.0/3.0/0.5 ms\n"
+	tests := []struct {
+		desc    string
+		args    []string
+		execer  *fakeExecer
+		wantOut []string
+		wantErr string
+	}{{
+		desc:    "no args",
+		args:    []string{"reachability"},
+		wantErr: "missing topology",
+	}, {
+		desc:    "bad format",
+		args:    []string{"reachability", "--format", "xml", "../testdata/line.pb.txt"},
+		wantErr: `unknown format "xml"`,
+	}, {
+		desc:    "missing spec",
+		args:    []string{"reachability", "--spec", "testdata/missing.yaml", "../testdata/line.pb.txt"},
+		wantErr: "missing.yaml",
+	}, {
+		desc:   "all reachable",
+		args:   []string{"reachability", "../testdata/line.pb.txt"},
+		execer: &fakeExecer{out: reachable},
+		wantOut: []string{
+			"SOURCE  r2   r1",
+			"r1      2ms  -",
+			"r2      -    2ms",
+		},
+	}, {
+		desc:    "json",
+		args:    []string{"reachability", "--format", "json", "--sources", "r2", "../testdata/line.pb.txt"},
+		execer:  &fakeExecer{out: reachable},
+		wantOut: []string{`"source": "r2"`, `"destination": "r1"`, `"rtt_ns": 2000000`},
+	}, {
+		desc:    "mismatch",
+		args:    []string{"reachability", "--spec", "testdata/isolated.yaml", "../testdata/line.pb.txt"},
+		execer:  &fakeExecer{out: reachable},
+		wantOut: []string{"2ms *", "* r1 -> r2 (ping 10.255.0.2): reachable, want unreachable"},
+		wantErr: "2 of 2 probes did not match expectations",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			origNewExecer := newExecer
+			newExecer = func(_ *tpb.Topology, _ ...topo.Option) (reachability.Target, error) {
+				return tt.execer, nil
+			}
+			defer func() {
+				newExecer = origNewExecer
+				reachabilitySpec = ""
+				reachabilitySources = nil
+				reachabilityFormat = "table"
+			}()
+			rCmd := New()
+			rCmd.PersistentFlags().String("kubecfg", "", "")
+			buf := &bytes.Buffer{}
+			rCmd.SetOut(buf)
+			rCmd.SetArgs(tt.args)
+			err := rCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("reachabilityCmd failed: %s", s)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTestbed(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		desc        string
+		args        []string
+		wantOut     bool
+		wantFile    string
+		wantBinding []string
+		wantErr     string
+	}{{
+		desc:    "no args",
+		args:    []string{"testbed"},
+		wantErr: "missing topology",
+	}, {
+		desc:    "stdout",
+		args:    []string{"testbed", "testdata/valid_topo.pb.txt"},
+		wantOut: true,
+	}, {
+		desc:     "file and binding",
+		args:     []string{"testbed", "-o", filepath.Join(dir, "testbed.textproto"), "--binding", filepath.Join(dir, "kne.yaml"), "--username", "admin", "--password", "pass", "testdata/valid_topo.pb.txt"},
+		wantFile: filepath.Join(dir, "testbed.textproto"),
+		wantBinding: []string{
+			"topology: /",
+			"testdata/valid_topo.pb.txt",
+			"kubecfg: /tmp/kubeconfig",
+			"username: admin",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			defer func() {
+				testbedOutput = ""
+				testbedBinding = ""
+				testbedUsername = ""
+				testbedPassword = ""
+			}()
+			tCmd := New()
+			tCmd.PersistentFlags().String("kubecfg", "/tmp/kubeconfig", "")
+			buf := &bytes.Buffer{}
+			tCmd.SetOut(buf)
+			tCmd.SetArgs(tt.args)
+			err := tCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("testbedCmd failed: %s", s)
+			}
+			// The spacing of prototext output is unstable, so the testbed
+			// is compared after parsing it.
+			checkTestbed := func(b []byte) {
+				t.Helper()
+				if !bytes.HasPrefix(b, []byte("# proto-file:")) || !bytes.Contains(b, []byte("# proto-message: ondatra.Testbed")) {
+					t.Errorf("testbed missing header:\n%s", b)
+				}
+				got := &opb.Testbed{}
+				if err := prototext.Unmarshal(b, got); err != nil {
+					t.Fatalf("testbed not parsable: %v", err)
+				}
+				want := &opb.Testbed{
+					Duts:  []*opb.Device{{Id: "r1", Vendor: opb.Device_ARISTA, Ports: []*opb.Port{{Id: "port1"}, {Id: "port2"}}}},
+					Ates:  []*opb.Device{{Id: "otg", Vendor: opb.Device_IXIA, Ports: []*opb.Port{{Id: "port1"}, {Id: "port2"}}}},
+					Links: []*opb.Link{{A: "r1:port1", B: "otg:port1"}, {A: "r1:port2", B: "otg:port2"}},
+				}
+				if s := cmp.Diff(want, got, protocmp.Transform(), protocmp.IgnoreFields(&opb.Device{}, "hardware_model", "software_version")); s != "" {
+					t.Errorf("unexpected testbed (-want +got):\n%s", s)
+				}
+			}
+			if tt.wantOut {
+				checkTestbed(buf.Bytes())
+			}
+			if tt.wantFile != "" {
+				b, err := os.ReadFile(tt.wantFile)
+				if err != nil {
+					t.Fatalf("testbed not written: %v", err)
+				}
+				checkTestbed(b)
+			}
+			if len(tt.wantBinding) > 0 {
+				b, err := os.ReadFile(filepath.Join(dir, "kne.yaml"))
+				if err != nil {
+					t.Fatalf("binding not written: %v", err)
+				}
+				for _, want := range tt.wantBinding {
+					if !strings.Contains(string(b), want) {
+						t.Errorf("binding missing %q:\n%s", want, b)
+					}
+				}
+			}
+		})
+	}
+}
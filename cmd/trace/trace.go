@@ -0,0 +1,124 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trace implements the kne trace command.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"time"
+
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	opts      trace.Options
+	otlpFile  string
+	requestID string
+)
+
+// TopologyManager is the part of *topo.Manager used to trace.
+type TopologyManager interface {
+	Show(ctx context.Context) (*cpb.ShowTopologyResponse, error)
+}
+
+// newTopologyManager and fetchAll are replaced in tests.
+var (
+	newTopologyManager = func(topopb *tpb.Topology, opts ...topo.Option) (TopologyManager, error) {
+		return topo.New(topopb, opts...)
+	}
+	fetchAll = trace.FetchAll
+)
+
+// New returns the trace command.
+func New() *cobra.Command {
+	traceCmd := &cobra.Command{
+		Use:   "trace <topology> <source node> <destination ip>",
+		Short: "Trace the forwarding path from a node to an address hop by hop over gNMI.",
+		Long: `Trace the forwarding path from a node to an address hop by hop.
+
+The forwarding tables (AFTs) and interface addresses of the nodes are read over
+their gNMI services. The path, and whether it is delivered, loops, is
+blackholed or leaves the topology, is printed as JSON. With --otlp the path is
+also written as OTLP/JSON spans of the trace of --request-id.`,
+		RunE: traceFn,
+	}
+	traceCmd.Flags().StringVar(&opts.NetworkInstance, "network-instance", opts.NetworkInstance, "network instance to trace in (the default instance if not set)")
+	traceCmd.Flags().StringVar(&opts.Username, "username", opts.Username, "gNMI username (no credentials are sent if not set)")
+	traceCmd.Flags().StringVar(&opts.Password, "password", opts.Password, "gNMI password")
+	traceCmd.Flags().StringVar(&otlpFile, "otlp", otlpFile, "file to write the path to as OTLP/JSON spans")
+	traceCmd.Flags().StringVar(&requestID, "request-id", requestID, "x-request-id of the traced request in the OTLP spans (random if not set)")
+	return traceCmd
+}
+
+func traceFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	dst, err := netip.ParseAddr(args[2])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	tm, err := newTopologyManager(topopb, topo.WithKubecfg(s))
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	ts, err := tm.Show(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	start := time.Now()
+	tables := fetchAll(cmd.Context(), ts.GetTopology(), &opts)
+	r, err := trace.Trace(ts.GetTopology(), tables, args[1], dst)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if otlpFile != "" {
+		id := requestID
+		if id == "" {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+			id = hex.EncodeToString(b)
+		}
+		req := &trace.Request{ID: id, Paths: []*trace.Result{r}, Start: start, End: time.Now()}
+		if err := (&trace.Exporter{}).WriteFile(otlpFile, []*trace.Request{req}); err != nil {
+			return fmt.Errorf("%s: %w", cmd.Use, err)
+		}
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(b))
+	return nil
+}
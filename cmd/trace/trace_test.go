@@ -0,0 +1,155 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/trace"
+)
+
+type fakeTopologyManager struct {
+	showErr error
+}
+
+func (f *fakeTopologyManager) Show(_ context.Context) (*cpb.ShowTopologyResponse, error) {
+	if f.showErr != nil {
+		return nil, f.showErr
+	}
+	t, err := topo.Load("../testdata/line.pb.txt")
+	if err != nil {
+		return nil, err
+	}
+	return &cpb.ShowTopologyResponse{Topology: t}, nil
+}
+
+func tables() map[string]*trace.Table {
+	r1, r2 := trace.NewTable(), trace.NewTable()
+	r1.Entries[netip.MustParsePrefix("10.0.0.0/8")] = 1
+	r1.NextHopGroups[1] = []uint64{1}
+	r1.NextHops[1] = &trace.NextHop{Index: 1, IP: netip.MustParseAddr("192.168.0.1"), Interface: "eth1"}
+	r2.Addresses[netip.MustParseAddr("192.168.0.1")] = "eth1"
+	r2.Addresses[netip.MustParseAddr("10.0.0.2")] = "lo"
+	return map[string]*trace.Table{"r1": r1, "r2": r2}
+}
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		desc     string
+		args     []string
+		tm       *fakeTopologyManager
+		want     string
+		wantOTLP []string
+		wantErr  string
+	}{{
+		desc: "delivered",
+		args: []string{"../testdata/line.pb.txt", "r1", "10.0.0.2"},
+		tm:   &fakeTopologyManager{},
+		want: `{
+  "source": "r1",
+  "destination": "10.0.0.2",
+  "status": "delivered",
+  "path": [
+    {
+      "node": "r1",
+      "prefix": "10.0.0.0/8",
+      "next_hop": "192.168.0.1",
+      "out_interface": "eth1"
+    },
+    {
+      "node": "r2",
+      "in_interface": "eth1"
+    }
+  ]
+}
+`,
+	}, {
+		desc:     "otlp",
+		args:     []string{"../testdata/line.pb.txt", "r1", "10.0.0.2", "--otlp", "spans.json", "--request-id", "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"},
+		tm:       &fakeTopologyManager{},
+		wantOTLP: []string{`"traceId": "4bf92f3577b34da6a3ce929d0e0e4736"`, `"name": "path r1 -> 10.0.0.2"`, `"key": "kne.interface.egress"`},
+	}, {
+		desc:    "otlp bad file",
+		args:    []string{"../testdata/line.pb.txt", "r1", "10.0.0.2", "--otlp", "missing/spans.json"},
+		tm:      &fakeTopologyManager{},
+		wantErr: "no such file",
+	}, {
+		desc:    "missing args",
+		args:    []string{"../testdata/line.pb.txt", "r1"},
+		tm:      &fakeTopologyManager{},
+		wantErr: "invalid args",
+	}, {
+		desc:    "bad address",
+		args:    []string{"../testdata/line.pb.txt", "r1", "10.0.0"},
+		tm:      &fakeTopologyManager{},
+		wantErr: "IPv4 address too short",
+	}, {
+		desc:    "unknown node",
+		args:    []string{"../testdata/line.pb.txt", "r9", "10.0.0.2"},
+		tm:      &fakeTopologyManager{},
+		wantErr: `node "r9" not found`,
+	}, {
+		desc:    "show fails",
+		args:    []string{"../testdata/line.pb.txt", "r1", "10.0.0.2"},
+		tm:      &fakeTopologyManager{showErr: fmt.Errorf("no cluster")},
+		wantErr: "no cluster",
+	}}
+	origNewTopologyManager, origFetchAll := newTopologyManager, fetchAll
+	defer func() {
+		newTopologyManager, fetchAll = origNewTopologyManager, origFetchAll
+		otlpFile, requestID = "", ""
+	}()
+	dir := t.TempDir()
+	fetchAll = func(context.Context, *tpb.Topology, *trace.Options) map[string]*trace.Table {
+		return tables()
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			newTopologyManager = func(*tpb.Topology, ...topo.Option) (TopologyManager, error) {
+				return tt.tm, nil
+			}
+			c := New()
+			c.Flags().String("kubecfg", "", "")
+			var out bytes.Buffer
+			c.SetOut(&out)
+			otlpFile, requestID = "", ""
+			var args []string
+			for i, a := range tt.args {
+				if i > 0 && tt.args[i-1] == "--otlp" {
+					a = filepath.Join(dir, a)
+				}
+				args = append(args, a)
+			}
+			c.SetArgs(args)
+			err := c.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, out.String()); tt.want != "" && s != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", s)
+			}
+			if len(tt.wantOTLP) == 0 {
+				return
+			}
+			b, err := os.ReadFile(filepath.Join(dir, "spans.json"))
+			if err != nil {
+				t.Fatalf("no OTLP file: %v", err)
+			}
+			for _, w := range tt.wantOTLP {
+				if !strings.Contains(string(b), w) {
+					t.Errorf("OTLP file does not contain %s:\n%s", w, b)
+				}
+			}
+		})
+	}
+}
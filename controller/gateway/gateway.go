@@ -0,0 +1,258 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gateway provides an HTTP/JSON front end to the TopologyManager
+// service.  Each RPC is mapped to an HTTP method and path.  Request and
+// response bodies are encoded with protojson and gRPC status codes are mapped
+// to HTTP status codes.  Errors are returned as a JSON encoded
+// google.rpc.Status.
+//
+// Typical Usage:
+//
+//	var srv cpb.TopologyManagerServer = ...
+//	http.ListenAndServe(":50052", gateway.New(srv))
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	cpb "github.com/openconfig/kne/proto/controller"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OpenAPIPath is the path the OpenAPI document is served on.
+const OpenAPIPath = "/v1/openapi.json"
+
+// A Route maps an HTTP method and path pattern to an RPC.  Segments of the
+// pattern enclosed in braces, such as {topology_name}, name a string field of
+// the request message that is set from the path.  If Body is true the request
+// message is decoded from the HTTP request body before path fields are set.
+type Route struct {
+	Method  string
+	Pattern string
+	RPC     string
+	Body    bool
+}
+
+// Routes are the routes served by the gateway, one per TopologyManager RPC.
+var Routes = []Route{
+	{Method: http.MethodPost, Pattern: "/v1/topologies", RPC: "CreateTopology", Body: true},
+	{Method: http.MethodGet, Pattern: "/v1/topologies/{topology_name}", RPC: "ShowTopology"},
+	{Method: http.MethodDelete, Pattern: "/v1/topologies/{topology_name}", RPC: "DeleteTopology"},
+	{Method: http.MethodPost, Pattern: "/v1/topologies/{topology_name}/devices/{device_name}/config", RPC: "PushConfig", Body: true},
+	{Method: http.MethodPost, Pattern: "/v1/topologies/{topology_name}/devices/{device_name}/reset", RPC: "ResetConfig"},
+	{Method: http.MethodPost, Pattern: "/v1/clusters", RPC: "CreateCluster", Body: true},
+	{Method: http.MethodGet, Pattern: "/v1/clusters/{name}", RPC: "ShowCluster"},
+	{Method: http.MethodDelete, Pattern: "/v1/clusters/{name}", RPC: "DeleteCluster"},
+}
+
+var marshaller = protojson.MarshalOptions{EmitUnpopulated: true}
+
+// An Option configures the gateway returned by New.
+type Option func(g *gateway)
+
+// WithInterceptor calls i for each RPC, the same as a gRPC server does for
+// grpc.UnaryInterceptor.
+func WithInterceptor(i grpc.UnaryServerInterceptor) Option {
+	return func(g *gateway) {
+		g.interceptor = i
+	}
+}
+
+type gateway struct {
+	srv         cpb.TopologyManagerServer
+	interceptor grpc.UnaryServerInterceptor
+	methods     map[string]grpc.MethodDesc
+}
+
+// New returns an http.Handler that serves Routes by calling srv.  The OpenAPI
+// document describing the routes is served on OpenAPIPath.
+func New(srv cpb.TopologyManagerServer, opts ...Option) http.Handler {
+	g := &gateway{
+		srv:     srv,
+		methods: map[string]grpc.MethodDesc{},
+	}
+	for _, o := range opts {
+		o(g)
+	}
+	for _, m := range cpb.TopologyManager_ServiceDesc.Methods {
+		g.methods[m.MethodName] = m
+	}
+	return g
+}
+
+// ServeHTTP implements http.Handler.
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == OpenAPIPath {
+		if r.Method != http.MethodGet {
+			writeError(w, status.Errorf(codes.Unimplemented, "method %s not allowed on %s", r.Method, r.URL.Path), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openapiJSON)
+		return
+	}
+	pathMatched := false
+	for _, rt := range Routes {
+		params, ok := match(rt.Pattern, r.URL.Path)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if rt.Method != r.Method {
+			continue
+		}
+		g.call(w, r, rt, params)
+		return
+	}
+	if pathMatched {
+		writeError(w, status.Errorf(codes.Unimplemented, "method %s not allowed on %s", r.Method, r.URL.Path), http.StatusMethodNotAllowed)
+		return
+	}
+	writeError(w, status.Errorf(codes.NotFound, "no route for %s", r.URL.Path), 0)
+}
+
+// call calls the RPC of rt and writes the response or error to w.
+func (g *gateway) call(w http.ResponseWriter, r *http.Request, rt Route, params map[string]string) {
+	md, ok := g.methods[rt.RPC]
+	if !ok {
+		writeError(w, status.Errorf(codes.Unimplemented, "unknown RPC %s", rt.RPC), 0)
+		return
+	}
+	var body []byte
+	if rt.Body {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			writeError(w, status.Errorf(codes.InvalidArgument, "reading body: %v", err), 0)
+			return
+		}
+	}
+	dec := func(v interface{}) error {
+		m, ok := v.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "%T is not a proto message", v)
+		}
+		if len(body) != 0 {
+			if err := protojson.Unmarshal(body, m); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+			}
+		}
+		return setFields(m.ProtoReflect(), params)
+	}
+	resp, err := md.Handler(g.srv, r.Context(), dec, g.interceptor)
+	if err != nil {
+		writeError(w, err, 0)
+		return
+	}
+	m, ok := resp.(proto.Message)
+	if !ok {
+		writeError(w, status.Errorf(codes.Internal, "%T is not a proto message", resp), 0)
+		return
+	}
+	b, err := marshaller.Marshal(m)
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "encoding response: %v", err), 0)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// match returns the path parameters of path if it matches pattern.
+func match(pattern, path string) (map[string]string, bool) {
+	pp := strings.Split(strings.Trim(pattern, "/"), "/")
+	sp := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pp) != len(sp) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, p := range pp {
+		switch {
+		case strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}"):
+			if sp[i] == "" {
+				return nil, false
+			}
+			params[p[1:len(p)-1]] = sp[i]
+		case p != sp[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// setFields sets the string fields of m named by the keys of params.
+func setFields(m protoreflect.Message, params map[string]string) error {
+	fields := m.Descriptor().Fields()
+	for k, v := range params {
+		fd := fields.ByName(protoreflect.Name(k))
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.Cardinality() == protoreflect.Repeated {
+			return status.Errorf(codes.Internal, "%s has no string field %q", m.Descriptor().FullName(), k)
+		}
+		m.Set(fd, protoreflect.ValueOfString(v))
+	}
+	return nil
+}
+
+// httpStatus maps gRPC status codes to HTTP status codes.
+var httpStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499, // Client Closed Request
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatus returns the HTTP status code that corresponds to the gRPC code c.
+func HTTPStatus(c codes.Code) int {
+	if s, ok := httpStatus[c]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError writes err to w as a JSON encoded google.rpc.Status.  If code is
+// zero the HTTP status code is derived from the gRPC code of err.
+func writeError(w http.ResponseWriter, err error, code int) {
+	s := status.Convert(err)
+	if code == 0 {
+		code = HTTPStatus(s.Code())
+	}
+	b, merr := marshaller.Marshal(s.Proto())
+	if merr != nil {
+		b = []byte(fmt.Sprintf(`{"code": %d, "message": %q}`, codes.Internal, merr.Error()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
@@ -0,0 +1,272 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// fakeServer records the last request it received.
+type fakeServer struct {
+	cpb.UnimplementedTopologyManagerServer
+	req proto.Message
+}
+
+func (f *fakeServer) CreateTopology(_ context.Context, req *cpb.CreateTopologyRequest) (*cpb.CreateTopologyResponse, error) {
+	f.req = req
+	return &cpb.CreateTopologyResponse{
+		TopologyName: req.GetTopology().GetName(),
+		State:        cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+	}, nil
+}
+
+func (f *fakeServer) ShowTopology(_ context.Context, req *cpb.ShowTopologyRequest) (*cpb.ShowTopologyResponse, error) {
+	f.req = req
+	if req.GetTopologyName() != "t1" {
+		return nil, status.Errorf(codes.NotFound, "topology %q not found", req.GetTopologyName())
+	}
+	return &cpb.ShowTopologyResponse{
+		State:    cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+		Topology: &tpb.Topology{Name: "t1"},
+	}, nil
+}
+
+func (f *fakeServer) DeleteTopology(_ context.Context, req *cpb.DeleteTopologyRequest) (*cpb.DeleteTopologyResponse, error) {
+	f.req = req
+	return &cpb.DeleteTopologyResponse{}, nil
+}
+
+func (f *fakeServer) PushConfig(_ context.Context, req *cpb.PushConfigRequest) (*cpb.PushConfigResponse, error) {
+	f.req = req
+	return &cpb.PushConfigResponse{}, nil
+}
+
+func (f *fakeServer) ShowCluster(_ context.Context, req *cpb.ShowClusterRequest) (*cpb.ShowClusterResponse, error) {
+	f.req = req
+	return nil, status.Errorf(codes.FailedPrecondition, "no cluster")
+}
+
+func TestGateway(t *testing.T) {
+	tests := []struct {
+		desc       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantReq    proto.Message
+		wantResp   proto.Message
+		wantCode   codes.Code
+	}{{
+		desc:       "create topology",
+		method:     http.MethodPost,
+		path:       "/v1/topologies",
+		body:       `{"topology": {"name": "t1"}, "kubecfg": "/tmp/kubecfg"}`,
+		wantStatus: http.StatusOK,
+		wantReq:    &cpb.CreateTopologyRequest{Topology: &tpb.Topology{Name: "t1"}, Kubecfg: "/tmp/kubecfg"},
+		wantResp:   &cpb.CreateTopologyResponse{TopologyName: "t1", State: cpb.TopologyState_TOPOLOGY_STATE_RUNNING},
+	}, {
+		desc:       "show topology",
+		method:     http.MethodGet,
+		path:       "/v1/topologies/t1",
+		wantStatus: http.StatusOK,
+		wantReq:    &cpb.ShowTopologyRequest{TopologyName: "t1"},
+		wantResp:   &cpb.ShowTopologyResponse{State: cpb.TopologyState_TOPOLOGY_STATE_RUNNING, Topology: &tpb.Topology{Name: "t1"}},
+	}, {
+		desc:       "show topology not found",
+		method:     http.MethodGet,
+		path:       "/v1/topologies/t2",
+		wantStatus: http.StatusNotFound,
+		wantReq:    &cpb.ShowTopologyRequest{TopologyName: "t2"},
+		wantCode:   codes.NotFound,
+	}, {
+		desc:       "delete topology",
+		method:     http.MethodDelete,
+		path:       "/v1/topologies/t1",
+		wantStatus: http.StatusOK,
+		wantReq:    &cpb.DeleteTopologyRequest{TopologyName: "t1"},
+		wantResp:   &cpb.DeleteTopologyResponse{},
+	}, {
+		desc:       "push config, path overrides body",
+		method:     http.MethodPost,
+		path:       "/v1/topologies/t1/devices/r1/config",
+		body:       `{"topologyName": "other", "config": "aG9zdG5hbWUgcjE="}`,
+		wantStatus: http.StatusOK,
+		wantReq:    &cpb.PushConfigRequest{TopologyName: "t1", DeviceName: "r1", Config: []byte("hostname r1")},
+		wantResp:   &cpb.PushConfigResponse{},
+	}, {
+		desc:       "unimplemented rpc",
+		method:     http.MethodPost,
+		path:       "/v1/topologies/t1/devices/r1/reset",
+		wantStatus: http.StatusNotImplemented,
+		wantCode:   codes.Unimplemented,
+	}, {
+		desc:       "failed precondition",
+		method:     http.MethodGet,
+		path:       "/v1/clusters/c1",
+		wantStatus: http.StatusBadRequest,
+		wantReq:    &cpb.ShowClusterRequest{Name: "c1"},
+		wantCode:   codes.FailedPrecondition,
+	}, {
+		desc:       "invalid body",
+		method:     http.MethodPost,
+		path:       "/v1/topologies",
+		body:       `{"topology": 1}`,
+		wantStatus: http.StatusBadRequest,
+		wantCode:   codes.InvalidArgument,
+	}, {
+		desc:       "unknown field",
+		method:     http.MethodPost,
+		path:       "/v1/topologies",
+		body:       `{"bogus": 1}`,
+		wantStatus: http.StatusBadRequest,
+		wantCode:   codes.InvalidArgument,
+	}, {
+		desc:       "unknown path",
+		method:     http.MethodGet,
+		path:       "/v1/bogus",
+		wantStatus: http.StatusNotFound,
+		wantCode:   codes.NotFound,
+	}, {
+		desc:       "method not allowed",
+		method:     http.MethodPut,
+		path:       "/v1/topologies/t1",
+		wantStatus: http.StatusMethodNotAllowed,
+		wantCode:   codes.Unimplemented,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := &fakeServer{}
+			intercepted := ""
+			i := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				intercepted = info.FullMethod
+				return handler(ctx, req)
+			}
+			s := httptest.NewServer(New(f, WithInterceptor(i)))
+			defer s.Close()
+			req, err := http.NewRequest(tt.method, s.URL+tt.path, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("NewRequest() failed: %v", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("%s %s failed: %v", tt.method, tt.path, err)
+			}
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("%s %s: got status %d, want %d (body %s)", tt.method, tt.path, resp.StatusCode, tt.wantStatus, b)
+			}
+			if tt.wantReq != nil {
+				if s := cmp.Diff(tt.wantReq, f.req, protocmp.Transform()); s != "" {
+					t.Errorf("request unexpected diff (-want +got):\n%s", s)
+				}
+				if intercepted == "" {
+					t.Errorf("interceptor not called")
+				}
+			}
+			if tt.wantResp != nil {
+				got := tt.wantResp.ProtoReflect().New().Interface()
+				if err := protojson.Unmarshal(b, got); err != nil {
+					t.Fatalf("failed to decode response %s: %v", b, err)
+				}
+				if s := cmp.Diff(tt.wantResp, got, protocmp.Transform()); s != "" {
+					t.Errorf("response unexpected diff (-want +got):\n%s", s)
+				}
+				return
+			}
+			var st struct {
+				Code    codes.Code `json:"code"`
+				Message string     `json:"message"`
+			}
+			if err := json.Unmarshal(b, &st); err != nil {
+				t.Fatalf("failed to decode error %s: %v", b, err)
+			}
+			if st.Code != tt.wantCode {
+				t.Errorf("got code %v, want %v", st.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	routed := map[string]bool{}
+	for _, rt := range Routes {
+		routed[rt.RPC] = true
+	}
+	for _, m := range cpb.TopologyManager_ServiceDesc.Methods {
+		if !routed[m.MethodName] {
+			t.Errorf("RPC %s has no route", m.MethodName)
+		}
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{code: codes.OK, want: http.StatusOK},
+		{code: codes.InvalidArgument, want: http.StatusBadRequest},
+		{code: codes.NotFound, want: http.StatusNotFound},
+		{code: codes.AlreadyExists, want: http.StatusConflict},
+		{code: codes.Unavailable, want: http.StatusServiceUnavailable},
+		{code: codes.Code(100), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := HTTPStatus(tt.code); got != tt.want {
+			t.Errorf("HTTPStatus(%v): got %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	b, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() failed: %v", err)
+	}
+	if s := cmp.Diff(string(openapiJSON), string(b)); s != "" {
+		t.Errorf("openapi.json is out of date, run go generate (-checked in +generated):\n%s", s)
+	}
+	s := httptest.NewServer(New(&fakeServer{}))
+	defer s.Close()
+	resp, err := http.Get(s.URL + OpenAPIPath)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", OpenAPIPath, err)
+	}
+	defer resp.Body.Close()
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode OpenAPI document: %v", err)
+	}
+	for _, rt := range Routes {
+		op, ok := doc.Paths[rt.Pattern][map[string]string{
+			http.MethodGet:    "get",
+			http.MethodPost:   "post",
+			http.MethodDelete: "delete",
+		}[rt.Method]]
+		if !ok || op.OperationID != rt.RPC {
+			t.Errorf("OpenAPI document missing %s %s for %s", rt.Method, rt.Pattern, rt.RPC)
+		}
+	}
+}
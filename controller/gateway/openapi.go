@@ -0,0 +1,211 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	cpb "github.com/openconfig/kne/proto/controller"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//go:generate go run ./genopenapi openapi.json
+
+// openapiJSON is the checked in copy of the document returned by OpenAPI.
+//
+//go:embed openapi.json
+var openapiJSON []byte
+
+const (
+	statusSchema = "google.rpc.Status"
+	anySchema    = "google.protobuf.Any"
+)
+
+type object = map[string]interface{}
+
+// OpenAPI returns an OpenAPI 3 document, encoded as JSON, that describes
+// Routes.  The schemas are derived from the descriptors of the request and
+// response messages and follow the protojson mapping.
+func OpenAPI() ([]byte, error) {
+	sd := cpb.File_controller_proto.Services().ByName("TopologyManager")
+	if sd == nil {
+		return nil, fmt.Errorf("TopologyManager service not found")
+	}
+	schemas := object{
+		statusSchema: object{
+			"type": "object",
+			"properties": object{
+				"code":    object{"type": "integer", "format": "int32"},
+				"message": object{"type": "string"},
+				"details": object{"type": "array", "items": ref(anySchema)},
+			},
+		},
+	}
+	paths := object{}
+	for _, rt := range Routes {
+		md := sd.Methods().ByName(protoreflect.Name(rt.RPC))
+		if md == nil {
+			return nil, fmt.Errorf("route %s %s: unknown RPC %s", rt.Method, rt.Pattern, rt.RPC)
+		}
+		addSchema(schemas, md.Input())
+		addSchema(schemas, md.Output())
+		op := object{
+			"operationId": rt.RPC,
+			"tags":        []string{string(sd.Name())},
+			"responses": object{
+				"200":     response("A successful response.", string(md.Output().FullName())),
+				"default": response("An error response.", statusSchema),
+			},
+		}
+		var params []object
+		for _, p := range strings.Split(rt.Pattern, "/") {
+			if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+				params = append(params, object{
+					"name":     p[1 : len(p)-1],
+					"in":       "path",
+					"required": true,
+					"schema":   object{"type": "string"},
+				})
+			}
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if rt.Body {
+			op["requestBody"] = object{
+				"required": true,
+				"content": object{
+					"application/json": object{"schema": ref(string(md.Input().FullName()))},
+				},
+			}
+		}
+		p, ok := paths[rt.Pattern].(object)
+		if !ok {
+			p = object{}
+			paths[rt.Pattern] = p
+		}
+		p[strings.ToLower(rt.Method)] = op
+	}
+	doc := object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "KNE TopologyManager",
+			"version": "v1",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+		},
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// ref returns a reference to the schema named name.
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// response returns an OpenAPI response whose JSON body is the schema named
+// name.
+func response(desc, name string) object {
+	return object{
+		"description": desc,
+		"content": object{
+			"application/json": object{"schema": ref(name)},
+		},
+	}
+}
+
+// addSchema adds the schema of md, and of all the messages it refers to, to
+// schemas.
+func addSchema(schemas object, md protoreflect.MessageDescriptor) {
+	name := string(md.FullName())
+	if _, ok := schemas[name]; ok {
+		return
+	}
+	if name == anySchema {
+		schemas[name] = object{
+			"type":                 "object",
+			"properties":           object{"@type": object{"type": "string"}},
+			"additionalProperties": true,
+		}
+		return
+	}
+	props := object{}
+	s := object{"type": "object", "properties": props}
+	// Add s before recursing so self referencing messages terminate.
+	schemas[name] = s
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		var fs object
+		switch {
+		case fd.IsMap():
+			fs = object{"type": "object", "additionalProperties": fieldSchema(schemas, fd.MapValue())}
+		case fd.IsList():
+			fs = object{"type": "array", "items": fieldSchema(schemas, fd)}
+		default:
+			fs = fieldSchema(schemas, fd)
+		}
+		if fd.Options() != nil {
+			if o, ok := fd.Options().(interface{ GetDeprecated() bool }); ok && o.GetDeprecated() {
+				fs["deprecated"] = true
+			}
+		}
+		props[fd.JSONName()] = fs
+	}
+}
+
+// fieldSchema returns the schema of a single value of fd.
+func fieldSchema(schemas object, fd protoreflect.FieldDescriptor) object {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return object{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return object{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return object{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return object{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return object{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind:
+		return object{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return object{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return object{"type": "string"}
+	case protoreflect.BytesKind:
+		return object{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		var names []string
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return object{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		addSchema(schemas, fd.Message())
+		return ref(string(fd.Message().FullName()))
+	}
+	return object{}
+}
@@ -19,13 +19,16 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
 	log "github.com/golang/glog"
+	"github.com/openconfig/kne/controller/gateway"
 	"github.com/openconfig/kne/deploy"
+	"github.com/openconfig/kne/metrics"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo"
@@ -35,6 +38,8 @@ import (
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/prototext"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -49,7 +54,9 @@ var (
 	defaultCEOSLabOperator = ""
 	defaultLemmingOperator = ""
 	// Flags.
-	port = flag.Int("port", 50051, "Controller server port")
+	port        = flag.Int("port", 50051, "Controller server port")
+	metricsPort = flag.Int("metrics_port", 9090, "Port to serve Prometheus metrics on, 0 disables metrics")
+	httpPort    = flag.Int("http_port", 50052, "Port to serve the HTTP/JSON gateway on, 0 disables the gateway")
 )
 
 func init() {
@@ -302,7 +309,7 @@ func (s *server) DeleteCluster(ctx context.Context, req *cpb.DeleteClusterReques
 	if !ok {
 		return nil, status.Errorf(codes.NotFound, "cluster %q not found, can only delete clusters created using TopologyManager", req.GetName())
 	}
-	if err := d.Delete(); err != nil {
+	if err := d.Delete(ctx, defaultKubeCfg); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to delete cluster: %v", err)
 	}
 	delete(s.deployments, req.GetName())
@@ -318,10 +325,33 @@ func (s *server) ShowCluster(ctx context.Context, req *cpb.ShowClusterRequest) (
 	if !ok {
 		return nil, status.Errorf(codes.NotFound, "cluster %q not found, can only show clusters created using TopologyManager", req.GetName())
 	}
+	resp := &cpb.ShowClusterResponse{State: cpb.ClusterState_CLUSTER_STATE_RUNNING}
 	if err := d.Healthy(ctx); err != nil {
-		return &cpb.ShowClusterResponse{State: cpb.ClusterState_CLUSTER_STATE_ERROR}, nil
+		resp.State = cpb.ClusterState_CLUSTER_STATE_ERROR
 	}
-	return &cpb.ShowClusterResponse{State: cpb.ClusterState_CLUSTER_STATE_RUNNING}, nil
+	statuses, err := d.Status(ctx, defaultKubeCfg)
+	if err != nil {
+		log.Warningf("Failed to get status of cluster %q: %v", req.GetName(), err)
+	}
+	resp.Components = componentStatusesToProto(statuses)
+	return resp, nil
+}
+
+// componentStatusesToProto converts the component statuses of a deployment
+// to their proto form.
+func componentStatusesToProto(statuses []*deploy.ComponentStatus) []*cpb.ComponentStatus {
+	var pbs []*cpb.ComponentStatus
+	for _, s := range statuses {
+		pbs = append(pbs, &cpb.ComponentStatus{
+			Component: s.Component,
+			Kind:      s.Kind,
+			Installed: s.Installed,
+			Healthy:   s.Healthy,
+			Version:   s.Version,
+			Details:   s.Details,
+		})
+	}
+	return pbs
 }
 
 func (s *server) CreateTopology(ctx context.Context, req *cpb.CreateTopologyRequest) (*cpb.CreateTopologyResponse, error) {
@@ -378,6 +408,7 @@ func (s *server) CreateTopology(ctx context.Context, req *cpb.CreateTopologyRequ
 	}
 
 	s.topos[topoPb.GetName()] = txtPb
+	metrics.SetTopologies(len(s.topos))
 	return &cpb.CreateTopologyResponse{
 		TopologyName: req.Topology.GetName(),
 		State:        cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
@@ -407,6 +438,8 @@ func (s *server) DeleteTopology(ctx context.Context, req *cpb.DeleteTopologyRequ
 	if err := tm.Delete(ctx); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to delete topology: %v", err)
 	}
+	delete(s.topos, req.GetTopologyName())
+	metrics.SetTopologies(len(s.topos))
 	return &cpb.DeleteTopologyResponse{}, nil
 }
 
@@ -502,8 +535,51 @@ func validatePath(path string) (string, error) {
 	return path, nil
 }
 
+// serveMetrics serves Prometheus metrics on port and records the restarts of
+// all pods in the cluster of the default kubecfg.
+func serveMetrics(ctx context.Context, port int) error {
+	if err := metrics.Serve(ctx, fmt.Sprintf(":%d", port)); err != nil {
+		return err
+	}
+	rCfg, err := clientcmd.BuildConfigFromFlags("", defaultKubeCfg)
+	if err != nil {
+		log.Warningf("Not recording pod restarts: %v", err)
+		return nil
+	}
+	kClient, err := kubernetes.NewForConfig(rCfg)
+	if err != nil {
+		log.Warningf("Not recording pod restarts: %v", err)
+		return nil
+	}
+	if err := metrics.WatchPodRestarts(ctx, kClient, ""); err != nil {
+		log.Warningf("Not recording pod restarts: %v", err)
+	}
+	return nil
+}
+
+// serveGateway serves the HTTP/JSON gateway for srv on port.
+func serveGateway(srv cpb.TopologyManagerServer, port int) error {
+	lis, err := net.Listen("tcp6", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	h := gateway.New(srv, gateway.WithInterceptor(metrics.UnaryServerInterceptor()))
+	go func() {
+		log.Infof("HTTP/JSON gateway listening at %v", lis.Addr())
+		if err := http.Serve(lis, h); err != nil {
+			log.Errorf("HTTP/JSON gateway failed: %v", err)
+		}
+	}()
+	return nil
+}
+
 func main() {
 	flag.Parse()
+	if *metricsPort != 0 {
+		if err := serveMetrics(context.Background(), *metricsPort); err != nil {
+			log.Fatalf("failed to serve metrics: %v", err)
+		}
+	}
 	addr := fmt.Sprintf(":%d", *port)
 	lis, err := net.Listen("tcp6", addr)
 	if err != nil {
@@ -516,8 +592,15 @@ func main() {
 			PermitWithoutStream: true,
 			MinTime:             time.Second * 10,
 		}),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor()),
 	)
-	cpb.RegisterTopologyManagerServer(s, newServer())
+	srv := newServer()
+	cpb.RegisterTopologyManagerServer(s, srv)
+	if *httpPort != 0 {
+		if err := serveGateway(srv, *httpPort); err != nil {
+			log.Fatalf("failed to serve HTTP/JSON gateway: %v", err)
+		}
+	}
 	log.Infof("Controller server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
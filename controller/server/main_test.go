@@ -9,6 +9,7 @@ import (
 	"github.com/h-fam/errdiff"
 	"github.com/openconfig/kne/deploy"
 	cpb "github.com/openconfig/kne/proto/controller"
+	"google.golang.org/protobuf/testing/protocmp"
 )
 
 func TestNewDeployment(t *testing.T) {
@@ -1152,3 +1153,32 @@ func TestNewDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestComponentStatusesToProto(t *testing.T) {
+	statuses := []*deploy.ComponentStatus{{
+		Component: "ingress",
+		Kind:      "MetalLB",
+		Installed: true,
+		Healthy:   true,
+		Version:   "v0.13.7",
+		Details:   map[string]string{"pool": "172.18.0.50 - 172.18.0.150"},
+	}, {
+		Component: "controller",
+		Kind:      "CEOSLab",
+	}}
+	want := []*cpb.ComponentStatus{{
+		Component: "ingress",
+		Kind:      "MetalLB",
+		Installed: true,
+		Healthy:   true,
+		Version:   "v0.13.7",
+		Details:   map[string]string{"pool": "172.18.0.50 - 172.18.0.150"},
+	}, {
+		Component: "controller",
+		Kind:      "CEOSLab",
+	}}
+	got := componentStatusesToProto(statuses)
+	if s := cmp.Diff(want, got, protocmp.Transform()); s != "" {
+		t.Errorf("componentStatusesToProto() unexpected diff (-want +got):\n%s", s)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"golang.org/x/oauth2/google"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	kversion "k8s.io/apimachinery/pkg/version"
@@ -49,10 +50,15 @@ var (
 	poolRetryDelay  = 5 * time.Second
 )
 
-// logCommand runs the specified command but records standard output
-// with log.Info and standard error with log.Warning.
-func logCommand(cmd string, args ...string) error {
-	c := kexec.Command(cmd, args...)
+// logCommand runs the specified command, which is killed if ctx is done, but
+// records standard output with log.Info and standard error with log.Warning.
+func logCommand(ctx context.Context, cmd string, args ...string) error {
+	return logCmd(kexec.CommandContext(ctx, cmd, args...), cmd)
+}
+
+// logCmd runs c, named cmd, but records standard output with log.Info and
+// standard error with log.Warning.
+func logCmd(c kexec.Cmd, cmd string) error {
 	outLog := logshim.New(func(v ...interface{}) {
 		log.Info(append([]interface{}{"(" + cmd + "): "}, v...)...)
 	})
@@ -68,10 +74,31 @@ func logCommand(cmd string, args ...string) error {
 	return c.Run()
 }
 
-// outCommand runs the specified command and returns any standard output
-// as well as any errors.
-func outCommand(cmd string, args ...string) ([]byte, error) {
-	c := kexec.Command(cmd, args...)
+// deleteManifest deletes the resources defined by the manifest data, or by
+// the manifest file at path if data is nil.  Resources that do not exist are
+// ignored.  kubectl is killed if ctx is done.
+func deleteManifest(ctx context.Context, path string, data []byte) error {
+	if data != nil {
+		f, err := os.CreateTemp("", "kne-manifest-*.yaml")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		path = f.Name()
+	}
+	return logCommand(ctx, "kubectl", "delete", "--ignore-not-found", "-f", path)
+}
+
+// outCommand runs the specified command, which is killed if ctx is done, and
+// returns any standard output as well as any errors.
+func outCommand(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	c := kexec.CommandContext(ctx, cmd, args...)
 	var stdout bytes.Buffer
 	c.SetStdout(&stdout)
 	err := c.Run()
@@ -88,14 +115,16 @@ var (
 
 type Cluster interface {
 	Deploy(context.Context) error
-	Delete() error
-	Healthy() error
+	Delete(context.Context) error
+	Healthy(context.Context) error
 	GetName() string
 	GetDockerNetworkResourceName() string
 }
 
 type Ingress interface {
 	Deploy(context.Context) error
+	Delete(context.Context) error
+	Status(context.Context) (*ComponentStatus, error)
 	SetKClient(kubernetes.Interface)
 	Healthy(context.Context) error
 	SetRCfg(*rest.Config)
@@ -104,12 +133,16 @@ type Ingress interface {
 
 type CNI interface {
 	Deploy(context.Context) error
+	Delete(context.Context) error
+	Status(context.Context) (*ComponentStatus, error)
 	SetKClient(kubernetes.Interface)
 	Healthy(context.Context) error
 }
 
 type Controller interface {
 	Deploy(context.Context) error
+	Delete(context.Context) error
+	Status(context.Context) (*ComponentStatus, error)
 	SetKClient(kubernetes.Interface)
 	Healthy(context.Context) error
 }
@@ -123,6 +156,30 @@ type Deployment struct {
 	// If Progress is true then deployment status updates will be sent to
 	// standard output.
 	Progress bool
+
+	// If Resume is true then Deploy reuses an existing kind cluster and skips
+	// each component that is already installed from the same manifests and
+	// is healthy.  The setup of the kind cluster, its additional manifests,
+	// Google Artifact Registry access and container images, always runs
+	// again: the registry credentials expire and image tags may move.
+	Resume bool
+
+	// If KeepCluster is true then Delete removes the controllers, CNI and
+	// ingress but leaves the cluster itself running.
+	KeepCluster bool
+
+	// If PreloadImages is true then Deploy loads the container images of the
+	// ingress, CNI and controller manifests, along with Images, into a kind
+	// cluster before deploying them.  Images are loaded from the local
+	// docker daemon or from the archives in ImageArchiveDir so the cluster
+	// does not need to pull them.
+	PreloadImages bool
+	// Images are additional container images to preload, such as the images
+	// of a topology.
+	Images []string
+	// ImageArchiveDir is a directory of image archives, named as returned by
+	// ImageArchiveName, to preload images from.
+	ImageArchiveDir string
 }
 
 func (d *Deployment) String() string {
@@ -150,12 +207,16 @@ func (d *Deployment) Deploy(ctx context.Context, kubecfg string) (rerr error) {
 	if err := d.checkDependencies(); err != nil {
 		return err
 	}
+	if k, ok := d.Cluster.(*KindSpec); ok && d.Resume {
+		k.Recycle = true
+		log.Infof("Resuming, the setup of kind cluster %q runs again", k.Name)
+	}
 	log.Infof("Deploying cluster...")
 	if err := d.Cluster.Deploy(ctx); err != nil {
 		return err
 	}
 	log.Infof("Cluster deployed")
-	if err := d.Cluster.Healthy(); err != nil {
+	if err := d.Cluster.Healthy(ctx); err != nil {
 		return err
 	}
 	log.Infof("Cluster healthy")
@@ -170,7 +231,7 @@ func (d *Deployment) Deploy(ctx context.Context, kubecfg string) (rerr error) {
 	}
 
 	log.Infof("Checking kubectl versions.")
-	output, err := outCommand("kubectl", "version", "--output=yaml")
+	output, err := outCommand(ctx, "kubectl", "version", "--output=yaml")
 	if err != nil {
 		return fmt.Errorf("failed get kubectl version: %w", err)
 	}
@@ -210,40 +271,41 @@ func (d *Deployment) Deploy(ctx context.Context, kubecfg string) (rerr error) {
 		}()
 	}
 
+	if d.PreloadImages {
+		if err := d.preloadImages(ctx); err != nil {
+			return err
+		}
+	}
+
+	state, err := loadState(ctx, kClient)
+	if err != nil {
+		return fmt.Errorf("failed to load deployment state: %w", err)
+	}
+
 	d.Ingress.SetKClient(kClient)
 	d.Ingress.SetRCfg(rCfg)
 	d.Ingress.SetDockerNetworkResourceName(d.Cluster.GetDockerNetworkResourceName())
+	if m, ok := d.Ingress.(*MetalLBSpec); ok {
+		if k, ok := d.Cluster.(*KindSpec); ok {
+			m.ipFamily = k.IPFamily
+		}
+	}
 
 	log.Infof("Deploying ingress...")
-	if err := d.Ingress.Deploy(ctx); err != nil {
-		return err
-	}
-	tCtx, cancel := context.WithTimeout(ctx, healthTimeout)
-	defer cancel()
-	if err := d.Ingress.Healthy(tCtx); err != nil {
+	if err := d.deployComponent(ctx, state, componentKey("ingress", d.Ingress), d.Ingress); err != nil {
 		return err
 	}
 	log.Infof("Ingress healthy")
 	log.Infof("Deploying CNI...")
-	if err := d.CNI.Deploy(ctx); err != nil {
-		return err
-	}
 	d.CNI.SetKClient(kClient)
-	tCtx, cancel = context.WithTimeout(ctx, healthTimeout)
-	defer cancel()
-	if err := d.CNI.Healthy(tCtx); err != nil {
+	if err := d.deployComponent(ctx, state, componentKey("cni", d.CNI), d.CNI); err != nil {
 		return err
 	}
 	log.Infof("CNI healthy")
 	for _, c := range d.Controllers {
 		log.Infof("Deploying controller...")
-		if err := c.Deploy(ctx); err != nil {
-			return err
-		}
 		c.SetKClient(kClient)
-		tCtx, cancel = context.WithTimeout(ctx, healthTimeout)
-		defer cancel()
-		if err := c.Healthy(tCtx); err != nil {
+		if err := d.deployComponent(ctx, state, componentKey("controller", c), c); err != nil {
 			return err
 		}
 	}
@@ -251,17 +313,72 @@ func (d *Deployment) Deploy(ctx context.Context, kubecfg string) (rerr error) {
 	return nil
 }
 
-func (d *Deployment) Delete() error {
+// Delete deletes the deployment.  The controllers, CNI and ingress are removed
+// in the reverse order they were deployed and then the cluster is deleted.
+// Deleting a kind cluster removes everything in it, so unless KeepCluster is
+// set the components of a kind cluster are not removed individually.
+func (d *Deployment) Delete(ctx context.Context, kubecfg string) error {
+	if _, ok := d.Cluster.(*KindSpec); !ok || d.KeepCluster {
+		if err := d.deleteComponents(ctx, kubecfg); err != nil {
+			return err
+		}
+	}
+	if d.KeepCluster {
+		log.Infof("Keeping cluster %q", d.Cluster.GetName())
+		return nil
+	}
 	log.Infof("Deleting cluster...")
-	if err := d.Cluster.Delete(); err != nil {
+	if err := d.Cluster.Delete(ctx); err != nil {
 		return err
 	}
 	log.Infof("Cluster deleted")
 	return nil
 }
 
+// deleteComponents removes the controllers, CNI and ingress of the deployment,
+// in that order.  A failure to remove one component does not prevent the
+// others from being removed.
+func (d *Deployment) deleteComponents(ctx context.Context, kubecfg string) error {
+	rCfg, err := clientcmd.BuildConfigFromFlags("", kubecfg)
+	if err != nil {
+		return err
+	}
+	kClient, err := kubernetes.NewForConfig(rCfg)
+	if err != nil {
+		return err
+	}
+	var errs errlist.List
+	for i := len(d.Controllers) - 1; i >= 0; i-- {
+		c := d.Controllers[i]
+		log.Infof("Deleting controller...")
+		c.SetKClient(kClient)
+		if err := c.Delete(ctx); err != nil {
+			errs.Add(fmt.Errorf("failed to delete controller: %w", err))
+		}
+	}
+	log.Infof("Deleting CNI...")
+	d.CNI.SetKClient(kClient)
+	if err := d.CNI.Delete(ctx); err != nil {
+		errs.Add(fmt.Errorf("failed to delete CNI: %w", err))
+	}
+	log.Infof("Deleting ingress...")
+	d.Ingress.SetKClient(kClient)
+	d.Ingress.SetRCfg(rCfg)
+	if err := d.Ingress.Delete(ctx); err != nil {
+		errs.Add(fmt.Errorf("failed to delete ingress: %w", err))
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	if err := deleteState(ctx, kClient); err != nil {
+		log.Warningf("Failed to delete deployment state: %v", err)
+	}
+	log.Infof("Controllers, CNI and ingress deleted")
+	return nil
+}
+
 func (d *Deployment) Healthy(ctx context.Context) error {
-	if err := d.Cluster.Healthy(); err != nil {
+	if err := d.Cluster.Healthy(ctx); err != nil {
 		return err
 	}
 	log.Infof("Cluster healthy")
@@ -304,13 +421,13 @@ func (e *ExternalSpec) Deploy(ctx context.Context) error {
 	return nil
 }
 
-func (e *ExternalSpec) Delete() error {
+func (e *ExternalSpec) Delete(ctx context.Context) error {
 	log.Infof("Delete is a no-op for the external cluster type")
 	return nil
 }
 
-func (e *ExternalSpec) Healthy() error {
-	if err := logCommand("kubectl", "cluster-info"); err != nil {
+func (e *ExternalSpec) Healthy(ctx context.Context) error {
+	if err := logCommand(ctx, "kubectl", "cluster-info"); err != nil {
 		return fmt.Errorf("cluster not healthy: %w", err)
 	}
 	return nil
@@ -328,6 +445,9 @@ func init() {
 	load.Register("Kind", &load.Spec{
 		Type: KindSpec{},
 		Tag:  "cluster",
+		Validate: func(_ *load.Config, spec interface{}) error {
+			return spec.(*KindSpec).validate()
+		},
 	})
 }
 
@@ -343,6 +463,23 @@ type KindSpec struct {
 	ContainerImages          map[string]string `yaml:"containerImages"`
 	KindConfigFile           string            `yaml:"config" kne:"yaml"`
 	AdditionalManifests      []string          `yaml:"additionalManifests" kne:"yaml"`
+	// Workers is the number of worker nodes to create in addition to the
+	// control plane.  Workers share the extra mounts of the control plane in
+	// the kind config file.
+	Workers int `yaml:"workers"`
+	// WorkerLabels are the node labels of each worker, in order.
+	WorkerLabels []map[string]string `yaml:"workerLabels"`
+	// ExtraMounts are host paths to mount in every node of the cluster.
+	ExtraMounts []*KindMount `yaml:"extraMounts"`
+	// IPFamily is the IP family of the cluster: ipv4, ipv6 or dual.
+	IPFamily string `yaml:"ipFamily"`
+}
+
+// KindMount is a host path mounted in a kind node.
+type KindMount struct {
+	HostPath      string `yaml:"hostPath"`
+	ContainerPath string `yaml:"containerPath"`
+	ReadOnly      bool   `yaml:"readOnly"`
 }
 
 type version struct {
@@ -392,7 +529,7 @@ func getVersion(s string) (*version, error) {
 	return v, nil
 }
 
-func (k *KindSpec) checkDependencies() error {
+func (k *KindSpec) checkDependencies(ctx context.Context) error {
 	var errs errlist.List
 	bins := []string{"kind"}
 	for _, bin := range bins {
@@ -409,7 +546,7 @@ func (k *KindSpec) checkDependencies() error {
 			return err
 		}
 
-		stdout, err := outCommand("kind", "version")
+		stdout, err := outCommand(ctx, "kind", "version")
 		if err != nil {
 			return fmt.Errorf("failed to get kind version: %w", err)
 		}
@@ -431,14 +568,14 @@ func (k *KindSpec) checkDependencies() error {
 	return nil
 }
 
-func (k *KindSpec) create() error {
+func (k *KindSpec) create(ctx context.Context) error {
 	// Create a KNE dir under /tmp intended to hold files to be mounted into the kind cluster.
 	if err := os.MkdirAll("/tmp/kne", os.ModePerm); err != nil {
 		return err
 	}
 	if k.Recycle {
 		log.Infof("Attempting to recycle existing cluster %q...", k.Name)
-		if err := logCommand("kubectl", "cluster-info", "--context", fmt.Sprintf("kind-%s", k.Name)); err == nil {
+		if err := logCommand(ctx, "kubectl", "cluster-info", "--context", fmt.Sprintf("kind-%s", k.Name)); err == nil {
 			log.Infof("Recycling existing cluster %q", k.Name)
 			return nil
 		}
@@ -459,23 +596,148 @@ func (k *KindSpec) create() error {
 	if k.Kubecfg != "" {
 		args = append(args, "--kubeconfig", k.Kubecfg)
 	}
-	if k.KindConfigFile != "" {
+	cfg, err := k.kindConfig()
+	if err != nil {
+		return err
+	}
+	switch {
+	case cfg != nil:
+		f, err := os.CreateTemp("", "kne-kind-config-*.yaml")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(cfg); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		log.V(1).Infof("Kind config:\n%s", cfg)
+		args = append(args, "--config", f.Name())
+	case k.KindConfigFile != "":
 		args = append(args, "--config", k.KindConfigFile)
 	}
 	log.Infof("Creating kind cluster with: %v", args)
-	if err := logCommand("kind", args...); err != nil {
+	if err := logCommand(ctx, "kind", args...); err != nil {
 		return fmt.Errorf("failed to create cluster: %w", err)
 	}
 	log.Infof("Deployed kind cluster: %s", k.Name)
 	return nil
 }
 
+// validate returns an error if the fields of k are inconsistent.
+func (k *KindSpec) validate() error {
+	switch k.IPFamily {
+	case "", "ipv4", "ipv6", "dual":
+	default:
+		return fmt.Errorf("invalid ip family %q, must be ipv4, ipv6 or dual", k.IPFamily)
+	}
+	if k.Workers < 0 {
+		return fmt.Errorf("invalid number of workers %d", k.Workers)
+	}
+	if len(k.WorkerLabels) > k.Workers {
+		return fmt.Errorf("labels for %d workers but only %d workers", len(k.WorkerLabels), k.Workers)
+	}
+	return nil
+}
+
+// kindConfig returns the kind config to create the cluster with, which is
+// the kind config file with the workers and extra mounts of k added.  It
+// returns nil if the kind config file can be used as is.
+func (k *KindSpec) kindConfig() ([]byte, error) {
+	if k.Workers == 0 && len(k.WorkerLabels) == 0 && len(k.ExtraMounts) == 0 && k.IPFamily == "" {
+		return nil, nil
+	}
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	cfg := map[string]interface{}{}
+	if k.KindConfigFile != "" {
+		b, err := os.ReadFile(k.KindConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse kind config %q: %w", k.KindConfigFile, err)
+		}
+		if cfg == nil {
+			cfg = map[string]interface{}{}
+		}
+	}
+	if _, ok := cfg["kind"]; !ok {
+		cfg["kind"] = "Cluster"
+	}
+	if _, ok := cfg["apiVersion"]; !ok {
+		cfg["apiVersion"] = "kind.x-k8s.io/v1alpha4"
+	}
+	nodes, _ := cfg["nodes"].([]interface{})
+	var controlPlane map[string]interface{}
+	for _, n := range nodes {
+		m, ok := n.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid node in kind config %q: %v", k.KindConfigFile, n)
+		}
+		switch m["role"] {
+		case "control-plane", nil:
+			if controlPlane == nil {
+				controlPlane = m
+			}
+		case "worker":
+			if k.Workers != 0 {
+				return nil, fmt.Errorf("kind config %q already has workers", k.KindConfigFile)
+			}
+		}
+	}
+	if controlPlane == nil {
+		controlPlane = map[string]interface{}{"role": "control-plane"}
+		nodes = append([]interface{}{controlPlane}, nodes...)
+	}
+	for i := 0; i < k.Workers; i++ {
+		w := map[string]interface{}{"role": "worker"}
+		if m, ok := controlPlane["extraMounts"].([]interface{}); ok {
+			w["extraMounts"] = append([]interface{}{}, m...)
+		}
+		if i < len(k.WorkerLabels) && len(k.WorkerLabels[i]) != 0 {
+			w["labels"] = k.WorkerLabels[i]
+		}
+		nodes = append(nodes, w)
+	}
+	for _, n := range nodes {
+		m := n.(map[string]interface{})
+		mounts, _ := m["extraMounts"].([]interface{})
+		for _, em := range k.ExtraMounts {
+			mount := map[string]interface{}{
+				"hostPath":      em.HostPath,
+				"containerPath": em.ContainerPath,
+			}
+			if em.ReadOnly {
+				mount["readOnly"] = true
+			}
+			mounts = append(mounts, mount)
+		}
+		if len(mounts) != 0 {
+			m["extraMounts"] = mounts
+		}
+	}
+	cfg["nodes"] = nodes
+	if k.IPFamily != "" {
+		networking, _ := cfg["networking"].(map[string]interface{})
+		if networking == nil {
+			networking = map[string]interface{}{}
+		}
+		networking["ipFamily"] = k.IPFamily
+		cfg["networking"] = networking
+	}
+	return yaml.Marshal(cfg)
+}
+
 func (k *KindSpec) Deploy(ctx context.Context) error {
-	if err := k.checkDependencies(); err != nil {
+	if err := k.checkDependencies(ctx); err != nil {
 		return err
 	}
 
-	if err := k.create(); err != nil {
+	if err := k.create(ctx); err != nil {
 		return err
 	}
 
@@ -483,14 +745,14 @@ func (k *KindSpec) Deploy(ctx context.Context) error {
 	// The set_pid_max script modifies the kernel.pid_max value to
 	// be acceptable for the Cisco 8000e container.
 	if _, err := os.Stat(setPIDMaxScript); err == nil {
-		if err := logCommand(setPIDMaxScript); err != nil {
+		if err := logCommand(ctx, setPIDMaxScript); err != nil {
 			return err
 		}
 	}
 
 	for _, s := range k.AdditionalManifests {
 		log.Infof("Found manifest %q", s)
-		if err := logCommand("kubectl", "apply", "-f", s); err != nil {
+		if err := logCommand(ctx, "kubectl", "apply", "-f", s); err != nil {
 			return fmt.Errorf("failed to deploy manifest: %w", err)
 		}
 	}
@@ -504,7 +766,7 @@ func (k *KindSpec) Deploy(ctx context.Context) error {
 
 	if len(k.ContainerImages) != 0 {
 		log.Infof("Loading container images")
-		if err := k.loadContainerImages(); err != nil {
+		if err := k.loadContainerImages(ctx); err != nil {
 			return fmt.Errorf("failed to load container images: %w", err)
 		}
 	}
@@ -512,19 +774,19 @@ func (k *KindSpec) Deploy(ctx context.Context) error {
 	return nil
 }
 
-func (k *KindSpec) Delete() error {
+func (k *KindSpec) Delete(ctx context.Context) error {
 	args := []string{"delete", "cluster"}
 	if k.Name != "" {
 		args = append(args, "--name", k.Name)
 	}
-	if err := logCommand("kind", args...); err != nil {
+	if err := logCommand(ctx, "kind", args...); err != nil {
 		return fmt.Errorf("failed to delete cluster using cli: %w", err)
 	}
 	return nil
 }
 
-func (k *KindSpec) Healthy() error {
-	if err := logCommand("kubectl", "cluster-info", "--context", fmt.Sprintf("kind-%s", k.GetName())); err != nil {
+func (k *KindSpec) Healthy(ctx context.Context) error {
+	if err := logCommand(ctx, "kubectl", "cluster-info", "--context", fmt.Sprintf("kind-%s", k.GetName())); err != nil {
 		return fmt.Errorf("cluster not healthy: %w", err)
 	}
 	return nil
@@ -573,7 +835,11 @@ func (k *KindSpec) setupGoogleArtifactRegistryAccess(ctx context.Context) error
 	// from an external program that is the best we can do.
 	for _, r := range k.GoogleArtifactRegistries {
 		s := fmt.Sprintf("https://%s", r)
-		if err := logCommand("docker", "login", "-u", "oauth2accesstoken", "-p", token.AccessToken, s); err != nil {
+		// The token is passed on standard input to keep it out of the
+		// process list and the command log.
+		c := kexec.CommandContext(ctx, "docker", "login", "-u", "oauth2accesstoken", "--password-stdin", s)
+		c.SetStdin(strings.NewReader(token.AccessToken))
+		if err := logCmd(c, "docker"); err != nil {
 			return err
 		}
 	}
@@ -581,7 +847,7 @@ func (k *KindSpec) setupGoogleArtifactRegistryAccess(ctx context.Context) error
 	if k.Name != "" {
 		args = append(args, "--name", k.Name)
 	}
-	nodes, err := outCommand("kind", args...)
+	nodes, err := outCommand(ctx, "kind", args...)
 	if err != nil {
 		return err
 	}
@@ -589,10 +855,10 @@ func (k *KindSpec) setupGoogleArtifactRegistryAccess(ctx context.Context) error
 	// picks up the new config that contains the embedded credentials.
 	for _, node := range strings.Split(string(nodes), " ") {
 		node = strings.TrimSuffix(node, "\n")
-		if err := logCommand("docker", "cp", configPath, fmt.Sprintf(kubeletConfigPathTemplate, node)); err != nil {
+		if err := logCommand(ctx, "docker", "cp", configPath, fmt.Sprintf(kubeletConfigPathTemplate, node)); err != nil {
 			return err
 		}
-		if err := logCommand("docker", "exec", node, "systemctl", "restart", "kubelet.service"); err != nil {
+		if err := logCommand(ctx, "docker", "exec", node, "systemctl", "restart", "kubelet.service"); err != nil {
 			return err
 		}
 	}
@@ -600,7 +866,7 @@ func (k *KindSpec) setupGoogleArtifactRegistryAccess(ctx context.Context) error
 	return nil
 }
 
-func (k *KindSpec) loadContainerImages() error {
+func (k *KindSpec) loadContainerImages(ctx context.Context) error {
 	for s, d := range k.ContainerImages {
 		if s == "" {
 			return fmt.Errorf("source container must not be empty")
@@ -615,7 +881,7 @@ func (k *KindSpec) loadContainerImages() error {
 		var out []byte
 		var err error
 		for ; ; retries-- {
-			out, err = outCommand("docker", "pull", s)
+			out, err = outCommand(ctx, "docker", "pull", s)
 			// Command succeeded or out of retries then break.
 			if err == nil || retries == 0 {
 				break
@@ -632,7 +898,7 @@ func (k *KindSpec) loadContainerImages() error {
 			return err
 		}
 		if d != s {
-			if err := logCommand("docker", "tag", s, d); err != nil {
+			if err := logCommand(ctx, "docker", "tag", s, d); err != nil {
 				return fmt.Errorf("failed to tag %q with %q: %w", s, d, err)
 			}
 		}
@@ -640,7 +906,7 @@ func (k *KindSpec) loadContainerImages() error {
 		if k.Name != "" {
 			args = append(args, "--name", k.Name)
 		}
-		if err := logCommand("kind", args...); err != nil {
+		if err := logCommand(ctx, "kind", args...); err != nil {
 			return fmt.Errorf("failed to load %q: %w", d, err)
 		}
 	}
@@ -676,15 +942,24 @@ func init() {
 	load.Register("MetalLB", &load.Spec{
 		Type: MetalLBSpec{},
 		Tag:  "ingress",
+		Validate: func(_ *load.Config, spec interface{}) error {
+			return spec.(*MetalLBSpec).validate()
+		},
 	})
 }
 
 type MetalLBSpec struct {
-	IPCount                   int    `yaml:"ip_count"`
-	ManifestDir               string `yaml:"manifests"`
-	Manifest                  string `yaml:"manifest" kne:"yaml"`
+	// IPCount is the number of addresses of each IP family of the docker
+	// network to include in the pool.
+	IPCount int `yaml:"ip_count"`
+	// CIDRs, if set, are the IPv4 and IPv6 subnets of the pool instead of
+	// the subnets of the docker network.
+	CIDRs                     []string `yaml:"cidrs"`
+	ManifestDir               string   `yaml:"manifests"`
+	Manifest                  string   `yaml:"manifest" kne:"yaml"`
 	ManifestData              []byte
 	dockerNetworkResourceName string
+	ipFamily                  string
 	kClient                   kubernetes.Interface
 	mClient                   metallbclientv1.Interface
 	rCfg                      *rest.Config
@@ -715,24 +990,100 @@ func inc(ip net.IP, cnt int) {
 	}
 }
 
-func makePool(n *net.IPNet, count int) *metallbv1.IPAddressPool {
+// poolRange returns the range of count addresses of n, skipping the first
+// 50 addresses which docker assigns to the cluster nodes.
+func poolRange(n *net.IPNet, count int) string {
 	start := make(net.IP, len(n.IP))
 	copy(start, n.IP)
 	inc(start, 50)
 	end := make(net.IP, len(start))
 	copy(end, start)
 	inc(end, count)
+	return fmt.Sprintf("%s - %s", start, end)
+}
+
+func makePool(addresses []string) *metallbv1.IPAddressPool {
 	return &metallbv1.IPAddressPool{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: "metallb-system",
 			Name:      "kne-service-pool",
 		},
 		Spec: metallbv1.IPAddressPoolSpec{
-			Addresses: []string{fmt.Sprintf("%s - %s", start, end)},
+			Addresses: addresses,
 		},
 	}
 }
 
+// validate returns an error if the ip count or the CIDRs of m are invalid.
+func (m *MetalLBSpec) validate() error {
+	if m.IPCount < 0 {
+		return fmt.Errorf("invalid ip_count %d", m.IPCount)
+	}
+	for _, c := range m.CIDRs {
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolAddresses returns the addresses of the pool: the CIDRs of the spec or
+// a range of the first IPv4 and the first IPv6 subnet of the docker network.
+// The pool of an ipv6 cluster only has the range of the IPv6 subnet.
+func (m *MetalLBSpec) poolAddresses(ctx context.Context) ([]string, error) {
+	if len(m.CIDRs) != 0 {
+		if err := m.validate(); err != nil {
+			return nil, err
+		}
+		return m.CIDRs, nil
+	}
+	// Get Network information from docker.
+	nr, err := m.dClient.NetworkList(ctx, dtypes.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var network dtypes.NetworkResource
+	for _, v := range nr {
+		name := m.dockerNetworkResourceName
+		if name == "" {
+			name = "bridge"
+		}
+		if v.Name == name {
+			network = v
+			break
+		}
+	}
+	var v4, v6 *net.IPNet
+	for _, ipRange := range network.IPAM.Config {
+		_, ipNet, err := net.ParseCIDR(ipRange.Subnet)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case ipNet.IP.To4() != nil:
+			if v4 == nil {
+				v4 = ipNet
+			}
+		case v6 == nil:
+			v6 = ipNet
+		}
+	}
+	if m.ipFamily == "ipv6" {
+		if v6 == nil {
+			return nil, fmt.Errorf("failed to find kind ipv6 docker net")
+		}
+		return []string{poolRange(v6, m.IPCount)}, nil
+	}
+	if v4 == nil {
+		return nil, fmt.Errorf("failed to find kind ipv4 docker net")
+	}
+	addresses := []string{poolRange(v4, m.IPCount)}
+	if v6 != nil {
+		addresses = append(addresses, poolRange(v6, m.IPCount))
+	}
+	return addresses, nil
+}
+
 func (m *MetalLBSpec) Deploy(ctx context.Context) error {
 	var err error
 	if m.dClient == nil {
@@ -768,7 +1119,7 @@ func (m *MetalLBSpec) Deploy(ctx context.Context) error {
 		m.Manifest = filepath.Join(m.ManifestDir, "metallb-native.yaml")
 	}
 	log.Infof("Deploying MetalLB from: %s", m.Manifest)
-	if err := logCommand("kubectl", "apply", "-f", m.Manifest); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", m.Manifest); err != nil {
 		return err
 	}
 	if _, err := m.kClient.CoreV1().Secrets("metallb-system").Get(ctx, "memberlist", metav1.GetOptions{}); err != nil {
@@ -797,37 +1148,11 @@ func (m *MetalLBSpec) Deploy(ctx context.Context) error {
 
 	if _, err = m.mClient.IPAddressPool("metallb-system").Get(ctx, "kne-service-pool", metav1.GetOptions{}); err != nil {
 		log.Infof("Applying metallb ingress config")
-		// Get Network information from docker.
-		nr, err := m.dClient.NetworkList(ctx, dtypes.NetworkListOptions{})
+		addresses, err := m.poolAddresses(ctx)
 		if err != nil {
 			return err
 		}
-		var network dtypes.NetworkResource
-		for _, v := range nr {
-			name := m.dockerNetworkResourceName
-			if name == "" {
-				name = "bridge"
-			}
-			if v.Name == name {
-				network = v
-				break
-			}
-		}
-		var n *net.IPNet
-		for _, ipRange := range network.IPAM.Config {
-			_, ipNet, err := net.ParseCIDR(ipRange.Subnet)
-			if err != nil {
-				return err
-			}
-			if ipNet.IP.To4() != nil {
-				n = ipNet
-				break
-			}
-		}
-		if n == nil {
-			return fmt.Errorf("failed to find kind ipv4 docker net")
-		}
-		pool := makePool(n, m.IPCount)
+		pool := makePool(addresses)
 		retries := 5
 		for ; ; retries-- {
 			_, err = m.mClient.IPAddressPool("metallb-system").Create(ctx, pool, metav1.CreateOptions{})
@@ -856,6 +1181,36 @@ func (m *MetalLBSpec) Deploy(ctx context.Context) error {
 	return nil
 }
 
+func (m *MetalLBSpec) Delete(ctx context.Context) error {
+	if m.mClient == nil {
+		var err error
+		m.mClient, err = metallbclientv1.NewForConfig(m.rCfg)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("Deleting metallb ingress config")
+	if err := m.mClient.L2Advertisement("metallb-system").Delete(ctx, "kne-l2-service-pool", metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := m.mClient.IPAddressPool("metallb-system").Delete(ctx, "kne-service-pool", metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	log.Infof("Deleting metallb secret")
+	if err := m.kClient.CoreV1().Secrets("metallb-system").Delete(ctx, "memberlist", metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if m.Manifest == "" && m.ManifestDir != "" {
+		m.Manifest = filepath.Join(m.ManifestDir, "metallb-native.yaml")
+	}
+	log.Infof("Deleting MetalLB from: %s", m.Manifest)
+	if err := deleteManifest(ctx, m.Manifest, m.ManifestData); err != nil {
+		return err
+	}
+	log.Infof("MetalLB deleted")
+	return nil
+}
+
 func (m *MetalLBSpec) Healthy(ctx context.Context) error {
 	return deploymentHealthy(ctx, m.kClient, "metallb-system")
 }
@@ -898,13 +1253,25 @@ func (m *MeshnetSpec) Deploy(ctx context.Context) error {
 		m.Manifest = filepath.Join(m.ManifestDir, "manifest.yaml")
 	}
 	log.Infof("Deploying Meshnet from: %s", m.Manifest)
-	if err := logCommand("kubectl", "apply", "-f", m.Manifest); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", m.Manifest); err != nil {
 		return err
 	}
 	log.Infof("Meshnet Deployed")
 	return nil
 }
 
+func (m *MeshnetSpec) Delete(ctx context.Context) error {
+	if m.Manifest == "" && m.ManifestDir != "" {
+		m.Manifest = filepath.Join(m.ManifestDir, "manifest.yaml")
+	}
+	log.Infof("Deleting Meshnet from: %s", m.Manifest)
+	if err := deleteManifest(ctx, m.Manifest, m.ManifestData); err != nil {
+		return err
+	}
+	log.Infof("Meshnet deleted")
+	return nil
+}
+
 func (m *MeshnetSpec) Healthy(ctx context.Context) error {
 	log.Infof("Waiting on Meshnet to be Healthy")
 	w, err := m.kClient.AppsV1().DaemonSets("meshnet").Watch(ctx, metav1.ListOptions{
@@ -972,13 +1339,25 @@ func (c *CEOSLabSpec) Deploy(ctx context.Context) error {
 		c.Operator = filepath.Join(c.ManifestDir, "manifest.yaml")
 	}
 	log.Infof("Deploying CEOSLab controller from: %s", c.Operator)
-	if err := logCommand("kubectl", "apply", "-f", c.Operator); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", c.Operator); err != nil {
 		return err
 	}
 	log.Infof("CEOSLab controller deployed")
 	return nil
 }
 
+func (c *CEOSLabSpec) Delete(ctx context.Context) error {
+	if c.Operator == "" && c.ManifestDir != "" {
+		c.Operator = filepath.Join(c.ManifestDir, "manifest.yaml")
+	}
+	log.Infof("Deleting CEOSLab controller from: %s", c.Operator)
+	if err := deleteManifest(ctx, c.Operator, c.OperatorData); err != nil {
+		return err
+	}
+	log.Infof("CEOSLab controller deleted")
+	return nil
+}
+
 func (c *CEOSLabSpec) Healthy(ctx context.Context) error {
 	return deploymentHealthy(ctx, c.kClient, "arista-ceoslab-operator-system")
 }
@@ -1021,13 +1400,25 @@ func (l *LemmingSpec) Deploy(ctx context.Context) error {
 		l.Operator = filepath.Join(l.ManifestDir, "manifest.yaml")
 	}
 	log.Infof("Deploying Lemming controller from: %s", l.Operator)
-	if err := logCommand("kubectl", "apply", "-f", l.Operator); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", l.Operator); err != nil {
 		return err
 	}
 	log.Infof("Lemming controller deployed")
 	return nil
 }
 
+func (l *LemmingSpec) Delete(ctx context.Context) error {
+	if l.Operator == "" && l.ManifestDir != "" {
+		l.Operator = filepath.Join(l.ManifestDir, "manifest.yaml")
+	}
+	log.Infof("Deleting Lemming controller from: %s", l.Operator)
+	if err := deleteManifest(ctx, l.Operator, l.OperatorData); err != nil {
+		return err
+	}
+	log.Infof("Lemming controller deleted")
+	return nil
+}
+
 func (l *LemmingSpec) Healthy(ctx context.Context) error {
 	return deploymentHealthy(ctx, l.kClient, "lemming-operator")
 }
@@ -1070,13 +1461,25 @@ func (s *SRLinuxSpec) Deploy(ctx context.Context) error {
 		s.Operator = filepath.Join(s.ManifestDir, "manifest.yaml")
 	}
 	log.Infof("Deploying SRLinux controller from: %s", s.Operator)
-	if err := logCommand("kubectl", "apply", "-f", s.Operator); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", s.Operator); err != nil {
 		return err
 	}
 	log.Infof("SRLinux controller deployed")
 	return nil
 }
 
+func (s *SRLinuxSpec) Delete(ctx context.Context) error {
+	if s.Operator == "" && s.ManifestDir != "" {
+		s.Operator = filepath.Join(s.ManifestDir, "manifest.yaml")
+	}
+	log.Infof("Deleting SRLinux controller from: %s", s.Operator)
+	if err := deleteManifest(ctx, s.Operator, s.OperatorData); err != nil {
+		return err
+	}
+	log.Infof("SRLinux controller deleted")
+	return nil
+}
+
 func (s *SRLinuxSpec) Healthy(ctx context.Context) error {
 	return deploymentHealthy(ctx, s.kClient, "srlinux-controller")
 }
@@ -1121,7 +1524,7 @@ func (i *IxiaTGSpec) Deploy(ctx context.Context) error {
 		i.Operator = filepath.Join(i.ManifestDir, "ixiatg-operator.yaml")
 	}
 	log.Infof("Deploying IxiaTG controller from: %s", i.Operator)
-	if err := logCommand("kubectl", "apply", "-f", i.Operator); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", i.Operator); err != nil {
 		return err
 	}
 
@@ -1155,13 +1558,40 @@ func (i *IxiaTGSpec) Deploy(ctx context.Context) error {
 		i.ConfigMap = f.Name()
 	}
 	log.Infof("Deploying IxiaTG config map from: %s", i.ConfigMap)
-	if err := logCommand("kubectl", "apply", "-f", i.ConfigMap); err != nil {
+	if err := logCommand(ctx, "kubectl", "apply", "-f", i.ConfigMap); err != nil {
 		return err
 	}
 	log.Infof("IxiaTG controller deployed")
 	return nil
 }
 
+func (i *IxiaTGSpec) Delete(ctx context.Context) error {
+	if i.ConfigMap == "" && i.ManifestDir != "" {
+		for _, name := range []string{"ixiatg-configmap.yaml", "ixia-configmap.yaml"} {
+			path := filepath.Join(i.ManifestDir, name)
+			if _, err := os.Stat(path); err == nil {
+				i.ConfigMap = path
+				break
+			}
+		}
+	}
+	if i.ConfigMap != "" || i.ConfigMapData != nil {
+		log.Infof("Deleting IxiaTG config map from: %s", i.ConfigMap)
+		if err := deleteManifest(ctx, i.ConfigMap, i.ConfigMapData); err != nil {
+			return err
+		}
+	}
+	if i.Operator == "" && i.ManifestDir != "" {
+		i.Operator = filepath.Join(i.ManifestDir, "ixiatg-operator.yaml")
+	}
+	log.Infof("Deleting IxiaTG controller from: %s", i.Operator)
+	if err := deleteManifest(ctx, i.Operator, i.OperatorData); err != nil {
+		return err
+	}
+	log.Infof("IxiaTG controller deleted")
+	return nil
+}
+
 func (i *IxiaTGSpec) Healthy(ctx context.Context) error {
 	return deploymentHealthy(ctx, i.kClient, "ixiatg-op-system")
 }
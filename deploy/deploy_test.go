@@ -3,6 +3,8 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -24,8 +26,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	fakecorev1 "k8s.io/client-go/kubernetes/typed/core/v1/fake"
+	"k8s.io/client-go/rest"
 	ktest "k8s.io/client-go/testing"
 	"k8s.io/klog/v2"
 )
@@ -39,6 +43,160 @@ func init() {
 	klog.LogToStderr(false)
 }
 
+func TestKindConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatalf("failed to write kind config: %v", err)
+		}
+		return path
+	}
+	base := writeConfig("base.yaml", `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+networking:
+  disableDefaultCNI: true
+nodes:
+- role: control-plane
+  extraMounts:
+  - hostPath: /tmp/kne
+    containerPath: /tmp/kne
+`)
+	withWorkers := writeConfig("workers.yaml", `nodes:
+- role: control-plane
+- role: worker
+`)
+	tests := []struct {
+		desc    string
+		k       *KindSpec
+		want    string
+		wantErr string
+	}{{
+		desc: "unchanged",
+		k:    &KindSpec{KindConfigFile: base},
+	}, {
+		desc: "workers",
+		k: &KindSpec{
+			KindConfigFile: base,
+			Workers:        2,
+			WorkerLabels:   []map[string]string{{"kne/pool": "large"}},
+		},
+		want: `apiVersion: kind.x-k8s.io/v1alpha4
+kind: Cluster
+networking:
+  disableDefaultCNI: true
+nodes:
+- extraMounts:
+  - containerPath: /tmp/kne
+    hostPath: /tmp/kne
+  role: control-plane
+- extraMounts:
+  - containerPath: /tmp/kne
+    hostPath: /tmp/kne
+  labels:
+    kne/pool: large
+  role: worker
+- extraMounts:
+  - containerPath: /tmp/kne
+    hostPath: /tmp/kne
+  role: worker
+`,
+	}, {
+		desc: "extra mounts without config",
+		k: &KindSpec{
+			Workers:     1,
+			ExtraMounts: []*KindMount{{HostPath: "/images", ContainerPath: "/images", ReadOnly: true}},
+		},
+		want: `apiVersion: kind.x-k8s.io/v1alpha4
+kind: Cluster
+nodes:
+- extraMounts:
+  - containerPath: /images
+    hostPath: /images
+    readOnly: true
+  role: control-plane
+- extraMounts:
+  - containerPath: /images
+    hostPath: /images
+    readOnly: true
+  role: worker
+`,
+	}, {
+		desc: "dual stack",
+		k:    &KindSpec{KindConfigFile: base, IPFamily: "dual"},
+		want: `apiVersion: kind.x-k8s.io/v1alpha4
+kind: Cluster
+networking:
+  disableDefaultCNI: true
+  ipFamily: dual
+nodes:
+- extraMounts:
+  - containerPath: /tmp/kne
+    hostPath: /tmp/kne
+  role: control-plane
+`,
+	}, {
+		desc:    "invalid ip family",
+		k:       &KindSpec{IPFamily: "ipv5"},
+		wantErr: `invalid ip family "ipv5"`,
+	}, {
+		desc:    "too many labels",
+		k:       &KindSpec{Workers: 1, WorkerLabels: []map[string]string{{"a": "b"}, {"c": "d"}}},
+		wantErr: "labels for 2 workers but only 1 workers",
+	}, {
+		desc:    "config with workers",
+		k:       &KindSpec{KindConfigFile: withWorkers, Workers: 1},
+		wantErr: "already has workers",
+	}, {
+		desc:    "missing config",
+		k:       &KindSpec{KindConfigFile: filepath.Join(dir, "missing.yaml"), Workers: 1},
+		wantErr: "missing.yaml",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.k.kindConfig()
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, string(got)); s != "" {
+				t.Errorf("kindConfig() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestExternalSpecHealthy(t *testing.T) {
+	tests := []struct {
+		desc      string
+		responses string
+		wantErr   string
+	}{{
+		desc:      "healthy",
+		responses: "testdata/external-healthy.yaml",
+	}, {
+		desc:      "unhealthy",
+		responses: "testdata/external-unhealthy.yaml",
+		wantErr:   "cluster not healthy",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			done, err := fexec.Replay(tt.responses)
+			if err != nil {
+				t.Fatalf("failed to replay commands: %v", err)
+			}
+			defer func() {
+				if err := done(); err != nil {
+					t.Error(err)
+				}
+			}()
+			err = (&ExternalSpec{}).Healthy(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Errorf("Healthy() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
 func TestKindSpec(t *testing.T) {
 	ctx := context.Background()
 
@@ -89,6 +247,29 @@ func TestKindSpec(t *testing.T) {
 		resp: []fexec.Response{
 			{Cmd: "kubectl", Args: []string{"cluster-info", "--context", "kind-test"}},
 		},
+	}, {
+		desc: "exists cluster with recycle runs setup again",
+		k: &KindSpec{
+			Name:                "test",
+			Recycle:             true,
+			ContainerImages:     map[string]string{"docker": ""},
+			AdditionalManifests: []string{"bar:latest"},
+		},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"cluster-info", "--context", "kind-test"}},
+			{Cmd: "kubectl", Args: []string{"apply", "-f", "bar:latest"}},
+			{Cmd: "docker", Args: []string{"pull", "docker"}},
+			{Cmd: "kind", Args: []string{"load", "docker-image", "docker", "--name", "test"}},
+		},
+	}, {
+		desc: "create cluster with workers",
+		k: &KindSpec{
+			Name:    "test",
+			Workers: 2,
+		},
+		resp: []fexec.Response{
+			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test", "--config", ".*.yaml"}},
+		},
 	}, {
 		desc: "unable to find kind cli",
 		k: &KindSpec{
@@ -113,7 +294,7 @@ func TestKindSpec(t *testing.T) {
 		},
 		resp: []fexec.Response{
 			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-west1-docker.pkg.dev"}},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-west1-docker.pkg.dev"}, Stdin: fakeAccessToken},
 			{Cmd: "kind", Args: []string{"get", "nodes", "--name", "test"}},
 			{Cmd: "docker", Args: []string{"cp", ".*/config.json", ":/var/lib/kubelet/config.json"}},
 			{Cmd: "docker", Args: []string{"exec", "", "systemctl", "restart", "kubelet.service"}},
@@ -126,8 +307,8 @@ func TestKindSpec(t *testing.T) {
 		},
 		resp: []fexec.Response{
 			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-west1-docker.pkg.dev"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-central1-docker.pkg.dev"}},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-west1-docker.pkg.dev"}, Stdin: fakeAccessToken},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-central1-docker.pkg.dev"}, Stdin: fakeAccessToken},
 			{Cmd: "kind", Args: []string{"get", "nodes", "--name", "test"}},
 			{Cmd: "docker", Args: []string{"cp", ".*/config.json", ":/var/lib/kubelet/config.json"}},
 			{Cmd: "docker", Args: []string{"exec", "", "systemctl", "restart", "kubelet.service"}},
@@ -162,7 +343,7 @@ func TestKindSpec(t *testing.T) {
 		},
 		resp: []fexec.Response{
 			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-west1-docker.pkg.dev"}, Err: "failed to login to docker"},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-west1-docker.pkg.dev"}, Stdin: fakeAccessToken, Err: "failed to login to docker"},
 		},
 		wantErr: "failed to login to docker",
 	}, {
@@ -173,7 +354,7 @@ func TestKindSpec(t *testing.T) {
 		},
 		resp: []fexec.Response{
 			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-west1-docker.pkg.dev"}},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-west1-docker.pkg.dev"}, Stdin: fakeAccessToken},
 			{Cmd: "kind", Args: []string{"get", "nodes", "--name", "test"}, Err: "failed to get nodes"},
 		},
 		wantErr: "failed to get nodes",
@@ -185,7 +366,7 @@ func TestKindSpec(t *testing.T) {
 		},
 		resp: []fexec.Response{
 			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-west1-docker.pkg.dev"}},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-west1-docker.pkg.dev"}, Stdin: fakeAccessToken},
 			{Cmd: "kind", Args: []string{"get", "nodes", "--name", "test"}},
 			{Cmd: "docker", Args: []string{"cp", ".*/config.json", ":/var/lib/kubelet/config.json"}, Err: "failed to cp config to node"},
 		},
@@ -198,7 +379,7 @@ func TestKindSpec(t *testing.T) {
 		},
 		resp: []fexec.Response{
 			{Cmd: "kind", Args: []string{"create", "cluster", "--name", "test"}},
-			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "-p", fakeAccessToken, "https://us-west1-docker.pkg.dev"}},
+			{Cmd: "docker", Args: []string{"login", "-u", "oauth2accesstoken", "--password-stdin", "https://us-west1-docker.pkg.dev"}, Stdin: fakeAccessToken},
 			{Cmd: "kind", Args: []string{"get", "nodes", "--name", "test"}},
 			{Cmd: "docker", Args: []string{"cp", ".*/config.json", ":/var/lib/kubelet/config.json"}},
 			{Cmd: "docker", Args: []string{"exec", "", "systemctl", "restart", "kubelet.service"}, Err: "failed to restart kubelet"},
@@ -495,6 +676,7 @@ func TestKindSpec(t *testing.T) {
 			}
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 
 			execLookPath = func(_ string) (string, error) {
@@ -563,6 +745,85 @@ func (f *fakeWatch) ResultChan() <-chan watch.Event {
 
 //go:generate mockgen -destination=mocks/mock_dnetwork.go -package=mocks github.com/docker/docker/client  NetworkAPIClient
 
+func TestPoolAddresses(t *testing.T) {
+	nl := []dtypes.NetworkResource{{
+		Name: "kind",
+		IPAM: network.IPAM{
+			Config: []network.IPAMConfig{
+				{Subnet: "fc00:f853:ccd:e793::/64"},
+				{Subnet: "172.18.0.0/16"},
+				{Subnet: "fc00:f853:ccd:e794::/64"},
+			},
+		},
+	}, {
+		Name: "v4",
+		IPAM: network.IPAM{
+			Config: []network.IPAMConfig{{Subnet: "172.19.0.0/16"}},
+		},
+	}, {
+		Name: "v6",
+		IPAM: network.IPAM{
+			Config: []network.IPAMConfig{{Subnet: "fc00::/64"}},
+		},
+	}}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	tests := []struct {
+		desc    string
+		m       *MetalLBSpec
+		want    []string
+		wantErr string
+	}{{
+		desc: "dual stack",
+		m:    &MetalLBSpec{IPCount: 100, dockerNetworkResourceName: "kind"},
+		want: []string{"172.18.0.50 - 172.18.0.150", "fc00:f853:ccd:e793::32 - fc00:f853:ccd:e793::96"},
+	}, {
+		desc: "ipv4",
+		m:    &MetalLBSpec{IPCount: 10, dockerNetworkResourceName: "v4"},
+		want: []string{"172.19.0.50 - 172.19.0.60"},
+	}, {
+		desc:    "ipv6 only net",
+		m:       &MetalLBSpec{IPCount: 10, dockerNetworkResourceName: "v6"},
+		wantErr: "failed to find kind ipv4 docker net",
+	}, {
+		desc: "ipv6 cluster",
+		m:    &MetalLBSpec{IPCount: 10, dockerNetworkResourceName: "v6", ipFamily: "ipv6"},
+		want: []string{"fc00::32 - fc00::3c"},
+	}, {
+		desc: "ipv6 cluster on dual stack net",
+		m:    &MetalLBSpec{IPCount: 100, dockerNetworkResourceName: "kind", ipFamily: "ipv6"},
+		want: []string{"fc00:f853:ccd:e793::32 - fc00:f853:ccd:e793::96"},
+	}, {
+		desc:    "ipv6 cluster without ipv6 net",
+		m:       &MetalLBSpec{IPCount: 10, dockerNetworkResourceName: "v4", ipFamily: "ipv6"},
+		wantErr: "failed to find kind ipv6 docker net",
+	}, {
+		desc: "cidrs",
+		m:    &MetalLBSpec{CIDRs: []string{"10.0.0.0/24", "2001:db8::/120"}},
+		want: []string{"10.0.0.0/24", "2001:db8::/120"},
+	}, {
+		desc:    "invalid cidr",
+		m:       &MetalLBSpec{CIDRs: []string{"10.0.0.0"}},
+		wantErr: "invalid CIDR address",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m := mocks.NewMockNetworkAPIClient(mockCtrl)
+			if tt.m.CIDRs == nil {
+				m.EXPECT().NetworkList(gomock.Any(), gomock.Any()).Return(nl, nil)
+			}
+			tt.m.dClient = m
+			got, err := tt.m.poolAddresses(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("poolAddresses() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
 func TestMetalLBSpec(t *testing.T) {
 	nl := []dtypes.NetworkResource{
 		{
@@ -799,7 +1060,7 @@ func TestMetalLBSpec(t *testing.T) {
 				Namespace: "metallb-system",
 			},
 			Spec: metallbv1.IPAddressPoolSpec{
-				Addresses: []string{"192.18.0.50 - 192.18.0.70"},
+				Addresses: []string{"192.18.0.50 - 192.18.0.70", "129::32 - 129::46"},
 			},
 		},
 		mockExpects: func(m *mocks.MockNetworkAPIClient) {
@@ -858,7 +1119,7 @@ func TestMetalLBSpec(t *testing.T) {
 				Namespace: "metallb-system",
 			},
 			Spec: metallbv1.IPAddressPoolSpec{
-				Addresses: []string{"192.18.0.50 - 192.18.0.70"},
+				Addresses: []string{"192.18.0.50 - 192.18.0.70", "129::32 - 129::46"},
 			},
 		},
 		mockExpects: func(m *mocks.MockNetworkAPIClient) {
@@ -923,7 +1184,7 @@ func TestMetalLBSpec(t *testing.T) {
 				Namespace: "metallb-system",
 			},
 			Spec: metallbv1.IPAddressPoolSpec{
-				Addresses: []string{"172.18.0.50 - 172.18.0.70"},
+				Addresses: []string{"172.18.0.50 - 172.18.0.70", "127::32 - 127::46"},
 			},
 		},
 		mockExpects: func(m *mocks.MockNetworkAPIClient) {
@@ -976,6 +1237,7 @@ func TestMetalLBSpec(t *testing.T) {
 			}
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 
 			tt.k8sObjects = append(tt.k8sObjects, d)
@@ -1132,6 +1394,7 @@ func TestMeshnetSpec(t *testing.T) {
 			tt.m.SetKClient(ki)
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 			err := tt.m.Deploy(context.Background())
 			if s := errdiff.Substring(err, tt.dErr); s != "" {
@@ -1362,6 +1625,7 @@ func TestIxiaTGSpec(t *testing.T) {
 			}
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 
 			ki := fake.NewSimpleClientset(d)
@@ -1528,6 +1792,7 @@ func TestSRLinuxSpec(t *testing.T) {
 			}
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 
 			ki := fake.NewSimpleClientset(d)
@@ -1696,6 +1961,7 @@ func TestCEOSLabSpec(t *testing.T) {
 			}
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 
 			ki := fake.NewSimpleClientset(d)
@@ -1863,6 +2129,7 @@ func TestLemmingSpec(t *testing.T) {
 			}
 			cmds := fexec.Commands(tt.resp)
 			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
 			defer checkCmds(t, cmds)
 
 			ki := fake.NewSimpleClientset(d)
@@ -1894,3 +2161,293 @@ func checkCmds(t *testing.T, cmds *fexec.Command) {
 		t.Errorf("%v", err)
 	}
 }
+
+func TestDeleteSpecs(t *testing.T) {
+	tests := []struct {
+		desc    string
+		c       interface{ Delete(context.Context) error }
+		resp    []fexec.Response
+		wantErr string
+	}{{
+		desc: "meshnet",
+		c:    &MeshnetSpec{Manifest: "/path/to/meshnet.yaml"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/meshnet.yaml"}},
+		},
+	}, {
+		desc: "meshnet manifest data over file",
+		c:    &MeshnetSpec{Manifest: "/path/to/meshnet.yaml", ManifestData: []byte("fake manifest data")},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", ".*.yaml"}},
+		},
+	}, {
+		desc: "meshnet deprecated manifest dir",
+		c:    &MeshnetSpec{ManifestDir: "/path/to"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/manifest.yaml"}},
+		},
+	}, {
+		desc: "meshnet error",
+		c:    &MeshnetSpec{Manifest: "/path/to/meshnet.yaml"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/meshnet.yaml"}, Err: "delete failed"},
+		},
+		wantErr: "delete failed",
+	}, {
+		desc: "ceoslab",
+		c:    &CEOSLabSpec{Operator: "/path/to/operator.yaml"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/operator.yaml"}},
+		},
+	}, {
+		desc: "lemming",
+		c:    &LemmingSpec{OperatorData: []byte("fake operator data")},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", ".*.yaml"}},
+		},
+	}, {
+		desc: "srlinux",
+		c:    &SRLinuxSpec{ManifestDir: "/path/to"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/manifest.yaml"}},
+		},
+	}, {
+		desc: "ixiatg configmap before operator",
+		c: &IxiaTGSpec{
+			Operator:  "/path/to/operator.yaml",
+			ConfigMap: "/path/to/configmap.yaml",
+		},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/configmap.yaml"}},
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/operator.yaml"}},
+		},
+	}, {
+		desc: "ixiatg without configmap",
+		c:    &IxiaTGSpec{Operator: "/path/to/operator.yaml"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/operator.yaml"}},
+		},
+	}, {
+		desc: "ixiatg configmap error",
+		c: &IxiaTGSpec{
+			Operator:      "/path/to/operator.yaml",
+			ConfigMapData: []byte("fake configmap data"),
+		},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", ".*.yaml"}, Err: "configmap delete failed"},
+		},
+		wantErr: "configmap delete failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if verbose {
+				fexec.LogCommand = func(s string) {
+					t.Logf("%s: %s", tt.desc, s)
+				}
+			}
+			cmds := fexec.Commands(tt.resp)
+			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
+			defer checkCmds(t, cmds)
+			err := tt.c.Delete(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestMetalLBSpecDelete(t *testing.T) {
+	tests := []struct {
+		desc       string
+		m          *MetalLBSpec
+		resp       []fexec.Response
+		k8sObjects []runtime.Object
+		mObjects   []runtime.Object
+		wantErr    string
+	}{{
+		desc: "all resources present",
+		m:    &MetalLBSpec{Manifest: "/path/to/metallb.yaml"},
+		k8sObjects: []runtime.Object{
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "metallb-system",
+					Name:      "memberlist",
+				},
+			},
+		},
+		mObjects: []runtime.Object{
+			&metallbv1.IPAddressPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "metallb-system",
+					Name:      "kne-service-pool",
+				},
+			},
+			&metallbv1.L2Advertisement{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "metallb-system",
+					Name:      "kne-l2-service-pool",
+				},
+			},
+		},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/metallb.yaml"}},
+		},
+	}, {
+		desc: "resources already removed",
+		m:    &MetalLBSpec{ManifestDir: "/path/to"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/metallb-native.yaml"}},
+		},
+	}, {
+		desc: "manifest error",
+		m:    &MetalLBSpec{Manifest: "/path/to/metallb.yaml"},
+		resp: []fexec.Response{
+			{Cmd: "kubectl", Args: []string{"delete", "--ignore-not-found", "-f", "/path/to/metallb.yaml"}, Err: "delete failed"},
+		},
+		wantErr: "delete failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if verbose {
+				fexec.LogCommand = func(s string) {
+					t.Logf("%s: %s", tt.desc, s)
+				}
+			}
+			cmds := fexec.Commands(tt.resp)
+			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
+			defer checkCmds(t, cmds)
+
+			ki := fake.NewSimpleClientset(tt.k8sObjects...)
+			mi, err := mfake.NewSimpleClientset(tt.mObjects...)
+			if err != nil {
+				t.Fatalf("failed to create fake client: %v", err)
+			}
+			tt.m.SetKClient(ki)
+			tt.m.mClient = mi
+			err = tt.m.Delete(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := ki.CoreV1().Secrets("metallb-system").Get(context.Background(), "memberlist", metav1.GetOptions{}); err == nil {
+				t.Errorf("memberlist secret not deleted")
+			}
+			if _, err := mi.IPAddressPool("metallb-system").Get(context.Background(), "kne-service-pool", metav1.GetOptions{}); err == nil {
+				t.Errorf("address pool not deleted")
+			}
+			if _, err := mi.L2Advertisement("metallb-system").Get(context.Background(), "kne-l2-service-pool", metav1.GetOptions{}); err == nil {
+				t.Errorf("l2 advertisement not deleted")
+			}
+		})
+	}
+}
+
+// fakeComponent records the order in which components are deleted.
+type fakeComponent struct {
+	name    string
+	deleted *[]string
+	err     error
+}
+
+func (f *fakeComponent) Deploy(context.Context) error        { return nil }
+func (f *fakeComponent) Healthy(context.Context) error       { return nil }
+func (f *fakeComponent) SetKClient(kubernetes.Interface)     {}
+func (f *fakeComponent) SetRCfg(*rest.Config)                {}
+func (f *fakeComponent) SetDockerNetworkResourceName(string) {}
+func (f *fakeComponent) Status(context.Context) (*ComponentStatus, error) {
+	return &ComponentStatus{Installed: true, Healthy: f.err == nil}, f.err
+}
+func (f *fakeComponent) Delete(context.Context) error {
+	*f.deleted = append(*f.deleted, f.name)
+	return f.err
+}
+
+const testKubecfg = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user: {}
+`
+
+func TestDeploymentDelete(t *testing.T) {
+	kubecfg := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubecfg, []byte(testKubecfg), 0600); err != nil {
+		t.Fatalf("failed to write kubecfg: %v", err)
+	}
+	tests := []struct {
+		desc        string
+		cluster     Cluster
+		keepCluster bool
+		resp        []fexec.Response
+		ctrlErr     error
+		wantDeleted []string
+		wantErr     string
+	}{{
+		desc:        "external cluster",
+		cluster:     &ExternalSpec{},
+		wantDeleted: []string{"c2", "c1", "cni", "ingress"},
+	}, {
+		desc:    "kind cluster",
+		cluster: &KindSpec{Name: "test"},
+		resp: []fexec.Response{
+			{Cmd: "kind", Args: []string{"delete", "cluster", "--name", "test"}},
+		},
+	}, {
+		desc:        "kind cluster keep cluster",
+		cluster:     &KindSpec{Name: "test"},
+		keepCluster: true,
+		wantDeleted: []string{"c2", "c1", "cni", "ingress"},
+	}, {
+		desc:        "controller error",
+		cluster:     &ExternalSpec{},
+		ctrlErr:     fmt.Errorf("controller delete failed"),
+		wantDeleted: []string{"c2", "c1", "cni", "ingress"},
+		wantErr:     "controller delete failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if verbose {
+				fexec.LogCommand = func(s string) {
+					t.Logf("%s: %s", tt.desc, s)
+				}
+			}
+			cmds := fexec.Commands(tt.resp)
+			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
+			defer checkCmds(t, cmds)
+
+			var deleted []string
+			d := &Deployment{
+				Cluster: tt.cluster,
+				Ingress: &fakeComponent{name: "ingress", deleted: &deleted},
+				CNI:     &fakeComponent{name: "cni", deleted: &deleted},
+				Controllers: []Controller{
+					&fakeComponent{name: "c1", deleted: &deleted, err: tt.ctrlErr},
+					&fakeComponent{name: "c2", deleted: &deleted},
+				},
+				KeepCluster: tt.keepCluster,
+			}
+			err := d.Delete(context.Background(), kubecfg)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.wantDeleted, deleted); s != "" {
+				t.Errorf("deleted components unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
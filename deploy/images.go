@@ -0,0 +1,244 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	log "k8s.io/klog/v2"
+)
+
+// A manifester returns the manifests a component applies to the cluster.
+type manifester interface {
+	manifests() []manifest
+}
+
+// manifestImages returns the sorted, unique container images referenced by
+// manifests: the value of every "image" field as well as the images listed by
+// path and tag in JSON documents embedded in the manifests, such as the
+// IxiaTG release config map.
+func manifestImages(manifests []manifest) ([]string, error) {
+	images := map[string]bool{}
+	for _, m := range manifests {
+		data := m.data
+		if data == nil {
+			if m.path == "" {
+				continue
+			}
+			var err error
+			if data, err = os.ReadFile(m.path); err != nil {
+				return nil, err
+			}
+		}
+		d := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for {
+			var doc interface{}
+			err := d.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse manifest %q: %w", m.path, err)
+			}
+			collectImages(doc, images)
+		}
+	}
+	return sortedKeys(images), nil
+}
+
+// collectImages adds the images referenced by v to images.
+func collectImages(v interface{}, images map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			s, ok := e.(string)
+			switch {
+			case ok && k == "image":
+				images[s] = true
+			case ok && strings.HasPrefix(strings.TrimSpace(s), "{"):
+				var embedded interface{}
+				if err := json.Unmarshal([]byte(s), &embedded); err == nil {
+					collectImages(embedded, images)
+				}
+			default:
+				collectImages(e, images)
+			}
+		}
+		path, _ := v["path"].(string)
+		tag, _ := v["tag"].(string)
+		if path != "" && tag != "" {
+			images[path+":"+tag] = true
+		}
+	case []interface{}:
+		for _, e := range v {
+			collectImages(e, images)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ManifestImages returns the sorted, unique container images referenced by
+// the manifests of the ingress, CNI and controllers of the deployment.
+func (d *Deployment) ManifestImages() ([]string, error) {
+	components := []interface{}{d.Ingress, d.CNI}
+	for _, c := range d.Controllers {
+		components = append(components, c)
+	}
+	images := map[string]bool{}
+	for _, c := range components {
+		m, ok := c.(manifester)
+		if !ok {
+			continue
+		}
+		l, err := manifestImages(m.manifests())
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range l {
+			images[image] = true
+		}
+	}
+	return sortedKeys(images), nil
+}
+
+// preloadImages loads the images of the manifests of the deployment, and
+// d.Images, into the kind cluster.
+func (d *Deployment) preloadImages(ctx context.Context) error {
+	k, ok := d.Cluster.(*KindSpec)
+	if !ok {
+		return fmt.Errorf("preloading images requires a Kind cluster, got %s", strings.TrimPrefix(componentKey("cluster", d.Cluster), "cluster."))
+	}
+	images, err := d.ManifestImages()
+	if err != nil {
+		return fmt.Errorf("failed to find manifest images: %w", err)
+	}
+	images = append(images, d.Images...)
+	log.Infof("Preloading %d container images", len(images))
+	r, err := k.PreloadImages(ctx, images, d.ImageArchiveDir)
+	if r != nil {
+		log.Infof("Preloaded container images:\n%s", r)
+	}
+	return err
+}
+
+// ImageReport is the result of preloading container images into a kind
+// cluster.
+type ImageReport struct {
+	// Present are the images already in the cluster.
+	Present []string `json:"present,omitempty"`
+	// Loaded are the images loaded from the local docker daemon.
+	Loaded []string `json:"loaded,omitempty"`
+	// Archived are the images loaded from an image archive.
+	Archived []string `json:"archived,omitempty"`
+	// Missing are the images that were not found locally.
+	Missing []string `json:"missing,omitempty"`
+}
+
+func (r *ImageReport) String() string {
+	var b strings.Builder
+	for _, s := range []struct {
+		name   string
+		images []string
+	}{
+		{"present", r.Present},
+		{"loaded", r.Loaded},
+		{"archived", r.Archived},
+		{"missing", r.Missing},
+	} {
+		for _, image := range s.images {
+			fmt.Fprintf(&b, "%-8s  %s\n", s.name, image)
+		}
+	}
+	return b.String()
+}
+
+// ImageArchiveName returns the file name of the archive of image in an image
+// archive directory, such as "ghcr.io_nokia_srlinux_latest.tar" for
+// "ghcr.io/nokia/srlinux:latest".  Archives are created with docker save or
+// any other tool that writes docker or OCI image archives.
+func ImageArchiveName(image string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(image) + ".tar"
+}
+
+// PreloadImages makes the container images available in the cluster without
+// pulling them from a registry.  Images already in the cluster are left as
+// is, the others are loaded from the local docker daemon or, failing that,
+// from their archive in archiveDir.  The returned report lists what was done
+// with each image.  An error is returned if any image could not be found.
+func (k *KindSpec) PreloadImages(ctx context.Context, images []string, archiveDir string) (*ImageReport, error) {
+	r := &ImageReport{}
+	node := k.GetName() + "-control-plane"
+	seen := map[string]bool{}
+	for _, image := range images {
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		if _, err := outCommand(ctx, "docker", "exec", node, "crictl", "inspecti", "-q", image); err == nil {
+			r.Present = append(r.Present, image)
+			continue
+		}
+		if _, err := outCommand(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image); err == nil {
+			if err := k.kindLoad(ctx, "docker-image", image); err != nil {
+				return r, fmt.Errorf("failed to load %q: %w", image, err)
+			}
+			r.Loaded = append(r.Loaded, image)
+			continue
+		}
+		if archiveDir != "" {
+			path := filepath.Join(archiveDir, ImageArchiveName(image))
+			if _, err := os.Stat(path); err == nil {
+				if err := k.kindLoad(ctx, "image-archive", path); err != nil {
+					return r, fmt.Errorf("failed to load %q from %q: %w", image, path, err)
+				}
+				r.Archived = append(r.Archived, image)
+				continue
+			}
+		}
+		r.Missing = append(r.Missing, image)
+	}
+	if len(r.Missing) != 0 {
+		return r, fmt.Errorf("%d container images not found locally: %s", len(r.Missing), strings.Join(r.Missing, ", "))
+	}
+	return r, nil
+}
+
+// kindLoad runs kind load with the given subcommand and argument against the
+// cluster.
+func (k *KindSpec) kindLoad(ctx context.Context, cmd, arg string) error {
+	args := []string{"load", cmd, arg}
+	if k.Name != "" {
+		args = append(args, "--name", k.Name)
+	}
+	return logCommand(ctx, "kind", args...)
+}
@@ -0,0 +1,221 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	kexec "github.com/openconfig/kne/exec"
+	fexec "github.com/openconfig/kne/exec/fake"
+)
+
+const testOperator = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: operator
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: example.com/operator:v1
+      - name: proxy
+        image: example.com/proxy:v2
+      initContainers:
+      - name: init
+        image: example.com/operator:v1
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - schema:
+      openAPIV3Schema:
+        properties:
+          image:
+            type: string
+`
+
+const testConfigMap = `
+apiVersion: v1
+kind: ConfigMap
+data:
+  versions: |
+    {
+      "release": "1",
+      "images": [
+        {"name": "controller", "path": "example.com/controller", "tag": "1.0"},
+        {"name": "engine", "path": "example.com/engine", "tag": "2.0"}
+      ]
+    }
+`
+
+func TestManifestImages(t *testing.T) {
+	tests := []struct {
+		desc    string
+		d       *Deployment
+		want    []string
+		wantErr string
+	}{{
+		desc: "operators",
+		d: &Deployment{
+			Ingress: &MetalLBSpec{ManifestData: []byte(testOperator)},
+			CNI:     &MeshnetSpec{ManifestData: []byte("apiVersion: v1\nkind: Namespace\n")},
+			Controllers: []Controller{
+				&IxiaTGSpec{OperatorData: []byte(testOperator), ConfigMapData: []byte(testConfigMap)},
+				&fakeComponent{name: "fake"},
+			},
+		},
+		want: []string{
+			"example.com/controller:1.0",
+			"example.com/engine:2.0",
+			"example.com/operator:v1",
+			"example.com/proxy:v2",
+		},
+	}, {
+		desc: "repo manifests",
+		d: &Deployment{
+			Ingress: &MetalLBSpec{Manifest: "../manifests/metallb/manifest.yaml"},
+			CNI:     &MeshnetSpec{Manifest: "../manifests/meshnet/grpc/manifest.yaml"},
+		},
+		want: []string{
+			"us-west1-docker.pkg.dev/kne-external/kne/metallb/controller:v0.13.5",
+			"us-west1-docker.pkg.dev/kne-external/kne/metallb/speaker:v0.13.5",
+			"us-west1-docker.pkg.dev/kne-external/kne/networkop/meshnet:v0.3.2",
+		},
+	}, {
+		desc: "missing manifest",
+		d: &Deployment{
+			Ingress: &MetalLBSpec{Manifest: "missing.yaml"},
+			CNI:     &MeshnetSpec{},
+		},
+		wantErr: "missing.yaml",
+	}, {
+		desc: "invalid manifest",
+		d: &Deployment{
+			Ingress: &MetalLBSpec{ManifestData: []byte("a: [")},
+			CNI:     &MeshnetSpec{},
+		},
+		wantErr: "failed to parse manifest",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.d.ManifestImages()
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("ManifestImages() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestImageArchiveName(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "ghcr.io/nokia/srlinux:latest", want: "ghcr.io_nokia_srlinux_latest.tar"},
+		{image: "alpine", want: "alpine.tar"},
+		{image: "localhost:5000/a@sha256:abc", want: "localhost_5000_a_sha256_abc.tar"},
+	}
+	for _, tt := range tests {
+		if got := ImageArchiveName(tt.image); got != tt.want {
+			t.Errorf("ImageArchiveName(%q): got %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestPreloadImages(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, ImageArchiveName("archived:v1"))
+	if err := os.WriteFile(archive, nil, 0600); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	tests := []struct {
+		desc       string
+		k          *KindSpec
+		images     []string
+		archiveDir string
+		resp       []fexec.Response
+		want       *ImageReport
+		wantErr    string
+	}{{
+		desc:   "present",
+		k:      &KindSpec{Name: "kne"},
+		images: []string{"present:v1", "", "present:v1"},
+		resp: []fexec.Response{
+			{Cmd: "docker", Args: []string{"exec", "kne-control-plane", "crictl", "inspecti", "-q", "present:v1"}},
+		},
+		want: &ImageReport{Present: []string{"present:v1"}},
+	}, {
+		desc:       "loaded and archived",
+		k:          &KindSpec{},
+		images:     []string{"local:v1", "archived:v1"},
+		archiveDir: dir,
+		resp: []fexec.Response{
+			{Cmd: "docker", Args: []string{"exec", "kind-control-plane", "crictl", "inspecti", "-q", "local:v1"}, Err: "not found"},
+			{Cmd: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}}", "local:v1"}},
+			{Cmd: "kind", Args: []string{"load", "docker-image", "local:v1"}},
+			{Cmd: "docker", Args: []string{"exec", "kind-control-plane", "crictl", "inspecti", "-q", "archived:v1"}, Err: "not found"},
+			{Cmd: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}}", "archived:v1"}, Err: "not found"},
+			{Cmd: "kind", Args: []string{"load", "image-archive", archive}},
+		},
+		want: &ImageReport{Loaded: []string{"local:v1"}, Archived: []string{"archived:v1"}},
+	}, {
+		desc:       "missing",
+		k:          &KindSpec{Name: "kne"},
+		images:     []string{"missing:v1", "present:v1"},
+		archiveDir: dir,
+		resp: []fexec.Response{
+			{Cmd: "docker", Args: []string{"exec", "kne-control-plane", "crictl", "inspecti", "-q", "missing:v1"}, Err: "not found"},
+			{Cmd: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}}", "missing:v1"}, Err: "not found"},
+			{Cmd: "docker", Args: []string{"exec", "kne-control-plane", "crictl", "inspecti", "-q", "present:v1"}},
+		},
+		want:    &ImageReport{Present: []string{"present:v1"}, Missing: []string{"missing:v1"}},
+		wantErr: "1 container images not found locally: missing:v1",
+	}, {
+		desc:   "load fails",
+		k:      &KindSpec{Name: "kne"},
+		images: []string{"local:v1"},
+		resp: []fexec.Response{
+			{Cmd: "docker", Args: []string{"exec", "kne-control-plane", "crictl", "inspecti", "-q", "local:v1"}, Err: "not found"},
+			{Cmd: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}}", "local:v1"}},
+			{Cmd: "kind", Args: []string{"load", "docker-image", "local:v1", "--name", "kne"}, Err: "load failed"},
+		},
+		want:    &ImageReport{},
+		wantErr: "load failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cmds := fexec.Commands(tt.resp)
+			kexec.Command = cmds.Command
+			kexec.CommandContext = cmds.CommandContext
+			defer checkCmds(t, cmds)
+			got, err := tt.k.PreloadImages(context.Background(), tt.images, tt.archiveDir)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("PreloadImages() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestPreloadImagesCluster(t *testing.T) {
+	d := &Deployment{Cluster: &ExternalSpec{}, Ingress: &MetalLBSpec{}, CNI: &MeshnetSpec{}}
+	if err := d.preloadImages(context.Background()); err == nil {
+		t.Errorf("preloadImages() of external cluster succeeded")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openconfig/kne/ingress"
+	"github.com/openconfig/kne/load"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	log "k8s.io/klog/v2"
+)
+
+func init() {
+	load.Register("NodePort", &load.Spec{
+		Type: NodePortSpec{},
+		Tag:  "ingress",
+	})
+}
+
+// NodePortSpec is an ingress that exposes services on the node ports of the
+// cluster nodes instead of through a load balancer.  It is an alternative to
+// MetalLB for clusters where L2 announcements do not work.
+type NodePortSpec struct {
+	// Address is the address the node ports are reachable at, such as the
+	// address of a load balancer in front of the cluster nodes.  If empty
+	// the internal IP of the first cluster node is used.
+	Address string `yaml:"address"`
+	kClient kubernetes.Interface
+}
+
+func (n *NodePortSpec) SetKClient(c kubernetes.Interface) {
+	n.kClient = c
+}
+
+func (n *NodePortSpec) SetRCfg(*rest.Config) {}
+
+func (n *NodePortSpec) SetDockerNetworkResourceName(string) {}
+
+// Deploy records the node port mode, and address, in the cluster.
+func (n *NodePortSpec) Deploy(ctx context.Context) error {
+	log.Infof("Exposing services on node ports")
+	return ingress.Write(ctx, n.kClient, &ingress.Config{Mode: ingress.NodePort, Address: n.Address})
+}
+
+func (n *NodePortSpec) Healthy(ctx context.Context) error {
+	_, ok, err := ingress.NodePortAddress(ctx, n.kClient)
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return fmt.Errorf("node port ingress not deployed")
+	}
+	return nil
+}
+
+func (n *NodePortSpec) Delete(ctx context.Context) error {
+	return ingress.Delete(ctx, n.kClient)
+}
+
+func (n *NodePortSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	s := &ComponentStatus{Details: map[string]string{}}
+	c, err := ingress.Read(ctx, n.kClient)
+	if err != nil {
+		return nil, err
+	}
+	if s.Installed = c != nil && c.Mode == ingress.NodePort; !s.Installed {
+		return s, nil
+	}
+	addr, _, err := ingress.NodePortAddress(ctx, n.kClient)
+	if err != nil {
+		s.Details["error"] = err.Error()
+		return s, nil
+	}
+	s.Healthy = true
+	s.Details["address"] = addr
+	return s, nil
+}
+
+// Fingerprint returns a fingerprint of the node port address.
+func (n *NodePortSpec) Fingerprint() (string, error) {
+	return fingerprint(nil, "NodePort", n.Address)
+}
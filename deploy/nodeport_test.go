@@ -0,0 +1,109 @@
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/ingress"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodePortSpec(t *testing.T) {
+	kneNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "kne-control-plane"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "172.18.0.2"}},
+		},
+	}
+	tests := []struct {
+		desc       string
+		n          *NodePortSpec
+		objects    []runtime.Object
+		wantData   map[string]string
+		wantStatus *ComponentStatus
+		wantErr    string
+	}{{
+		desc:     "node address",
+		n:        &NodePortSpec{},
+		objects:  []runtime.Object{kneNode},
+		wantData: map[string]string{"mode": "NodePort"},
+		wantStatus: &ComponentStatus{
+			Installed: true,
+			Healthy:   true,
+			Details:   map[string]string{"address": "172.18.0.2"},
+		},
+	}, {
+		desc:     "address",
+		n:        &NodePortSpec{Address: "10.0.0.1"},
+		wantData: map[string]string{"mode": "NodePort", "address": "10.0.0.1"},
+		wantStatus: &ComponentStatus{
+			Installed: true,
+			Healthy:   true,
+			Details:   map[string]string{"address": "10.0.0.1"},
+		},
+	}, {
+		desc: "redeploy",
+		n:    &NodePortSpec{Address: "10.0.0.2"},
+		objects: []runtime.Object{&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ingress.ConfigMap, Namespace: ingress.Namespace},
+			Data:       map[string]string{"mode": "NodePort", "address": "10.0.0.1"},
+		}},
+		wantData: map[string]string{"mode": "NodePort", "address": "10.0.0.2"},
+		wantStatus: &ComponentStatus{
+			Installed: true,
+			Healthy:   true,
+			Details:   map[string]string{"address": "10.0.0.2"},
+		},
+	}, {
+		desc:     "no nodes",
+		n:        &NodePortSpec{},
+		wantData: map[string]string{"mode": "NodePort"},
+		wantStatus: &ComponentStatus{
+			Installed: true,
+			Details:   map[string]string{"error": "no cluster node has an internal IP"},
+		},
+		wantErr: "no cluster node has an internal IP",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ki := fake.NewSimpleClientset(tt.objects...)
+			tt.n.SetKClient(ki)
+			if err := tt.n.Deploy(ctx); err != nil {
+				t.Fatalf("Deploy() failed: %v", err)
+			}
+			cm, err := ki.CoreV1().ConfigMaps(ingress.Namespace).Get(ctx, ingress.ConfigMap, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get ingress config map: %v", err)
+			}
+			if s := cmp.Diff(tt.wantData, cm.Data); s != "" {
+				t.Errorf("Deploy() config map unexpected diff (-want +got):\n%s", s)
+			}
+			err = tt.n.Healthy(ctx)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Errorf("Healthy() unexpected error: %s", s)
+			}
+			got, err := tt.n.Status(ctx)
+			if err != nil {
+				t.Fatalf("Status() failed: %v", err)
+			}
+			if s := cmp.Diff(tt.wantStatus, got); s != "" {
+				t.Errorf("Status() unexpected diff (-want +got):\n%s", s)
+			}
+			if err := tt.n.Delete(ctx); err != nil {
+				t.Fatalf("Delete() failed: %v", err)
+			}
+			if err := tt.n.Delete(ctx); err != nil {
+				t.Fatalf("Delete() of deleted ingress failed: %v", err)
+			}
+			if err := tt.n.Healthy(ctx); err == nil {
+				t.Errorf("Healthy() of deleted ingress succeeded")
+			}
+		})
+	}
+}
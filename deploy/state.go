@@ -0,0 +1,266 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	log "k8s.io/klog/v2"
+)
+
+const (
+	// stateNamespace and stateConfigMap name the config map that records
+	// the fingerprint of each component installed in the cluster.
+	stateNamespace = "kube-system"
+	stateConfigMap = "kne-deployment"
+)
+
+// installedHealthTimeout is how long to wait for an already installed
+// component to report healthy before deploying it again.
+var installedHealthTimeout = 10 * time.Second
+
+// component is the part of the Ingress, CNI and Controller interfaces needed
+// to deploy a component.
+type component interface {
+	Deploy(context.Context) error
+	Healthy(context.Context) error
+}
+
+// A fingerprinter returns a fingerprint of everything a component installs.
+// Two deployments of a component with the same fingerprint install the same
+// resources.
+type fingerprinter interface {
+	Fingerprint() (string, error)
+}
+
+// componentKey returns the key of c in the state config map, such as
+// "controller.CEOSLab".
+func componentKey(role string, c interface{}) string {
+	t := reflect.TypeOf(c)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return role + "." + strings.TrimSuffix(t.Name(), "Spec")
+}
+
+// deployState is the set of component fingerprints recorded in the cluster.
+type deployState struct {
+	kClient kubernetes.Interface
+	data    map[string]string
+}
+
+// loadState reads the component fingerprints recorded in the cluster.  A
+// cluster without any recorded state returns an empty state.
+func loadState(ctx context.Context, kClient kubernetes.Interface) (*deployState, error) {
+	s := &deployState{kClient: kClient, data: map[string]string{}}
+	cm, err := kClient.CoreV1().ConfigMaps(stateNamespace).Get(ctx, stateConfigMap, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return s, nil
+	case err != nil:
+		return nil, err
+	}
+	for k, v := range cm.Data {
+		s.data[k] = v
+	}
+	return s, nil
+}
+
+// get returns the fingerprint recorded for key.
+func (s *deployState) get(key string) string {
+	return s.data[key]
+}
+
+// set records fingerprint for key in the cluster.
+func (s *deployState) set(ctx context.Context, key, fingerprint string) error {
+	s.data[key] = fingerprint
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stateConfigMap,
+			Namespace: stateNamespace,
+		},
+		Data: s.data,
+	}
+	_, err := s.kClient.CoreV1().ConfigMaps(stateNamespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = s.kClient.CoreV1().ConfigMaps(stateNamespace).Create(ctx, cm, metav1.CreateOptions{})
+	}
+	return err
+}
+
+// deleteState removes the component fingerprints recorded in the cluster.
+func deleteState(ctx context.Context, kClient kubernetes.Interface) error {
+	err := kClient.CoreV1().ConfigMaps(stateNamespace).Delete(ctx, stateConfigMap, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// deployComponent deploys c and waits for it to become healthy.  If resuming,
+// and c was previously deployed with the same fingerprint and is still
+// healthy, c is left as is.  Once c is healthy its fingerprint is recorded in
+// s under key.
+func (d *Deployment) deployComponent(ctx context.Context, s *deployState, key string, c component) error {
+	var fp string
+	if f, ok := c.(fingerprinter); ok {
+		var err error
+		if fp, err = f.Fingerprint(); err != nil {
+			log.Warningf("Failed to fingerprint %s, it will always be deployed: %v", key, err)
+		}
+	}
+	if d.Resume && fp != "" && s.get(key) == fp {
+		tCtx, cancel := context.WithTimeout(ctx, installedHealthTimeout)
+		err := c.Healthy(tCtx)
+		cancel()
+		if err == nil {
+			log.Infof("%s already installed and healthy, skipping", key)
+			return nil
+		}
+		log.Infof("%s installed but not healthy, deploying again: %v", key, err)
+	}
+	if err := c.Deploy(ctx); err != nil {
+		return err
+	}
+	tCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	if err := c.Healthy(tCtx); err != nil {
+		return err
+	}
+	if fp != "" {
+		if err := s.set(ctx, key, fp); err != nil {
+			log.Warningf("Failed to record state of %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// A manifest is either the path to a manifest file or the contents of the
+// manifest.  If data is not nil then path is ignored.
+type manifest struct {
+	path string
+	data []byte
+}
+
+// fingerprint returns the hex encoded sha256 of the contents of manifests and
+// of the values in extra.
+func fingerprint(manifests []manifest, extra ...interface{}) (string, error) {
+	h := sha256.New()
+	for _, m := range manifests {
+		data := m.data
+		if data == nil && m.path != "" {
+			var err error
+			if data, err = os.ReadFile(m.path); err != nil {
+				return "", err
+			}
+		}
+		fmt.Fprintf(h, "%d:", len(data))
+		h.Write(data)
+	}
+	for _, e := range extra {
+		fmt.Fprintf(h, "%v;", e)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dirManifest returns path, or the file name in dir if path is empty.  It
+// mirrors the handling of the deprecated manifests directory field in Deploy.
+func dirManifest(path, dir, name string) string {
+	if path == "" && dir != "" {
+		return filepath.Join(dir, name)
+	}
+	return path
+}
+
+// Fingerprint returns a fingerprint of the MetalLB manifest and the address
+// pool.
+func (m *MetalLBSpec) Fingerprint() (string, error) {
+	return fingerprint(m.manifests(), m.IPCount, m.CIDRs)
+}
+
+// Fingerprint returns a fingerprint of the Meshnet manifest.
+func (m *MeshnetSpec) Fingerprint() (string, error) {
+	return fingerprint(m.manifests())
+}
+
+// Fingerprint returns a fingerprint of the CEOSLab operator manifest.
+func (c *CEOSLabSpec) Fingerprint() (string, error) {
+	return fingerprint(c.manifests())
+}
+
+// Fingerprint returns a fingerprint of the Lemming operator manifest.
+func (l *LemmingSpec) Fingerprint() (string, error) {
+	return fingerprint(l.manifests())
+}
+
+// Fingerprint returns a fingerprint of the SRLinux operator manifest.
+func (s *SRLinuxSpec) Fingerprint() (string, error) {
+	return fingerprint(s.manifests())
+}
+
+// Fingerprint returns a fingerprint of the IxiaTG operator and config map
+// manifests.
+func (i *IxiaTGSpec) Fingerprint() (string, error) {
+	return fingerprint(i.manifests())
+}
+
+func (m *MetalLBSpec) manifests() []manifest {
+	return []manifest{{dirManifest(m.Manifest, m.ManifestDir, "metallb-native.yaml"), m.ManifestData}}
+}
+
+func (m *MeshnetSpec) manifests() []manifest {
+	return []manifest{{dirManifest(m.Manifest, m.ManifestDir, "manifest.yaml"), m.ManifestData}}
+}
+
+func (c *CEOSLabSpec) manifests() []manifest {
+	return []manifest{{dirManifest(c.Operator, c.ManifestDir, "manifest.yaml"), c.OperatorData}}
+}
+
+func (l *LemmingSpec) manifests() []manifest {
+	return []manifest{{dirManifest(l.Operator, l.ManifestDir, "manifest.yaml"), l.OperatorData}}
+}
+
+func (s *SRLinuxSpec) manifests() []manifest {
+	return []manifest{{dirManifest(s.Operator, s.ManifestDir, "manifest.yaml"), s.OperatorData}}
+}
+
+func (i *IxiaTGSpec) manifests() []manifest {
+	configMap := i.ConfigMap
+	if configMap == "" && i.ManifestDir != "" {
+		for _, name := range []string{"ixiatg-configmap.yaml", "ixia-configmap.yaml"} {
+			path := filepath.Join(i.ManifestDir, name)
+			if _, err := os.Stat(path); err == nil {
+				configMap = path
+				break
+			}
+		}
+	}
+	return []manifest{
+		{dirManifest(i.Operator, i.ManifestDir, "ixiatg-operator.yaml"), i.OperatorData},
+		{configMap, i.ConfigMapData},
+	}
+}
@@ -0,0 +1,258 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestComponentKey(t *testing.T) {
+	tests := []struct {
+		role string
+		c    interface{}
+		want string
+	}{
+		{role: "ingress", c: &MetalLBSpec{}, want: "ingress.MetalLB"},
+		{role: "cni", c: &MeshnetSpec{}, want: "cni.Meshnet"},
+		{role: "controller", c: &IxiaTGSpec{}, want: "controller.IxiaTG"},
+		{role: "controller", c: fakeDeployComponent{}, want: "controller.fakeDeployComponent"},
+	}
+	for _, tt := range tests {
+		if got := componentKey(tt.role, tt.c); got != tt.want {
+			t.Errorf("componentKey(%q, %T): got %q, want %q", tt.role, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, []byte("manifest"), 0600); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	fp := func(f fingerprinter) string {
+		t.Helper()
+		s, err := f.Fingerprint()
+		if err != nil {
+			t.Fatalf("Fingerprint() failed: %v", err)
+		}
+		return s
+	}
+	if fp(&MeshnetSpec{Manifest: path}) != fp(&MeshnetSpec{ManifestData: []byte("manifest")}) {
+		t.Errorf("manifest file and data with the same contents have different fingerprints")
+	}
+	if fp(&MeshnetSpec{Manifest: path}) != fp(&MeshnetSpec{ManifestDir: dir}) {
+		t.Errorf("manifest file and manifest dir have different fingerprints")
+	}
+	if fp(&CEOSLabSpec{OperatorData: []byte("a")}) == fp(&CEOSLabSpec{OperatorData: []byte("b")}) {
+		t.Errorf("different operators have the same fingerprint")
+	}
+	if fp(&MetalLBSpec{Manifest: path, IPCount: 10}) == fp(&MetalLBSpec{Manifest: path, IPCount: 20}) {
+		t.Errorf("different ip counts have the same fingerprint")
+	}
+	if fp(&MetalLBSpec{Manifest: path, IPCount: 10}) == fp(&MetalLBSpec{Manifest: path, IPCount: 10, CIDRs: []string{"10.0.0.0/24"}}) {
+		t.Errorf("different cidrs have the same fingerprint")
+	}
+	if fp(&IxiaTGSpec{OperatorData: []byte("a")}) == fp(&IxiaTGSpec{OperatorData: []byte("a"), ConfigMapData: []byte("b")}) {
+		t.Errorf("different config maps have the same fingerprint")
+	}
+	if _, err := (&SRLinuxSpec{Operator: filepath.Join(dir, "missing.yaml")}).Fingerprint(); err == nil {
+		t.Errorf("Fingerprint() of missing manifest succeeded")
+	}
+}
+
+// fakeDeployComponent counts the number of times it is deployed.
+type fakeDeployComponent struct {
+	deploys    *int
+	deployErr  error
+	healthyErr error
+	fp         string
+}
+
+func (f fakeDeployComponent) Deploy(context.Context) error {
+	*f.deploys++
+	return f.deployErr
+}
+
+func (f fakeDeployComponent) Healthy(context.Context) error {
+	return f.healthyErr
+}
+
+// fakeFingerprintComponent is a fakeDeployComponent with a fingerprint.
+type fakeFingerprintComponent struct {
+	fakeDeployComponent
+}
+
+func (f fakeFingerprintComponent) Fingerprint() (string, error) {
+	if f.fp == "" {
+		return "", fmt.Errorf("no fingerprint")
+	}
+	return f.fp, nil
+}
+
+func TestDeployComponent(t *testing.T) {
+	origTimeout := installedHealthTimeout
+	defer func() {
+		installedHealthTimeout = origTimeout
+	}()
+	installedHealthTimeout = time.Millisecond
+
+	const key = "controller.Fake"
+	tests := []struct {
+		desc        string
+		resume      bool
+		recorded    string
+		fp          string
+		noFP        bool
+		deployErr   error
+		healthyErr  error
+		wantDeploys int
+		wantState   string
+		wantErr     string
+	}{{
+		desc:        "first deploy",
+		fp:          "a",
+		wantDeploys: 1,
+		wantState:   "a",
+	}, {
+		desc:        "redeploy without resume",
+		recorded:    "a",
+		fp:          "a",
+		wantDeploys: 1,
+		wantState:   "a",
+	}, {
+		desc:      "resume installed and healthy",
+		resume:    true,
+		recorded:  "a",
+		fp:        "a",
+		wantState: "a",
+	}, {
+		desc:        "resume changed manifest",
+		resume:      true,
+		recorded:    "a",
+		fp:          "b",
+		wantDeploys: 1,
+		wantState:   "b",
+	}, {
+		desc:        "resume not installed",
+		resume:      true,
+		fp:          "a",
+		wantDeploys: 1,
+		wantState:   "a",
+	}, {
+		desc:        "resume unhealthy",
+		resume:      true,
+		recorded:    "a",
+		fp:          "a",
+		healthyErr:  fmt.Errorf("not healthy"),
+		wantDeploys: 1,
+		wantState:   "a",
+		wantErr:     "not healthy",
+	}, {
+		desc:        "resume without fingerprint",
+		resume:      true,
+		noFP:        true,
+		wantDeploys: 1,
+	}, {
+		desc:        "fingerprint error",
+		resume:      true,
+		wantDeploys: 1,
+	}, {
+		desc:        "deploy error",
+		fp:          "a",
+		deployErr:   fmt.Errorf("deploy failed"),
+		wantDeploys: 1,
+		wantErr:     "deploy failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var objs []runtime.Object
+			if tt.recorded != "" {
+				objs = append(objs, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: stateConfigMap, Namespace: stateNamespace},
+					Data:       map[string]string{key: tt.recorded},
+				})
+			}
+			ki := fake.NewSimpleClientset(objs...)
+			state, err := loadState(context.Background(), ki)
+			if err != nil {
+				t.Fatalf("loadState() failed: %v", err)
+			}
+			deploys := 0
+			f := fakeDeployComponent{
+				deploys:    &deploys,
+				deployErr:  tt.deployErr,
+				healthyErr: tt.healthyErr,
+				fp:         tt.fp,
+			}
+			var c component = fakeFingerprintComponent{f}
+			if tt.noFP {
+				c = f
+			}
+			d := &Deployment{Resume: tt.resume}
+			err = d.deployComponent(context.Background(), state, key, c)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: %s", s)
+			}
+			if deploys != tt.wantDeploys {
+				t.Errorf("got %d deploys, want %d", deploys, tt.wantDeploys)
+			}
+			if tt.wantState == "" {
+				tt.wantState = tt.recorded
+			}
+			got, err := loadState(context.Background(), ki)
+			if err != nil {
+				t.Fatalf("loadState() failed: %v", err)
+			}
+			if got.get(key) != tt.wantState {
+				t.Errorf("got recorded state %q, want %q", got.get(key), tt.wantState)
+			}
+		})
+	}
+}
+
+func TestState(t *testing.T) {
+	ctx := context.Background()
+	ki := fake.NewSimpleClientset()
+	s, err := loadState(ctx, ki)
+	if err != nil {
+		t.Fatalf("loadState() failed: %v", err)
+	}
+	if err := s.set(ctx, "ingress.MetalLB", "a"); err != nil {
+		t.Fatalf("set() failed: %v", err)
+	}
+	if err := s.set(ctx, "cni.Meshnet", "b"); err != nil {
+		t.Fatalf("set() failed: %v", err)
+	}
+	cm, err := ki.CoreV1().ConfigMaps(stateNamespace).Get(ctx, stateConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get state config map: %v", err)
+	}
+	want := map[string]string{"ingress.MetalLB": "a", "cni.Meshnet": "b"}
+	if s := cmp.Diff(want, cm.Data); s != "" {
+		t.Errorf("state unexpected diff (-want +got):\n%s", s)
+	}
+	if err := deleteState(ctx, ki); err != nil {
+		t.Fatalf("deleteState() failed: %v", err)
+	}
+	if err := deleteState(ctx, ki); err != nil {
+		t.Fatalf("deleteState() of missing state failed: %v", err)
+	}
+	s, err = loadState(ctx, ki)
+	if err != nil {
+		t.Fatalf("loadState() failed: %v", err)
+	}
+	if len(s.data) != 0 {
+		t.Errorf("state not deleted: %v", s.data)
+	}
+}
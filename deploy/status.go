@@ -0,0 +1,239 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metallbclientv1 "github.com/openconfig/kne/api/metallb/clientset/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// ComponentStatus is the state of a component of a deployment as found in
+// the cluster.
+type ComponentStatus struct {
+	// Component is the role of the component: cluster, ingress, cni or
+	// controller.
+	Component string `json:"component"`
+	// Kind is the kind of the component, such as Kind, MetalLB or CEOSLab.
+	Kind      string `json:"kind"`
+	Installed bool   `json:"installed"`
+	Healthy   bool   `json:"healthy"`
+	Version   string `json:"version,omitempty"`
+	// Details are component specific, such as the MetalLB address pools or
+	// the meshnet daemonset rollout.
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Status returns the status of each component of the deployment in the
+// cluster of kubecfg, starting with the cluster itself.  Components that
+// cannot be inspected are reported as unhealthy with the error in their
+// details.
+func (d *Deployment) Status(ctx context.Context, kubecfg string) ([]*ComponentStatus, error) {
+	rCfg, err := clientcmd.BuildConfigFromFlags("", kubecfg)
+	if err != nil {
+		return nil, err
+	}
+	kClient, err := kubernetes.NewForConfig(rCfg)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []*ComponentStatus{d.clusterStatus(ctx, kubecfg, kClient)}
+	add := func(role string, c interface {
+		Status(context.Context) (*ComponentStatus, error)
+	}) {
+		s, err := c.Status(ctx)
+		if err != nil {
+			s = &ComponentStatus{Details: map[string]string{"error": err.Error()}}
+		}
+		s.Component = role
+		s.Kind = strings.TrimPrefix(componentKey(role, c), role+".")
+		statuses = append(statuses, s)
+	}
+	d.Ingress.SetKClient(kClient)
+	d.Ingress.SetRCfg(rCfg)
+	add("ingress", d.Ingress)
+	d.CNI.SetKClient(kClient)
+	add("cni", d.CNI)
+	for _, c := range d.Controllers {
+		c.SetKClient(kClient)
+		add("controller", c)
+	}
+	return statuses, nil
+}
+
+// clusterStatus returns the status of the cluster of kubecfg: the client and
+// server versions, the state of each node and, for kind, the node image.
+func (d *Deployment) clusterStatus(ctx context.Context, kubecfg string, kClient kubernetes.Interface) *ComponentStatus {
+	s := &ComponentStatus{
+		Component: "cluster",
+		Kind:      strings.TrimPrefix(componentKey("cluster", d.Cluster), "cluster."),
+		Details:   map[string]string{"name": d.Cluster.GetName()},
+	}
+	if err := d.Cluster.Healthy(ctx); err != nil {
+		s.Details["error"] = err.Error()
+		return s
+	}
+	s.Installed = true
+	args := []string{"version", "--output=yaml"}
+	if kubecfg != "" {
+		args = append(args, "--kubeconfig", kubecfg)
+	}
+	if out, err := outCommand(ctx, "kubectl", args...); err == nil {
+		kv := kubeVersion{}
+		if err := yaml.Unmarshal(out, &kv); err == nil {
+			if kv.ServerVersion != nil {
+				s.Version = kv.ServerVersion.GitVersion
+			}
+			if kv.ClientVersion != nil {
+				s.Details["client"] = kv.ClientVersion.GitVersion
+			}
+		}
+	}
+	if k, ok := d.Cluster.(*KindSpec); ok {
+		node := k.GetName() + "-control-plane"
+		if out, err := outCommand(ctx, "docker", "inspect", "--format", "{{.Config.Image}}", node); err == nil {
+			s.Details["image"] = strings.TrimSpace(string(out))
+		}
+	}
+	nodes, err := kClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		s.Details["error"] = err.Error()
+		return s
+	}
+	s.Healthy = true
+	for _, n := range nodes.Items {
+		ready := "NotReady"
+		for _, c := range n.Status.Conditions {
+			if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+				ready = "Ready"
+			}
+		}
+		if ready != "Ready" {
+			s.Healthy = false
+		}
+		s.Details["node/"+n.Name] = fmt.Sprintf("%s %s", ready, n.Status.NodeInfo.KubeletVersion)
+	}
+	return s
+}
+
+// imageVersion returns the tag, or digest, of image.
+func imageVersion(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// deploymentsStatus returns the status of the deployments in namespace.  The
+// component is installed if namespace has any deployments, healthy if all of
+// them have their desired number of ready replicas and its version is the
+// image version of the first container of the first deployment.
+func deploymentsStatus(ctx context.Context, c kubernetes.Interface, namespace string) (*ComponentStatus, error) {
+	s := &ComponentStatus{Details: map[string]string{"namespace": namespace}}
+	l, err := c.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(l.Items) == 0 {
+		return s, nil
+	}
+	sort.Slice(l.Items, func(i, j int) bool { return l.Items[i].Name < l.Items[j].Name })
+	s.Installed = true
+	s.Healthy = true
+	for _, d := range l.Items {
+		var r int32 = 1
+		if d.Spec.Replicas != nil {
+			r = *d.Spec.Replicas
+		}
+		if d.Status.ReadyReplicas != r || d.Status.AvailableReplicas != r {
+			s.Healthy = false
+		}
+		s.Details["deployment/"+d.Name] = fmt.Sprintf("%d/%d ready", d.Status.ReadyReplicas, r)
+		if cs := d.Spec.Template.Spec.Containers; s.Version == "" && len(cs) > 0 {
+			s.Version = imageVersion(cs[0].Image)
+		}
+	}
+	return s, nil
+}
+
+func (m *MetalLBSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	s, err := deploymentsStatus(ctx, m.kClient, "metallb-system")
+	if err != nil || !s.Installed {
+		return s, err
+	}
+	if m.mClient == nil {
+		if m.mClient, err = metallbclientv1.NewForConfig(m.rCfg); err != nil {
+			return nil, err
+		}
+	}
+	pool, err := m.mClient.IPAddressPool("metallb-system").Get(ctx, "kne-service-pool", metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		s.Healthy = false
+		s.Details["pool"] = "none"
+	case err != nil:
+		return nil, err
+	default:
+		s.Details["pool"] = strings.Join(pool.Spec.Addresses, ", ")
+	}
+	return s, nil
+}
+
+func (m *MeshnetSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	s := &ComponentStatus{Details: map[string]string{"namespace": "meshnet"}}
+	d, err := m.kClient.AppsV1().DaemonSets("meshnet").Get(ctx, "meshnet", metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return s, nil
+	case err != nil:
+		return nil, err
+	}
+	s.Installed = true
+	s.Healthy = d.Status.NumberReady == d.Status.DesiredNumberScheduled && d.Status.NumberUnavailable == 0
+	s.Details["rollout"] = fmt.Sprintf("%d desired, %d ready, %d updated, %d available",
+		d.Status.DesiredNumberScheduled, d.Status.NumberReady, d.Status.UpdatedNumberScheduled, d.Status.NumberAvailable)
+	if cs := d.Spec.Template.Spec.Containers; len(cs) > 0 {
+		s.Version = imageVersion(cs[0].Image)
+	}
+	return s, nil
+}
+
+func (c *CEOSLabSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	return deploymentsStatus(ctx, c.kClient, "arista-ceoslab-operator-system")
+}
+
+func (l *LemmingSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	return deploymentsStatus(ctx, l.kClient, "lemming-operator")
+}
+
+func (s *SRLinuxSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	return deploymentsStatus(ctx, s.kClient, "srlinux-controller")
+}
+
+func (i *IxiaTGSpec) Status(ctx context.Context) (*ComponentStatus, error) {
+	return deploymentsStatus(ctx, i.kClient, "ixiatg-op-system")
+}
@@ -0,0 +1,308 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	mfake "github.com/openconfig/kne/api/metallb/clientset/v1beta1/fake"
+	kexec "github.com/openconfig/kne/exec"
+	fexec "github.com/openconfig/kne/exec/fake"
+	metallbv1 "go.universe.tf/metallb/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestImageVersion(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "quay.io/metallb/controller:v0.13.7", want: "v0.13.7"},
+		{image: "localhost:5000/meshnet:v0.3.1", want: "v0.3.1"},
+		{image: "localhost:5000/meshnet", want: ""},
+		{image: "ghcr.io/lemming@sha256:abc", want: "sha256:abc"},
+		{image: "busybox", want: ""},
+	}
+	for _, tt := range tests {
+		if got := imageVersion(tt.image); got != tt.want {
+			t.Errorf("imageVersion(%q): got %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
+
+func testDeployment(namespace, name, image string, want, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &want,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: name, Image: image}},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			ReadyReplicas:     ready,
+			AvailableReplicas: ready,
+		},
+	}
+}
+
+func TestControllerStatus(t *testing.T) {
+	tests := []struct {
+		desc    string
+		c       Controller
+		objects []runtime.Object
+		want    *ComponentStatus
+	}{{
+		desc: "not installed",
+		c:    &CEOSLabSpec{},
+		want: &ComponentStatus{
+			Details: map[string]string{"namespace": "arista-ceoslab-operator-system"},
+		},
+	}, {
+		desc: "healthy",
+		c:    &SRLinuxSpec{},
+		objects: []runtime.Object{
+			testDeployment("srlinux-controller", "controller-manager", "ghcr.io/srl-labs/srl-controller:0.5.0", 1, 1),
+		},
+		want: &ComponentStatus{
+			Installed: true,
+			Healthy:   true,
+			Version:   "0.5.0",
+			Details: map[string]string{
+				"namespace":                     "srlinux-controller",
+				"deployment/controller-manager": "1/1 ready",
+			},
+		},
+	}, {
+		desc: "unhealthy",
+		c:    &IxiaTGSpec{},
+		objects: []runtime.Object{
+			testDeployment("ixiatg-op-system", "b", "ixia/operator:v2", 2, 2),
+			testDeployment("ixiatg-op-system", "a", "ixia/operator:v1", 2, 1),
+		},
+		want: &ComponentStatus{
+			Installed: true,
+			Version:   "v1",
+			Details: map[string]string{
+				"namespace":    "ixiatg-op-system",
+				"deployment/a": "1/2 ready",
+				"deployment/b": "2/2 ready",
+			},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.c.SetKClient(fake.NewSimpleClientset(tt.objects...))
+			got, err := tt.c.Status(context.Background())
+			if err != nil {
+				t.Fatalf("Status() failed: %v", err)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("Status() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestMetalLBStatus(t *testing.T) {
+	tests := []struct {
+		desc     string
+		objects  []runtime.Object
+		mObjects []runtime.Object
+		want     *ComponentStatus
+	}{{
+		desc: "not installed",
+		want: &ComponentStatus{Details: map[string]string{"namespace": "metallb-system"}},
+	}, {
+		desc: "no pool",
+		objects: []runtime.Object{
+			testDeployment("metallb-system", "controller", "quay.io/metallb/controller:v0.13.7", 1, 1),
+		},
+		want: &ComponentStatus{
+			Installed: true,
+			Version:   "v0.13.7",
+			Details: map[string]string{
+				"namespace":             "metallb-system",
+				"deployment/controller": "1/1 ready",
+				"pool":                  "none",
+			},
+		},
+	}, {
+		desc: "healthy",
+		objects: []runtime.Object{
+			testDeployment("metallb-system", "controller", "quay.io/metallb/controller:v0.13.7", 1, 1),
+		},
+		mObjects: []runtime.Object{
+			&metallbv1.IPAddressPool{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "metallb-system", Name: "kne-service-pool"},
+				Spec: metallbv1.IPAddressPoolSpec{
+					Addresses: []string{"172.18.0.50 - 172.18.0.150"},
+				},
+			},
+		},
+		want: &ComponentStatus{
+			Installed: true,
+			Healthy:   true,
+			Version:   "v0.13.7",
+			Details: map[string]string{
+				"namespace":             "metallb-system",
+				"deployment/controller": "1/1 ready",
+				"pool":                  "172.18.0.50 - 172.18.0.150",
+			},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mi, err := mfake.NewSimpleClientset(tt.mObjects...)
+			if err != nil {
+				t.Fatalf("failed to create fake client: %v", err)
+			}
+			m := &MetalLBSpec{mClient: mi}
+			m.SetKClient(fake.NewSimpleClientset(tt.objects...))
+			got, err := m.Status(context.Background())
+			if err != nil {
+				t.Fatalf("Status() failed: %v", err)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("Status() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestMeshnetStatus(t *testing.T) {
+	m := &MeshnetSpec{}
+	m.SetKClient(fake.NewSimpleClientset())
+	got, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	if got.Installed {
+		t.Errorf("Status() of missing meshnet reported installed")
+	}
+	m.SetKClient(fake.NewSimpleClientset(&appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "meshnet", Namespace: "meshnet"},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "meshnet", Image: "networkop/meshnet:v0.3.0"}},
+				},
+			},
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 2,
+			NumberReady:            2,
+			UpdatedNumberScheduled: 2,
+			NumberAvailable:        2,
+		},
+	}))
+	got, err = m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	want := &ComponentStatus{
+		Installed: true,
+		Healthy:   true,
+		Version:   "v0.3.0",
+		Details: map[string]string{
+			"namespace": "meshnet",
+			"rollout":   "2 desired, 2 ready, 2 updated, 2 available",
+		},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Status() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestClusterStatus(t *testing.T) {
+	cmds := fexec.Commands([]fexec.Response{
+		{Cmd: "kubectl", Args: []string{"cluster-info", "--context", "kind-kne"}},
+		{Cmd: "kubectl", Args: []string{"version", "--output=yaml", "--kubeconfig", "/tmp/kubecfg"}, Stdout: "clientVersion:\n  gitVersion: v1.26.1\nserverVersion:\n  gitVersion: v1.25.3\n"},
+		{Cmd: "docker", Args: []string{"inspect", "--format", "{{.Config.Image}}", "kne-control-plane"}, Stdout: "kindest/node:v1.25.3\n"},
+	})
+	kexec.Command = cmds.Command
+	kexec.CommandContext = cmds.CommandContext
+	defer checkCmds(t, cmds)
+	ki := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "kne-control-plane"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: "v1.25.3"},
+		},
+	})
+	d := &Deployment{Cluster: &KindSpec{Name: "kne"}}
+	got := d.clusterStatus(context.Background(), "/tmp/kubecfg", ki)
+	want := &ComponentStatus{
+		Component: "cluster",
+		Kind:      "Kind",
+		Installed: true,
+		Healthy:   true,
+		Version:   "v1.25.3",
+		Details: map[string]string{
+			"name":                   "kne",
+			"client":                 "v1.26.1",
+			"image":                  "kindest/node:v1.25.3",
+			"node/kne-control-plane": "Ready v1.25.3",
+		},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("clusterStatus() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestDeploymentStatus(t *testing.T) {
+	kubecfg := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubecfg, []byte(testKubecfg), 0600); err != nil {
+		t.Fatalf("failed to write kubecfg: %v", err)
+	}
+	cmds := fexec.Commands([]fexec.Response{
+		{Cmd: "kubectl", Args: []string{"cluster-info"}, Err: "unreachable"},
+	})
+	kexec.Command = cmds.Command
+	kexec.CommandContext = cmds.CommandContext
+	defer checkCmds(t, cmds)
+	var deleted []string
+	d := &Deployment{
+		Cluster: &ExternalSpec{},
+		Ingress: &fakeComponent{name: "ingress", deleted: &deleted},
+		CNI:     &fakeComponent{name: "cni", deleted: &deleted},
+		Controllers: []Controller{
+			&fakeComponent{name: "c1", deleted: &deleted, err: fmt.Errorf("status failed")},
+		},
+	}
+	got, err := d.Status(context.Background(), kubecfg)
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	want := []*ComponentStatus{{
+		Component: "cluster",
+		Kind:      "External",
+		Details:   map[string]string{"name": "external", "error": "cluster not healthy: unreachable"},
+	}, {
+		Component: "ingress",
+		Kind:      "fakeComponent",
+		Installed: true,
+		Healthy:   true,
+	}, {
+		Component: "cni",
+		Kind:      "fakeComponent",
+		Installed: true,
+		Healthy:   true,
+	}, {
+		Component: "controller",
+		Kind:      "fakeComponent",
+		Details:   map[string]string{"error": "status failed"},
+	}}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Status() unexpected diff (-want +got):\n%s", s)
+	}
+}
@@ -6,49 +6,116 @@
 //	c := Command("echo", "bob")
 //	c.SetStdout(&buf)
 //	err := c.Run()
+//
+// CommandContext returns a Cmd that is killed, along with any processes it
+// started, when the context is done or its timeout expires:
+//
+//	c := CommandContext(ctx, "docker", "login", "--password-stdin", registry)
+//	c.SetStdin(strings.NewReader(token))
+//	c.SetTimeout(time.Minute)
+//	err := c.Run()
 package exec
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"time"
 )
 
 // A Cmd is an interface representing a command to run.
 type Cmd interface {
-	SetStdout(io.Writer) // Redirect standard output to the writer
-	SetStderr(io.Writer) // Redirect standard error to the writer
-	Run() error          // Operates the same as os/exec/Cmd.Run
+	SetStdout(io.Writer)      // Redirect standard output to the writer
+	SetStderr(io.Writer)      // Redirect standard error to the writer
+	SetStdin(io.Reader)       // Read standard input from the reader
+	SetEnv([]string)          // Add "KEY=value" variables to the environment
+	SetTimeout(time.Duration) // Kill the command if it runs for longer
+	Run() error               // Operates the same as os/exec/Cmd.Run
 }
 
 // Command is a variable that normally points to NewCommand.
 // It is a variable so tests can redirect calls to a fake.
 var Command func(cmd string, args ...string) Cmd = NewCommand
 
+// CommandContext is a variable that normally points to NewCommandContext.
+// It is a variable so tests can redirect calls to a fake.
+var CommandContext func(ctx context.Context, cmd string, args ...string) Cmd = NewCommandContext
+
+// waitDelay is how long Run waits for the output of a killed command to be
+// closed before returning.
+const waitDelay = 5 * time.Second
+
 // NewCommand returns a Cmd that can run the supplied command.
 // NewCommand sets up stdout and stderr to go to os.Stdout and os.Stderr.
 // SetStdout and SetStderr are used to change where to send the output.
 //
 // Most programs should use exec.Command rather than exec.NewCommand.
 func NewCommand(cmd string, args ...string) Cmd {
-	c := exec.Command(cmd, args...)
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	return command{cmd: c}
+	return NewCommandContext(context.Background(), cmd, args...)
+}
+
+// NewCommandContext is like NewCommand but the command, and any processes it
+// started, are killed if ctx is done before the command completes.  On Unix
+// the command is run in its own process group, which is killed as a whole.
+//
+// Most programs should use exec.CommandContext rather than
+// exec.NewCommandContext.
+func NewCommandContext(ctx context.Context, cmd string, args ...string) Cmd {
+	return &command{
+		ctx:    ctx,
+		name:   cmd,
+		args:   args,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
 }
 
-// A command wraps the standard os/exec Cmd type.  Since it only contains one
-// pointer we can pass it by value.
+// A command holds the settings of a command until it is run with the standard
+// os/exec Cmd type.
 type command struct {
-	cmd *exec.Cmd
+	ctx     context.Context
+	name    string
+	args    []string
+	stdout  io.Writer
+	stderr  io.Writer
+	stdin   io.Reader
+	env     []string
+	timeout time.Duration
 }
 
-func (c command) SetStdout(w io.Writer) { c.cmd.Stdout = w }
-func (c command) SetStderr(w io.Writer) { c.cmd.Stderr = w }
-func (c command) Run() error {
-	if err := c.cmd.Run(); err != nil {
-		return fmt.Errorf("%q failed: %v", c.cmd.String(), err)
+func (c *command) SetStdout(w io.Writer)            { c.stdout = w }
+func (c *command) SetStderr(w io.Writer)            { c.stderr = w }
+func (c *command) SetStdin(r io.Reader)             { c.stdin = r }
+func (c *command) SetEnv(env []string)              { c.env = append(c.env, env...) }
+func (c *command) SetTimeout(timeout time.Duration) { c.timeout = timeout }
+func (c *command) Run() error {
+	ctx := c.ctx
+	if c.timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+	cmd.Stdin = c.stdin
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	cmd.WaitDelay = waitDelay
+	setProcessGroup(cmd)
+
+	run := cmd.Run
+	if r := getRecorder(); r != nil {
+		run = func() error { return r.run(cmd) }
+	}
+	if err := run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%q failed: %v: %w", cmd.String(), err, ctx.Err())
+		}
+		return fmt.Errorf("%q failed: %v", cmd.String(), err)
 	}
 	return nil
 }
@@ -4,10 +4,13 @@ package exec
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/openconfig/gnmi/errdiff"
 )
@@ -81,3 +84,74 @@ func TestCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tt := range []struct {
+		name    string
+		ctx     context.Context
+		cmd     string
+		args    []string
+		stdin   string
+		env     []string
+		timeout time.Duration
+		stdout  string
+		err     error
+	}{
+		{
+			name:   "stdin",
+			ctx:    context.Background(),
+			cmd:    "cat",
+			stdin:  "hello\n",
+			stdout: "hello\n",
+		},
+		{
+			name:   "env",
+			ctx:    context.Background(),
+			cmd:    "sh",
+			args:   []string{"-c", "echo $KNE_TEST_A $KNE_TEST_B"},
+			env:    []string{"KNE_TEST_A=a", "KNE_TEST_B=b"},
+			stdout: "a b\n",
+		},
+		{
+			// The background sleep keeps standard output open
+			// unless the whole process group is killed.
+			name:    "timeout",
+			ctx:     context.Background(),
+			cmd:     "sh",
+			args:    []string{"-c", "sleep 60 & wait"},
+			timeout: 100 * time.Millisecond,
+			err:     context.DeadlineExceeded,
+		},
+		{
+			name: "canceled",
+			ctx:  canceled,
+			cmd:  "sleep",
+			args: []string{"60"},
+			err:  context.Canceled,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			c := CommandContext(tt.ctx, tt.cmd, tt.args...)
+			c.SetStdout(&stdout)
+			if tt.stdin != "" {
+				c.SetStdin(strings.NewReader(tt.stdin))
+			}
+			c.SetEnv(tt.env)
+			c.SetTimeout(tt.timeout)
+			start := time.Now()
+			err := c.Run()
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Run() got error %v, want %v", err, tt.err)
+			}
+			if d := time.Since(start); d > waitDelay {
+				t.Errorf("Run() took %v, want less than %v", d, waitDelay)
+			}
+			if got := stdout.String(); got != tt.stdout {
+				t.Errorf("Got stdout %q, want %q", got, tt.stdout)
+			}
+		})
+	}
+}
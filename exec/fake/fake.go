@@ -19,26 +19,35 @@
 //		... test code ...
 //
 //	}
+//
+// Rather than being written by hand, responses can be recorded from a run of
+// the real commands with a Recorder and saved in a responses file, which is
+// then loaded with LoadResponses.  Replay does both: it replays a responses
+// file, or records it when the environment variable KNE_RECORD_COMMANDS is
+// set.
 package fake
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/openconfig/kne/exec"
 )
 
 // A Response indicates how Command should respond to Run.
 type Response struct {
-	Cmd        string
-	Args       []string
-	Err        interface{}
-	Stdout     string
-	Stderr     string
-	OutOfOrder bool // This response can be out of order
-	Optional   bool // This response might not be used
+	Cmd        string      `yaml:"cmd"`
+	Args       []string    `yaml:"args,omitempty"`
+	Err        interface{} `yaml:"err,omitempty"`
+	Stdout     string      `yaml:"stdout,omitempty"`
+	Stderr     string      `yaml:"stderr,omitempty"`
+	Stdin      string      `yaml:"stdin,omitempty"`      // If set, the expected standard input, or its HashStdin
+	OutOfOrder bool        `yaml:"outOfOrder,omitempty"` // This response can be out of order
+	Optional   bool        `yaml:"optional,omitempty"`   // This response might not be used
 }
 
 func (r Response) String() string {
@@ -57,6 +66,9 @@ func (r Response) String() string {
 	if r.Stderr != "" {
 		fmt.Fprintf(&buf, ", Stderr: %q", r.Stderr)
 	}
+	if r.Stdin != "" {
+		fmt.Fprintf(&buf, ", Stdin: %q", r.Stdin)
+	}
 	if r.Err != nil {
 		fmt.Fprintf(&buf, ", Err: %q", r.Err)
 	}
@@ -74,12 +86,17 @@ func (r Response) String() string {
 // predefined results when exec.Cmd.Run is called.
 type Command struct {
 	Name       string // if set it is included in errors
+	Strict     bool   // if set Run returns an error for unexpected commands
 	cmd        string
 	args       []string
 	responses  []Response
 	unexpected []Response
 	stdout     io.Writer
 	stderr     io.Writer
+	stdin      io.Reader
+	env        []string
+	timeout    time.Duration
+	ctx        context.Context
 	cnt        int
 }
 
@@ -93,8 +110,19 @@ func Commands(resp []Response) *Command {
 
 // Command resets the command associated with c.
 func (c *Command) Command(cmd string, args ...string) exec.Cmd {
+	return c.CommandContext(context.Background(), cmd, args...)
+}
+
+// CommandContext resets the command associated with c.  Run returns the
+// error of ctx, without using a response, if ctx is done.  It can be used to
+// override exec.CommandContext.
+func (c *Command) CommandContext(ctx context.Context, cmd string, args ...string) exec.Cmd {
 	c.cmd = cmd
 	c.args = args
+	c.stdin = nil
+	c.env = nil
+	c.timeout = 0
+	c.ctx = ctx
 	return c
 }
 
@@ -104,6 +132,17 @@ func (c *Command) SetStdout(w io.Writer) { c.stdout = w }
 // Stderr sets standard err to w.
 func (c *Command) SetStderr(w io.Writer) { c.stderr = w }
 
+// SetStdin sets standard input to r.  It is read by Run and matched against
+// the Stdin of the responses.
+func (c *Command) SetStdin(r io.Reader) { c.stdin = r }
+
+// SetEnv adds env to the environment of the command.  It is otherwise
+// ignored.
+func (c *Command) SetEnv(env []string) { c.env = append(c.env, env...) }
+
+// SetTimeout sets the timeout of the command.  It is otherwise ignored.
+func (c *Command) SetTimeout(timeout time.Duration) { c.timeout = timeout }
+
 // LogCommand is called with the string representation of the command that is
 // running.  The test program can optionally set this to their own function.
 var LogCommand = func(string) {}
@@ -114,40 +153,57 @@ var LogCommand = func(string) {}
 // calls LogCommand with a string representation of a Response that matches this
 // command.
 //
-// Run returns nil if no matching response is found.  Use c.Done to detect
-// these errors.
+// Run returns nil if no matching response is found, unless c.Strict is set, in
+// which case it returns an error describing how the command differs from the
+// next response.  Use c.Done to detect these errors.
 func (c *Command) Run() error {
 	c.cnt++
 	call := Response{
 		Cmd:  c.cmd,
 		Args: c.args,
 	}
+	if c.ctx != nil && c.ctx.Err() != nil {
+		return c.ctx.Err()
+	}
+	if c.stdin != nil {
+		b, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		call.Stdin = string(b)
+	}
 
 	defer func() {
 		LogCommand(call.String())
 	}()
 	if len(c.responses) == 0 {
-		c.unexpected = append(c.unexpected, Response{Cmd: c.cmd, Args: c.args})
+		c.unexpected = append(c.unexpected, call)
+		if c.Strict {
+			return fmt.Errorf("unexpected command: %s: no more responses", call.argv())
+		}
 		return nil
 	}
 
 	// Always check to see if we match the next expected response.
 	// If we don't then look to see if there is an OutOfOrder response that we match.
 	r := c.responses[0]
-	if c.matches(r) {
+	if c.matches(r, call.Stdin) {
 		c.responses = c.responses[1:]
 	} else {
 		matched := false
 		var i int
 		for i, r = range c.responses {
-			if r.OutOfOrder && c.matches(r) {
+			if r.OutOfOrder && c.matches(r, call.Stdin) {
 				matched = true
 				c.responses = append(c.responses[:i], c.responses[i+1:]...)
 				break
 			}
 		}
 		if !matched {
-			c.unexpected = append(c.unexpected, Response{Cmd: c.cmd, Args: c.args})
+			c.unexpected = append(c.unexpected, call)
+			if c.Strict {
+				return mismatch(c.responses[0], call)
+			}
 			return nil
 		}
 	}
@@ -237,11 +293,21 @@ func (c *Command) left() []Response {
 	return resp
 }
 
-// matches returns true if the current command in c matches r.
-func (c *Command) matches(r Response) bool {
+// matches returns true if the current command in c, with standard input
+// stdin, matches r.
+func (c *Command) matches(r Response, stdin string) bool {
 	if c.cmd != r.Cmd && r.Cmd != "" {
 		return false
 	}
+	switch {
+	case r.Stdin == "":
+	case strings.HasPrefix(r.Stdin, StdinHashPrefix):
+		if r.Stdin != HashStdin(stdin) {
+			return false
+		}
+	case r.Stdin != stdin:
+		return false
+	}
 	return compareArgs(c.args, r.Args)
 }
 
@@ -1,6 +1,7 @@
 package fake
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -415,3 +416,48 @@ func TestComparArg(t *testing.T) {
 		}
 	}
 }
+
+func TestStdin(t *testing.T) {
+	cmds := Commands([]Response{
+		{Cmd: "docker", Args: []string{"login", "--password-stdin"}, Stdin: "secret", Stdout: "Login Succeeded\n"},
+	})
+	var stdout strings.Builder
+	c := cmds.Command("docker", "login", "--password-stdin")
+	c.SetStdin(strings.NewReader("wrong"))
+	c.SetStdout(&stdout)
+	if err := c.Run(); err != nil {
+		t.Fatalf("Run() with wrong stdin failed: %v", err)
+	}
+	if stdout.String() != "" {
+		t.Errorf("Run() with wrong stdin matched response: %q", stdout.String())
+	}
+	c = cmds.Command("docker", "login", "--password-stdin")
+	c.SetStdin(strings.NewReader("secret"))
+	c.SetStdout(&stdout)
+	if err := c.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if got, want := stdout.String(), "Login Succeeded\n"; got != want {
+		t.Errorf("Run() got stdout %q, want %q", got, want)
+	}
+	want := `Done: unexpected executions:
+	{Cmd: "docker", Args: []string{"login", "--password-stdin"}, Stdin: "wrong"}`
+	if s := errdiff.Check(cmds.Done(), want); s != "" {
+		t.Errorf("Done() unexpected error: %s", s)
+	}
+}
+
+func TestCommandContext(t *testing.T) {
+	cmds := Commands([]Response{{Cmd: "kind", Args: []string{"create", "cluster"}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cmds.CommandContext(ctx, "kind", "create", "cluster").Run(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() with canceled context got error %v, want %v", err, context.Canceled)
+	}
+	if err := cmds.CommandContext(context.Background(), "kind", "create", "cluster").Run(); err != nil {
+		t.Errorf("Run() failed: %v", err)
+	}
+	if err := cmds.Done(); err != nil {
+		t.Errorf("Done() failed: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/kne/exec"
+	"gopkg.in/yaml.v3"
+)
+
+// RecordEnv is the environment variable that, when set to a non-empty value,
+// makes Replay run and record the real commands rather than replay them.
+const RecordEnv = "KNE_RECORD_COMMANDS"
+
+// A Recorder runs real commands and records a Response for each of them.  The
+// responses can be written to a responses file with Write and loaded by a test
+// with LoadResponses.
+//
+// Arguments that vary between runs, such as temporary file names, should be
+// edited in the responses file to use a ".*" prefix or suffix match.
+//
+// Standard input can hold secrets, such as the password of docker login
+// --password-stdin, so it is recorded as its SHA-256 hash, which still
+// matches the same input when replayed, unless RecordStdin is set.
+type Recorder struct {
+	// RecordStdin records the standard input of commands as is.
+	RecordStdin bool
+
+	command func(context.Context, string, ...string) exec.Cmd
+
+	mu        sync.Mutex
+	responses []Response
+}
+
+// NewRecorder returns a Recorder that runs commands with command, which is
+// normally exec.NewCommandContext.
+func NewRecorder(command func(context.Context, string, ...string) exec.Cmd) *Recorder {
+	return &Recorder{command: command}
+}
+
+// Command returns a Cmd that runs cmd with command and records its response.
+// It can be used to override exec.Command.
+func (r *Recorder) Command(cmd string, args ...string) exec.Cmd {
+	return r.CommandContext(context.Background(), cmd, args...)
+}
+
+// CommandContext is like Command but the command is run with ctx.  It can be
+// used to override exec.CommandContext.
+func (r *Recorder) CommandContext(ctx context.Context, cmd string, args ...string) exec.Cmd {
+	return &recordingCmd{
+		r:      r,
+		cmd:    cmd,
+		args:   args,
+		c:      r.command(ctx, cmd, args...),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Responses returns the responses recorded so far, in the order the commands
+// completed.
+func (r *Recorder) Responses() []Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Response(nil), r.responses...)
+}
+
+// Write writes the responses recorded so far to the responses file at path.
+func (r *Recorder) Write(path string) error {
+	return WriteResponses(path, r.Responses())
+}
+
+// A recordingCmd is a Cmd that records its response to its Recorder.
+type recordingCmd struct {
+	r              *Recorder
+	cmd            string
+	args           []string
+	c              exec.Cmd
+	stdout, stderr io.Writer
+	stdin          io.Reader
+}
+
+func (c *recordingCmd) SetStdout(w io.Writer)            { c.stdout = w }
+func (c *recordingCmd) SetStderr(w io.Writer)            { c.stderr = w }
+func (c *recordingCmd) SetStdin(r io.Reader)             { c.stdin = r }
+func (c *recordingCmd) SetEnv(env []string)              { c.c.SetEnv(env) }
+func (c *recordingCmd) SetTimeout(timeout time.Duration) { c.c.SetTimeout(timeout) }
+
+func (c *recordingCmd) Run() error {
+	var stdout, stderr, stdin bytes.Buffer
+	c.c.SetStdout(tee(c.stdout, &stdout))
+	c.c.SetStderr(tee(c.stderr, &stderr))
+	if c.stdin != nil {
+		c.c.SetStdin(io.TeeReader(c.stdin, &stdin))
+	}
+	err := c.c.Run()
+	resp := Response{
+		Cmd:    c.cmd,
+		Args:   c.args,
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+	if stdin.Len() > 0 {
+		if c.r.RecordStdin {
+			resp.Stdin = stdin.String()
+		} else {
+			resp.Stdin = HashStdin(stdin.String())
+		}
+	}
+	if err != nil {
+		resp.Err = err.Error()
+	}
+	c.r.mu.Lock()
+	c.r.responses = append(c.r.responses, resp)
+	c.r.mu.Unlock()
+	return err
+}
+
+// StdinHashPrefix is the prefix of the Stdin of a response that is the
+// SHA-256 hash of the expected standard input rather than the input itself.
+const StdinHashPrefix = "sha256:"
+
+// HashStdin returns stdin as the Stdin of a response that matches it by its
+// hash, without holding it.
+func HashStdin(stdin string) string {
+	sum := sha256.Sum256([]byte(stdin))
+	return StdinHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// tee returns a writer that writes to both w, which may be nil, and b.
+func tee(w io.Writer, b *bytes.Buffer) io.Writer {
+	if w == nil {
+		return b
+	}
+	return io.MultiWriter(w, b)
+}
+
+// WriteResponses writes responses to the responses file at path.  Errors are
+// written as strings.
+func WriteResponses(path string, responses []Response) error {
+	out := make([]Response, len(responses))
+	for i, r := range responses {
+		if err, ok := r.Err.(error); ok {
+			r.Err = err.Error()
+		}
+		out[i] = r
+	}
+	var b bytes.Buffer
+	e := yaml.NewEncoder(&b)
+	e.SetIndent(2)
+	if err := e.Encode(out); err != nil {
+		return err
+	}
+	if err := e.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// LoadResponses returns the responses in the responses file at path, as
+// written by WriteResponses.
+func LoadResponses(path string) ([]Response, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var responses []Response
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(&responses); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return responses, nil
+}
+
+// Replay sets exec.Command and exec.CommandContext to replay the responses in
+// the responses file at path with a strict Command.  If the environment
+// variable RecordEnv is set the real commands are run instead and recorded in
+// path.  The returned function restores exec.Command and
+// exec.CommandContext and returns the error from Done, or from writing path,
+// and should be called at the end of the test:
+//
+//	done, err := fake.Replay("testdata/deploy.yaml")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer func() {
+//		if err := done(); err != nil {
+//			t.Error(err)
+//		}
+//	}()
+func Replay(path string) (func() error, error) {
+	oCommand := exec.Command
+	oCommandContext := exec.CommandContext
+	if os.Getenv(RecordEnv) != "" {
+		r := NewRecorder(exec.NewCommandContext)
+		exec.Command = r.Command
+		exec.CommandContext = r.CommandContext
+		return func() error {
+			exec.Command = oCommand
+			exec.CommandContext = oCommandContext
+			return r.Write(path)
+		}, nil
+	}
+	responses, err := LoadResponses(path)
+	if err != nil {
+		return nil, err
+	}
+	cmds := Commands(responses)
+	cmds.Name = path
+	cmds.Strict = true
+	exec.Command = cmds.Command
+	exec.CommandContext = cmds.CommandContext
+	return func() error {
+		exec.Command = oCommand
+		exec.CommandContext = oCommandContext
+		return cmds.Done()
+	}, nil
+}
+
+// argv returns the command line of r.
+func (r Response) argv() string {
+	return strings.Join(append([]string{r.Cmd}, r.Args...), " ")
+}
+
+// mismatch returns an error describing how the command of got differs from
+// the command of the response want.
+func mismatch(want, got Response) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "unexpected command:\n\twant: %s\n\tgot:  %s", want.argv(), got.argv())
+	wantArgv := append([]string{want.Cmd}, want.Args...)
+	gotArgv := append([]string{got.Cmd}, got.Args...)
+	for i := 0; i < len(wantArgv) || i < len(gotArgv); i++ {
+		switch {
+		case i >= len(wantArgv):
+			fmt.Fprintf(&buf, "\n\targ %d: want nothing, got %q", i, gotArgv[i])
+		case i >= len(gotArgv):
+			fmt.Fprintf(&buf, "\n\targ %d: want %q, got nothing", i, wantArgv[i])
+		case i == 0 && want.Cmd == "":
+		case i > 0 && compareArgs(gotArgv[i:i+1], wantArgv[i:i+1]):
+		case i == 0 && wantArgv[0] == gotArgv[0]:
+		default:
+			fmt.Fprintf(&buf, "\n\targ %d: want %q, got %q", i, wantArgv[i], gotArgv[i])
+		}
+	}
+	return fmt.Errorf("%s", buf.String())
+}
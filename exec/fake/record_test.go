@@ -0,0 +1,247 @@
+package fake
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/kne/exec"
+)
+
+func TestRecorder(t *testing.T) {
+	want := []Response{{
+		Cmd:    "echo",
+		Args:   []string{"one", "two"},
+		Stdout: "one two\n",
+	}, {
+		Cmd:    "sh",
+		Args:   []string{"-c", "echo oops >&2; exit 1"},
+		Stderr: "oops\n",
+		Err:    "exit status 1",
+	}}
+	// The recorded commands are themselves fake.
+	cmds := Commands(want)
+	r := NewRecorder(cmds.CommandContext)
+	var stdout bytes.Buffer
+	c := r.Command("echo", "one", "two")
+	c.SetStdout(&stdout)
+	if err := c.Run(); err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if got, want := stdout.String(), "one two\n"; got != want {
+		t.Errorf("echo got stdout %q, want %q", got, want)
+	}
+	c = r.Command("sh", "-c", "echo oops >&2; exit 1")
+	c.SetStderr(nil)
+	if err := c.Run(); err == nil {
+		t.Errorf("sh succeeded, want error")
+	}
+	if err := cmds.Done(); err != nil {
+		t.Fatal(err)
+	}
+	if s := cmp.Diff(want, r.Responses()); s != "" {
+		t.Errorf("Responses() unexpected diff (-want +got):\n%s", s)
+	}
+	path := filepath.Join(t.TempDir(), "responses.yaml")
+	if err := r.Write(path); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	got, err := LoadResponses(path)
+	if err != nil {
+		t.Fatalf("LoadResponses() failed: %v", err)
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("LoadResponses() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestRecorderStdin(t *testing.T) {
+	for _, tt := range []struct {
+		desc        string
+		recordStdin bool
+		want        string
+	}{{
+		desc: "hashed",
+		want: HashStdin("secret"),
+	}, {
+		desc:        "as is",
+		recordStdin: true,
+		want:        "secret",
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			cmds := Commands([]Response{{Cmd: "docker", Args: []string{"login", "--password-stdin"}, Stdout: "ok\n"}})
+			r := NewRecorder(cmds.CommandContext)
+			r.RecordStdin = tt.recordStdin
+			c := r.Command("docker", "login", "--password-stdin")
+			c.SetStdin(strings.NewReader("secret"))
+			c.SetStdout(nil)
+			if err := c.Run(); err != nil {
+				t.Fatalf("docker login failed: %v", err)
+			}
+			got := r.Responses()
+			if len(got) != 1 || got[0].Stdin != tt.want {
+				t.Fatalf("Responses() got %v, want stdin %q", got, tt.want)
+			}
+			// The recorded response only matches the same input.
+			replay := Commands(got)
+			for _, stdin := range []string{"wrong", "secret"} {
+				var stdout strings.Builder
+				c = replay.Command("docker", "login", "--password-stdin")
+				c.SetStdin(strings.NewReader(stdin))
+				c.SetStdout(&stdout)
+				if err := c.Run(); err != nil {
+					t.Fatalf("Run() failed: %v", err)
+				}
+				if matched := stdout.String() != ""; matched != (stdin == "secret") {
+					t.Errorf("Run() with stdin %q matched the response: %v", stdin, matched)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadResponses(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name    string
+		data    string
+		want    []Response
+		wantErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "responses",
+			data: `- cmd: kubectl
+  args: [apply, -f, .*.yaml]
+  outOfOrder: true
+- cmd: kind
+  err: failed
+  optional: true
+`,
+			want: []Response{
+				{Cmd: "kubectl", Args: []string{"apply", "-f", ".*.yaml"}, OutOfOrder: true},
+				{Cmd: "kind", Err: "failed", Optional: true},
+			},
+		},
+		{
+			name:    "unknown field",
+			data:    "- command: kind\n",
+			wantErr: "field command not found",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".yaml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatalf("failed to write responses: %v", err)
+			}
+			got, err := LoadResponses(path)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("LoadResponses() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("LoadResponses() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		resp    []Response
+		cmd     string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "match",
+			resp: []Response{{Cmd: "kubectl", Args: []string{"apply", "-f", ".*.yaml"}}},
+			cmd:  "kubectl",
+			args: []string{"apply", "-f", "/tmp/manifest.yaml"},
+		},
+		{
+			name: "different argument",
+			resp: []Response{{Cmd: "kubectl", Args: []string{"apply", "-f", "a.yaml"}}},
+			cmd:  "kubectl",
+			args: []string{"apply", "-f", "b.yaml"},
+			wantErr: `unexpected command:
+	want: kubectl apply -f a.yaml
+	got:  kubectl apply -f b.yaml
+	arg 3: want "a.yaml", got "b.yaml"`,
+		},
+		{
+			name: "different command",
+			resp: []Response{{Cmd: "kind", Args: []string{"get", "clusters"}}},
+			cmd:  "kubectl",
+			args: []string{"get", "clusters", "-A"},
+			wantErr: `unexpected command:
+	want: kind get clusters
+	got:  kubectl get clusters -A
+	arg 0: want "kind", got "kubectl"
+	arg 3: want nothing, got "-A"`,
+		},
+		{
+			name:    "no responses",
+			cmd:     "kind",
+			args:    []string{"version"},
+			wantErr: "unexpected command: kind version: no more responses",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := Commands(tt.resp)
+			cmds.Strict = true
+			err := cmds.Command(tt.cmd, tt.args...).Run()
+			if s := errdiff.Check(err, tt.wantErr); s != "" {
+				t.Errorf("Run() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.yaml")
+	oCommand := exec.Command
+	defer func() { exec.Command = oCommand }()
+
+	t.Setenv(RecordEnv, "1")
+	done, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() recording failed: %v", err)
+	}
+	var stdout bytes.Buffer
+	c := exec.Command("echo", "hello")
+	c.SetStdout(&stdout)
+	if err := c.Run(); err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if err := done(); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+
+	t.Setenv(RecordEnv, "")
+	done, err = Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() failed: %v", err)
+	}
+	stdout.Reset()
+	c = exec.Command("echo", "hello")
+	c.SetStdout(&stdout)
+	if err := c.Run(); err != nil {
+		t.Fatalf("replayed echo failed: %v", err)
+	}
+	if got, want := stdout.String(), "hello\n"; got != want {
+		t.Errorf("replayed echo got stdout %q, want %q", got, want)
+	}
+	if err := exec.Command("echo", "goodbye").Run(); err == nil {
+		t.Errorf("unexpected replayed command succeeded")
+	}
+	if s := errdiff.Substring(done(), "unexpected executions"); s != "" {
+		t.Errorf("replay unexpected error: %s", s)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package exec
+
+import "os/exec"
+
+// setProcessGroup does nothing as process groups are not supported.  Only the
+// command itself is killed when its context is done.
+func setProcessGroup(c *exec.Cmd) {}
@@ -0,0 +1,176 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// IndexFile is the name of the index of the commands in a recording
+// directory.
+const IndexFile = "commands.jsonl"
+
+// A Record describes a command run while recording.  Records are written to
+// the index file, one JSON object per line, in the order the commands
+// complete.
+type Record struct {
+	Seq      int           `json:"seq"`             // Sequence number of the command, starting at 1
+	Args     []string      `json:"args"`            // The command and its arguments
+	Start    time.Time     `json:"start"`           // When the command was started
+	Duration time.Duration `json:"duration"`        // How long the command ran, in nanoseconds
+	ExitCode int           `json:"exit_code"`       // Exit code, -1 if the command did not run
+	Error    string        `json:"error,omitempty"` // The error returned by Run, if any
+	Stdout   string        `json:"stdout"`          // Name of the file holding standard output
+	Stderr   string        `json:"stderr"`          // Name of the file holding standard error
+}
+
+// A Recorder records the commands run by Cmds returned by NewCommand, along
+// with their full standard output and standard error, in a directory.
+type Recorder struct {
+	dir string
+
+	mu    sync.Mutex
+	seq   int
+	index *os.File
+}
+
+var (
+	recorderMu sync.Mutex
+	recorder   *Recorder
+)
+
+// NewRecorder returns a Recorder that records commands in dir, which is
+// created if needed.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, IndexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{dir: dir, index: index}, nil
+}
+
+// Dir returns the directory r records commands in.
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// Close closes the index of r.  Commands run after r is closed are not
+// recorded.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index == nil {
+		return nil
+	}
+	err := r.index.Close()
+	r.index = nil
+	return err
+}
+
+// SetRecorder sets the Recorder of the commands run by Cmds returned by
+// NewCommand.  A nil r stops recording.
+func SetRecorder(r *Recorder) {
+	recorderMu.Lock()
+	recorder = r
+	recorderMu.Unlock()
+}
+
+func getRecorder() *Recorder {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	return recorder
+}
+
+// run runs c, with its output also written to the output files of a new
+// record, and then writes the record to the index.
+func (r *Recorder) run(c *exec.Cmd) error {
+	r.mu.Lock()
+	r.seq++
+	rec := &Record{
+		Seq:  r.seq,
+		Args: c.Args,
+	}
+	r.mu.Unlock()
+	prefix := fmt.Sprintf("%04d-%s", rec.Seq, filepath.Base(c.Args[0]))
+	rec.Stdout, rec.Stderr = prefix+".stdout", prefix+".stderr"
+	stdout, err := os.Create(filepath.Join(r.dir, rec.Stdout))
+	if err != nil {
+		return err
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(r.dir, rec.Stderr))
+	if err != nil {
+		return err
+	}
+	defer stderr.Close()
+	c.Stdout = tee(c.Stdout, stdout)
+	c.Stderr = tee(c.Stderr, stderr)
+
+	rec.Start = time.Now()
+	rerr := c.Run()
+	rec.Duration = time.Since(rec.Start)
+	rec.ExitCode = -1
+	if c.ProcessState != nil {
+		rec.ExitCode = c.ProcessState.ExitCode()
+	}
+	if rerr != nil {
+		rec.Error = rerr.Error()
+	}
+	if err := r.write(rec); err != nil {
+		return errors.Join(rerr, err)
+	}
+	return rerr
+}
+
+// tee returns a writer that writes to both w, which may be nil, and f.
+func tee(w io.Writer, f *os.File) io.Writer {
+	if w == nil {
+		return f
+	}
+	return io.MultiWriter(w, f)
+}
+
+// write appends rec to the index.
+func (r *Recorder) write(rec *Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index == nil {
+		return nil
+	}
+	_, err = r.index.Write(append(b, '\n'))
+	return err
+}
+
+// ReadIndex returns the records in the index of the recording directory dir.
+func ReadIndex(dir string) ([]*Record, error) {
+	f, err := os.Open(filepath.Join(dir, IndexFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var recs []*Record
+	d := json.NewDecoder(f)
+	for {
+		rec := &Record{}
+		if err := d.Decode(rec); err != nil {
+			if err == io.EOF {
+				return recs, nil
+			}
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+}
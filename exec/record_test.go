@@ -0,0 +1,92 @@
+package exec
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run")
+	r, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatalf("NewRecorder() failed: %v", err)
+	}
+	SetRecorder(r)
+	defer SetRecorder(nil)
+
+	var stdout bytes.Buffer
+	c := Command("echo", "one", "two")
+	c.SetStdout(&stdout)
+	if err := c.Run(); err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if got, want := stdout.String(), "one two\n"; got != want {
+		t.Errorf("echo got stdout %q, want %q", got, want)
+	}
+	c = Command("sh", "-c", "echo oops >&2; exit 3")
+	c.SetStderr(&bytes.Buffer{})
+	if s := errdiff.Substring(c.Run(), "exit status 3"); s != "" {
+		t.Errorf("sh unexpected error: %s", s)
+	}
+	c = Command("no-such-command")
+	if s := errdiff.Substring(c.Run(), "not found"); s != "" {
+		t.Errorf("no-such-command unexpected error: %s", s)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	// Commands run after the recorder is closed are not recorded.
+	if err := Command("true").Run(); err != nil {
+		t.Fatalf("true failed: %v", err)
+	}
+
+	got, err := ReadIndex(dir)
+	if err != nil {
+		t.Fatalf("ReadIndex() failed: %v", err)
+	}
+	want := []*Record{{
+		Seq:    1,
+		Args:   []string{"echo", "one", "two"},
+		Stdout: "0001-echo.stdout",
+		Stderr: "0001-echo.stderr",
+	}, {
+		Seq:      2,
+		Args:     []string{"sh", "-c", "echo oops >&2; exit 3"},
+		ExitCode: 3,
+		Error:    "exit status 3",
+		Stdout:   "0002-sh.stdout",
+		Stderr:   "0002-sh.stderr",
+	}, {
+		Seq:      3,
+		Args:     []string{"no-such-command"},
+		ExitCode: -1,
+		Error:    `exec: "no-such-command": executable file not found in $PATH`,
+		Stdout:   "0003-no-such-command.stdout",
+		Stderr:   "0003-no-such-command.stderr",
+	}}
+	if s := cmp.Diff(want, got, cmpopts.IgnoreFields(Record{}, "Start", "Duration")); s != "" {
+		t.Errorf("ReadIndex() unexpected diff (-want +got):\n%s", s)
+	}
+	for _, f := range []struct {
+		name string
+		want string
+	}{
+		{"0001-echo.stdout", "one two\n"},
+		{"0002-sh.stderr", "oops\n"},
+		{"0003-no-such-command.stdout", ""},
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, f.name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.name, err)
+		}
+		if got := string(b); got != f.want {
+			t.Errorf("%s got %q, want %q", f.name, got, f.want)
+		}
+	}
+}
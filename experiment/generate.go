@@ -0,0 +1,303 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/kne/addressing"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+)
+
+// Graph returns the links of a connected random graph of n nodes, numbered
+// from 0, as pairs of nodes in ascending order.  Each node but the last is
+// linked to one random node numbered above it, which keeps the graph
+// connected, and any two nodes are also linked with probability p.
+func Graph(n int, p float64, r *rand.Rand) [][2]int {
+	var links [][2]int
+	for i := 0; i < n-1; i++ {
+		pick := i + 1 + r.Intn(n-i-1)
+		for j := i + 1; j < n; j++ {
+			if j == pick || r.Float64() < p {
+				links = append(links, [2]int{i, j})
+			}
+		}
+	}
+	return links
+}
+
+// A Case is a generated case of an experiment.
+type Case struct {
+	Params   *Params
+	Topology *tpb.Topology
+	// Scenario is the scenario of the faults of the case, with no faults
+	// if the case has none.
+	Scenario *scenario.Scenario
+	// Plan is the addressing plan of the topology the faults are compiled
+	// with.
+	Plan *addressing.Plan
+}
+
+// Generate generates the case p of s: the topology of p with its addresses
+// and routes configured, the destinations of p and its faults.  The
+// destinations, faults and links of p are set.  The same parameters always
+// generate the same case.
+func (s *Spec) Generate(p *Params) (*Case, error) {
+	r := rand.New(rand.NewSource(p.Seed))
+	p.Topology = topologyName(p.ID)
+	t := s.topology(p.Topology, p.Nodes, Graph(p.Nodes, p.LinkProbability, r))
+	p.Links = len(t.GetLinks())
+	names := make([]string, p.Nodes)
+	for i := range names {
+		names[i] = t.GetNodes()[i].GetName()
+	}
+	var err error
+	if p.Destinations, err = destinations(p.DestinationSet, names, r); err != nil {
+		return nil, err
+	}
+	sc := &scenario.Scenario{Name: p.ID}
+	plan, err := scenario.Plan(sc, t)
+	if err != nil {
+		return nil, err
+	}
+	pl := &placer{plan: plan, nodes: names, dsts: p.Destinations, r: r, used: map[string]bool{}}
+	for i := 0; i < p.FaultCount; i++ {
+		kind := p.FaultKinds[r.Intn(len(p.FaultKinds))]
+		f, err := pl.place(kind, p.Placement)
+		if err != nil {
+			return nil, fmt.Errorf("case %s: fault %d: %w", p.ID, i, err)
+		}
+		sc.Faults = append(sc.Faults, f)
+	}
+	p.Faults = sc.Faults
+	if _, err := scenario.Compile(sc, t); err != nil {
+		return nil, fmt.Errorf("case %s: %w", p.ID, err)
+	}
+	if err := plan.Configure(t); err != nil {
+		return nil, fmt.Errorf("case %s: %w", p.ID, err)
+	}
+	return &Case{Params: p, Topology: t, Scenario: sc, Plan: plan}, nil
+}
+
+// maxNameLen is the maximum length of a DNS label.
+const maxNameLen = 63
+
+// topologyName returns the name of the topology of the case id.  Topology
+// names are namespace names and must be DNS labels, so the characters of id
+// other than lowercase letters, digits and dashes, such as the dot of a
+// link probability, are replaced by dashes.  Names too long are truncated
+// and end in a hash of id to keep them unique.
+func topologyName(id string) string {
+	name := strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, id), "-")
+	if len(name) > maxNameLen {
+		h := fnv.New32a()
+		h.Write([]byte(id))
+		suffix := fmt.Sprintf("-%08x", h.Sum32())
+		name = strings.TrimRight(name[:maxNameLen-len(suffix)], "-") + suffix
+	}
+	return name
+}
+
+// topology returns the topology named name of n nodes of s linked by links.
+// The interfaces of each node are numbered from 1 in link order.
+func (s *Spec) topology(name string, n int, links [][2]int) *tpb.Topology {
+	vendor := tpb.Vendor(tpb.Vendor_value[s.Node.Vendor])
+	intf := "eth%d"
+	if vendor == tpb.Vendor_NOKIA {
+		intf = "e1-%d"
+	}
+	t := &tpb.Topology{Name: name}
+	for i := 0; i < n; i++ {
+		t.Nodes = append(t.Nodes, &tpb.Node{
+			Name:   s.Node.Prefix + strconv.Itoa(i),
+			Vendor: vendor,
+			Model:  s.Node.Model,
+			Os:     s.Node.OS,
+			Config: &tpb.Config{Image: s.Node.Image},
+		})
+	}
+	ports := make([]int, n)
+	for _, l := range links {
+		ports[l[0]]++
+		ports[l[1]]++
+		t.Links = append(t.Links, &tpb.Link{
+			ANode: t.Nodes[l[0]].GetName(),
+			AInt:  fmt.Sprintf(intf, ports[l[0]]),
+			ZNode: t.Nodes[l[1]].GetName(),
+			ZInt:  fmt.Sprintf(intf, ports[l[1]]),
+		})
+	}
+	return t
+}
+
+// destinations returns the nodes of the destination set d of the nodes
+// names.
+func destinations(d string, names []string, r *rand.Rand) ([]string, error) {
+	count, err := destinationCount(d)
+	switch {
+	case err != nil:
+		return nil, err
+	case d == Last:
+		return names[len(names)-1:], nil
+	case d == All:
+		return names, nil
+	case count > len(names):
+		return nil, fmt.Errorf("destination set %q has more nodes than the topology", d)
+	}
+	var dsts []string
+	for _, i := range r.Perm(len(names))[:count] {
+		dsts = append(dsts, names[i])
+	}
+	sort.Strings(dsts)
+	return dsts, nil
+}
+
+// maxTries is the number of placements tried for a fault.
+const maxTries = 100
+
+// A placer places faults in a topology.  A node is given at most one fault
+// for each prefix, and a link at most one fault.
+type placer struct {
+	plan  *addressing.Plan
+	nodes []string
+	dsts  []string
+	r     *rand.Rand
+	// used are the node and prefix, or node and interface, of the faults
+	// placed.
+	used map[string]bool
+}
+
+// place returns a fault of kind placed by pl.  On path faults are placed on
+// the path from a random node to a random destination, and random faults
+// on a random node, affecting the traffic to a random destination.
+func (pl *placer) place(kind scenario.Kind, placement Placement) (*scenario.Fault, error) {
+	for i := 0; i < maxTries; i++ {
+		dst := pl.dsts[pl.r.Intn(len(pl.dsts))]
+		prefix := pl.plan.Node(dst).Loopback4.String()
+		var path []string
+		if placement == OnPath {
+			src := pl.nodes[pl.r.Intn(len(pl.nodes))]
+			if path = pl.plan.Path(src, dst); len(path) < 2 {
+				continue
+			}
+		}
+		var f *scenario.Fault
+		switch kind {
+		case scenario.RoutingLoop:
+			f = pl.loop(path, dst, prefix)
+		case scenario.Blackhole:
+			f = pl.blackhole(path, dst, prefix)
+		case scenario.LinkDown:
+			f = pl.linkDown(path)
+		}
+		if f == nil {
+			continue
+		}
+		if f.Kind == scenario.LinkDown {
+			// Both ends of the link go down.
+			intf := pl.plan.Node(f.Node).Interface(f.Interface)
+			if pl.used[f.Node+" "+f.Interface] || pl.used[intf.Peer+" "+intf.PeerKey] {
+				continue
+			}
+			pl.used[f.Node+" "+f.Interface] = true
+			pl.used[intf.Peer+" "+intf.PeerKey] = true
+			return f, nil
+		}
+		if key := f.Node + " " + f.Prefix; !pl.used[key] {
+			pl.used[key] = true
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no place for a %s fault found", kind)
+}
+
+// loop returns a routing loop to prefix of dst on a node that a neighbor
+// routes the traffic to dst through: a random node on path, after the
+// first, or else a random node.
+func (pl *placer) loop(path []string, dst, prefix string) *scenario.Fault {
+	var node, via string
+	if path != nil {
+		if len(path) < 3 {
+			return nil
+		}
+		k := pl.r.Intn(len(path) - 2)
+		node, via = path[k+1], path[k]
+	} else {
+		node = pl.nodes[pl.r.Intn(len(pl.nodes))]
+		var vias []string
+		for _, intf := range pl.plan.Node(node).Interfaces {
+			if p := pl.plan.Path(intf.Peer, dst); len(p) > 1 && p[1] == node {
+				vias = append(vias, intf.Peer)
+			}
+		}
+		if node == dst || len(vias) == 0 {
+			return nil
+		}
+		via = vias[pl.r.Intn(len(vias))]
+	}
+	return &scenario.Fault{Kind: scenario.RoutingLoop, Node: node, Via: via, Prefix: prefix}
+}
+
+// blackhole returns a blackhole of prefix of dst on a random node of path,
+// other than dst, or else on a random node.
+func (pl *placer) blackhole(path []string, dst, prefix string) *scenario.Fault {
+	var node string
+	if path != nil {
+		node = path[pl.r.Intn(len(path)-1)]
+	} else {
+		node = pl.nodes[pl.r.Intn(len(pl.nodes))]
+	}
+	if node == dst {
+		return nil
+	}
+	return &scenario.Fault{Kind: scenario.Blackhole, Node: node, Prefix: prefix}
+}
+
+// linkDown returns a link going down on a random link of path, or else a
+// random link of a random node.
+func (pl *placer) linkDown(path []string) *scenario.Fault {
+	var node, peer string
+	if path != nil {
+		k := pl.r.Intn(len(path) - 1)
+		node, peer = path[k], path[k+1]
+	} else {
+		node = pl.nodes[pl.r.Intn(len(pl.nodes))]
+	}
+	var candidates []*addressing.Interface
+	for _, intf := range pl.plan.Node(node).Interfaces {
+		if peer == "" || intf.Peer == peer {
+			candidates = append(candidates, intf)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	intf := candidates[pl.r.Intn(len(candidates))]
+	return &scenario.Fault{Kind: scenario.LinkDown, Node: node, Interface: intf.Key}
+}
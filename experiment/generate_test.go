@@ -0,0 +1,240 @@
+package experiment
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// connected returns whether the graph of n nodes with links is connected.
+func connected(n int, links [][2]int) bool {
+	adj := map[int][]int{}
+	for _, l := range links {
+		adj[l[0]] = append(adj[l[0]], l[1])
+		adj[l[1]] = append(adj[l[1]], l[0])
+	}
+	seen := map[int]bool{0: true}
+	for queue := []int{0}; len(queue) > 0; queue = queue[1:] {
+		for _, m := range adj[queue[0]] {
+			if !seen[m] {
+				seen[m] = true
+				queue = append(queue, m)
+			}
+		}
+	}
+	return len(seen) == n
+}
+
+func TestGraph(t *testing.T) {
+	tests := []struct {
+		desc      string
+		n         int
+		p         float64
+		wantLinks int
+	}{{
+		desc:      "tree",
+		n:         10,
+		p:         0,
+		wantLinks: 9,
+	}, {
+		desc:      "complete",
+		n:         6,
+		p:         1,
+		wantLinks: 15,
+	}, {
+		desc: "sparse",
+		n:    20,
+		p:    0.1,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for seed := int64(1); seed <= 5; seed++ {
+				links := Graph(tt.n, tt.p, rand.New(rand.NewSource(seed)))
+				if !connected(tt.n, links) {
+					t.Errorf("Graph(%d, %v) with seed %d is not connected: %v", tt.n, tt.p, seed, links)
+				}
+				if tt.wantLinks != 0 && len(links) != tt.wantLinks {
+					t.Errorf("Graph(%d, %v) with seed %d has %d links, want %d", tt.n, tt.p, seed, len(links), tt.wantLinks)
+				}
+				again := Graph(tt.n, tt.p, rand.New(rand.NewSource(seed)))
+				if s := cmp.Diff(links, again); s != "" {
+					t.Errorf("Graph(%d, %v) with seed %d is not repeatable (-first +second):\n%s", tt.n, tt.p, seed, s)
+				}
+			}
+		})
+	}
+}
+
+func spec(t *testing.T, in string) *Spec {
+	t.Helper()
+	s, err := Parse([]byte(in))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	return s
+}
+
+func TestGenerate(t *testing.T) {
+	s := spec(t, `
+name: gen
+nodes: [3]
+link_probabilities: [0]
+node: {vendor: NOKIA, model: ixr-d2, image: srlinux:latest}
+`)
+	p := &Params{ID: "n3", Nodes: 3, Seed: 1, DestinationSet: Last}
+	c, err := s.Generate(p)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if got, want := p.Destinations, []string{"r2"}; !cmp.Equal(got, want) {
+		t.Errorf("Generate() destinations %v, want %v", got, want)
+	}
+	if got, want := p.Links, 2; got != want {
+		t.Errorf("Generate() links %d, want %d", got, want)
+	}
+	if got := c.Topology.GetName(); got != "n3" {
+		t.Errorf("Generate() topology name %q, want n3", got)
+	}
+	for _, n := range c.Topology.GetNodes() {
+		if n.GetVendor() != tpb.Vendor_NOKIA || n.GetModel() != "ixr-d2" || n.GetConfig().GetImage() != "srlinux:latest" {
+			t.Errorf("Generate() node %q is not from the template: %v", n.GetName(), n)
+		}
+		if len(n.GetConfig().GetData()) == 0 {
+			t.Errorf("Generate() node %q has no addresses configured", n.GetName())
+		}
+	}
+	for _, l := range c.Topology.GetLinks() {
+		if l.GetAInt() != "e1-1" && l.GetAInt() != "e1-2" {
+			t.Errorf("Generate() link has interface %q, want e1-N", l.GetAInt())
+		}
+	}
+	if len(c.Scenario.Faults) != 0 {
+		t.Errorf("Generate() placed faults without a fault count: %v", c.Scenario.Faults)
+	}
+}
+
+func TestTopologyNames(t *testing.T) {
+	for _, path := range []string{"testdata/loops.yaml", "../wtf/experiment.yaml"} {
+		s, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		seen := map[string]string{}
+		for _, p := range s.Cases() {
+			// The topology name is set even if the faults of the case
+			// cannot be placed.
+			c, err := s.Generate(p)
+			if err == nil && c.Topology.GetName() != p.Topology {
+				t.Errorf("Generate(%s) topology name %q, params have %q", p.ID, c.Topology.GetName(), p.Topology)
+			}
+			if errs := validation.IsDNS1123Label(p.Topology); len(errs) != 0 {
+				t.Errorf("Generate(%s) topology name %q is not a DNS label: %v", p.ID, p.Topology, errs)
+			}
+			if id, ok := seen[p.Topology]; ok {
+				t.Errorf("Generate(%s) topology name %q is also the name of case %s", p.ID, p.Topology, id)
+			}
+			seen[p.Topology] = p.ID
+		}
+	}
+}
+
+func TestTopologyName(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "n8-p0.05-s1-last-f0", want: "n8-p0-05-s1-last-f0"},
+		{id: "n8-p1e-05-s1-random2-f1-on-path", want: "n8-p1e-05-s1-random2-f1-on-path"},
+		{id: "N3.", want: "n3"},
+	}
+	for _, tt := range tests {
+		if got := topologyName(tt.id); got != tt.want {
+			t.Errorf("topologyName(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+	long := strings.Repeat("n8-p0.05-", 10)
+	got := topologyName(long)
+	if errs := validation.IsDNS1123Label(got); len(errs) != 0 {
+		t.Errorf("topologyName(%q) = %q is not a DNS label: %v", long, got, errs)
+	}
+	if got == topologyName(long+"x") {
+		t.Errorf("topologyName() of long IDs that differ after the limit are equal: %q", got)
+	}
+}
+
+func TestGenerateFaults(t *testing.T) {
+	s := spec(t, "name: faults\nnodes: [8]\nlink_probabilities: [0.2]\n")
+	for _, kind := range []scenario.Kind{scenario.RoutingLoop, scenario.Blackhole, scenario.LinkDown} {
+		for _, placement := range []Placement{OnPath, Random} {
+			for seed := int64(1); seed <= 10; seed++ {
+				p := &Params{ID: "f", Nodes: 8, LinkProbability: 0.2, Seed: seed, DestinationSet: "random:2", FaultCount: 2, Placement: placement, FaultKinds: []scenario.Kind{kind}}
+				c, err := s.Generate(p)
+				if err != nil {
+					t.Errorf("Generate() of %d %s %s faults with seed %d failed: %v", p.FaultCount, placement, kind, seed, err)
+					continue
+				}
+				if len(p.Faults) != 2 {
+					t.Errorf("Generate() placed %d faults, want 2", len(p.Faults))
+				}
+				for _, f := range p.Faults {
+					if f.Kind != kind {
+						t.Errorf("Generate() placed a %s fault, want %s", f.Kind, kind)
+					}
+					if f.Node == "" {
+						t.Errorf("Generate() placed a fault on no node: %v", f)
+					}
+					for _, d := range p.Destinations {
+						if f.Kind != scenario.LinkDown && f.Node == d && f.Prefix == c.Plan.Node(d).Loopback4.String() {
+							t.Errorf("Generate() placed %v on its destination", f)
+						}
+					}
+				}
+				again, err := s.Generate(&Params{ID: "f", Nodes: 8, LinkProbability: 0.2, Seed: seed, DestinationSet: "random:2", FaultCount: 2, Placement: placement, FaultKinds: []scenario.Kind{kind}})
+				if err != nil {
+					t.Fatalf("Generate() again failed: %v", err)
+				}
+				if s := cmp.Diff(c.Params, again.Params); s != "" {
+					t.Errorf("Generate() with seed %d is not repeatable (-first +second):\n%s", seed, s)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		spec    string
+		p       *Params
+		wantErr string
+	}{{
+		desc:    "too many destinations",
+		spec:    "name: x\nnodes: [3]\nlink_probabilities: [0]\n",
+		p:       &Params{ID: "x", Nodes: 3, Seed: 1, DestinationSet: "random:4"},
+		wantErr: "more nodes than the topology",
+	}, {
+		desc:    "no room for loops",
+		spec:    "name: x\nnodes: [2]\nlink_probabilities: [0]\n",
+		p:       &Params{ID: "x", Nodes: 2, Seed: 1, DestinationSet: Last, FaultCount: 1, Placement: OnPath, FaultKinds: []scenario.Kind{scenario.RoutingLoop}},
+		wantErr: "no place for a routing-loop fault",
+	}, {
+		desc:    "unsupported vendor",
+		spec:    "name: x\nnodes: [3]\nlink_probabilities: [0]\nnode: {vendor: OPENCONFIG}\n",
+		p:       &Params{ID: "x", Nodes: 3, Seed: 1, DestinationSet: Last},
+		wantErr: "case x",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := spec(t, tt.spec).Generate(tt.p)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Errorf("Generate() unexpected error: %s", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+	"github.com/openconfig/kne/telemetry"
+	"github.com/openconfig/kne/trace"
+	"google.golang.org/protobuf/encoding/prototext"
+	"gopkg.in/yaml.v3"
+	log "k8s.io/klog/v2"
+)
+
+// Files written for each case, in the directory of the case.
+const (
+	TopologyFile  = "topology.pbtxt"
+	ScenarioFile  = "scenario.yaml"
+	ParamsFile    = "params.json"
+	ResultFile    = "result.json"
+	TelemetryFile = "telemetry.jsonl"
+	TracesFile    = "traces.otlp.json"
+)
+
+// DeleteTimeout is the timeout of deleting the topology of a case.
+const DeleteTimeout = 5 * time.Minute
+
+// ResultsFile is the file in the output directory each result is appended
+// to, as a line of JSON.
+const ResultsFile = "results.jsonl"
+
+// A Topology is the topology of a case in the cluster.  It is implemented
+// by *topo.Manager.
+type Topology interface {
+	scenario.Target
+	Create(ctx context.Context, timeout time.Duration) error
+	Delete(ctx context.Context) error
+	Show(ctx context.Context) (*cpb.ShowTopologyResponse, error)
+}
+
+// fetchAll and collect are replaced in tests.
+var (
+	fetchAll = trace.FetchAll
+	collect  = telemetry.Collect
+)
+
+// A Runner runs the cases of a spec.
+type Runner struct {
+	Spec *Spec
+	// Dir is the directory the files of each case are written to, in a
+	// directory named by its ID.
+	Dir string
+	// New returns the Topology of the topology of a case.
+	New func(t *tpb.Topology) (Topology, error)
+	// DryRun only generates the files of the cases, without creating their
+	// topologies.
+	DryRun bool
+}
+
+// A Result is the result of a case.
+type Result struct {
+	Params *Params   `json:"params"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	// Error is why the case failed, if it did.
+	Error string `json:"error,omitempty"`
+	// Baseline are the paths from every other node to each destination
+	// before the faults are applied, and Faulted after.  Cases without
+	// faults are only traced once.
+	Baseline []*trace.Result `json:"baseline,omitempty"`
+	Faulted  []*trace.Result `json:"faulted,omitempty"`
+	// Statuses counts the paths traced last by their status.
+	Statuses map[trace.Status]int `json:"statuses,omitempty"`
+}
+
+// Run runs the cases in order and returns their results.  A failed case is
+// recorded in its result and does not stop the others.  The topology of each
+// case is deleted after it runs.  An error is only returned if a result
+// cannot be written or ctx is done, in which case the remaining cases are
+// not run and the results of those that ran are returned.
+func (r *Runner) Run(ctx context.Context, cases []*Params) ([]*Result, error) {
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(r.Dir, ResultsFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var results []*Result
+	for i, p := range cases {
+		if err := ctx.Err(); err != nil {
+			log.Warningf("Not running the remaining %d cases: %v", len(cases)-i, err)
+			return results, err
+		}
+		log.Infof("Running case %d of %d: %s", i+1, len(cases), p.ID)
+		res := &Result{Params: p, Start: time.Now()}
+		if err := r.run(ctx, p, res); err != nil {
+			log.Errorf("Case %s failed: %v", p.ID, err)
+			res.Error = err.Error()
+		}
+		res.End = time.Now()
+		results = append(results, res)
+		if err := writeJSON(filepath.Join(r.Dir, p.ID, ResultFile), res); err != nil {
+			return results, err
+		}
+		b, err := json.Marshal(res)
+		if err != nil {
+			return results, err
+		}
+		if _, err := f.Write(append(b, '\n')); err != nil {
+			return results, err
+		}
+	}
+	return results, f.Close()
+}
+
+// run runs the case p, recording its traces in res.
+func (r *Runner) run(ctx context.Context, p *Params, res *Result) (rerr error) {
+	dir := filepath.Join(r.Dir, p.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	c, err := r.Spec.Generate(p)
+	if err != nil {
+		return err
+	}
+	if err := writeCase(dir, c); err != nil {
+		return err
+	}
+	if r.DryRun {
+		return nil
+	}
+	tm, err := r.New(c.Topology)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The topology is deleted even if ctx is cancelled, as it is on an
+		// interrupt, so that it is not left in the cluster.
+		dctx, cancel := context.WithTimeout(context.Background(), DeleteTimeout)
+		defer cancel()
+		if err := tm.Delete(dctx); err != nil {
+			log.Errorf("Failed to delete topology of case %s: %v", p.ID, err)
+			if rerr == nil {
+				rerr = fmt.Errorf("delete: %w", err)
+			}
+		}
+	}()
+	if err := tm.Create(ctx, r.Spec.Timeout); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	ts, err := tm.Show(ctx)
+	if err != nil {
+		return fmt.Errorf("show: %w", err)
+	}
+	var reqs []*trace.Request
+	req, err := r.trace(ctx, ts.GetTopology(), c, p.ID+"-baseline")
+	if err != nil {
+		return err
+	}
+	res.Baseline, reqs = req.Paths, append(reqs, req)
+	last := res.Baseline
+	if len(c.Scenario.Faults) > 0 {
+		if err := scenario.Apply(ctx, tm, c.Scenario, c.Topology); err != nil {
+			return fmt.Errorf("apply faults: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.Spec.Settle):
+		}
+		req, err := r.trace(ctx, ts.GetTopology(), c, p.ID+"-faulted")
+		if err != nil {
+			return err
+		}
+		res.Faulted, reqs = req.Paths, append(reqs, req)
+		last = res.Faulted
+	}
+	res.Statuses = map[trace.Status]int{}
+	for _, t := range last {
+		res.Statuses[t.Status]++
+	}
+	e := &trace.Exporter{ServiceName: "kne-experiment"}
+	if err := e.WriteFile(filepath.Join(dir, TracesFile), reqs); err != nil {
+		return err
+	}
+	if r.Spec.Telemetry != nil {
+		if err := r.collect(ctx, filepath.Join(dir, TelemetryFile), ts.GetTopology()); err != nil {
+			return fmt.Errorf("telemetry: %w", err)
+		}
+	}
+	return nil
+}
+
+// trace traces the paths from every node of c to each of its destinations
+// in the running topology t, as the request id.
+func (r *Runner) trace(ctx context.Context, t *tpb.Topology, c *Case, id string) (*trace.Request, error) {
+	req := &trace.Request{ID: id, Start: time.Now()}
+	tables := fetchAll(ctx, t, &trace.Options{
+		NetworkInstance: r.Spec.NetworkInstance,
+		Username:        r.Spec.Username,
+		Password:        r.Spec.Password,
+	})
+	for _, dst := range c.Params.Destinations {
+		addr := c.Plan.Node(dst).Loopback4.Addr()
+		for _, n := range c.Plan.Nodes {
+			if n.Name == dst {
+				continue
+			}
+			res, err := trace.Trace(t, tables, n.Name, addr)
+			if err != nil {
+				return nil, fmt.Errorf("trace from %s to %s: %w", n.Name, dst, err)
+			}
+			req.Paths = append(req.Paths, res)
+		}
+	}
+	req.End = time.Now()
+	return req, nil
+}
+
+// collect writes the telemetry of the spec of r collected from the running
+// topology t to the file path as JSON lines.
+func (r *Runner) collect(ctx context.Context, path string, t *tpb.Topology) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	opts := &telemetry.Options{
+		Paths:    r.Spec.Telemetry.Paths,
+		Interval: r.Spec.Telemetry.Interval,
+		Duration: r.Spec.Telemetry.Duration,
+		Username: r.Spec.Username,
+		Password: r.Spec.Password,
+	}
+	if err := collect(ctx, t, opts, telemetry.NewJSONLWriter(f)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeCase writes the topology, scenario and parameters of c to dir.
+func writeCase(dir string, c *Case) error {
+	b, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(c.Topology)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, TopologyFile), b, 0o644); err != nil {
+		return err
+	}
+	if b, err = yaml.Marshal(c.Scenario); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ScenarioFile), b, 0o644); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, ParamsFile), c.Params)
+}
+
+func writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// WriteSummary writes a table of results to w, with the number of paths
+// traced last of each status.
+func WriteSummary(w io.Writer, results []*Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CASE\tNODES\tLINKS\tSEED\tDESTINATIONS\tFAULTS\tDELIVERED\tLOOP\tBLACKHOLE\tOTHER\tERROR")
+	failed := 0
+	for _, r := range results {
+		p := r.Params
+		var faults []string
+		for _, f := range p.Faults {
+			faults = append(faults, f.String())
+		}
+		other := 0
+		for s, n := range r.Statuses {
+			if s != trace.Delivered && s != trace.Loop && s != trace.Blackhole {
+				other += n
+			}
+		}
+		e := "-"
+		if r.Error != "" {
+			e = r.Error
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", p.ID, p.Nodes, p.Links, p.Seed,
+			dash(strings.Join(p.Destinations, ",")), dash(strings.Join(faults, "; ")),
+			r.Statuses[trace.Delivered], r.Statuses[trace.Loop], r.Statuses[trace.Blackhole], other, e)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Cases: %d, failed: %d\n", len(results), failed)
+	return err
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,296 @@
+package experiment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/addressing"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+	"github.com/openconfig/kne/telemetry"
+	"github.com/openconfig/kne/trace"
+)
+
+type fakeTopology struct {
+	topo      *tpb.Topology
+	plan      *addressing.Plan
+	faults    []*scenario.Fault
+	createErr error
+	pushes    int
+	deleted   bool
+}
+
+func (f *fakeTopology) Create(context.Context, time.Duration) error { return f.createErr }
+
+func (f *fakeTopology) Delete(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.deleted = true
+	return nil
+}
+
+func (f *fakeTopology) Show(context.Context) (*cpb.ShowTopologyResponse, error) {
+	return &cpb.ShowTopologyResponse{Topology: f.topo}, nil
+}
+
+func (f *fakeTopology) ConfigPush(context.Context, string, io.Reader) error {
+	f.pushes++
+	return nil
+}
+
+func (f *fakeTopology) Exec(context.Context, string, []string, io.Reader, io.Writer, io.Writer) error {
+	return nil
+}
+
+// tables returns the forwarding state of the nodes of f: the addresses and
+// static routes of its plan, with the prefixes of its blackholes discarded
+// once configuration has been pushed.
+func (f *fakeTopology) tables() map[string]*trace.Table {
+	tables := map[string]*trace.Table{}
+	for _, n := range f.plan.Nodes {
+		t := trace.NewTable()
+		t.Addresses[n.Loopback4.Addr()] = "lo"
+		for _, intf := range n.Interfaces {
+			t.Addresses[intf.Addr4.Addr()] = intf.Name
+		}
+		for i, r := range n.Routes4() {
+			id := uint64(i + 1)
+			t.Entries[r.Prefix] = id
+			t.NextHopGroups[id] = []uint64{id}
+			t.NextHops[id] = &trace.NextHop{Index: id, IP: r.NextHop, Interface: r.Interface.Name}
+		}
+		for _, fault := range f.faults {
+			if f.pushes == 0 || fault.Kind != scenario.Blackhole || fault.Node != n.Name {
+				continue
+			}
+			for prefix, id := range t.Entries {
+				if prefix.String() == fault.Prefix {
+					t.NextHopGroups[id] = nil
+				}
+			}
+		}
+		tables[n.Name] = t
+	}
+	return tables
+}
+
+// setup replaces fetchAll and collect and returns a runner of s creating
+// fake topologies, which are appended to topos.
+func setup(t *testing.T, s *Spec, cases []*Params, topos *[]*fakeTopology) *Runner {
+	t.Helper()
+	origFetchAll, origCollect := fetchAll, collect
+	t.Cleanup(func() {
+		fetchAll, collect = origFetchAll, origCollect
+	})
+	fetchAll = func(context.Context, *tpb.Topology, *trace.Options) map[string]*trace.Table {
+		return (*topos)[len(*topos)-1].tables()
+	}
+	collect = func(_ context.Context, _ *tpb.Topology, opts *telemetry.Options, w telemetry.Writer) error {
+		if err := w.Write(&telemetry.Sample{Node: "r0", Path: opts.Paths[0], Value: "1"}); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	// Cases are found by the names of their topologies, which are set
+	// when they are generated.
+	byName := func(name string) *Params {
+		for _, p := range cases {
+			if p.Topology == name {
+				return p
+			}
+		}
+		return nil
+	}
+	return &Runner{
+		Spec: s,
+		Dir:  t.TempDir(),
+		New: func(tp *tpb.Topology) (Topology, error) {
+			plan, err := scenario.Plan(&scenario.Scenario{}, tp)
+			if err != nil {
+				return nil, err
+			}
+			ft := &fakeTopology{topo: tp, plan: plan, faults: byName(tp.GetName()).Faults}
+			if tp.GetName() == "n5-p0-2-s2-last-f0" {
+				ft.createErr = fmt.Errorf("no resources")
+			}
+			*topos = append(*topos, ft)
+			return ft, nil
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := spec(t, `
+name: run
+nodes: [5]
+link_probabilities: [0.2]
+seeds: [1, 2]
+faults:
+  kinds: [blackhole]
+  counts: [0, 1]
+settle: 1ms
+telemetry:
+  paths: [/interfaces]
+  duration: 1s
+`)
+	cases := s.Cases()
+	var topos []*fakeTopology
+	r := setup(t, s, cases, &topos)
+	results, err := r.Run(context.Background(), cases)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Run() returned %d results, want 4", len(results))
+	}
+	for _, ft := range topos {
+		if !ft.deleted {
+			t.Errorf("Run() did not delete topology %q", ft.topo.GetName())
+		}
+	}
+	clean, faulted, failed := results[0], results[1], results[2]
+	if clean.Error != "" || faulted.Error != "" {
+		t.Fatalf("Run() cases failed: %q, %q", clean.Error, faulted.Error)
+	}
+	if s := errdiff.Substring(fmt.Errorf("%s", failed.Error), "create: no resources"); s != "" {
+		t.Errorf("Run() unexpected error of failed case: %s", s)
+	}
+	if got, want := clean.Statuses[trace.Delivered], 4; got != want {
+		t.Errorf("Run() case without faults delivered %d paths, want %d: %v", got, want, clean.Statuses)
+	}
+	if clean.Faulted != nil {
+		t.Errorf("Run() traced case without faults after faults: %v", clean.Faulted)
+	}
+	if got := faulted.Statuses[trace.Blackhole]; got == 0 {
+		t.Errorf("Run() case with a blackhole blackholed no paths: %v", faulted.Statuses)
+	}
+	if got, want := len(faulted.Baseline), 4; got != want {
+		t.Errorf("Run() case with faults traced %d baseline paths, want %d", got, want)
+	}
+	for _, id := range []string{"n5-p0.2-s1-last-f0", "n5-p0.2-s1-last-f1-on-path"} {
+		for _, f := range []string{TopologyFile, ScenarioFile, ParamsFile, ResultFile, TracesFile, TelemetryFile} {
+			if _, err := os.Stat(filepath.Join(r.Dir, id, f)); err != nil {
+				t.Errorf("Run() did not write %s of case %s: %v", f, id, err)
+			}
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(r.Dir, ResultsFile))
+	if err != nil {
+		t.Fatalf("Run() did not write %s: %v", ResultsFile, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Run() wrote %d results, want 4", len(lines))
+	}
+	var got Result
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("Run() wrote bad result: %v", err)
+	}
+	if len(got.Params.Faults) != 1 || got.Params.Faults[0].Kind != scenario.Blackhole || got.Params.Seed != 1 {
+		t.Errorf("Run() result does not record the parameters of the case: %+v", got.Params)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	s := spec(t, "name: cancelled\nnodes: [3]\nlink_probabilities: [0]\nseeds: [1, 2]\n")
+	cases := s.Cases()
+	if len(cases) != 2 {
+		t.Fatalf("Cases() returned %d cases, want 2", len(cases))
+	}
+	var topos []*fakeTopology
+	r := setup(t, s, cases, &topos)
+	// The context is cancelled, as on an interrupt, while the first case
+	// runs.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newTopo := r.New
+	r.New = func(tp *tpb.Topology) (Topology, error) {
+		cancel()
+		return newTopo(tp)
+	}
+	results, err := r.Run(ctx, cases)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() returned error %v, want %v", err, context.Canceled)
+	}
+	if len(results) != 1 {
+		t.Errorf("Run() returned %d results, want the 1 of the case that ran", len(results))
+	}
+	if len(topos) != 1 {
+		t.Fatalf("Run() created %d topologies after the context was cancelled, want 1", len(topos))
+	}
+	if !topos[0].deleted {
+		t.Errorf("Run() did not delete the topology after the context was cancelled")
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	s := spec(t, "name: dry\nnodes: [6]\nlink_probabilities: [0]\ndestinations: [all]\nfaults: {counts: [1]}\n")
+	cases := s.Cases()
+	var topos []*fakeTopology
+	r := setup(t, s, cases, &topos)
+	r.DryRun = true
+	results, err := r.Run(context.Background(), cases)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if len(topos) != 0 {
+		t.Errorf("Run() created %d topologies in a dry run", len(topos))
+	}
+	if len(results) != 1 || results[0].Error != "" {
+		t.Fatalf("Run() unexpected results: %+v", results)
+	}
+	dir := filepath.Join(r.Dir, cases[0].ID)
+	b, err := os.ReadFile(filepath.Join(dir, ScenarioFile))
+	if err != nil {
+		t.Fatalf("Run() did not write %s: %v", ScenarioFile, err)
+	}
+	sc, err := scenario.Parse(b)
+	if err != nil {
+		t.Fatalf("Run() wrote bad scenario: %v", err)
+	}
+	if len(sc.Faults) != 1 || sc.Faults[0].Kind != scenario.RoutingLoop {
+		t.Errorf("Run() wrote scenario with faults %v, want one routing loop", sc.Faults)
+	}
+	if _, err := os.Stat(filepath.Join(dir, TracesFile)); err == nil {
+		t.Errorf("Run() traced a dry run")
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	results := []*Result{{
+		Params:   &Params{ID: "n3-p0-s1-last-f0", Nodes: 3, Links: 2, Seed: 1, Destinations: []string{"r2"}},
+		Statuses: map[trace.Status]int{trace.Delivered: 2},
+	}, {
+		Params: &Params{ID: "n3-p0-s1-last-f1-on-path", Nodes: 3, Links: 2, Seed: 1, Destinations: []string{"r2"},
+			Faults: []*scenario.Fault{{Kind: scenario.RoutingLoop, Node: "r1", Via: "r0", Prefix: "10.255.0.3/32"}}},
+		Statuses: map[trace.Status]int{trace.Delivered: 1, trace.Loop: 1},
+	}, {
+		Params: &Params{ID: "n3-p0-s2-last-f0", Nodes: 3, Seed: 2},
+		Error:  "create: no resources",
+	}}
+	var buf bytes.Buffer
+	if err := WriteSummary(&buf, results); err != nil {
+		t.Fatalf("WriteSummary() failed: %v", err)
+	}
+	want := `CASE                      NODES  LINKS  SEED  DESTINATIONS  FAULTS                                   DELIVERED  LOOP  BLACKHOLE  OTHER  ERROR
+n3-p0-s1-last-f0          3      2      1     r2            -                                        2          0     0          0      -
+n3-p0-s1-last-f1-on-path  3      2      1     r2            routing-loop on r1 10.255.0.3/32 via r0  1          1     0          0      -
+n3-p0-s2-last-f0          3      0      2     -             -                                        0          0     0          0      create: no resources
+Cases: 3, failed: 1
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteSummary() got:\n%s\nwant:\n%s", got, want)
+	}
+}
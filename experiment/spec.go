@@ -0,0 +1,350 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package experiment runs batches of experiments on random topologies.  An
+// experiment spec sweeps the parameters of the topologies and their faults,
+// and each combination is a case:
+//
+//	name: loops
+//	nodes: [8, 16]
+//	link_probabilities: [0.01, 0.05]
+//	seeds: [1, 2, 3]
+//	destinations: [last, random:2]
+//	faults:
+//	  kinds: [routing-loop]
+//	  counts: [0, 1]
+//	  placements: [on-path, random]
+//	node:
+//	  vendor: NOKIA
+//	  model: ixr-d2
+//	telemetry:
+//	  paths: [/interfaces/interface/state/counters]
+//	  duration: 30s
+//
+// For each case a connected random topology is generated from the seed, its
+// addresses and static routes are configured with package addressing, and
+// the faults are placed and compiled with package scenario.  The topology is
+// then created, the paths from every node to each destination are traced
+// before and after the faults are applied, telemetry is collected, and the
+// topology is deleted.  Every case records all its parameters, including the
+// placed faults, so that the results of many topologies can be compared.
+package experiment
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+// Placement is how faults are placed in a topology.
+type Placement string
+
+const (
+	// OnPath places faults on the path from a random node to a destination.
+	OnPath = Placement("on-path")
+	// Random places faults on random nodes.
+	Random = Placement("random")
+)
+
+const (
+	// Last is the destination set of the node with the highest number.
+	Last = "last"
+	// All is the destination set of all nodes.
+	All = "all"
+	// RandomPrefix is the prefix of random destination sets, such as
+	// random:3 for three random nodes.
+	RandomPrefix = "random:"
+)
+
+// Defaults of specs.
+const (
+	DefaultNodePrefix = "r"
+	DefaultVendor     = "NOKIA"
+	// DefaultSettle is how long faults are given to take effect before
+	// tracing.
+	DefaultSettle = 10 * time.Second
+	// DefaultTimeout is the timeout of creating a topology.
+	DefaultTimeout = 10 * time.Minute
+)
+
+// faultKinds are the kinds of faults that can be placed.
+var faultKinds = map[scenario.Kind]bool{
+	scenario.RoutingLoop: true,
+	scenario.Blackhole:   true,
+	scenario.LinkDown:    true,
+}
+
+// A Spec is the parameters swept by an experiment.  Every combination of
+// nodes, link probability, seed, destination set, fault count and placement
+// is a case.
+type Spec struct {
+	Name string `yaml:"name"`
+	// Nodes are the numbers of nodes of the topologies.
+	Nodes []int `yaml:"nodes"`
+	// LinkProbabilities are the probabilities of a link between any two
+	// nodes, on top of the links keeping the topology connected.
+	LinkProbabilities []float64 `yaml:"link_probabilities"`
+	// Seeds are the seeds of the random topologies and fault placements,
+	// 1 if not set.
+	Seeds []int64 `yaml:"seeds,omitempty"`
+	// Destinations are the destination sets: Last, All or random:N.  Last
+	// if not set.
+	Destinations []string `yaml:"destinations,omitempty"`
+	Faults       Faults   `yaml:"faults,omitempty"`
+	Node         Node     `yaml:"node,omitempty"`
+	// Settle is how long faults are given to take effect before tracing,
+	// DefaultSettle if not set.
+	Settle time.Duration `yaml:"settle,omitempty"`
+	// Timeout is the timeout of creating each topology, DefaultTimeout if
+	// not set.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// NetworkInstance, Username and Password are used to read the
+	// forwarding state and telemetry of the nodes over gNMI.
+	NetworkInstance string     `yaml:"network_instance,omitempty"`
+	Username        string     `yaml:"username,omitempty"`
+	Password        string     `yaml:"password,omitempty"`
+	Telemetry       *Telemetry `yaml:"telemetry,omitempty"`
+}
+
+// Faults are the fault parameters swept by an experiment.
+type Faults struct {
+	// Kinds are the kinds of faults placed, chosen at random for each
+	// fault.  Routing loops, blackholes and links going down are supported.
+	// Routing loops if not set.
+	Kinds []scenario.Kind `yaml:"kinds,omitempty"`
+	// Counts are the numbers of faults placed, 0 if not set.
+	Counts []int `yaml:"counts,omitempty"`
+	// Placements are how faults are placed, OnPath if not set.
+	Placements []Placement `yaml:"placements,omitempty"`
+}
+
+// Node is the template of the nodes of the topologies.
+type Node struct {
+	// Prefix is the prefix of the node names, which are numbered from 0.
+	// DefaultNodePrefix if not set.
+	Prefix string `yaml:"prefix,omitempty"`
+	// Vendor is the name of the vendor of the nodes, such as NOKIA.
+	// DefaultVendor if not set.
+	Vendor string `yaml:"vendor,omitempty"`
+	Model  string `yaml:"model,omitempty"`
+	OS     string `yaml:"os,omitempty"`
+	Image  string `yaml:"image,omitempty"`
+}
+
+// Telemetry is the telemetry collected after the faults are applied.
+type Telemetry struct {
+	Paths    []string      `yaml:"paths"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Load returns the spec in the YAML file at path.
+func Load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse returns the spec in the YAML b, with defaults set.  Unknown fields
+// are an error.
+func Parse(b []byte) (*Spec, error) {
+	s := &Spec{}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(s); err != nil && err != io.EOF {
+		return nil, err
+	}
+	s.setDefaults()
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Spec) setDefaults() {
+	if len(s.Seeds) == 0 {
+		s.Seeds = []int64{1}
+	}
+	if len(s.Destinations) == 0 {
+		s.Destinations = []string{Last}
+	}
+	if len(s.Faults.Kinds) == 0 {
+		s.Faults.Kinds = []scenario.Kind{scenario.RoutingLoop}
+	}
+	if len(s.Faults.Counts) == 0 {
+		s.Faults.Counts = []int{0}
+	}
+	if len(s.Faults.Placements) == 0 {
+		s.Faults.Placements = []Placement{OnPath}
+	}
+	if s.Node.Prefix == "" {
+		s.Node.Prefix = DefaultNodePrefix
+	}
+	if s.Node.Vendor == "" {
+		s.Node.Vendor = DefaultVendor
+	}
+	if s.Settle == 0 {
+		s.Settle = DefaultSettle
+	}
+	if s.Timeout == 0 {
+		s.Timeout = DefaultTimeout
+	}
+}
+
+func (s *Spec) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("experiment has no name")
+	}
+	if len(s.Nodes) == 0 || len(s.LinkProbabilities) == 0 {
+		return fmt.Errorf("experiment must have nodes and link_probabilities")
+	}
+	for _, n := range s.Nodes {
+		if n < 2 {
+			return fmt.Errorf("bad node count %d, must be at least 2", n)
+		}
+	}
+	for _, p := range s.LinkProbabilities {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("bad link probability %v, must be between 0 and 1", p)
+		}
+	}
+	for _, d := range s.Destinations {
+		if _, err := destinationCount(d); err != nil {
+			return err
+		}
+	}
+	for _, k := range s.Faults.Kinds {
+		if !faultKinds[k] {
+			return fmt.Errorf("fault kind %q cannot be placed", k)
+		}
+	}
+	for _, c := range s.Faults.Counts {
+		if c < 0 {
+			return fmt.Errorf("bad fault count %d", c)
+		}
+	}
+	for _, p := range s.Faults.Placements {
+		if p != OnPath && p != Random {
+			return fmt.Errorf("unknown placement %q", p)
+		}
+	}
+	if _, ok := tpb.Vendor_value[s.Node.Vendor]; !ok {
+		return fmt.Errorf("unknown vendor %q", s.Node.Vendor)
+	}
+	if s.Settle < 0 || s.Timeout < 0 {
+		return fmt.Errorf("settle and timeout must not be negative")
+	}
+	if t := s.Telemetry; t != nil && (len(t.Paths) == 0 || t.Duration <= 0) {
+		return fmt.Errorf("telemetry must have paths and a duration")
+	}
+	return nil
+}
+
+// destinationCount returns the number of nodes of a random destination set
+// d, or 0 for the other sets.
+func destinationCount(d string) (int, error) {
+	switch {
+	case d == Last || d == All:
+		return 0, nil
+	case strings.HasPrefix(d, RandomPrefix):
+		n, err := strconv.Atoi(strings.TrimPrefix(d, RandomPrefix))
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("bad destination set %q", d)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("unknown destination set %q, want %s, %s or %sN", d, Last, All, RandomPrefix)
+}
+
+// Params are the parameters of a case.
+type Params struct {
+	// ID identifies the case in an experiment.
+	ID string `json:"id"`
+	// Topology is the name of the topology of the case, which is also the
+	// name of its namespace.  It is the ID made a DNS label.
+	Topology        string  `json:"topology"`
+	Nodes           int     `json:"nodes"`
+	LinkProbability float64 `json:"link_probability"`
+	Seed            int64   `json:"seed"`
+	// DestinationSet is the destination set of the spec and Destinations
+	// the nodes it resolved to.
+	DestinationSet string            `json:"destination_set"`
+	Destinations   []string          `json:"destinations,omitempty"`
+	FaultCount     int               `json:"fault_count"`
+	Placement      Placement         `json:"placement,omitempty"`
+	FaultKinds     []scenario.Kind   `json:"fault_kinds,omitempty"`
+	Faults         []*scenario.Fault `json:"faults,omitempty"`
+	// Links is the number of links of the generated topology.
+	Links int `json:"links"`
+}
+
+// Cases returns the parameters of the cases of s, in sweep order.  Cases
+// without faults have no placement.  The destinations, faults and links
+// are set when the case is generated.
+func (s *Spec) Cases() []*Params {
+	var cases []*Params
+	for _, n := range s.Nodes {
+		for _, p := range s.LinkProbabilities {
+			for _, seed := range s.Seeds {
+				for _, d := range s.Destinations {
+					for _, count := range s.Faults.Counts {
+						placements := s.Faults.Placements
+						if count == 0 {
+							placements = []Placement{""}
+						}
+						for _, pl := range placements {
+							c := &Params{
+								Nodes:           n,
+								LinkProbability: p,
+								Seed:            seed,
+								DestinationSet:  d,
+								FaultCount:      count,
+								Placement:       pl,
+							}
+							if count > 0 {
+								c.FaultKinds = s.Faults.Kinds
+							}
+							c.ID = c.id()
+							cases = append(cases, c)
+						}
+					}
+				}
+			}
+		}
+	}
+	return cases
+}
+
+// id returns the ID of the case p, such as n8-p0.05-s1-last-f1-on-path.
+func (p *Params) id() string {
+	id := fmt.Sprintf("n%d-p%s-s%d-%s-f%d", p.Nodes, strconv.FormatFloat(p.LinkProbability, 'g', -1, 64), p.Seed, strings.ReplaceAll(p.DestinationSet, ":", ""), p.FaultCount)
+	if p.Placement != "" {
+		id += "-" + string(p.Placement)
+	}
+	return id
+}
@@ -0,0 +1,165 @@
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/scenario"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load("testdata/loops.yaml")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := &Spec{
+		Name:              "loops",
+		Nodes:             []int{4, 8},
+		LinkProbabilities: []float64{0.1},
+		Seeds:             []int64{1, 2},
+		Destinations:      []string{"last", "random:2"},
+		Faults: Faults{
+			Kinds:      []scenario.Kind{scenario.RoutingLoop, scenario.Blackhole},
+			Counts:     []int{0, 1},
+			Placements: []Placement{OnPath, Random},
+		},
+		Node:    Node{Prefix: "r", Vendor: "ARISTA", Model: "ceos", Image: "ceos:latest"},
+		Settle:  5 * time.Second,
+		Timeout: DefaultTimeout,
+		Telemetry: &Telemetry{
+			Paths:    []string{"/interfaces/interface/state/counters"},
+			Duration: 30 * time.Second,
+		},
+	}
+	if s := cmp.Diff(want, s); s != "" {
+		t.Errorf("Load() unexpected diff (-want +got):\n%s", s)
+	}
+	if _, err := Load("testdata/missing.yaml"); err == nil {
+		t.Errorf("Load() of missing file succeeded")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		want    *Spec
+		wantErr string
+	}{{
+		desc: "defaults",
+		in:   "name: small\nnodes: [3]\nlink_probabilities: [0]\n",
+		want: &Spec{
+			Name:              "small",
+			Nodes:             []int{3},
+			LinkProbabilities: []float64{0},
+			Seeds:             []int64{1},
+			Destinations:      []string{Last},
+			Faults: Faults{
+				Kinds:      []scenario.Kind{scenario.RoutingLoop},
+				Counts:     []int{0},
+				Placements: []Placement{OnPath},
+			},
+			Node:    Node{Prefix: DefaultNodePrefix, Vendor: DefaultVendor},
+			Settle:  DefaultSettle,
+			Timeout: DefaultTimeout,
+		},
+	}, {
+		desc:    "no name",
+		in:      "nodes: [3]\nlink_probabilities: [0]\n",
+		wantErr: "no name",
+	}, {
+		desc:    "no nodes",
+		in:      "name: x\nlink_probabilities: [0]\n",
+		wantErr: "must have nodes",
+	}, {
+		desc:    "one node",
+		in:      "name: x\nnodes: [1]\nlink_probabilities: [0]\n",
+		wantErr: "bad node count 1",
+	}, {
+		desc:    "bad probability",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [1.5]\n",
+		wantErr: "bad link probability 1.5",
+	}, {
+		desc:    "unknown destinations",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\ndestinations: [first]\n",
+		wantErr: `unknown destination set "first"`,
+	}, {
+		desc:    "bad random destinations",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\ndestinations: [\"random:0\"]\n",
+		wantErr: `bad destination set "random:0"`,
+	}, {
+		desc:    "unplaceable kind",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\nfaults: {kinds: [mtu-mismatch]}\n",
+		wantErr: `"mtu-mismatch" cannot be placed`,
+	}, {
+		desc:    "negative count",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\nfaults: {counts: [-1]}\n",
+		wantErr: "bad fault count -1",
+	}, {
+		desc:    "unknown placement",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\nfaults: {placements: [edge]}\n",
+		wantErr: `unknown placement "edge"`,
+	}, {
+		desc:    "unknown vendor",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\nnode: {vendor: ACME}\n",
+		wantErr: `unknown vendor "ACME"`,
+	}, {
+		desc:    "telemetry without duration",
+		in:      "name: x\nnodes: [3]\nlink_probabilities: [0]\ntelemetry: {paths: [/a]}\n",
+		wantErr: "telemetry must have paths and a duration",
+	}, {
+		desc:    "unknown field",
+		in:      "name: x\nnodes: [3]\nlink_probability: 0\n",
+		wantErr: "field link_probability not found",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Parse([]byte(tt.in))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Parse() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("Parse() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestCases(t *testing.T) {
+	s, err := Load("testdata/loops.yaml")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cases := s.Cases()
+	// 2 node counts * 2 seeds * 2 destination sets * (1 case without faults
+	// + 2 placements).
+	if got, want := len(cases), 24; got != want {
+		t.Fatalf("Cases() returned %d cases, want %d", got, want)
+	}
+	var ids []string
+	for _, c := range cases[:3] {
+		ids = append(ids, c.ID)
+	}
+	want := []string{"n4-p0.1-s1-last-f0", "n4-p0.1-s1-last-f1-on-path", "n4-p0.1-s1-last-f1-random"}
+	if s := cmp.Diff(want, ids); s != "" {
+		t.Errorf("Cases() unexpected IDs (-want +got):\n%s", s)
+	}
+	seen := map[string]bool{}
+	for _, c := range cases {
+		if seen[c.ID] {
+			t.Errorf("Cases() returned case %s twice", c.ID)
+		}
+		seen[c.ID] = true
+		if c.FaultCount == 0 && (c.Placement != "" || c.FaultKinds != nil) {
+			t.Errorf("Cases() case %s without faults has placement %q and kinds %v", c.ID, c.Placement, c.FaultKinds)
+		}
+	}
+	if !seen["n8-p0.1-s2-random2-f1-random"] {
+		t.Errorf("Cases() did not return case n8-p0.1-s2-random2-f1-random")
+	}
+}
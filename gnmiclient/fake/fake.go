@@ -0,0 +1,113 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake is a fake gNMI server for testing the clients of package
+// gnmiclient.
+//
+// Typical Usage:
+//
+//	f := &fake.GNMI{Notifications: []*gpb.Notification{...}, Sync: true}
+//	c := fake.Serve(t, f)
+//	origDial := gnmiclient.Dial
+//	defer func() { gnmiclient.Dial = origDial }()
+//	gnmiclient.Dial = func(context.Context, string) (gpb.GNMIClient, func() error, error) {
+//		return c, func() error { return nil }, nil
+//	}
+package fake
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// GNMI is a gNMI server that answers each subscription with its
+// notifications.
+type GNMI struct {
+	gpb.UnimplementedGNMIServer
+	Notifications []*gpb.Notification
+	// Sync ends each subscription with a sync response after the
+	// notifications.  Otherwise subscriptions stream until they are
+	// canceled, like STREAM subscriptions.
+	Sync bool
+
+	mu        sync.Mutex
+	requests  []*gpb.SubscribeRequest
+	usernames []string
+}
+
+func (f *GNMI) Subscribe(s gpb.GNMI_SubscribeServer) error {
+	var username string
+	if md, ok := metadata.FromIncomingContext(s.Context()); ok && len(md["username"]) > 0 {
+		username = md["username"][0]
+	}
+	req, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.usernames = append(f.usernames, username)
+	f.mu.Unlock()
+	for _, n := range f.Notifications {
+		if err := s.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_Update{Update: n}}); err != nil {
+			return err
+		}
+	}
+	if f.Sync {
+		return s.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_SyncResponse{SyncResponse: true}})
+	}
+	<-s.Context().Done()
+	return s.Context().Err()
+}
+
+// Requests returns the first request of each subscription so far.
+func (f *GNMI) Requests() []*gpb.SubscribeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*gpb.SubscribeRequest(nil), f.requests...)
+}
+
+// Usernames returns the username metadata of each subscription so far, ""
+// for subscriptions without one.
+func (f *GNMI) Usernames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.usernames...)
+}
+
+// Serve serves f until the test is done and returns a client of it.
+func Serve(t testing.TB, f *GNMI) gpb.GNMIClient {
+	t.Helper()
+	l := bufconn.Listen(1 << 16)
+	s := grpc.NewServer()
+	gpb.RegisterGNMIServer(s, f)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial fake gNMI server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gpb.NewGNMIClient(conn)
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gnmiclient connects to the gNMI services of the nodes of a running
+// topology and converts between gNMI paths and values and strings.
+package gnmiclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// Address returns the external address of the gNMI service of node n, as
+// returned by topo.Manager.Show, or "" if n has no gNMI service.
+func Address(n *tpb.Node) string {
+	for _, s := range n.GetServices() {
+		if s.GetName() != "gnmi" || s.GetOutsideIp() == "" {
+			continue
+		}
+		return net.JoinHostPort(s.GetOutsideIp(), strconv.FormatUint(uint64(s.GetOutside()), 10))
+	}
+	return ""
+}
+
+// Dial returns a gNMI client of the service at addr and a function closing
+// it.  It can be replaced in tests.
+var Dial = func(ctx context.Context, addr string) (gpb.GNMIClient, func() error, error) {
+	// Nodes use self-signed certificates.
+	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, err
+	}
+	return gpb.NewGNMIClient(conn), conn.Close, nil
+}
+
+// WithCredentials returns ctx with username and password added as gNMI
+// metadata, or ctx if username is empty.
+func WithCredentials(ctx context.Context, username, password string) context.Context {
+	if username == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "username", username, "password", password)
+}
+
+// ParsePath returns the gNMI path of p, a path of the form /a/b[k=v]/c.  The
+// leading slash is optional and slashes within keys do not separate
+// elements.
+func ParsePath(p string) (*gpb.Path, error) {
+	var elems []string
+	depth, start := 0, 0
+	p = strings.TrimPrefix(p, "/")
+	for i, c := range p {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				elems = append(elems, p[start:i])
+				start = i + 1
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return nil, fmt.Errorf("path %q: unbalanced brackets", p)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("path %q: unbalanced brackets", p)
+	}
+	elems = append(elems, p[start:])
+	path := &gpb.Path{}
+	for _, s := range elems {
+		e := &gpb.PathElem{}
+		if i := strings.Index(s, "["); i >= 0 {
+			if !strings.HasSuffix(s, "]") {
+				return nil, fmt.Errorf("path %q: bad element %q", p, s)
+			}
+			e.Key = map[string]string{}
+			for _, kv := range strings.Split(s[i+1:len(s)-1], "][") {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok || k == "" {
+					return nil, fmt.Errorf("path %q: bad key %q", p, kv)
+				}
+				e.Key[k] = v
+			}
+			s = s[:i]
+		}
+		if s == "" {
+			return nil, fmt.Errorf("path %q: empty element", p)
+		}
+		e.Name = s
+		path.Elem = append(path.Elem, e)
+	}
+	return path, nil
+}
+
+// PathString returns the string form of a path with elems, with keys in
+// sorted order.
+func PathString(elems []*gpb.PathElem) string {
+	var b strings.Builder
+	for _, e := range elems {
+		b.WriteString("/" + e.GetName())
+		var keys []string
+		for k := range e.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "[%s=%s]", k, e.GetKey()[k])
+		}
+	}
+	return b.String()
+}
+
+// Scalar returns the scalar value v as a string.  JSON values must be a
+// string, number or boolean.
+func Scalar(v *gpb.TypedValue) (string, error) {
+	switch v := v.GetValue().(type) {
+	case *gpb.TypedValue_StringVal:
+		return v.StringVal, nil
+	case *gpb.TypedValue_UintVal:
+		return strconv.FormatUint(v.UintVal, 10), nil
+	case *gpb.TypedValue_IntVal:
+		return strconv.FormatInt(v.IntVal, 10), nil
+	case *gpb.TypedValue_BoolVal:
+		return strconv.FormatBool(v.BoolVal), nil
+	case *gpb.TypedValue_DoubleVal:
+		return strconv.FormatFloat(v.DoubleVal, 'g', -1, 64), nil
+	case *gpb.TypedValue_JsonIetfVal:
+		return jsonScalar(v.JsonIetfVal)
+	case *gpb.TypedValue_JsonVal:
+		return jsonScalar(v.JsonVal)
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+func jsonScalar(b []byte) (string, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var i any
+	if err := d.Decode(&i); err != nil {
+		return "", err
+	}
+	switch i := i.(type) {
+	case string:
+		return i, nil
+	case json.Number:
+		return i.String(), nil
+	case bool:
+		return strconv.FormatBool(i), nil
+	}
+	return "", fmt.Errorf("unsupported JSON value %s", b)
+}
@@ -0,0 +1,142 @@
+package gnmiclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    *gpb.Path
+		wantErr string
+	}{{
+		path: "/interfaces/interface[name=ethernet-1/1]/state/counters",
+		want: &gpb.Path{Elem: []*gpb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+			{Name: "state"},
+			{Name: "counters"},
+		}},
+	}, {
+		path: "a[x=1][y=*]/b",
+		want: &gpb.Path{Elem: []*gpb.PathElem{
+			{Name: "a", Key: map[string]string{"x": "1", "y": "*"}},
+			{Name: "b"},
+		}},
+	}, {
+		path:    "a[x=1/b",
+		wantErr: "unbalanced brackets",
+	}, {
+		path:    "a]/b",
+		wantErr: "unbalanced brackets",
+	}, {
+		path:    "a[x]/b",
+		wantErr: `bad key "x"`,
+	}, {
+		path:    "a//b",
+		wantErr: "empty element",
+	}, {
+		path:    "a[x=1]b",
+		wantErr: "bad element",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParsePath(tt.path)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ParsePath() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got, protocmp.Transform()); s != "" {
+				t.Errorf("ParsePath() unexpected path (-want +got):\n%s", s)
+			}
+			if tt.want != nil {
+				if got := PathString(got.GetElem()); got != "/"+strings.TrimPrefix(tt.path, "/") {
+					t.Errorf("PathString() got %q, want %q", got, tt.path)
+				}
+			}
+		})
+	}
+}
+
+func TestScalar(t *testing.T) {
+	tests := []struct {
+		desc    string
+		v       *gpb.TypedValue
+		want    string
+		wantErr string
+	}{
+		{"string", &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "up"}}, "up", ""},
+		{"uint", &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: 18446744073709551615}}, "18446744073709551615", ""},
+		{"int", &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{IntVal: -1}}, "-1", ""},
+		{"bool", &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{BoolVal: true}}, "true", ""},
+		{"double", &gpb.TypedValue{Value: &gpb.TypedValue_DoubleVal{DoubleVal: 0.5}}, "0.5", ""},
+		{"json string", &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`"UP"`)}}, "UP", ""},
+		{"json number", &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: []byte(`18446744073709551615`)}}, "18446744073709551615", ""},
+		{"json object", &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: []byte(`{"a": 1}`)}}, "", "unsupported JSON value"},
+		{"bytes", &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: []byte("x")}}, "", "unsupported value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Scalar(tt.v)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Scalar() unexpected error: %s", s)
+			}
+			if got != tt.want {
+				t.Errorf("Scalar() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		desc string
+		node *tpb.Node
+		want string
+	}{{
+		desc: "ipv4",
+		node: &tpb.Node{Services: map[uint32]*tpb.Service{
+			22:   {Name: "ssh", Outside: 22, OutsideIp: "10.1.1.1"},
+			9339: {Name: "gnmi", Outside: 9339, OutsideIp: "10.1.1.1"},
+		}},
+		want: "10.1.1.1:9339",
+	}, {
+		desc: "ipv6",
+		node: &tpb.Node{Services: map[uint32]*tpb.Service{9339: {Name: "gnmi", Outside: 9339, OutsideIp: "2001:db8::1"}}},
+		want: "[2001:db8::1]:9339",
+	}, {
+		desc: "no address",
+		node: &tpb.Node{Services: map[uint32]*tpb.Service{9339: {Name: "gnmi", Outside: 9339}}},
+	}, {
+		desc: "no service",
+		node: &tpb.Node{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := Address(tt.node); got != tt.want {
+				t.Errorf("Address() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCredentials(t *testing.T) {
+	md, _ := metadata.FromOutgoingContext(WithCredentials(context.Background(), "admin", "secret"))
+	if got, want := md["username"], []string{"admin"}; !cmp.Equal(got, want) {
+		t.Errorf("WithCredentials() got username %q, want %q", got, want)
+	}
+	if got, want := md["password"], []string{"secret"}; !cmp.Equal(got, want) {
+		t.Errorf("WithCredentials() got password %q, want %q", got, want)
+	}
+	if _, ok := metadata.FromOutgoingContext(WithCredentials(context.Background(), "", "")); ok {
+		t.Errorf("WithCredentials() without username added metadata")
+	}
+}
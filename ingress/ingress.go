@@ -0,0 +1,121 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ingress records how the services of a cluster are exposed outside
+// of it.  The NodePort ingress of package deploy records its mode and address
+// in a config map, which the topology manager reads to report the outside IP
+// of services without a load balancer.
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Namespace and ConfigMap name the config map the ingress is recorded
+	// in.
+	Namespace = "kube-system"
+	ConfigMap = "kne-ingress"
+	// NodePort is the mode of the NodePort ingress.
+	NodePort = "NodePort"
+)
+
+// Config is the ingress recorded in a cluster.
+type Config struct {
+	// Mode is the kind of the ingress, such as NodePort.
+	Mode string
+	// Address is the address the node ports are reachable at.  If empty the
+	// internal IP of the first cluster node is used.
+	Address string
+}
+
+// Read returns the ingress recorded in the cluster of kClient, or nil if none
+// is recorded.
+func Read(ctx context.Context, kClient kubernetes.Interface) (*Config, error) {
+	cm, err := kClient.CoreV1().ConfigMaps(Namespace).Get(ctx, ConfigMap, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return &Config{Mode: cm.Data["mode"], Address: cm.Data["address"]}, nil
+}
+
+// Write records c in the cluster of kClient, replacing any recorded ingress.
+func Write(ctx context.Context, kClient kubernetes.Interface, c *Config) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMap,
+			Namespace: Namespace,
+		},
+		Data: map[string]string{"mode": c.Mode},
+	}
+	if c.Address != "" {
+		cm.Data["address"] = c.Address
+	}
+	_, err := kClient.CoreV1().ConfigMaps(Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = kClient.CoreV1().ConfigMaps(Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	}
+	return err
+}
+
+// Delete removes the ingress recorded in the cluster of kClient, if any.
+func Delete(ctx context.Context, kClient kubernetes.Interface) error {
+	err := kClient.CoreV1().ConfigMaps(Namespace).Delete(ctx, ConfigMap, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// NodePortAddress returns the address the node ports of the cluster of
+// kClient are reachable at, and whether the NodePort ingress is in use.  The
+// address is empty if it is not.
+func NodePortAddress(ctx context.Context, kClient kubernetes.Interface) (string, bool, error) {
+	c, err := Read(ctx, kClient)
+	if err != nil || c == nil || c.Mode != NodePort {
+		return "", false, err
+	}
+	if c.Address != "" {
+		return c.Address, true, nil
+	}
+	addr, err := NodeAddress(ctx, kClient)
+	return addr, true, err
+}
+
+// NodeAddress returns the internal IP of the first cluster node, by name.
+func NodeAddress(ctx context.Context, kClient kubernetes.Interface) (string, error) {
+	nodes, err := kClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(nodes.Items, func(i, j int) bool { return nodes.Items[i].Name < nodes.Items[j].Name })
+	for _, node := range nodes.Items {
+		for _, a := range node.Status.Addresses {
+			if a.Type == corev1.NodeInternalIP {
+				return a.Address, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no cluster node has an internal IP")
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/h-fam/errdiff"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodePortAddress(t *testing.T) {
+	node := func(name, ip string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+			},
+		}
+	}
+	config := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ConfigMap, Namespace: Namespace},
+			Data:       data,
+		}
+	}
+	tests := []struct {
+		desc     string
+		objects  []runtime.Object
+		want     string
+		wantNode bool
+		wantErr  string
+	}{{
+		desc:    "no ingress",
+		objects: []runtime.Object{node("kne-control-plane", "172.18.0.2")},
+	}, {
+		desc:    "other mode",
+		objects: []runtime.Object{node("kne-control-plane", "172.18.0.2"), config(map[string]string{"mode": "MetalLB"})},
+	}, {
+		desc:     "first node",
+		objects:  []runtime.Object{node("kne-worker", "172.18.0.3"), node("kne-control-plane", "172.18.0.2"), config(map[string]string{"mode": NodePort})},
+		want:     "172.18.0.2",
+		wantNode: true,
+	}, {
+		desc:     "address",
+		objects:  []runtime.Object{node("kne-control-plane", "172.18.0.2"), config(map[string]string{"mode": NodePort, "address": "10.0.0.1"})},
+		want:     "10.0.0.1",
+		wantNode: true,
+	}, {
+		desc:     "no nodes",
+		objects:  []runtime.Object{config(map[string]string{"mode": NodePort})},
+		wantNode: true,
+		wantErr:  "no cluster node has an internal IP",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, ok, err := NodePortAddress(context.Background(), fake.NewSimpleClientset(tt.objects...))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("NodePortAddress() unexpected error: %s", s)
+			}
+			if got != tt.want || ok != tt.wantNode {
+				t.Errorf("NodePortAddress() got %q, %v, want %q, %v", got, ok, tt.want, tt.wantNode)
+			}
+		})
+	}
+}
+
+func TestWriteDelete(t *testing.T) {
+	ctx := context.Background()
+	kClient := fake.NewSimpleClientset()
+	for _, c := range []*Config{{Mode: NodePort, Address: "10.0.0.1"}, {Mode: NodePort}} {
+		if err := Write(ctx, kClient, c); err != nil {
+			t.Fatalf("Write(%+v) failed: %v", c, err)
+		}
+		got, err := Read(ctx, kClient)
+		if err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		if *got != *c {
+			t.Errorf("Read() got %+v, want %+v", got, c)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := Delete(ctx, kClient); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+	}
+	if got, err := Read(ctx, kClient); err != nil || got != nil {
+		t.Errorf("Read() of deleted ingress got %+v, %v, want nil", got, err)
+	}
+}
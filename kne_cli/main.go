@@ -19,10 +19,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/openconfig/kne/cmd"
 	"github.com/openconfig/kne/flags"
@@ -45,7 +47,16 @@ func main() {
 		}
 	}
 	flags.Import()
-	err := cmd.ExecuteContext(context.Background())
+	// Commands run by kne are in their own process groups and are killed
+	// when the context is canceled on the first interrupt.  A second
+	// interrupt exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := cmd.ExecuteContext(ctx)
+	stop()
 	flushLogs()
 	if err != nil {
 		os.Exit(1)
@@ -1,6 +1,7 @@
 package load
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -44,14 +45,30 @@ func Register(kind string, spec *Spec) {
 type Config struct {
 	Path       string      // Path of the configuration file
 	Dir        string      // Absolute path of the diretory Path is in
+	Overlays   []string    // Paths of the overlays merged on top of Path
+	Data       []byte      // The resolved configuration YAML
 	Config     interface{} // The configuration structure
 	Deployment interface{} // Filled by Config.Decode
 
 	IgnoreMissingFiles bool // when set there is no error when a file is missing.
+
+	errs []error           // errors found by Validate
+	dirs map[string]string // directories of the files values are from, by dirKey
 }
 
-// NewConfig reads a yaml configuration file at path and populates the provided
-// config structure.
+// NewConfig reads a yaml configuration file at path, merges the overlay files
+// on top of it, in order, and populates the provided config structure.
+//
+// Before merging, ${NAME} and ${NAME:-default} in values are replaced with the
+// value of the environment variable NAME, and a top level "include" of a path,
+// or list of paths, is replaced by the included files with the including file
+// merged on top of them.  Relative include paths are relative to the
+// directory of path.  Relative paths of YAML files, such as manifests, are
+// relative to the directory of the file, included or overlay, they are
+// set in.  Overlays are merged by kind: mappings are merged key by
+// key unless their kinds differ, the controllers are matched by kind, and a
+// controller with "$patch: delete" removes the controller of its kind.  All
+// other values in an overlay replace those of the base.
 //
 // A sample config structure:
 //
@@ -61,21 +78,39 @@ type Config struct {
 //		CNI         CNISpec           `yaml:"cni"`
 //		Controllers []*ControllerSpec `yaml:"controllers"`
 //	}
-func NewConfig(path string, config interface{}) (*Config, error) {
+func NewConfig(path string, config interface{}, overlays ...string) (*Config, error) {
 	c := &Config{
-		Path:   path,
-		Config: config,
+		Path:     path,
+		Overlays: overlays,
+		Config:   config,
 	}
-	var err error
-	if c.Dir, err = filepath.Abs(path); err != nil {
+	abs, err := filepath.Abs(path)
+	if err != nil {
 		return nil, err
 	}
-	c.Dir = filepath.Dir(c.Dir)
-	fp, err := open(path)
+	c.Dir = filepath.Dir(abs)
+	dirs := map[*yaml.Node]string{}
+	root, err := resolve(abs, c.Dir, map[string]bool{}, dirs)
 	if err != nil {
 		return nil, err
 	}
-	decoder := yaml.NewDecoder(fp)
+	for _, o := range overlays {
+		if !filepath.IsAbs(o) {
+			if o, err = filepath.Abs(o); err != nil {
+				return nil, err
+			}
+		}
+		n, err := resolve(o, c.Dir, map[string]bool{}, dirs)
+		if err != nil {
+			return nil, err
+		}
+		root = merge(root, n)
+	}
+	c.dirs = scalarDirs(root, dirs)
+	if c.Data, err = encode(root); err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(c.Data))
 	decoder.KnownFields(true)
 	if err := decoder.Decode(config); err != nil {
 		return nil, err
@@ -98,27 +133,79 @@ func NewConfig(path string, config interface{}) (*Config, error) {
 //	}
 func (c *Config) Decode(deployment interface{}) error {
 	c.Deployment = deployment
-	err := c.decode(reflect.ValueOf(c.Config), []string{"root"}, "")
+	c.errs = nil
+	err := c.decode(reflect.ValueOf(c.Config), nil, "")
 	return err
 }
 
-func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (rerr error) {
-	t := v.Type()
-	if t == yamlNodeType {
-		yn := v.Interface().(yaml.Node)
-		var data []byte
-		if err := yn.Encode(&data); err != nil {
-			return err
+// Validate decodes the configuration in Config c into deployment like Decode,
+// but rather than stopping at the first error it continues and returns all the
+// errors found.  Each error is prefixed with the YAML path of the value in
+// error, such as "controllers[1].spec.operator".  Line numbers in errors are
+// lines of Data, the configuration after includes and overlays are merged.
+// Nothing is deployed.
+func (c *Config) Validate(deployment interface{}) []error {
+	c.Deployment = deployment
+	c.errs = []error{}
+	if err := c.decode(reflect.ValueOf(c.Config), nil, ""); err != nil {
+		c.errs = append(c.errs, err)
+	}
+	errs := c.errs
+	c.errs = nil
+	return errs
+}
+
+// fail returns err, or, when validating, records err and returns nil so the
+// rest of the configuration is still checked.
+func (c *Config) fail(err error) error {
+	if c.errs == nil {
+		return err
+	}
+	c.errs = append(c.errs, err)
+	return nil
+}
+
+// yamlPath returns path as a YAML path, such as "controllers[1].spec".
+func yamlPath(path []string) string {
+	if len(path) == 0 {
+		return "root"
+	}
+	var b strings.Builder
+	for i, p := range path {
+		if i > 0 && !strings.HasPrefix(p, "[") {
+			b.WriteByte('.')
 		}
+		b.WriteString(p)
 	}
+	return b.String()
+}
+
+// yamlName returns the YAML name of the struct field sf, or "" if the field is
+// not decoded from YAML.
+func yamlName(sf reflect.StructField) string {
+	if !sf.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(sf.Name)
+	}
+	return name
+}
+
+func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (rerr error) {
+	t := v.Type()
 	switch v.Kind() {
 	case reflect.String:
 		if v.String() == "" {
 			return nil
 		}
 		if tag.Get("kne") == "yaml" {
-			if err := c.checkYAMLFile(v); err != nil {
-				return fmt.Errorf("%s: %v", strings.Join(path, "."), err)
+			if err := c.checkYAMLFile(v, path); err != nil {
+				return c.fail(fmt.Errorf("%s: %v", yamlPath(path), err))
 			}
 		}
 	case reflect.Array:
@@ -151,6 +238,9 @@ func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (
 			}
 		}
 	case reflect.Struct:
+		if t == yamlNodeType {
+			return nil
+		}
 		// Decode all the struct fields while looking for a structure
 		// that has both a kind and a spec YAML field.  We expect the
 		// spec field to be a yaml.Node which should be decoded based on
@@ -174,43 +264,49 @@ func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (
 					return fmt.Errorf("kind must be a string")
 				}
 				kind = sv.String()
-				if err := c.decode(sv, append(path, sf.Name), sf.Tag); err != nil {
+				if err := c.decode(sv, append(path, "kind"), sf.Tag); err != nil {
 					return err
 				}
 			case "spec":
 				if sf.Type != yamlNodeType {
-					return fmt.Errorf("%s is not of type %v\n", strings.Join(append(path, sf.Name), "."), yamlNodeType)
+					return fmt.Errorf("%s is not of type %v\n", yamlPath(append(path, "spec")), yamlNodeType)
 				}
 				node := sv.Interface().(yaml.Node)
 				spec = &node
 			case "":
 			default:
-				if err := c.decode(sv, append(path, sf.Name), sf.Tag); err != nil {
+				if err := c.decode(sv, append(path, yamlName(sf)), sf.Tag); err != nil {
 					return err
 				}
 			}
 		}
 		switch {
-		case kind == "" && spec == nil:
+		case kind == "" && (spec == nil || spec.Kind == 0):
 		case kind == "":
-			return fmt.Errorf("spec field without kind: %s\n", strings.Join(path, "."))
+			return c.fail(fmt.Errorf("%s: spec field without kind", yamlPath(path)))
 		case spec == nil:
-			return fmt.Errorf("kind field without spec: %s\n", strings.Join(path, "."))
+			return c.fail(fmt.Errorf("%s: kind field without spec", yamlPath(path)))
 		default:
-			// kind and spec have been supplied.
+			// kind and spec have been supplied, although the spec
+			// may be empty.
 
 			st, ok := specs[kind]
 			if !ok {
-				return fmt.Errorf("kind %s not supported", kind)
+				return c.fail(fmt.Errorf("%s: kind %s not supported", yamlPath(append(path, "kind")), kind))
 			}
+			path = append(path, "spec")
 
 			// Create a structure of the correct type and decode the
-			// yaml.Node into it.
+			// yaml.Node into it.  Unlike a yaml.Decoder, a yaml.Node
+			// ignores unknown fields, so they are checked separately.
 			val := reflect.New(reflect.ValueOf(st.Type).Type())
 			if err := spec.Decode(val.Interface()); err != nil {
-				return err
+				return c.fail(fmt.Errorf("%s: %v", yamlPath(path), err))
+			}
+			if err := unknownFields(spec, val.Type()); err != nil {
+				return c.fail(fmt.Errorf("%s: %v", yamlPath(path), err))
 			}
-			if err := c.decode(val.Elem(), append(path, kind), ""); err != nil {
+			if err := c.decode(val.Elem(), path, ""); err != nil {
 				return err
 			}
 
@@ -230,7 +326,6 @@ func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (
 			}
 
 			if !val.Type().AssignableTo(t) {
-				fmt.Printf("%v is not assignable to %v\n", val.Type(), t)
 				return fmt.Errorf("%v is not assignable to %v", val.Type(), t)
 			}
 
@@ -242,7 +337,7 @@ func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (
 
 			if st.Validate != nil {
 				if err := st.Validate(c, val.Interface()); err != nil {
-					return err
+					return c.fail(fmt.Errorf("%s: %v", yamlPath(path), err))
 				}
 			}
 		}
@@ -265,15 +360,82 @@ func (c *Config) decode(v reflect.Value, path []string, tag reflect.StructTag) (
 	return nil
 }
 
+// unknownFields returns an error for the first field of n, a YAML node to be
+// decoded into a value of type t, that has no corresponding field in t.
+func unknownFields(n *yaml.Node, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case n.Kind == yaml.DocumentNode || n.Kind == yaml.AliasNode:
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return unknownFields(n.Content[0], t)
+	case n.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, e := range n.Content {
+			if err := unknownFields(e, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := unknownFields(n.Content[i], t.Elem()); err != nil {
+				return err
+			}
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct && t != yamlNodeType:
+		fields := structFields(t)
+		for i := 0; i < len(n.Content); i += 2 {
+			k := n.Content[i]
+			ft, ok := fields[k.Value]
+			if !ok {
+				return fmt.Errorf("line %d: field %s not found in type %v", k.Line, k.Value, t)
+			}
+			if err := unknownFields(n.Content[i+1], ft); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// structFields returns the types of the fields of struct type t by YAML name,
+// including the fields of inlined structures.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := yamlName(sf)
+		if name == "" {
+			continue
+		}
+		if _, opts, _ := strings.Cut(sf.Tag.Get("yaml"), ","); strings.Contains(opts, "inline") && sf.Type.Kind() == reflect.Struct {
+			for k, v := range structFields(sf.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		fields[name] = sf.Type
+	}
+	return fields
+}
+
 // checkYAMLFile returns an error if v is not a string, is not a path to an existing
-// file, or the file cannot be decoded as a YAML file.
-func (c *Config) checkYAMLFile(v reflect.Value) error {
+// file, or the file cannot be decoded as a YAML file.  A relative path is made
+// relative to the directory of the file the value at the YAML path ypath is
+// set in, or to Dir.
+func (c *Config) checkYAMLFile(v reflect.Value, ypath []string) error {
 	if v.Kind() != reflect.String {
 		return fmt.Errorf("is of type %v, not string", v.Kind())
 	}
 	path := v.String()
 	if !filepath.IsAbs(path) {
-		path = filepath.Join(c.Dir, path)
+		dir, ok := c.dirs[dirKey(ypath)]
+		if !ok {
+			dir = c.Dir
+		}
+		path = filepath.Join(dir, path)
 	}
 	v.SetString(path)
 	fi, err := os.Stat(path)
@@ -1,12 +1,16 @@
 package load_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/h-fam/errdiff"
 	cmddeploy "github.com/openconfig/kne/cmd/deploy"
 	"github.com/openconfig/kne/deploy"
 	"github.com/openconfig/kne/load"
@@ -68,6 +72,205 @@ func TestNewConfig(t *testing.T) {
 	}
 }
 
+func TestNewConfigOverlays(t *testing.T) {
+	const base = `cni:
+  kind: Meshnet
+  spec:
+    manifest: ../manifests/meshnet/grpc/manifest.yaml
+controllers:
+  - kind: SRLinux
+    spec:
+      operator: ../manifests/controllers/srlinux/manifest.yaml
+  - kind: CEOSLab
+    spec:
+      operator: ../manifests/controllers/ceoslab/manifest.yaml
+cluster:
+  kind: Kind
+  spec:
+    name: kne
+    image: %s
+ingress:
+  kind: MetalLB
+  spec:
+    manifest: ../manifests/metallb/manifest.yaml
+    ip_count: %s
+`
+	tests := []struct {
+		desc        string
+		path        string
+		overlays    []string
+		env         map[string]string
+		wantData    string
+		wantIPCount int
+		wantErr     string
+	}{{
+		desc:        "include and defaults",
+		path:        "testdata/overlay/base.yaml",
+		wantData:    fmt.Sprintf(base, "kindest/node:v1.26.0", "100"),
+		wantIPCount: 100,
+	}, {
+		desc:        "environment",
+		path:        "testdata/overlay/base.yaml",
+		env:         map[string]string{"KNE_KIND_IMAGE": "kindest/node:v1.27.0", "KNE_IP_COUNT": "20"},
+		wantData:    fmt.Sprintf(base, "kindest/node:v1.27.0", "20"),
+		wantIPCount: 20,
+	}, {
+		desc:     "overlay",
+		path:     "testdata/overlay/base.yaml",
+		overlays: []string{"testdata/overlay/overlay.yaml"},
+		env:      map[string]string{"SRL_OPERATOR": "srlinux.yaml"},
+		wantData: `cni:
+  kind: Meshnet
+  spec:
+    manifest: ../manifests/meshnet/grpc/manifest.yaml
+controllers:
+  - kind: SRLinux
+    spec:
+      operator: srlinux.yaml
+  - kind: Lemming
+    spec:
+      operator: ../manifests/controllers/lemming/manifest.yaml
+cluster:
+  kind: Kind
+  spec:
+    name: kne
+    image: kindest/node:v1.26.0
+    googleArtifactRegistries:
+      - us-west1-docker.pkg.dev
+ingress:
+  kind: NodePort
+  spec:
+    address: 10.0.0.1
+`,
+	}, {
+		desc:     "unset environment variable",
+		path:     "testdata/overlay/base.yaml",
+		overlays: []string{"testdata/overlay/overlay.yaml"},
+		wantErr:  "environment variable SRL_OPERATOR not set",
+	}, {
+		desc:     "missing overlay",
+		path:     "testdata/overlay/base.yaml",
+		overlays: []string{"testdata/overlay/missing.yaml"},
+		wantErr:  "no such file",
+	}, {
+		desc:    "include cycle",
+		path:    "testdata/overlay/cycle.yaml",
+		wantErr: "include cycle",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, k := range []string{"KNE_KIND_IMAGE", "KNE_IP_COUNT", "SRL_OPERATOR"} {
+				// Setenv restores the variable when the test is done.
+				t.Setenv(k, tt.env[k])
+				if _, ok := tt.env[k]; !ok {
+					os.Unsetenv(k)
+				}
+			}
+			c, err := load.NewConfig(tt.path, &cmddeploy.DeploymentConfig{}, tt.overlays...)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("NewConfig() unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if s := cmp.Diff(tt.wantData, string(c.Data)); s != "" {
+				t.Errorf("NewConfig() unexpected data diff (-want +got):\n%s", s)
+			}
+			if tt.wantIPCount == 0 {
+				return
+			}
+			c.IgnoreMissingFiles = true
+			d := &deploy.Deployment{}
+			if err := c.Decode(d); err != nil {
+				t.Fatalf("Decode() failed: %v", err)
+			}
+			m, ok := d.Ingress.(*deploy.MetalLBSpec)
+			if !ok {
+				t.Fatalf("Decode() got ingress %T, want *deploy.MetalLBSpec", d.Ingress)
+			}
+			if m.IPCount != tt.wantIPCount {
+				t.Errorf("Decode() got ip_count %d, want %d", m.IPCount, tt.wantIPCount)
+			}
+		})
+	}
+}
+
+func TestNewConfigOverlayDir(t *testing.T) {
+	c, err := load.NewConfig("testdata/overlay/base.yaml", &cmddeploy.DeploymentConfig{}, "testdata/site/lab/overlay.yaml")
+	if err != nil {
+		t.Fatalf("NewConfig() failed: %v", err)
+	}
+	d := &deploy.Deployment{}
+	if err := c.Decode(d); err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	abs := func(path string) string {
+		p, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatalf("Abs(%q) failed: %v", path, err)
+		}
+		return p
+	}
+	got := map[string]string{}
+	for _, ctrl := range d.Controllers {
+		switch ctrl := ctrl.(type) {
+		case *deploy.LemmingSpec:
+			got["Lemming"] = ctrl.Operator
+		case *deploy.SRLinuxSpec:
+			got["SRLinux"] = ctrl.Operator
+		}
+	}
+	want := map[string]string{
+		// Set in the overlay, in testdata/site/lab.
+		"Lemming": abs("testdata/manifests/controllers/lemming/manifest.yaml"),
+		// Set in common.yaml, included by base.yaml in testdata/overlay.
+		"SRLinux": abs("testdata/manifests/controllers/srlinux/manifest.yaml"),
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Decode() unexpected operators (-want +got):\n%s", s)
+	}
+	if got, want := d.CNI.(*deploy.MeshnetSpec).Manifest, abs("testdata/manifests/meshnet/grpc/manifest.yaml"); got != want {
+		t.Errorf("Decode() got CNI manifest %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc     string
+		ipCount  string
+		wantErrs []string
+	}{{
+		desc:    "valid",
+		ipCount: "100",
+	}, {
+		desc:     "invalid ip count",
+		ipCount:  "-1",
+		wantErrs: []string{"ingress.spec: invalid ip_count -1"},
+	}, {
+		desc:    "not a number",
+		ipCount: "many",
+		wantErrs: []string{
+			"ingress.spec: yaml: unmarshal errors:\n  line 21: cannot unmarshal !!str `many` into int",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Setenv("KNE_IP_COUNT", tt.ipCount)
+			c, err := load.NewConfig("testdata/overlay/base.yaml", &cmddeploy.DeploymentConfig{})
+			if err != nil {
+				t.Fatalf("NewConfig() failed: %v", err)
+			}
+			var got []string
+			for _, err := range c.Validate(&deploy.Deployment{}) {
+				got = append(got, err.Error())
+			}
+			if s := cmp.Diff(tt.wantErrs, got, cmpopts.EquateEmpty()); s != "" {
+				t.Errorf("Validate() unexpected errors (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
 // fixStrings does two things for us.  First, it fixes up absolute pathnames so
 // we can compare them.  Second, it records every type of struct we see so we
 // can pass them to cmpopts.IngoreUnexported.  There appears to be no way to
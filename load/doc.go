@@ -22,4 +22,8 @@
 //		...
 //	}
 //	// deployment is now ready for use.
+//
+// Validate is like Decode but reports all the errors in the configuration
+// rather than just the first.  Schema returns a JSON Schema of the
+// configuration, including the specs of all the registered kinds.
 package load
@@ -0,0 +1,306 @@
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolve reads the YAML configuration at path, expands environment
+// variables, and merges in the files it includes.  Relative include paths are
+// resolved relative to dir.  seen holds the files being resolved and is used
+// to detect include cycles.  The directory of the file each scalar value is
+// read from is recorded in dirs.
+func resolve(path, dir string, seen map[string]bool, dirs map[*yaml.Node]string) (*yaml.Node, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if seen[path] {
+		return nil, fmt.Errorf("%s: include cycle", path)
+	}
+	seen[path] = true
+	defer delete(seen, path)
+
+	fp, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	var doc yaml.Node
+	if err := yaml.NewDecoder(fp).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if err := expandEnv(root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	recordDir(root, filepath.Dir(path), dirs)
+	if root.Kind != yaml.MappingNode {
+		return root, nil
+	}
+
+	// The including file is merged on top of the files it includes, in
+	// order.
+	var includes []string
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value != "include" {
+			continue
+		}
+		v := root.Content[i+1]
+		switch v.Kind {
+		case yaml.ScalarNode:
+			includes = []string{v.Value}
+		case yaml.SequenceNode:
+			if err := v.Decode(&includes); err != nil {
+				return nil, fmt.Errorf("%s: include: %w", path, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s: include must be a path or a list of paths", path)
+		}
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		break
+	}
+	if len(includes) == 0 {
+		return root, nil
+	}
+	var base *yaml.Node
+	for _, inc := range includes {
+		n, err := resolve(inc, dir, seen, dirs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		base = merge(base, n)
+	}
+	return merge(base, root), nil
+}
+
+// recordDir records dir as the directory of the scalar values of n in dirs.
+func recordDir(n *yaml.Node, dir string, dirs map[*yaml.Node]string) {
+	if n.Kind == yaml.ScalarNode {
+		dirs[n] = dir
+	}
+	for _, c := range n.Content {
+		recordDir(c, dir, dirs)
+	}
+}
+
+// scalarDirs returns the directories of the scalar values of n that are in
+// dirs by their key, the keys of the mappings and the indices of the
+// sequences leading to them from n.
+func scalarDirs(n *yaml.Node, dirs map[*yaml.Node]string) map[string]string {
+	keyed := map[string]string{}
+	var walk func(n *yaml.Node, key []string)
+	walk = func(n *yaml.Node, key []string) {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			if dir, ok := dirs[n]; ok {
+				keyed[dirKey(key)] = dir
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], append(key, n.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, append(key, strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(n, nil)
+	return keyed
+}
+
+// dirKey returns the key of a value in the map returned by scalarDirs from
+// its YAML path, given as the elements of a path passed to Config.decode,
+// such as "controllers", "[1]", "spec" and "operator".
+func dirKey(path []string) string {
+	key := make([]string, len(path))
+	for i, p := range path {
+		key[i] = strings.TrimSuffix(strings.TrimPrefix(p, "["), "]")
+	}
+	return strings.Join(key, "\x00")
+}
+
+// envRE matches ${NAME} and ${NAME:-default}.
+var envRE = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces ${NAME} in the scalar values of n with the value of the
+// environment variable NAME.  ${NAME:-default} is replaced with default when
+// NAME is unset or empty.  An unset variable without a default is an error.
+// Unquoted values are retyped after expansion, so "${COUNT:-100}" is an int.
+func expandEnv(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if !envRE.MatchString(n.Value) {
+			return nil
+		}
+		var err error
+		n.Value = envRE.ReplaceAllStringFunc(n.Value, func(s string) string {
+			m := envRE.FindStringSubmatch(s)
+			if v := os.Getenv(m[1]); v != "" {
+				return v
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			if _, ok := os.LookupEnv(m[1]); !ok && err == nil {
+				err = fmt.Errorf("environment variable %s not set", m[1])
+			}
+			return ""
+		})
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			n.Tag = ""
+		}
+		return err
+	case yaml.MappingNode:
+		// Only values are expanded, not keys.
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := expandEnv(n.Content[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, c := range n.Content {
+			if err := expandEnv(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// merge returns overlay merged on top of base.  Mappings are merged key by
+// key, except that mappings with different kinds replace each other.
+// Sequences of mappings with a kind, such as the controllers, are merged by
+// kind: an overlay element is merged into the base element of the same kind,
+// or appended if there is none, and an overlay element with "$patch: delete"
+// removes the base element of its kind.  All other values are replaced.
+func merge(base, overlay *yaml.Node) *yaml.Node {
+	switch {
+	case base == nil:
+		return dropDeletes(overlay)
+	case overlay == nil:
+		return base
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		bk, bok := kindOf(base)
+		if ok, ook := kindOf(overlay); bok && ook && bk != ok {
+			return dropDeletes(overlay)
+		}
+		for i := 0; i < len(overlay.Content); i += 2 {
+			key, val := overlay.Content[i], overlay.Content[i+1]
+			if j := mapIndex(base, key.Value); j >= 0 {
+				base.Content[j+1] = merge(base.Content[j+1], val)
+			} else {
+				base.Content = append(base.Content, key, dropDeletes(val))
+			}
+		}
+		return base
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && kinded(base) && kinded(overlay):
+		for _, o := range overlay.Content {
+			k, _ := kindOf(o)
+			idx := -1
+			for j, b := range base.Content {
+				if bk, _ := kindOf(b); bk == k {
+					idx = j
+					break
+				}
+			}
+			switch {
+			case isDelete(o):
+				if idx >= 0 {
+					base.Content = append(base.Content[:idx], base.Content[idx+1:]...)
+				}
+			case idx >= 0:
+				base.Content[idx] = merge(base.Content[idx], o)
+			default:
+				base.Content = append(base.Content, o)
+			}
+		}
+		return base
+	default:
+		return dropDeletes(overlay)
+	}
+}
+
+// dropDeletes removes the "$patch: delete" elements of kinded sequences in n,
+// which have nothing to delete when there is no base.
+func dropDeletes(n *yaml.Node) *yaml.Node {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		var content []*yaml.Node
+		for _, c := range n.Content {
+			if !isDelete(c) {
+				content = append(content, dropDeletes(c))
+			}
+		}
+		n.Content = content
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			n.Content[i] = dropDeletes(n.Content[i])
+		}
+	}
+	return n
+}
+
+// mapIndex returns the index of the key in mapping n, or -1.
+func mapIndex(n *yaml.Node, key string) int {
+	for i := 0; i < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// kindOf returns the value of the kind field of mapping n.
+func kindOf(n *yaml.Node) (string, bool) {
+	if n.Kind != yaml.MappingNode {
+		return "", false
+	}
+	if i := mapIndex(n, "kind"); i >= 0 && n.Content[i+1].Kind == yaml.ScalarNode {
+		return n.Content[i+1].Value, true
+	}
+	return "", false
+}
+
+// kinded reports whether every element of sequence n is a mapping with a kind.
+func kinded(n *yaml.Node) bool {
+	for _, c := range n.Content {
+		if _, ok := kindOf(c); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isDelete reports whether n is a mapping with "$patch: delete".
+func isDelete(n *yaml.Node) bool {
+	if n.Kind != yaml.MappingNode {
+		return false
+	}
+	i := mapIndex(n, "$patch")
+	return i >= 0 && n.Content[i+1].Value == "delete"
+}
+
+// encode returns n as YAML.
+func encode(n *yaml.Node) ([]byte, error) {
+	var b bytes.Buffer
+	e := yaml.NewEncoder(&b)
+	e.SetIndent(2)
+	if err := e.Encode(n); err != nil {
+		return nil, err
+	}
+	if err := e.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,140 @@
+package load
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema returns a JSON Schema of the YAML configuration decoded into the
+// structure config, for use by editors and linters.  Each structure with a
+// kind and a spec field allows the kinds registered with the kne tag matching
+// the YAML name of the structure's field, and the spec is described by the
+// type registered for the kind.  Fields with the kne tag "yaml" are marked
+// with "x-kne": "yaml" as they are paths to YAML files.
+func Schema(config interface{}) ([]byte, error) {
+	specMu.Lock()
+	defer specMu.Unlock()
+	s := typeSchema(reflect.TypeOf(config), "", "")
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// typeSchema returns the schema of a value of type t.  tag is the YAML name of
+// the field the value is in, which selects the registered kinds, and kne is
+// the field's kne tag.
+func typeSchema(t reflect.Type, tag, kne string) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == yamlNodeType:
+		return map[string]interface{}{}
+	case t == durationType:
+		return map[string]interface{}{
+			"type":        []string{"string", "integer"},
+			"description": "A duration, such as 90s or 5m.",
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		s := map[string]interface{}{"type": "string"}
+		if kne == "yaml" {
+			s["x-kne"] = "yaml"
+			s["description"] = "Path of a YAML file, relative to the directory of the configuration."
+		}
+		return s
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), tag, kne),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem(), tag, kne),
+		}
+	case reflect.Struct:
+		return structSchema(t, tag)
+	}
+	// Interfaces, and anything else, can hold any value.
+	return map[string]interface{}{}
+}
+
+// structSchema returns the schema of a structure of type t in the field with
+// YAML name tag.
+func structSchema(t reflect.Type, tag string) map[string]interface{} {
+	props := map[string]interface{}{}
+	hasKind, hasSpec := false, false
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name := yamlName(sf)
+			if name == "" {
+				continue
+			}
+			if _, opts, _ := strings.Cut(sf.Tag.Get("yaml"), ","); strings.Contains(opts, "inline") && sf.Type.Kind() == reflect.Struct {
+				addFields(sf.Type)
+				continue
+			}
+			switch sf.Tag.Get("yaml") {
+			case "kind":
+				hasKind = true
+			case "spec":
+				hasSpec = true
+			}
+			props[name] = typeSchema(sf.Type, name, sf.Tag.Get("kne"))
+		}
+	}
+	addFields(t)
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if !hasKind || !hasSpec {
+		return s
+	}
+
+	// The spec is described by the type registered for the kind.
+	var kinds []string
+	for k, st := range specs {
+		if st.Tag == tag {
+			kinds = append(kinds, k)
+		}
+	}
+	sort.Strings(kinds)
+	var cases []interface{}
+	for _, k := range kinds {
+		spec := typeSchema(reflect.TypeOf(specs[k].Type), "", "")
+		cases = append(cases, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"kind": map[string]interface{}{"const": k},
+				},
+			},
+			"then": map[string]interface{}{
+				"properties": map[string]interface{}{"spec": spec},
+			},
+		})
+	}
+	s["required"] = []string{"kind"}
+	if len(kinds) > 0 {
+		props["kind"] = map[string]interface{}{"type": "string", "enum": kinds}
+		s["allOf"] = cases
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package load_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/kne/load"
+	"gopkg.in/yaml.v3"
+)
+
+type widgetSpec struct {
+	Name     string            `yaml:"name"`
+	Count    uint              `yaml:"count"`
+	Wait     time.Duration     `yaml:"wait"`
+	Manifest string            `yaml:"manifest" kne:"yaml"`
+	Labels   map[string]string `yaml:"labels"`
+	internal bool
+}
+
+type widgetConfig struct {
+	Widgets []struct {
+		Kind string    `yaml:"kind"`
+		Spec yaml.Node `yaml:"spec"`
+	} `yaml:"widgets"`
+	Debug bool `yaml:"debug"`
+}
+
+func TestSchema(t *testing.T) {
+	load.Register("Widget", &load.Spec{
+		Type: widgetSpec{},
+		Tag:  "widgets",
+	})
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "debug": {"type": "boolean"},
+    "widgets": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["kind"],
+        "properties": {
+          "kind": {"type": "string", "enum": ["Widget"]},
+          "spec": {}
+        },
+        "allOf": [{
+          "if": {"properties": {"kind": {"const": "Widget"}}},
+          "then": {"properties": {"spec": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string"},
+              "count": {"type": "integer", "minimum": 0},
+              "wait": {"type": ["string", "integer"], "description": "A duration, such as 90s or 5m."},
+              "manifest": {"type": "string", "x-kne": "yaml", "description": "Path of a YAML file, relative to the directory of the configuration."},
+              "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+            }
+          }}}
+        }]
+      }
+    }
+  }
+}`
+	b, err := load.Schema(&widgetConfig{})
+	if err != nil {
+		t.Fatalf("Schema() failed: %v", err)
+	}
+	var got, wantSchema interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Schema() returned invalid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantSchema); err != nil {
+		t.Fatalf("invalid want JSON: %v", err)
+	}
+	if s := cmp.Diff(wantSchema, got); s != "" {
+		t.Errorf("Schema() unexpected diff (-want +got):\n%s", s)
+	}
+}
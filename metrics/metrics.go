@@ -0,0 +1,241 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics collects Prometheus metrics for KNE.  It records the latency
+// and errors of controller RPCs, the duration of topology and node operations
+// labeled by vendor and model, the number of topologies and nodes by state, and
+// container restart counts of pods.
+//
+// The metrics are kept in Registry and are exposed over HTTP by Handler and
+// Serve.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/openconfig/kne/pods"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes"
+	log "k8s.io/klog/v2"
+)
+
+const namespace = "kne"
+
+// An Operation is an operation performed on a topology or a node.
+type Operation string
+
+const (
+	OpCreate Operation = "create"
+	OpDelete Operation = "delete"
+	OpPush   Operation = "push"
+	OpReset  Operation = "reset"
+)
+
+// Registry contains all the KNE metrics as well as the standard Go and process
+// collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Latency of TopologyManager RPCs.",
+		Buckets:   []float64{.01, .1, .5, 1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	}, []string{"method", "code"})
+	rpcErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Number of TopologyManager RPCs that returned an error.",
+	}, []string{"method", "code"})
+	topologyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "topology_operation_duration_seconds",
+		Help:      "Duration of operations on a whole topology.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 2400},
+	}, []string{"operation"})
+	topologyErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "topology_operation_errors_total",
+		Help:      "Number of failed operations on a whole topology.",
+	}, []string{"operation"})
+	nodeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "node_operation_duration_seconds",
+		Help:      "Duration of operations on a single node.",
+		Buckets:   []float64{.1, .5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"operation", "vendor", "model"})
+	nodeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_operation_errors_total",
+		Help:      "Number of failed operations on a single node.",
+	}, []string{"operation", "vendor", "model"})
+	topologies = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "topologies",
+		Help:      "Number of topologies currently managed.",
+	})
+	nodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "topology_nodes",
+		Help:      "Number of nodes in a topology by state.",
+	}, []string{"topology", "state"})
+	restarts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pod_container_restarts",
+		Help:      "Number of times a container in a pod has restarted.",
+	}, []string{"namespace", "pod", "container"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcDuration,
+		rpcErrors,
+		topologyDuration,
+		topologyErrors,
+		nodeDuration,
+		nodeErrors,
+		topologies,
+		nodes,
+		restarts,
+	)
+}
+
+// ObserveTopology records the duration of operation op on a topology that
+// started at start.  If err is not nil the operation is counted as failed.
+func ObserveTopology(op Operation, start time.Time, err error) {
+	topologyDuration.WithLabelValues(string(op)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		topologyErrors.WithLabelValues(string(op)).Inc()
+	}
+}
+
+// ObserveNode records the duration of operation op on a node of the provided
+// vendor and model that started at start.  If err is not nil the operation is
+// counted as failed.
+func ObserveNode(op Operation, vendor, model string, start time.Time, err error) {
+	nodeDuration.WithLabelValues(string(op), vendor, model).Observe(time.Since(start).Seconds())
+	if err != nil {
+		nodeErrors.WithLabelValues(string(op), vendor, model).Inc()
+	}
+}
+
+// SetTopologies sets the number of topologies currently managed.
+func SetTopologies(n int) {
+	topologies.Set(float64(n))
+}
+
+// SetNodeStates replaces the node counts of topology with counts, which maps
+// a node state to the number of nodes in that state.
+func SetNodeStates(topology string, counts map[string]int) {
+	nodes.DeletePartialMatch(prometheus.Labels{"topology": topology})
+	for state, n := range counts {
+		nodes.WithLabelValues(topology, state).Set(float64(n))
+	}
+}
+
+// DeleteTopology removes all the node counts of topology and the restart
+// counts of the containers in its namespace.
+func DeleteTopology(topology string) {
+	nodes.DeletePartialMatch(prometheus.Labels{"topology": topology})
+	restarts.DeletePartialMatch(prometheus.Labels{"namespace": topology})
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor that records the latency
+// and errors of each unary RPC.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err).String()
+		rpcDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+		if err != nil {
+			rpcErrors.WithLabelValues(info.FullMethod, code).Inc()
+		}
+		return resp, err
+	}
+}
+
+// WatchPodRestarts records the container restart counts of the pods in
+// namespace, or all namespaces if namespace is empty, until ctx is canceled.
+func WatchPodRestarts(ctx context.Context, client kubernetes.Interface, namespace string) error {
+	ch, stop, err := pods.WatchPodStatus(ctx, client, namespace)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s, ok := <-ch:
+				if !ok {
+					return
+				}
+				recordRestarts(s)
+			}
+		}
+	}()
+	return nil
+}
+
+// recordRestarts sets the restart counts of the containers in s.
+func recordRestarts(s *pods.PodStatus) {
+	for _, cs := range [][]pods.ContainerStatus{s.Containers, s.InitContainers} {
+		for _, c := range cs {
+			if c.Raw == nil {
+				continue
+			}
+			restarts.WithLabelValues(s.Namespace, s.Name, c.Name).Set(float64(c.Raw.RestartCount))
+		}
+	}
+}
+
+// Handler returns an http.Handler that serves the metrics in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Serve serves Handler on /metrics at addr until ctx is canceled.  Serve
+// returns once the listener has been created.
+func Serve(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	s := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	go func() {
+		log.Infof("Serving metrics at %v/metrics", lis.Addr())
+		if err := s.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warningf("Metrics server failed: %v", err)
+		}
+	}()
+	return nil
+}
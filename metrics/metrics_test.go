@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/kne/pods"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktest "k8s.io/client-go/testing"
+)
+
+func TestObserveNode(t *testing.T) {
+	nodeDuration.Reset()
+	nodeErrors.Reset()
+	start := time.Now()
+	ObserveNode(OpPush, "NOKIA", "ixr10", start, nil)
+	ObserveNode(OpPush, "NOKIA", "ixr10", start, errors.New("failed"))
+	ObserveNode(OpCreate, "ARISTA", "ceos", start, nil)
+
+	if got := testutil.CollectAndCount(nodeDuration); got != 2 {
+		t.Errorf("node duration series: got %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(nodeErrors.WithLabelValues("push", "NOKIA", "ixr10")); got != 1 {
+		t.Errorf("push errors: got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(nodeErrors.WithLabelValues("create", "ARISTA", "ceos")); got != 0 {
+		t.Errorf("create errors: got %v, want 0", got)
+	}
+}
+
+func TestObserveTopology(t *testing.T) {
+	topologyDuration.Reset()
+	topologyErrors.Reset()
+	ObserveTopology(OpCreate, time.Now(), nil)
+	ObserveTopology(OpDelete, time.Now(), errors.New("failed"))
+	if got := testutil.CollectAndCount(topologyDuration); got != 2 {
+		t.Errorf("topology duration series: got %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(topologyErrors.WithLabelValues("delete")); got != 1 {
+		t.Errorf("delete errors: got %v, want 1", got)
+	}
+}
+
+func TestNodeStates(t *testing.T) {
+	nodes.Reset()
+	SetNodeStates("t1", map[string]int{"RUNNING": 2, "PENDING": 1})
+	SetNodeStates("t2", map[string]int{"FAILED": 1})
+	if got := testutil.ToFloat64(nodes.WithLabelValues("t1", "RUNNING")); got != 2 {
+		t.Errorf("t1 RUNNING: got %v, want 2", got)
+	}
+	// Replacing the states of t1 should drop the PENDING series.
+	SetNodeStates("t1", map[string]int{"RUNNING": 3})
+	if got := testutil.CollectAndCount(nodes); got != 2 {
+		t.Errorf("node series after update: got %d, want 2", got)
+	}
+	restarts.Reset()
+	restarts.WithLabelValues("t1", "r1", "r1").Set(1)
+	restarts.WithLabelValues("t2", "r1", "r1").Set(1)
+	DeleteTopology("t1")
+	if got := testutil.CollectAndCount(nodes); got != 1 {
+		t.Errorf("node series after delete: got %d, want 1", got)
+	}
+	if got := testutil.CollectAndCount(restarts); got != 1 {
+		t.Errorf("restart series after delete: got %d, want 1", got)
+	}
+	SetTopologies(4)
+	if got := testutil.ToFloat64(topologies); got != 4 {
+		t.Errorf("topologies: got %v, want 4", got)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	rpcDuration.Reset()
+	rpcErrors.Reset()
+	i := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/controller.TopologyManager/ShowTopology"}
+	ok := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	fail := func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	}
+	if resp, err := i(context.Background(), nil, info, ok); err != nil || resp != "ok" {
+		t.Fatalf("interceptor: got (%v, %v), want (ok, nil)", resp, err)
+	}
+	if _, err := i(context.Background(), nil, info, fail); status.Code(err) != codes.NotFound {
+		t.Fatalf("interceptor: got err %v, want NotFound", err)
+	}
+	if got := testutil.CollectAndCount(rpcDuration); got != 2 {
+		t.Errorf("rpc duration series: got %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(rpcErrors.WithLabelValues(info.FullMethod, "NotFound")); got != 1 {
+		t.Errorf("rpc errors: got %v, want 1", got)
+	}
+}
+
+type fakeWatch struct {
+	ch chan watch.Event
+}
+
+func (f *fakeWatch) Stop()                          {}
+func (f *fakeWatch) ResultChan() <-chan watch.Event { return f.ch }
+
+func TestWatchPodRestarts(t *testing.T) {
+	restarts.Reset()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "ns", UID: "1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "r1",
+				RestartCount: 3,
+			}},
+		},
+	}
+	fw := &fakeWatch{ch: make(chan watch.Event, 1)}
+	client := kfake.NewSimpleClientset()
+	client.PrependWatchReactor("pods", func(ktest.Action) (bool, watch.Interface, error) {
+		return true, fw, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := WatchPodRestarts(ctx, client, "ns"); err != nil {
+		t.Fatalf("WatchPodRestarts() failed: %v", err)
+	}
+	fw.ch <- watch.Event{Type: watch.Modified, Object: pod}
+	close(fw.ch)
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.CollectAndCount(restarts) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("restart count not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := testutil.ToFloat64(restarts.WithLabelValues("ns", "r1", "r1")); got != 3 {
+		t.Errorf("restarts: got %v, want 3", got)
+	}
+}
+
+func TestRecordRestartsNoRaw(t *testing.T) {
+	restarts.Reset()
+	recordRestarts(&pods.PodStatus{
+		Name:       "p",
+		Namespace:  "ns",
+		Containers: []pods.ContainerStatus{{Name: "c"}},
+	})
+	if got := testutil.CollectAndCount(restarts); got != 0 {
+		t.Errorf("restart series: got %d, want 0", got)
+	}
+}
+
+func TestRecordRestartsSharedSlice(t *testing.T) {
+	restarts.Reset()
+	containers := make([]pods.ContainerStatus, 1, 2)
+	containers[0] = pods.ContainerStatus{Name: "c", Raw: &corev1.ContainerStatus{RestartCount: 1}}
+	recordRestarts(&pods.PodStatus{
+		Name:           "p",
+		Namespace:      "ns",
+		Containers:     containers,
+		InitContainers: []pods.ContainerStatus{{Name: "init", Raw: &corev1.ContainerStatus{RestartCount: 2}}},
+	})
+	if got := testutil.CollectAndCount(restarts); got != 2 {
+		t.Errorf("restart series: got %d, want 2", got)
+	}
+	if got := containers[:2][1]; got.Name != "" {
+		t.Errorf("recordRestarts() wrote %q into the spare capacity of the containers", got.Name)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	SetTopologies(1)
+	s := httptest.NewServer(Handler())
+	defer s.Close()
+	resp, err := http.Get(s.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	for _, want := range []string{"kne_topologies 1", "go_goroutines"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := Serve(ctx, "localhost:0"); err != nil {
+		t.Fatalf("Serve() failed: %v", err)
+	}
+	if err := Serve(ctx, "bad address"); err == nil {
+		t.Fatalf("Serve() with bad address succeeded")
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pipeline runs the steps that bring up a lab end to end: deploying
+// the cluster, building and loading images, creating the topology, applying
+// manifests and running tests.  Rather than sleeping, steps wait for the
+// conditions they depend on, such as pods being ready or a service getting
+// a load balancer address.
+//
+// A pipeline is a YAML file:
+//
+//	name: bookinfo
+//	env:
+//	  TOPOLOGY: out/topo.pbtxt
+//	timeout: 10m
+//	steps:
+//	- name: cleanup
+//	  delete: ${TOPOLOGY}
+//	  ignore_error: true
+//	- name: build egress
+//	  run: [docker, build, -t, egress, ./egress]
+//	- load_image: egress:latest
+//	- create: ${TOPOLOGY}
+//	- apply: bookinfo.yaml
+//	- name: routers loaded
+//	  wait:
+//	    resource:
+//	      group: kne.srlinux.dev
+//	      version: v1alpha1
+//	      resource: srlinuxes
+//	      field: status.status
+//	      value: loaded
+//	- wait:
+//	    pods: {namespace: default}
+//	- wait:
+//	    service:
+//	      namespace: istio-system
+//	      name: istio-ingressgateway
+//	      env: INGRESS_HOST
+//	- name: tests
+//	  run: [go, test, ./tests/..., -args, -ingress=${INGRESS_HOST}]
+//
+// Steps are run in order and a failed step stops the pipeline unless it sets
+// ignore_error.  Variables in steps are expanded from the env of the
+// pipeline, the variables set by earlier steps and then the environment.
+// Relative file names are relative to the directory of the pipeline file,
+// while commands are run in the current directory.
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a step.
+	DefaultTimeout = 10 * time.Minute
+	// DefaultInterval is the default interval between the checks of a wait.
+	DefaultInterval = 2 * time.Second
+	// DefaultCluster is the default kind cluster images are loaded into.
+	DefaultCluster = "kne"
+)
+
+// A Pipeline is a sequence of steps.
+type Pipeline struct {
+	Name string `yaml:"name,omitempty"`
+	// Env are variables set in the environment of commands and expanded in
+	// steps.
+	Env map[string]string `yaml:"env,omitempty"`
+	// Cluster is the kind cluster images are loaded into, DefaultCluster if
+	// not set.
+	Cluster string `yaml:"cluster,omitempty"`
+	// Timeout is the timeout of steps that do not set their own,
+	// DefaultTimeout if not set.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Interval is the interval between the checks of waits, DefaultInterval
+	// if not set.
+	Interval time.Duration `yaml:"interval,omitempty"`
+	Steps    []*Step       `yaml:"steps"`
+
+	// dir is the directory relative file names are relative to.
+	dir string
+}
+
+// A Step is one of a command, a kne or kubectl action, or a wait.  Exactly
+// one of them must be set.
+type Step struct {
+	// Name is the name of the step in the summary, its kind and argument if
+	// not set.
+	Name string `yaml:"name,omitempty"`
+	// Run is a command and its arguments.
+	Run []string `yaml:"run,omitempty"`
+	// Deploy is a deployment yaml deployed with kne deploy.
+	Deploy string `yaml:"deploy,omitempty"`
+	// Create and Delete are a topology created with kne create or deleted
+	// with kne delete.
+	Create string `yaml:"create,omitempty"`
+	Delete string `yaml:"delete,omitempty"`
+	// Apply is a manifest, a directory of manifests or a URL applied with
+	// kubectl apply.
+	Apply string `yaml:"apply,omitempty"`
+	// LoadImage is a docker image loaded into the kind cluster of the
+	// pipeline.
+	LoadImage string `yaml:"load_image,omitempty"`
+	Wait      *Wait  `yaml:"wait,omitempty"`
+	// Timeout overrides the timeout of the pipeline.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// IgnoreError continues the pipeline if the step fails.
+	IgnoreError bool `yaml:"ignore_error,omitempty"`
+}
+
+// Kind returns the kind of s: run, deploy, create, delete, apply, load_image
+// or wait.
+func (s *Step) Kind() string {
+	switch {
+	case len(s.Run) > 0:
+		return "run"
+	case s.Deploy != "":
+		return "deploy"
+	case s.Create != "":
+		return "create"
+	case s.Delete != "":
+		return "delete"
+	case s.Apply != "":
+		return "apply"
+	case s.LoadImage != "":
+		return "load_image"
+	case s.Wait != nil:
+		return "wait"
+	}
+	return ""
+}
+
+// kinds returns the number of kinds set in s.
+func (s *Step) kinds() int {
+	n := 0
+	for _, set := range []bool{len(s.Run) > 0, s.Deploy != "", s.Create != "", s.Delete != "", s.Apply != "", s.LoadImage != "", s.Wait != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// title returns the name of s in the summary.
+func (s *Step) title() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	switch s.Kind() {
+	case "run":
+		return s.Run[0]
+	case "deploy":
+		return "deploy " + s.Deploy
+	case "create":
+		return "create " + s.Create
+	case "delete":
+		return "delete " + s.Delete
+	case "apply":
+		return "apply " + s.Apply
+	case "load_image":
+		return "load " + s.LoadImage
+	}
+	return "wait " + s.Wait.kind()
+}
+
+// A Wait waits for a condition of the cluster.  Exactly one condition must
+// be set.
+type Wait struct {
+	// Nodes waits for all the nodes of the cluster to be ready.
+	Nodes    bool               `yaml:"nodes,omitempty"`
+	Pods     *PodsCondition     `yaml:"pods,omitempty"`
+	Service  *ServiceCondition  `yaml:"service,omitempty"`
+	Resource *ResourceCondition `yaml:"resource,omitempty"`
+}
+
+func (w *Wait) kind() string {
+	switch {
+	case w.Nodes:
+		return "nodes"
+	case w.Pods != nil:
+		return "pods"
+	case w.Service != nil:
+		return "service"
+	case w.Resource != nil:
+		return "resource"
+	}
+	return ""
+}
+
+func (w *Wait) conditions() int {
+	n := 0
+	for _, set := range []bool{w.Nodes, w.Pods != nil, w.Service != nil, w.Resource != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// A PodsCondition is met when there are pods and all of them are ready, or
+// have completed.
+type PodsCondition struct {
+	// Namespace is the namespace of the pods, all namespaces if not set.
+	Namespace string `yaml:"namespace,omitempty"`
+	// Selector is a label selector of the pods, such as app=productpage.
+	Selector string `yaml:"selector,omitempty"`
+}
+
+// A ServiceCondition is met when a service has a load balancer address.
+type ServiceCondition struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	// Env is a variable set to the address for later steps, if set.
+	Env string `yaml:"env,omitempty"`
+}
+
+// A ResourceCondition is met when the field of resources, such as the
+// status of vendor nodes, has a value.  By default all the resources, and
+// at least one, must match; with Count set at least Count must match.
+type ResourceCondition struct {
+	Group    string `yaml:"group,omitempty"`
+	Version  string `yaml:"version"`
+	Resource string `yaml:"resource"`
+	// Namespace is the namespace of the resources, all namespaces if not
+	// set.
+	Namespace string `yaml:"namespace,omitempty"`
+	// Field is the dot separated path of the field, such as status.status.
+	Field string `yaml:"field"`
+	// Value is the value of the field, any value but "" if not set.
+	Value string `yaml:"value,omitempty"`
+	Count int    `yaml:"count,omitempty"`
+}
+
+// Load returns the pipeline in the YAML file at path.  Relative file names
+// in the pipeline are relative to the directory of path.
+func Load(path string) (*Pipeline, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.dir = filepath.Dir(path)
+	return p, nil
+}
+
+// Parse returns the pipeline in the YAML b.  Unknown fields are an error.
+func Parse(b []byte) (*Pipeline, error) {
+	p := &Pipeline{}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(p); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Pipeline) validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("no steps")
+	}
+	if p.Timeout < 0 || p.Interval < 0 {
+		return fmt.Errorf("timeout and interval must not be negative")
+	}
+	for i, s := range p.Steps {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("steps[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (s *Step) validate() error {
+	switch s.kinds() {
+	case 0:
+		return fmt.Errorf("no run, deploy, create, delete, apply, load_image or wait")
+	case 1:
+	default:
+		return fmt.Errorf("more than one of run, deploy, create, delete, apply, load_image and wait")
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if s.Wait == nil {
+		return nil
+	}
+	w := s.Wait
+	if w.conditions() != 1 {
+		return fmt.Errorf("wait must have exactly one of nodes, pods, service and resource")
+	}
+	switch {
+	case w.Service != nil && (w.Service.Namespace == "" || w.Service.Name == ""):
+		return fmt.Errorf("wait service must have namespace and name")
+	case w.Resource != nil && (w.Resource.Version == "" || w.Resource.Resource == "" || w.Resource.Field == ""):
+		return fmt.Errorf("wait resource must have version, resource and field")
+	case w.Resource != nil && w.Resource.Count < 0:
+		return fmt.Errorf("wait resource count must not be negative")
+	}
+	return nil
+}
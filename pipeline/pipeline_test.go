@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/h-fam/errdiff"
+)
+
+func TestLoad(t *testing.T) {
+	p, err := Load("testdata/lab.yaml")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := &Pipeline{
+		Name:    "lab",
+		Env:     map[string]string{"TOPOLOGY": "topo.pbtxt"},
+		Timeout: 5 * time.Minute,
+		Steps: []*Step{
+			{Name: "cleanup", Delete: "${TOPOLOGY}", IgnoreError: true},
+			{Create: "${TOPOLOGY}"},
+			{Apply: "https://example.com/app.yaml"},
+			{Wait: &Wait{Pods: &PodsCondition{Namespace: "default"}}, Timeout: time.Minute},
+		},
+		dir: "testdata",
+	}
+	if s := cmp.Diff(want, p, cmp.AllowUnexported(Pipeline{})); s != "" {
+		t.Errorf("Load() unexpected diff (-want +got):\n%s", s)
+	}
+	if _, err := Load("testdata/missing.yaml"); err == nil {
+		t.Errorf("Load() of missing file succeeded")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		want    *Pipeline
+		wantErr string
+	}{{
+		desc: "all kinds",
+		in: `
+steps:
+- run: [docker, build, -t, egress, .]
+- deploy: kind.yaml
+- load_image: egress:latest
+- wait: {nodes: true}
+- wait:
+    service: {namespace: istio-system, name: ingress, env: INGRESS_HOST}
+- wait:
+    resource: {group: kne.srlinux.dev, version: v1alpha1, resource: srlinuxes, field: status.status, value: loaded, count: 3}
+`,
+		want: &Pipeline{Steps: []*Step{
+			{Run: []string{"docker", "build", "-t", "egress", "."}},
+			{Deploy: "kind.yaml"},
+			{LoadImage: "egress:latest"},
+			{Wait: &Wait{Nodes: true}},
+			{Wait: &Wait{Service: &ServiceCondition{Namespace: "istio-system", Name: "ingress", Env: "INGRESS_HOST"}}},
+			{Wait: &Wait{Resource: &ResourceCondition{Group: "kne.srlinux.dev", Version: "v1alpha1", Resource: "srlinuxes", Field: "status.status", Value: "loaded", Count: 3}}},
+		}},
+	}, {
+		desc:    "no steps",
+		in:      "name: empty\n",
+		wantErr: "no steps",
+	}, {
+		desc:    "unknown field",
+		in:      "steps:\n- shell: ls\n",
+		wantErr: "field shell not found",
+	}, {
+		desc:    "empty step",
+		in:      "steps:\n- name: nothing\n",
+		wantErr: "steps[0]: no run",
+	}, {
+		desc:    "two kinds",
+		in:      "steps:\n- create: a.pbtxt\n  apply: b.yaml\n",
+		wantErr: "steps[0]: more than one",
+	}, {
+		desc:    "negative timeout",
+		in:      "steps:\n- create: a.pbtxt\n  timeout: -1s\n",
+		wantErr: "timeout must not be negative",
+	}, {
+		desc:    "empty wait",
+		in:      "steps:\n- wait: {}\n",
+		wantErr: "exactly one of nodes",
+	}, {
+		desc:    "two conditions",
+		in:      "steps:\n- wait: {nodes: true, pods: {}}\n",
+		wantErr: "exactly one of nodes",
+	}, {
+		desc:    "service without name",
+		in:      "steps:\n- wait:\n    service: {namespace: default}\n",
+		wantErr: "must have namespace and name",
+	}, {
+		desc:    "resource without field",
+		in:      "steps:\n- wait:\n    resource: {version: v1, resource: pods}\n",
+		wantErr: "must have version, resource and field",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Parse([]byte(tt.in))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Parse() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(Pipeline{})); s != "" {
+				t.Errorf("Parse() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		step *Step
+		want string
+	}{
+		{&Step{Name: "tests", Run: []string{"go", "test"}}, "tests"},
+		{&Step{Run: []string{"go", "test"}}, "go"},
+		{&Step{Create: "topo.pbtxt"}, "create topo.pbtxt"},
+		{&Step{LoadImage: "egress:latest"}, "load egress:latest"},
+		{&Step{Wait: &Wait{Pods: &PodsCondition{}}}, "wait pods"},
+	}
+	for _, tt := range tests {
+		if got := tt.step.title(); got != tt.want {
+			t.Errorf("title(%+v) got %q, want %q", tt.step, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openconfig/kne/exec"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	log "k8s.io/klog/v2"
+)
+
+// Status is the status of a step after a run.
+type Status string
+
+const (
+	// Passed steps succeeded.
+	Passed = Status("passed")
+	// Failed steps failed and stopped the pipeline.
+	Failed = Status("failed")
+	// Ignored steps failed but set ignore_error.
+	Ignored = Status("ignored")
+	// Skipped steps were not run because an earlier step failed.
+	Skipped = Status("skipped")
+)
+
+// A Result is the result of running a step.
+type Result struct {
+	Step     string
+	Kind     string
+	Status   Status
+	Duration time.Duration
+	// Detail is the outcome of waits, such as the address of a service, or
+	// the error of failed steps.
+	Detail string
+}
+
+// A Runner runs pipelines.
+type Runner struct {
+	// Kubecfg is the kubeconfig file of the cluster, the default of kne and
+	// kubectl if not set.
+	Kubecfg string
+	// KNE is the kne binary run by deploy, create and delete steps, "kne"
+	// if not set.
+	KNE string
+	// Stdout and Stderr receive the output of commands, os.Stdout and
+	// os.Stderr if not set.
+	Stdout io.Writer
+	Stderr io.Writer
+	// KClient and DClient are the clients of waits, created from Kubecfg
+	// when first needed if not set.
+	KClient kubernetes.Interface
+	DClient dynamic.Interface
+
+	env map[string]string
+}
+
+// Run runs the steps of p in order and returns their results.  The error is
+// that of the first failed step, after which the remaining steps are
+// skipped.
+func (r *Runner) Run(ctx context.Context, p *Pipeline) ([]*Result, error) {
+	r.env = map[string]string{}
+	for k, v := range p.Env {
+		r.env[k] = v
+	}
+	var results []*Result
+	var failed error
+	for i, s := range p.Steps {
+		res := &Result{Step: r.expand(s.title()), Kind: s.Kind(), Status: Skipped}
+		results = append(results, res)
+		if failed != nil {
+			continue
+		}
+		log.Infof("Step %d/%d: %s", i+1, len(p.Steps), res.Step)
+		start := time.Now()
+		detail, err := r.runStep(ctx, p, s)
+		res.Duration = time.Since(start)
+		res.Detail = detail
+		switch {
+		case err == nil:
+			res.Status = Passed
+		case s.IgnoreError:
+			log.Warningf("Step %s failed, ignored: %v", res.Step, err)
+			res.Status = Ignored
+			res.Detail = err.Error()
+		default:
+			res.Status = Failed
+			res.Detail = err.Error()
+			failed = fmt.Errorf("step %q: %w", res.Step, err)
+		}
+	}
+	return results, failed
+}
+
+// runStep runs s, within its timeout, and returns the detail of its result.
+func (r *Runner) runStep(ctx context.Context, p *Pipeline, s *Step) (string, error) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = p.Timeout
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	kne := r.KNE
+	if kne == "" {
+		kne = "kne"
+	}
+	var kneFlags, kubectlFlags []string
+	if r.Kubecfg != "" {
+		kneFlags = []string{"--kubecfg", r.Kubecfg}
+		kubectlFlags = []string{"--kubeconfig", r.Kubecfg}
+	}
+	switch s.Kind() {
+	case "run":
+		var args []string
+		for _, a := range s.Run {
+			args = append(args, r.expand(a))
+		}
+		return "", r.command(ctx, args...)
+	case "deploy":
+		return "", r.command(ctx, append([]string{kne, "deploy", r.path(p, s.Deploy)}, kneFlags...)...)
+	case "create":
+		return "", r.command(ctx, append([]string{kne, "create", r.path(p, s.Create)}, kneFlags...)...)
+	case "delete":
+		return "", r.command(ctx, append([]string{kne, "delete", r.path(p, s.Delete)}, kneFlags...)...)
+	case "apply":
+		return "", r.command(ctx, append([]string{"kubectl", "apply", "-f", r.path(p, s.Apply)}, kubectlFlags...)...)
+	case "load_image":
+		cluster := p.Cluster
+		if cluster == "" {
+			cluster = DefaultCluster
+		}
+		return "", r.command(ctx, "kind", "load", "docker-image", r.expand(s.LoadImage), "--name", cluster)
+	}
+	interval := p.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	return r.wait(ctx, s.Wait, interval)
+}
+
+// expand returns s with its variables replaced by those of the pipeline, or
+// else of the environment.
+func (r *Runner) expand(s string) string {
+	return os.Expand(s, func(k string) string {
+		if v, ok := r.env[k]; ok {
+			return v
+		}
+		return os.Getenv(k)
+	})
+}
+
+// path returns the expanded file name f, relative to the directory of p if
+// not absolute or a URL.
+func (r *Runner) path(p *Pipeline, f string) string {
+	f = r.expand(f)
+	if p.dir == "" || filepath.IsAbs(f) || strings.Contains(f, "://") {
+		return f
+	}
+	return filepath.Join(p.dir, f)
+}
+
+// command runs args with the variables of the pipeline in its environment.
+func (r *Runner) command(ctx context.Context, args ...string) error {
+	var env []string
+	for k, v := range r.env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	c := exec.CommandContext(ctx, args[0], args[1:]...)
+	c.SetEnv(env)
+	if r.Stdout != nil {
+		c.SetStdout(r.Stdout)
+	}
+	if r.Stderr != nil {
+		c.SetStderr(r.Stderr)
+	}
+	return c.Run()
+}
+
+// A check returns whether a condition is met and a description of the state
+// it checked.
+type check func(ctx context.Context) (bool, string, error)
+
+// wait checks the condition of w every interval until it is met or ctx is
+// done, and returns the description of the last check.
+func (r *Runner) wait(ctx context.Context, w *Wait, interval time.Duration) (string, error) {
+	if err := r.clients(); err != nil {
+		return "", err
+	}
+	var c check
+	switch w.kind() {
+	case "nodes":
+		c = r.nodesReady
+	case "pods":
+		c = r.podsReady(r.expand(w.Pods.Namespace), r.expand(w.Pods.Selector))
+	case "service":
+		c = r.serviceAddress(r.expand(w.Service.Namespace), r.expand(w.Service.Name), w.Service.Env)
+	case "resource":
+		rc := *w.Resource
+		for _, f := range []*string{&rc.Group, &rc.Version, &rc.Resource, &rc.Namespace, &rc.Value} {
+			*f = r.expand(*f)
+		}
+		c = r.resourceField(&rc)
+	}
+	for {
+		ok, detail, err := c(ctx)
+		if err != nil {
+			detail = err.Error()
+		}
+		if ok {
+			return detail, nil
+		}
+		log.V(1).Infof("Waiting for %s: %s", w.kind(), detail)
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", fmt.Errorf("timed out waiting for %s: %s", w.kind(), detail)
+			}
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// clients creates the clients of r that are not set.
+func (r *Runner) clients() error {
+	if r.KClient != nil && r.DClient != nil {
+		return nil
+	}
+	rCfg, err := clientcmd.BuildConfigFromFlags("", r.Kubecfg)
+	if err != nil {
+		return err
+	}
+	if r.KClient == nil {
+		if r.KClient, err = kubernetes.NewForConfig(rCfg); err != nil {
+			return err
+		}
+	}
+	if r.DClient == nil {
+		if r.DClient, err = dynamic.NewForConfig(rCfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) nodesReady(ctx context.Context) (bool, string, error) {
+	nodes, err := r.KClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, "", err
+	}
+	ready := 0
+	for _, n := range nodes.Items {
+		for _, c := range n.Status.Conditions {
+			if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	return ready > 0 && ready == len(nodes.Items), fmt.Sprintf("%d of %d nodes ready", ready, len(nodes.Items)), nil
+}
+
+func (r *Runner) podsReady(namespace, selector string) check {
+	return func(ctx context.Context) (bool, string, error) {
+		pods, err := r.KClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, "", err
+		}
+		ready := 0
+		for _, p := range pods.Items {
+			if podReady(&p) {
+				ready++
+			}
+		}
+		return ready > 0 && ready == len(pods.Items), fmt.Sprintf("%d of %d pods ready", ready, len(pods.Items)), nil
+	}
+}
+
+// podReady returns whether p has completed, or is running with all its
+// containers ready.
+func podReady(p *corev1.Pod) bool {
+	switch {
+	case p.Status.Phase == corev1.PodSucceeded:
+		return true
+	case p.Status.Phase != corev1.PodRunning || p.DeletionTimestamp != nil:
+		return false
+	}
+	for _, c := range p.Status.ContainerStatuses {
+		if !c.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// serviceAddress checks for the load balancer address of a service and
+// sets the variable env to it, if env is set.
+func (r *Runner) serviceAddress(namespace, name, env string) check {
+	return func(ctx context.Context) (bool, string, error) {
+		s, err := r.KClient.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		for _, in := range s.Status.LoadBalancer.Ingress {
+			addr := in.IP
+			if addr == "" {
+				addr = in.Hostname
+			}
+			if addr == "" {
+				continue
+			}
+			if env == "" {
+				return true, "address " + addr, nil
+			}
+			r.env[env] = addr
+			return true, env + "=" + addr, nil
+		}
+		return false, fmt.Sprintf("service %s/%s has no load balancer address", namespace, name), nil
+	}
+}
+
+func (r *Runner) resourceField(rc *ResourceCondition) check {
+	gvr := schema.GroupVersionResource{Group: rc.Group, Version: rc.Version, Resource: rc.Resource}
+	path := strings.Split(rc.Field, ".")
+	want := rc.Field + "=" + rc.Value
+	if rc.Value == "" {
+		want = rc.Field + " set"
+	}
+	return func(ctx context.Context) (bool, string, error) {
+		l, err := r.DClient.Resource(gvr).Namespace(rc.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		matched := 0
+		for _, u := range l.Items {
+			v, found, err := unstructured.NestedFieldNoCopy(u.Object, path...)
+			if err != nil || !found {
+				continue
+			}
+			if s := fmt.Sprint(v); s == rc.Value || (rc.Value == "" && s != "") {
+				matched++
+			}
+		}
+		detail := fmt.Sprintf("%d of %d %s with %s", matched, len(l.Items), rc.Resource, want)
+		if rc.Count > 0 {
+			return matched >= rc.Count, detail, nil
+		}
+		return matched > 0 && matched == len(l.Items), detail, nil
+	}
+}
+
+// WriteSummary writes a table of results followed by the total duration.
+func WriteSummary(w io.Writer, results []*Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tKIND\tSTATUS\tDURATION\tDETAIL")
+	var total time.Duration
+	for _, r := range results {
+		d := "-"
+		if r.Status != Skipped {
+			d = r.Duration.Round(time.Millisecond).String()
+		}
+		total += r.Duration
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Step, r.Kind, r.Status, d, r.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Total: %v\n", total.Round(time.Millisecond))
+	return err
+}
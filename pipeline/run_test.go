@@ -0,0 +1,269 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/exec"
+	"github.com/openconfig/kne/exec/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dfake "k8s.io/client-go/dynamic/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var srlGVR = schema.GroupVersionResource{Group: "kne.srlinux.dev", Version: "v1alpha1", Resource: "srlinuxes"}
+
+func srlinux(name, status string) runtime.Object {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("kne.srlinux.dev/v1alpha1")
+	u.SetKind("Srlinux")
+	u.SetNamespace("lab")
+	u.SetName(name)
+	if status != "" {
+		u.Object["status"] = map[string]interface{}{"status": status}
+	}
+	return u
+}
+
+func pod(name string, phase corev1.PodPhase, ready ...bool) runtime.Object {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": name}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+	for _, r := range ready {
+		p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{Ready: r})
+	}
+	return p
+}
+
+func node(name string, ready corev1.ConditionStatus) runtime.Object {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: ready},
+		}},
+	}
+}
+
+func service(ip string) runtime.Object {
+	s := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "istio-system"}}
+	if ip != "" {
+		s.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ip}}
+	}
+	return s
+}
+
+// newRunner returns a runner with fake clients of kObjs and of the srlinux
+// resources dObjs.
+func newRunner(kObjs []runtime.Object, dObjs ...runtime.Object) *Runner {
+	dClient := dfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{srlGVR: "SrlinuxList"})
+	for _, o := range dObjs {
+		if err := dClient.Tracker().Create(srlGVR, o, "lab"); err != nil {
+			panic(err)
+		}
+	}
+	return &Runner{
+		KNE:     "/usr/bin/kne",
+		Kubecfg: "/tmp/kubeconfig",
+		Stdout:  &bytes.Buffer{},
+		Stderr:  &bytes.Buffer{},
+		KClient: kfake.NewSimpleClientset(kObjs...),
+		DClient: dClient,
+	}
+}
+
+func TestRun(t *testing.T) {
+	p, err := Load("testdata/lab.yaml")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	p.Steps = append(p.Steps,
+		&Step{Run: []string{"docker", "build", "-t", "egress", "./egress"}},
+		&Step{LoadImage: "egress:latest"},
+		&Step{Wait: &Wait{Resource: &ResourceCondition{Group: "kne.srlinux.dev", Version: "v1alpha1", Resource: "srlinuxes", Namespace: "lab", Field: "status.status", Value: "loaded"}}},
+		&Step{Wait: &Wait{Service: &ServiceCondition{Namespace: "istio-system", Name: "ingress", Env: "INGRESS_HOST"}}},
+		&Step{Name: "tests", Run: []string{"go", "test", "./tests", "-args", "-ingress=${INGRESS_HOST}:80", "-topology=${TOPOLOGY}"}},
+	)
+	cmds := fake.Commands([]fake.Response{
+		{Cmd: "/usr/bin/kne", Args: []string{"delete", "testdata/topo.pbtxt", "--kubecfg", "/tmp/kubeconfig"}, Err: "topology not found"},
+		{Cmd: "/usr/bin/kne", Args: []string{"create", "testdata/topo.pbtxt", "--kubecfg", "/tmp/kubeconfig"}},
+		{Cmd: "kubectl", Args: []string{"apply", "-f", "https://example.com/app.yaml", "--kubeconfig", "/tmp/kubeconfig"}},
+		{Cmd: "docker", Args: []string{"build", "-t", "egress", "./egress"}},
+		{Cmd: "kind", Args: []string{"load", "docker-image", "egress:latest", "--name", "kne"}},
+		{Cmd: "go", Args: []string{"test", "./tests", "-args", "-ingress=192.168.18.100:80", "-topology=topo.pbtxt"}},
+	})
+	cmds.Strict = true
+	origCommandContext := exec.CommandContext
+	defer func() { exec.CommandContext = origCommandContext }()
+	exec.CommandContext = cmds.CommandContext
+
+	r := newRunner(
+		[]runtime.Object{pod("web", corev1.PodRunning, true, true), pod("job", corev1.PodSucceeded), service("192.168.18.100")},
+		srlinux("r1", "loaded"), srlinux("r2", "loaded"), srlinux("r3", "loaded"),
+	)
+	got, err := r.Run(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if err := cmds.Done(); err != nil {
+		t.Errorf("Run() commands: %v", err)
+	}
+	want := []*Result{
+		{Step: "cleanup", Kind: "delete", Status: Ignored, Detail: "topology not found"},
+		{Step: "create topo.pbtxt", Kind: "create", Status: Passed},
+		{Step: "apply https://example.com/app.yaml", Kind: "apply", Status: Passed},
+		{Step: "wait pods", Kind: "wait", Status: Passed, Detail: "2 of 2 pods ready"},
+		{Step: "docker", Kind: "run", Status: Passed},
+		{Step: "load egress:latest", Kind: "load_image", Status: Passed},
+		{Step: "wait resource", Kind: "wait", Status: Passed, Detail: "3 of 3 srlinuxes with status.status=loaded"},
+		{Step: "wait service", Kind: "wait", Status: Passed, Detail: "INGRESS_HOST=192.168.18.100"},
+		{Step: "tests", Kind: "run", Status: Passed},
+	}
+	if s := cmp.Diff(want, got, cmpopts.IgnoreFields(Result{}, "Duration")); s != "" {
+		t.Errorf("Run() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	p := &Pipeline{Steps: []*Step{
+		{Name: "build", Run: []string{"docker", "build", "."}},
+		{Name: "tests", Run: []string{"go", "test"}},
+	}}
+	cmds := fake.Commands([]fake.Response{
+		{Cmd: "docker", Args: []string{"build", "."}, Err: "no Dockerfile"},
+	})
+	cmds.Strict = true
+	origCommandContext := exec.CommandContext
+	defer func() { exec.CommandContext = origCommandContext }()
+	exec.CommandContext = cmds.CommandContext
+
+	got, err := newRunner(nil).Run(context.Background(), p)
+	if s := errdiff.Substring(err, `step "build": no Dockerfile`); s != "" {
+		t.Errorf("Run() unexpected error: %s", s)
+	}
+	if err := cmds.Done(); err != nil {
+		t.Errorf("Run() commands: %v", err)
+	}
+	want := []*Result{
+		{Step: "build", Kind: "run", Status: Failed, Detail: "no Dockerfile"},
+		{Step: "tests", Kind: "run", Status: Skipped},
+	}
+	if s := cmp.Diff(want, got, cmpopts.IgnoreFields(Result{}, "Duration")); s != "" {
+		t.Errorf("Run() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		desc       string
+		wait       *Wait
+		kObjs      []runtime.Object
+		dObjs      []runtime.Object
+		wantDetail string
+		wantErr    string
+	}{{
+		desc:       "nodes ready",
+		wait:       &Wait{Nodes: true},
+		kObjs:      []runtime.Object{node("n1", corev1.ConditionTrue), node("n2", corev1.ConditionTrue)},
+		wantDetail: "2 of 2 nodes ready",
+	}, {
+		desc:    "node not ready",
+		wait:    &Wait{Nodes: true},
+		kObjs:   []runtime.Object{node("n1", corev1.ConditionTrue), node("n2", corev1.ConditionFalse)},
+		wantErr: "timed out waiting for nodes: 1 of 2 nodes ready",
+	}, {
+		desc:    "no nodes",
+		wait:    &Wait{Nodes: true},
+		wantErr: "0 of 0 nodes ready",
+	}, {
+		desc:    "container not ready",
+		wait:    &Wait{Pods: &PodsCondition{Namespace: "default"}},
+		kObjs:   []runtime.Object{pod("web", corev1.PodRunning, true, false), pod("db", corev1.PodRunning, true)},
+		wantErr: "1 of 2 pods ready",
+	}, {
+		desc:    "pod pending",
+		wait:    &Wait{Pods: &PodsCondition{}},
+		kObjs:   []runtime.Object{pod("web", corev1.PodPending)},
+		wantErr: "0 of 1 pods ready",
+	}, {
+		desc:       "selected pods",
+		wait:       &Wait{Pods: &PodsCondition{Namespace: "default", Selector: "app=db"}},
+		kObjs:      []runtime.Object{pod("web", corev1.PodPending), pod("db", corev1.PodRunning, true)},
+		wantDetail: "1 of 1 pods ready",
+	}, {
+		desc:       "service address",
+		wait:       &Wait{Service: &ServiceCondition{Namespace: "istio-system", Name: "ingress"}},
+		kObjs:      []runtime.Object{service("192.168.18.100")},
+		wantDetail: "address 192.168.18.100",
+	}, {
+		desc:    "service pending",
+		wait:    &Wait{Service: &ServiceCondition{Namespace: "istio-system", Name: "ingress"}},
+		kObjs:   []runtime.Object{service("")},
+		wantErr: "service istio-system/ingress has no load balancer address",
+	}, {
+		desc:    "service missing",
+		wait:    &Wait{Service: &ServiceCondition{Namespace: "istio-system", Name: "ingress"}},
+		wantErr: `"ingress" not found`,
+	}, {
+		desc:    "resources not loaded",
+		wait:    &Wait{Resource: &ResourceCondition{Group: "kne.srlinux.dev", Version: "v1alpha1", Resource: "srlinuxes", Field: "status.status", Value: "loaded"}},
+		dObjs:   []runtime.Object{srlinux("r1", "loaded"), srlinux("r2", "starting"), srlinux("r3", "")},
+		wantErr: "1 of 3 srlinuxes with status.status=loaded",
+	}, {
+		desc:       "resource count",
+		wait:       &Wait{Resource: &ResourceCondition{Group: "kne.srlinux.dev", Version: "v1alpha1", Resource: "srlinuxes", Field: "status.status", Value: "loaded", Count: 2}},
+		dObjs:      []runtime.Object{srlinux("r1", "loaded"), srlinux("r2", "loaded"), srlinux("r3", "")},
+		wantDetail: "2 of 3 srlinuxes with status.status=loaded",
+	}, {
+		desc:       "resource field set",
+		wait:       &Wait{Resource: &ResourceCondition{Group: "kne.srlinux.dev", Version: "v1alpha1", Resource: "srlinuxes", Field: "status.status"}},
+		dObjs:      []runtime.Object{srlinux("r1", "loaded"), srlinux("r2", "starting")},
+		wantDetail: "2 of 2 srlinuxes with status.status set",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := newRunner(tt.kObjs, tt.dObjs...)
+			p := &Pipeline{Interval: time.Millisecond, Steps: []*Step{{Wait: tt.wait, Timeout: 20 * time.Millisecond}}}
+			got, err := r.Run(context.Background(), p)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Run() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if got[0].Detail != tt.wantDetail {
+				t.Errorf("Run() got detail %q, want %q", got[0].Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	results := []*Result{
+		{Step: "create topo.pbtxt", Kind: "create", Status: Passed, Duration: 62500 * time.Millisecond},
+		{Step: "wait service", Kind: "wait", Status: Failed, Duration: 2 * time.Minute, Detail: "timed out waiting for service"},
+		{Step: "tests", Kind: "run", Status: Skipped},
+	}
+	var buf bytes.Buffer
+	if err := WriteSummary(&buf, results); err != nil {
+		t.Fatalf("WriteSummary() failed: %v", err)
+	}
+	want := `STEP               KIND    STATUS   DURATION  DETAIL
+create topo.pbtxt  create  passed   1m2.5s    
+wait service       wait    failed   2m0s      timed out waiting for service
+tests              run     skipped  -         
+Total: 3m2.5s
+`
+	if s := cmp.Diff(want, buf.String()); s != "" {
+		t.Errorf("WriteSummary() unexpected diff (-want +got):\n%s", s)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reachability
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Mismatches returns the results that do not match their expectations.
+func Mismatches(results []*Result) []*Result {
+	var m []*Result
+	for _, r := range results {
+		if r.Mismatch != "" {
+			m = append(m, r)
+		}
+	}
+	return m
+}
+
+// WriteMatrix writes results as a matrix with a row for each source and a
+// column for each destination, followed by the results that do not match
+// their expectations, which are marked with a * in the matrix.  A cell is the
+// average round trip time of a reachable destination, with the loss if any,
+// "unreachable", "error" or "-" if not probed.
+func WriteMatrix(w io.Writer, results []*Result) error {
+	var sources, dsts []string
+	seen := map[string]bool{}
+	cells := map[[2]string]*Result{}
+	for _, r := range results {
+		if !seen["s:"+r.Source] {
+			seen["s:"+r.Source] = true
+			sources = append(sources, r.Source)
+		}
+		if !seen["d:"+r.Destination] {
+			seen["d:"+r.Destination] = true
+			dsts = append(dsts, r.Destination)
+		}
+		cells[[2]string{r.Source, r.Destination}] = r
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "SOURCE\t%s\n", strings.Join(dsts, "\t"))
+	for _, src := range sources {
+		row := []string{src}
+		for _, dst := range dsts {
+			row = append(row, cell(cells[[2]string{src, dst}]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, r := range Mismatches(results) {
+		detail := r.Mismatch
+		if r.Error != "" {
+			detail += ": " + r.Error
+		}
+		if _, err := fmt.Fprintf(w, "* %s -> %s (%s %s): %s\n", r.Source, r.Destination, r.Probe, r.Address, detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cell(r *Result) string {
+	var s string
+	switch {
+	case r == nil:
+		return "-"
+	case r.Error != "":
+		s = "error"
+	case !r.Reachable():
+		s = "unreachable"
+	case r.RTT > 0:
+		s = r.RTT.Round(10 * time.Microsecond).String()
+	default:
+		s = "ok"
+	}
+	if r.Reachable() && r.Loss > 0 {
+		s += fmt.Sprintf(" %.0f%%", r.Loss)
+	}
+	if r.Mismatch != "" {
+		s += " *"
+	}
+	return s
+}
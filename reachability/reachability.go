@@ -0,0 +1,378 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reachability
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/kne/addressing"
+	tpb "github.com/openconfig/kne/proto/topo"
+	log "k8s.io/klog/v2"
+)
+
+// A Target is the running topology probes are run in.  It is implemented by
+// *topo.Manager.
+type Target interface {
+	Exec(ctx context.Context, node string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// A Result is the result of the probes from a source to a destination.
+type Result struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Address     string `json:"address"`
+	Probe       Kind   `json:"probe"`
+	Sent        int    `json:"sent"`
+	Received    int    `json:"received"`
+	// Loss is the percentage of probes not answered.
+	Loss float64 `json:"loss"`
+	// RTT is the average round trip time, if measured.
+	RTT time.Duration `json:"rtt_ns,omitempty"`
+	// Status is the HTTP status code of HTTP probes.
+	Status int `json:"status,omitempty"`
+	// Error is set if the probe could not be run.
+	Error string `json:"error,omitempty"`
+	// Mismatch describes how the result differs from the expectation, if
+	// it does.
+	Mismatch string `json:"mismatch,omitempty"`
+}
+
+// Reachable returns whether any probe reached the destination.
+func (r *Result) Reachable() bool {
+	return r.Error == "" && r.Received > 0
+}
+
+// A probe is a probe from a source to a destination.
+type probe struct {
+	source, destination string
+	cmd                 []string
+	result              *Result
+}
+
+// Run runs the probes of s in the topology t running in target and returns
+// their results, in order of source and then destination, with any
+// mismatches with the expectations of s set.  Probes from different sources
+// are run concurrently.  A source does not probe itself.
+func Run(ctx context.Context, target Target, t *tpb.Topology, s *Spec) ([]*Result, error) {
+	probes, err := plan(t, s)
+	if err != nil {
+		return nil, err
+	}
+	bySource := map[string][]*probe{}
+	var sources []string
+	for _, p := range probes {
+		if _, ok := bySource[p.source]; !ok {
+			sources = append(sources, p.source)
+		}
+		bySource[p.source] = append(bySource[p.source], p)
+	}
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func(ps []*probe) {
+			defer wg.Done()
+			for _, p := range ps {
+				if ctx.Err() != nil {
+					return
+				}
+				run(ctx, target, p)
+			}
+		}(bySource[src])
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var results []*Result
+	for _, p := range probes {
+		p.result.Mismatch = s.mismatch(p.result)
+		results = append(results, p.result)
+	}
+	return results, nil
+}
+
+// plan returns the probes of s in t.
+func plan(t *tpb.Topology, s *Spec) ([]*probe, error) {
+	addrPlan, err := addressing.Allocate(t, addressing.DefaultPools)
+	if err != nil {
+		return nil, err
+	}
+	nodes := map[string]*tpb.Node{}
+	for _, n := range t.GetNodes() {
+		nodes[n.GetName()] = n
+	}
+	sources := s.Sources
+	if len(sources) == 0 {
+		for _, n := range t.GetNodes() {
+			sources = append(sources, n.GetName())
+		}
+	}
+	dsts := s.Destinations
+	if len(dsts) == 0 {
+		for _, n := range t.GetNodes() {
+			dsts = append(dsts, &Destination{Name: n.GetName()})
+		}
+	}
+	count, timeout := s.Count, s.Timeout
+	if count == 0 {
+		count = DefaultCount
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	var probes []*probe
+	for _, src := range sources {
+		n, ok := nodes[src]
+		if !ok {
+			return nil, fmt.Errorf("source %q not found", src)
+		}
+		for _, d := range dsts {
+			if d.Name == src {
+				continue
+			}
+			addr, err := address(addrPlan, d)
+			if err != nil {
+				return nil, err
+			}
+			var source string
+			switch s.Source {
+			case "":
+			case LoopbackSource:
+				lo := addrPlan.Node(src).Loopback4
+				if addr.Is6() {
+					lo = addrPlan.Node(src).Loopback6
+				}
+				if !lo.IsValid() {
+					return nil, fmt.Errorf("source %q has no loopback", src)
+				}
+				source = lo.Addr().String()
+			default:
+				source = s.Source
+			}
+			kind, port, path := d.Probe, d.Port, d.Path
+			if kind == "" {
+				kind, port, path = s.Probe, s.Port, s.Path
+			}
+			if kind == "" {
+				kind = Ping
+			}
+			cmd, err := command(kind, addr, port, path, source, count, timeout)
+			if err != nil {
+				return nil, fmt.Errorf("probe from %s to %s: %w", src, d.Name, err)
+			}
+			probes = append(probes, &probe{
+				source:      src,
+				destination: d.Name,
+				cmd:         Wrap(n.GetVendor(), s.NetworkInstance, cmd),
+				result: &Result{
+					Source:      src,
+					Destination: d.Name,
+					Address:     addr.String(),
+					Probe:       kind,
+				},
+			})
+		}
+	}
+	return probes, nil
+}
+
+// address returns the address of d, by default the IPv4, or else IPv6,
+// loopback of the node d in p.
+func address(p *addressing.Plan, d *Destination) (netip.Addr, error) {
+	if d.Address != "" {
+		return netip.ParseAddr(d.Address)
+	}
+	n := p.Node(d.Name)
+	switch {
+	case n == nil:
+		return netip.Addr{}, fmt.Errorf("destination %q is not a node and has no address", d.Name)
+	case n.Loopback4.IsValid():
+		return n.Loopback4.Addr(), nil
+	case n.Loopback6.IsValid():
+		return n.Loopback6.Addr(), nil
+	}
+	return netip.Addr{}, fmt.Errorf("destination %q has no loopback", d.Name)
+}
+
+// command returns the command probing addr.  Source is the address or name
+// of the interface probes are sent from, if set.
+func command(kind Kind, addr netip.Addr, port int, path, source string, count int, timeout time.Duration) ([]string, error) {
+	secs := strconv.Itoa(int((timeout + time.Second - 1) / time.Second))
+	switch kind {
+	case Ping:
+		cmd := []string{"ping", "-c", strconv.Itoa(count), "-W", secs}
+		if source != "" {
+			cmd = append(cmd, "-I", source)
+		}
+		return append(cmd, addr.String()), nil
+	case TCP:
+		if port == 0 {
+			return nil, fmt.Errorf("tcp probe has no port")
+		}
+		cmd := []string{"nc", "-z", "-w", secs}
+		if source != "" {
+			if _, err := netip.ParseAddr(source); err != nil {
+				return nil, fmt.Errorf("tcp probes must be sent from an address, not %q", source)
+			}
+			cmd = append(cmd, "-s", source)
+		}
+		return append(cmd, addr.String(), strconv.Itoa(port)), nil
+	case HTTP:
+		if port == 0 {
+			port = 80
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		cmd := []string{"curl", "-sS", "-o", "/dev/null", "-m", secs, "-w", "%{http_code} %{time_total}"}
+		if source != "" {
+			cmd = append(cmd, "--interface", source)
+		}
+		return append(cmd, "http://"+netip.AddrPortFrom(addr, uint16(port)).String()+path), nil
+	}
+	return nil, fmt.Errorf("unknown probe %q", kind)
+}
+
+// Wrap returns cmd wrapped to run in the network instance of a node of
+// vendor.  Nokia SR Linux runs each network instance, including the default
+// one, in its own namespace.  Arista cEOS runs VRFs other than the default
+// in namespaces, and other nodes are assumed to be Linux with VRF devices.
+func Wrap(vendor tpb.Vendor, instance string, cmd []string) []string {
+	isDefault := instance == "" || strings.EqualFold(instance, "default")
+	var prefix []string
+	switch {
+	case vendor == tpb.Vendor_NOKIA:
+		if isDefault {
+			instance = "default"
+		}
+		prefix = []string{"ip", "netns", "exec", "srbase-" + instance}
+	case isDefault:
+	case vendor == tpb.Vendor_ARISTA:
+		prefix = []string{"ip", "netns", "exec", "ns-" + instance}
+	default:
+		prefix = []string{"ip", "vrf", "exec", instance}
+	}
+	return append(prefix, cmd...)
+}
+
+// run runs p on target and sets its result.  A probe command that runs
+// but fails, such as ping with no replies, means the destination is not
+// reachable, while failing to run it is an error.
+func run(ctx context.Context, target Target, p *probe) {
+	var out bytes.Buffer
+	log.V(1).Infof("Probing %s from %s: %q", p.destination, p.source, p.cmd)
+	err := target.Exec(ctx, p.source, p.cmd, nil, &out, &out)
+	r := p.result
+	var exit interface{ ExitStatus() int }
+	if err != nil && !errors.As(err, &exit) {
+		r.Error = err.Error()
+		return
+	}
+	switch r.Probe {
+	case Ping:
+		if !parsePing(r, out.String()) {
+			r.Error = fmt.Sprintf("no ping summary: %s", strings.TrimSpace(out.String()))
+			return
+		}
+	case TCP:
+		r.Sent = 1
+		if err == nil {
+			r.Received = 1
+		}
+	case HTTP:
+		r.Sent = 1
+		if err == nil {
+			if !parseHTTP(r, out.String()) {
+				r.Error = fmt.Sprintf("bad curl output: %s", strings.TrimSpace(out.String()))
+				return
+			}
+			r.Received = 1
+		}
+	}
+	if r.Sent > 0 {
+		r.Loss = 100 * float64(r.Sent-r.Received) / float64(r.Sent)
+	}
+}
+
+var (
+	pingCountRE = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+	pingRTTRE   = regexp.MustCompile(`(?:rtt|round-trip) min/avg/max(?:/mdev)? = [\d.]+/([\d.]+)/`)
+)
+
+// parsePing sets the counts and RTT of r from the output of iputils or
+// busybox ping.  It returns false if out has no summary.
+func parsePing(r *Result, out string) bool {
+	m := pingCountRE.FindStringSubmatch(out)
+	if m == nil {
+		return false
+	}
+	r.Sent, _ = strconv.Atoi(m[1])
+	r.Received, _ = strconv.Atoi(m[2])
+	if m := pingRTTRE.FindStringSubmatch(out); m != nil {
+		if ms, err := strconv.ParseFloat(m[1], 64); err == nil {
+			r.RTT = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return true
+}
+
+// parseHTTP sets the status and RTT of r from the output of curl.
+func parseHTTP(r *Result, out string) bool {
+	var secs float64
+	if _, err := fmt.Sscanf(strings.TrimSpace(out), "%d %f", &r.Status, &secs); err != nil || r.Status == 0 {
+		return false
+	}
+	r.RTT = time.Duration(secs * float64(time.Second))
+	return true
+}
+
+// mismatch returns how r differs from the expectation of s, or "" if it
+// does not.
+func (s *Spec) mismatch(r *Result) string {
+	e := &Expectation{Reachable: true}
+	if len(s.Expect) > 0 {
+		e = nil
+		for _, x := range s.Expect {
+			if x.matches(r.Source, r.Destination) {
+				e = x
+			}
+		}
+	}
+	switch {
+	case e == nil:
+		return ""
+	case e.Reachable && !r.Reachable():
+		return "unreachable, want reachable"
+	case !e.Reachable && r.Reachable():
+		return "reachable, want unreachable"
+	case !e.Reachable:
+		return ""
+	case e.MaxLoss > 0 && r.Loss > e.MaxLoss:
+		return fmt.Sprintf("%.0f%% loss, want at most %.0f%%", r.Loss, e.MaxLoss)
+	case e.MaxRTT > 0 && r.RTT > e.MaxRTT:
+		return fmt.Sprintf("rtt %v, want at most %v", r.RTT, e.MaxRTT)
+	}
+	return ""
+}
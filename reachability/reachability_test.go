@@ -0,0 +1,278 @@
+package reachability
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+// exitError is an error of a command exiting with a non-zero status.
+type exitError int
+
+func (e exitError) Error() string   { return fmt.Sprintf("command terminated with exit code %d", int(e)) }
+func (e exitError) ExitStatus() int { return int(e) }
+
+type reply struct {
+	out string
+	err error
+}
+
+// fakeTarget answers commands with the reply for the node and the last
+// argument of the command, the address or URL probed.
+type fakeTarget struct {
+	mu      sync.Mutex
+	replies map[string]reply
+	gotCmds map[string][]string
+}
+
+func (f *fakeTarget) Exec(_ context.Context, node string, cmd []string, _ io.Reader, stdout, _ io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := node + " " + cmd[len(cmd)-1]
+	if f.gotCmds == nil {
+		f.gotCmds = map[string][]string{}
+	}
+	f.gotCmds[key] = cmd
+	r, ok := f.replies[key]
+	if !ok {
+		return fmt.Errorf("unexpected command on %s: %q", node, cmd)
+	}
+	stdout.Write([]byte(r.out))
+	return r.err
+}
+
+const (
+	pingOK = `PING 10.255.0.2 (10.255.0.2): 56 data bytes
+3 packets transmitted, 3 packets received, 0% packet loss
+round-trip min/avg/max = 0.101/1.500/2.300 ms
+`
+	pingLoss = `3 packets transmitted, 2 received, 33.3333% packet loss, time 2003ms
+rtt min/avg/max/mdev = 0.1/0.25/0.4/0.1 ms
+`
+	pingNone = `3 packets transmitted, 0 received, 100% packet loss, time 2003ms
+`
+)
+
+// line returns a topology of nodes r1 (NOKIA), r2 (HOST) and r3 (ARISTA) in
+// a line.
+func line() *tpb.Topology {
+	return &tpb.Topology{
+		Nodes: []*tpb.Node{
+			{Name: "r1", Vendor: tpb.Vendor_NOKIA},
+			{Name: "r2", Vendor: tpb.Vendor_HOST},
+			{Name: "r3", Vendor: tpb.Vendor_ARISTA},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "e1-1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r2", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	f := &fakeTarget{replies: map[string]reply{
+		"r1 10.255.0.2":                   {out: pingOK},
+		"r1 10.255.0.3":                   {out: pingLoss, err: exitError(1)},
+		"r2 10.255.0.1":                   {out: pingNone, err: exitError(1)},
+		"r2 10.255.0.3":                   {err: fmt.Errorf("connection lost")},
+		"r1 http://10.96.0.10:8080/index": {out: "200 0.012"},
+		"r2 http://10.96.0.10:8080/index": {out: "curl: (28) timed out", err: exitError(28)},
+	}}
+	s := &Spec{
+		Sources: []string{"r1", "r2"},
+		Source:  LoopbackSource,
+		Destinations: []*Destination{
+			{Name: "r1"},
+			{Name: "r2"},
+			{Name: "r3"},
+			{Name: "web", Address: "10.96.0.10", Probe: HTTP, Port: 8080, Path: "index"},
+		},
+		Expect: []*Expectation{
+			{From: "*", To: "*", Reachable: true, MaxLoss: 10},
+			{From: "r2", To: "web", Reachable: false},
+		},
+	}
+	got, err := Run(context.Background(), f, line(), s)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	want := []*Result{
+		{Source: "r1", Destination: "r2", Address: "10.255.0.2", Probe: Ping, Sent: 3, Received: 3, RTT: 1500 * time.Microsecond},
+		{Source: "r1", Destination: "r3", Address: "10.255.0.3", Probe: Ping, Sent: 3, Received: 2, Loss: 100.0 / 3, RTT: 250 * time.Microsecond, Mismatch: "33% loss, want at most 10%"},
+		{Source: "r1", Destination: "web", Address: "10.96.0.10", Probe: HTTP, Sent: 1, Received: 1, Status: 200, RTT: 12 * time.Millisecond},
+		{Source: "r2", Destination: "r1", Address: "10.255.0.1", Probe: Ping, Sent: 3, Loss: 100, Mismatch: "unreachable, want reachable"},
+		{Source: "r2", Destination: "r3", Address: "10.255.0.3", Probe: Ping, Error: "connection lost", Mismatch: "unreachable, want reachable"},
+		{Source: "r2", Destination: "web", Address: "10.96.0.10", Probe: HTTP, Sent: 1, Loss: 100},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Run() unexpected results (-want +got):\n%s", s)
+	}
+	wantCmds := map[string][]string{
+		"r1 10.255.0.2":                   {"ip", "netns", "exec", "srbase-default", "ping", "-c", "3", "-W", "5", "-I", "10.255.0.1", "10.255.0.2"},
+		"r2 10.255.0.1":                   {"ping", "-c", "3", "-W", "5", "-I", "10.255.0.2", "10.255.0.1"},
+		"r2 http://10.96.0.10:8080/index": {"curl", "-sS", "-o", "/dev/null", "-m", "5", "-w", "%{http_code} %{time_total}", "--interface", "10.255.0.2", "http://10.96.0.10:8080/index"},
+	}
+	for key, want := range wantCmds {
+		if s := cmp.Diff(want, f.gotCmds[key]); s != "" {
+			t.Errorf("Run() unexpected command %s (-want +got):\n%s", key, s)
+		}
+	}
+}
+
+func TestRunTCP(t *testing.T) {
+	f := &fakeTarget{replies: map[string]reply{
+		"r3 443":  {},
+		"r2 443":  {err: exitError(1)},
+		"r3 8443": {err: exitError(1)},
+	}}
+	s := &Spec{
+		Probe:           TCP,
+		Port:            443,
+		Timeout:         1500 * time.Millisecond,
+		NetworkInstance: "mgmt",
+		Sources:         []string{"r3", "r2"},
+		Destinations:    []*Destination{{Name: "r1"}, {Name: "r2", Probe: TCP, Port: 8443}},
+		Expect:          []*Expectation{{From: "r2", To: "*", Reachable: false}},
+	}
+	got, err := Run(context.Background(), f, line(), s)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	want := []*Result{
+		{Source: "r3", Destination: "r1", Address: "10.255.0.1", Probe: TCP, Sent: 1, Received: 1},
+		{Source: "r3", Destination: "r2", Address: "10.255.0.2", Probe: TCP, Sent: 1, Loss: 100},
+		{Source: "r2", Destination: "r1", Address: "10.255.0.1", Probe: TCP, Sent: 1, Loss: 100},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Run() unexpected results (-want +got):\n%s", s)
+	}
+	if s := cmp.Diff([]string{"ip", "netns", "exec", "ns-mgmt", "nc", "-z", "-w", "2", "10.255.0.1", "443"}, f.gotCmds["r3 443"]); s != "" {
+		t.Errorf("Run() unexpected command (-want +got):\n%s", s)
+	}
+	if s := cmp.Diff([]string{"ip", "vrf", "exec", "mgmt", "nc", "-z", "-w", "2", "10.255.0.1", "443"}, f.gotCmds["r2 443"]); s != "" {
+		t.Errorf("Run() unexpected command (-want +got):\n%s", s)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		spec *Spec
+		want string
+	}{{
+		desc: "unknown source",
+		spec: &Spec{Sources: []string{"r9"}},
+		want: `source "r9" not found`,
+	}, {
+		desc: "unknown destination",
+		spec: &Spec{Destinations: []*Destination{{Name: "web"}}},
+		want: `destination "web" is not a node and has no address`,
+	}, {
+		desc: "tcp without port",
+		spec: &Spec{Probe: TCP},
+		want: "tcp probe has no port",
+	}, {
+		desc: "tcp from interface",
+		spec: &Spec{Probe: TCP, Port: 22, Source: "eth1"},
+		want: `must be sent from an address, not "eth1"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Run(context.Background(), &fakeTarget{}, line(), tt.spec)
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Run() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		want string
+	}{{
+		desc: "valid",
+		in: `
+probe: ping
+count: 1
+timeout: 2s
+destinations:
+- name: r1
+- name: web
+  address: 2001:db8::1
+  probe: http
+expect:
+- from: "*"
+  to: web
+  reachable: false
+  max_rtt: 10ms
+`,
+	}, {
+		desc: "empty",
+	}, {
+		desc: "unknown field",
+		in:   "sauces: [r1]",
+		want: "field sauces not found",
+	}, {
+		desc: "unknown probe",
+		in:   "probe: udp",
+		want: `unknown probe "udp"`,
+	}, {
+		desc: "bad address",
+		in:   "destinations: [{name: web, address: web.example.com}]",
+		want: "destination web",
+	}, {
+		desc: "bad port",
+		in:   "destinations: [{name: r1, probe: tcp, port: 70000}]",
+		want: "bad port 70000",
+	}, {
+		desc: "destination without name",
+		in:   "destinations: [{address: 10.0.0.1}]",
+		want: "destinations[0]: no name",
+	}, {
+		desc: "expectation without to",
+		in:   "expect: [{from: r1, reachable: true}]",
+		want: "expect[0]: must have from and to",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Parse() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestWriteMatrix(t *testing.T) {
+	results := []*Result{
+		{Source: "r1", Destination: "r2", Probe: Ping, Sent: 3, Received: 3, RTT: 1500 * time.Microsecond},
+		{Source: "r1", Destination: "web", Probe: HTTP, Address: "10.96.0.10", Sent: 1, Received: 1, Status: 200},
+		{Source: "r2", Destination: "r1", Probe: Ping, Sent: 3, Received: 2, Loss: 100.0 / 3, RTT: 250 * time.Microsecond},
+		{Source: "r2", Destination: "web", Probe: HTTP, Address: "10.96.0.10", Error: "connection lost", Mismatch: "unreachable, want reachable"},
+	}
+	var b bytes.Buffer
+	if err := WriteMatrix(&b, results); err != nil {
+		t.Fatalf("WriteMatrix() failed: %v", err)
+	}
+	want := strings.Join([]string{
+		"SOURCE  r2     web      r1",
+		"r1      1.5ms  ok       -",
+		"r2      -      error *  250µs 33%",
+		"* r2 -> web (http 10.96.0.10): unreachable, want reachable: connection lost",
+		"",
+	}, "\n")
+	if s := cmp.Diff(want, b.String()); s != "" {
+		t.Errorf("WriteMatrix() unexpected output (-want +got):\n%s", s)
+	}
+}
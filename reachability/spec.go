@@ -0,0 +1,210 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reachability probes the reachability of destinations, by default
+// the loopbacks of every node, from the nodes of a running topology and
+// compares the results to expectations.
+//
+// The probes and expectations are declared in a YAML spec:
+//
+//	probe: ping
+//	count: 3
+//	network_instance: default
+//	source: loopback
+//	destinations:
+//	- name: r2
+//	- name: ingress
+//	  address: 10.96.0.10
+//	  probe: http
+//	  path: /productpage
+//	expect:
+//	- from: "*"
+//	  to: "*"
+//	  reachable: true
+//	- from: r3
+//	  to: ingress
+//	  reachable: false
+//
+// Probes are run with the Exec of the source node, in the namespace of the
+// network instance for the vendor of the node, such as srbase-default on
+// Nokia SR Linux.  Node destinations without an address are probed at the
+// loopback of the node in the topology's addressing plan: the plan of package
+// addressing with the default pools.
+package reachability
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the kind of a probe.
+type Kind string
+
+const (
+	// Ping sends ICMP echo requests.
+	Ping = Kind("ping")
+	// TCP opens a TCP connection to a port.
+	TCP = Kind("tcp")
+	// HTTP sends an HTTP GET request.
+	HTTP = Kind("http")
+)
+
+// Kinds are the kinds of probes.
+var Kinds = []Kind{Ping, TCP, HTTP}
+
+const (
+	// DefaultCount is the default number of echo requests of ping probes.
+	DefaultCount = 3
+	// DefaultTimeout is the default timeout of a probe.
+	DefaultTimeout = 5 * time.Second
+	// LoopbackSource is the source of probes sent from the loopback of the
+	// source node in the addressing plan.
+	LoopbackSource = "loopback"
+)
+
+// A Spec is the probes run and their expected results.
+type Spec struct {
+	// Probe, Port and Path are the probe used for destinations that do not
+	// set their own.  Probe is ping if not set and Port is 80 for HTTP
+	// probes if not set.
+	Probe Kind   `yaml:"probe,omitempty"`
+	Port  int    `yaml:"port,omitempty"`
+	Path  string `yaml:"path,omitempty"`
+	// Count is the number of echo requests of ping probes, DefaultCount if
+	// not set.
+	Count int `yaml:"count,omitempty"`
+	// Timeout is the timeout of each probe, DefaultTimeout if not set.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// NetworkInstance is the network instance, or VRF, probes are sent
+	// from.  The default instance is used if not set.
+	NetworkInstance string `yaml:"network_instance,omitempty"`
+	// Source is where probes are sent from: the name of an interface,
+	// LoopbackSource, or if not set the address chosen by the node.
+	Source string `yaml:"source,omitempty"`
+	// Sources are the nodes probes are sent from, all nodes if not set.
+	Sources []string `yaml:"sources,omitempty"`
+	// Destinations are probed from each source, the loopbacks of all nodes
+	// if not set.
+	Destinations []*Destination `yaml:"destinations,omitempty"`
+	// Expect are the expected results.  If more than one expectation
+	// matches a probe the last one is used.  If not set every probe is
+	// expected to reach its destination.
+	Expect []*Expectation `yaml:"expect,omitempty"`
+}
+
+// A Destination is probed from each source.
+type Destination struct {
+	// Name is the name of a node or, with Address set, any name used in
+	// results.
+	Name string `yaml:"name"`
+	// Address is the address probed, the loopback of the node Name if not
+	// set.
+	Address string `yaml:"address,omitempty"`
+	// Probe, Port and Path override those of the spec.
+	Probe Kind   `yaml:"probe,omitempty"`
+	Port  int    `yaml:"port,omitempty"`
+	Path  string `yaml:"path,omitempty"`
+}
+
+// An Expectation is the expected result of the probes from a source to a
+// destination.  From and To may be "*" to match any source or destination.
+type Expectation struct {
+	From      string `yaml:"from"`
+	To        string `yaml:"to"`
+	Reachable bool   `yaml:"reachable"`
+	// MaxLoss is the highest percentage of lost ping requests of a
+	// reachable destination, if set.
+	MaxLoss float64 `yaml:"max_loss,omitempty"`
+	// MaxRTT is the highest average round trip time of a reachable
+	// destination, if set.
+	MaxRTT time.Duration `yaml:"max_rtt,omitempty"`
+}
+
+func (e *Expectation) matches(from, to string) bool {
+	return (e.From == "*" || e.From == from) && (e.To == "*" || e.To == to)
+}
+
+// Load returns the spec in the YAML file at path.
+func Load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse returns the spec in the YAML b.  Unknown fields are an error.
+func Parse(b []byte) (*Spec, error) {
+	s := &Spec{}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(s); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// validate checks the parts of s that do not depend on the topology.
+func (s *Spec) validate() error {
+	if err := validProbe(s.Probe, s.Port); err != nil {
+		return err
+	}
+	if s.Count < 0 || s.Timeout < 0 {
+		return fmt.Errorf("count and timeout must not be negative")
+	}
+	for i, d := range s.Destinations {
+		if d.Name == "" {
+			return fmt.Errorf("destinations[%d]: no name", i)
+		}
+		if d.Address != "" {
+			if _, err := netip.ParseAddr(d.Address); err != nil {
+				return fmt.Errorf("destination %s: %w", d.Name, err)
+			}
+		}
+		if err := validProbe(d.Probe, d.Port); err != nil {
+			return fmt.Errorf("destination %s: %w", d.Name, err)
+		}
+	}
+	for i, e := range s.Expect {
+		if e.From == "" || e.To == "" {
+			return fmt.Errorf("expect[%d]: must have from and to", i)
+		}
+	}
+	return nil
+}
+
+func validProbe(k Kind, port int) error {
+	switch k {
+	case "", Ping, TCP, HTTP:
+	default:
+		return fmt.Errorf("unknown probe %q", k)
+	}
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("bad port %d", port)
+	}
+	return nil
+}
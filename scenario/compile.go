@@ -0,0 +1,296 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"net/netip"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/openconfig/kne/addressing"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var funcs = template.FuncMap{
+	// group returns the name of the next hop group of the route to a
+	// prefix, the same as addressing names it.
+	"group": addressing.GroupName,
+}
+
+// Templates are the templates of the configuration changes of faults for
+// each vendor.  Each template defines some of the following templates,
+// executed with the fields of a change described below:
+//
+//	route:       route Prefix to NextHop through Interface
+//	unroute:     remove the route to Prefix through NextHop
+//	blackhole:   discard the traffic to Prefix
+//	unblackhole: remove the discard route to Prefix
+//	down:        disable Interface
+//	up:          enable Interface
+//	acl:         apply the filter Name dropping traffic to Prefix to Interface
+//	unacl:       remove the filter Name from Interface
+//	mtu:         set the MTU of Interface to MTU, or to the default if 0
+//
+// Faults needing a template not defined for a vendor are not supported on
+// nodes of the vendor.
+var Templates = map[tpb.Vendor]*template.Template{
+	tpb.Vendor_ARISTA:  mustParse("eos.tmpl"),
+	tpb.Vendor_CISCO:   mustParse("xr.tmpl"),
+	tpb.Vendor_FRR:     mustParse("frr.tmpl"),
+	tpb.Vendor_HOST:    mustParse("linux.tmpl"),
+	tpb.Vendor_JUNIPER: mustParse("junos.tmpl"),
+	tpb.Vendor_NOKIA:   mustParse("srlinux.tmpl"),
+}
+
+func mustParse(name string) *template.Template {
+	return template.Must(template.New(name).Funcs(funcs).ParseFS(templateFS, "templates/"+name))
+}
+
+// change is the data templates are executed with.
+type change struct {
+	Interface string
+	Prefix    netip.Prefix
+	NextHop   netip.Addr
+	Name      string
+	MTU       uint32
+}
+
+// An Action is a configuration change made to a node.
+type Action struct {
+	Node   string
+	Vendor tpb.Vendor
+	// Config is the configuration change.  It is pushed to the node,
+	// except for hosts, where it is run by sh, and FRR, where each line is
+	// run by vtysh in configuration mode.
+	Config string
+	// Wait is how long to wait after making the change.
+	Wait time.Duration
+}
+
+// A Program is the actions that apply and revert the faults of a scenario.
+type Program struct {
+	Apply  []*Action
+	Revert []*Action
+}
+
+// Compile returns the Program of the faults of s injected into topology t.
+func Compile(s *Scenario, t *tpb.Topology) (*Program, error) {
+	plan, err := Plan(s, t)
+	if err != nil {
+		return nil, err
+	}
+	p := &Program{}
+	var reverts [][]*Action
+	for i, f := range s.Faults {
+		c := &compiler{plan: plan, topo: t, fault: f, index: i}
+		if err := c.compile(); err != nil {
+			return nil, fmt.Errorf("faults[%d]: %s: %w", i, f.Kind, err)
+		}
+		p.Apply = append(p.Apply, c.apply...)
+		reverts = append(reverts, c.revert)
+	}
+	// Faults are reverted in reverse order.
+	for i := len(reverts) - 1; i >= 0; i-- {
+		p.Revert = append(p.Revert, reverts[i]...)
+	}
+	return p, nil
+}
+
+// Plan returns the addressing plan of topology t the faults of s are
+// compiled with.
+func Plan(s *Scenario, t *tpb.Topology) (*addressing.Plan, error) {
+	plan, err := addressing.Allocate(t, addressing.DefaultPools)
+	if err != nil {
+		return nil, err
+	}
+	plan.RouteAll()
+	for _, r := range s.Routes {
+		var prefixes []netip.Prefix
+		for _, p := range r.Prefixes {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		if err := plan.Route(r.Destination, prefixes...); err != nil {
+			return nil, fmt.Errorf("route: %w", err)
+		}
+	}
+	return plan, nil
+}
+
+// A compiler compiles a single fault.
+type compiler struct {
+	plan  *addressing.Plan
+	topo  *tpb.Topology
+	fault *Fault
+	index int
+
+	apply, revert []*Action
+	err           error
+}
+
+func (c *compiler) compile() error {
+	f := c.fault
+	n := c.plan.Node(f.Node)
+	if n == nil {
+		return fmt.Errorf("node %q not found", f.Node)
+	}
+	if _, ok := Templates[n.Vendor]; !ok {
+		return fmt.Errorf("vendor %v of node %q is not supported", n.Vendor, n.Name)
+	}
+	var prefix netip.Prefix
+	if f.Prefix != "" {
+		var err error
+		if prefix, err = netip.ParsePrefix(f.Prefix); err != nil {
+			return err
+		}
+		prefix = prefix.Masked()
+	}
+	var intf *addressing.Interface
+	if f.Interface != "" {
+		if intf = n.Interface(f.Interface); intf == nil {
+			return fmt.Errorf("interface %q of node %q is not on a link", f.Interface, n.Name)
+		}
+	}
+	// The existing route to prefix, which faults replacing it restore.
+	orig := route(n, prefix)
+
+	switch f.Kind {
+	case RoutingLoop:
+		var via *addressing.Interface
+		for _, i := range n.Interfaces {
+			if i.Peer == f.Via {
+				via = i
+				break
+			}
+		}
+		if via == nil {
+			return fmt.Errorf("node %q is not a neighbor of %q", f.Via, n.Name)
+		}
+		if r := route(c.plan.Node(f.Via), prefix); r == nil || r.Interface.Peer != n.Name {
+			return fmt.Errorf("node %q does not route %s to %q, so there would be no loop", f.Via, prefix, n.Name)
+		}
+		nh := via.PeerAddr4
+		if prefix.Addr().Is6() {
+			nh = via.PeerAddr6
+		}
+		if !nh.IsValid() {
+			return fmt.Errorf("link from %q to %q has no address for %s", n.Name, f.Via, prefix)
+		}
+		loop := &change{Interface: via.Name, Prefix: prefix, NextHop: nh}
+		if orig != nil {
+			c.add(&c.apply, n, "unroute", routeChange(orig), 0)
+		}
+		c.add(&c.apply, n, "route", loop, 0)
+		c.add(&c.revert, n, "unroute", loop, 0)
+		if orig != nil {
+			c.add(&c.revert, n, "route", routeChange(orig), 0)
+		}
+	case Blackhole:
+		if orig != nil {
+			c.add(&c.apply, n, "unroute", routeChange(orig), 0)
+		}
+		c.add(&c.apply, n, "blackhole", &change{Prefix: prefix}, 0)
+		c.add(&c.revert, n, "unblackhole", &change{Prefix: prefix}, 0)
+		if orig != nil {
+			c.add(&c.revert, n, "route", routeChange(orig), 0)
+		}
+	case LinkDown:
+		peer := c.plan.Node(intf.Peer)
+		peerIntf := peer.Interface(intf.PeerKey)
+		if _, ok := Templates[peer.Vendor]; !ok {
+			return fmt.Errorf("vendor %v of node %q is not supported", peer.Vendor, peer.Name)
+		}
+		c.add(&c.apply, n, "down", &change{Interface: intf.Name}, 0)
+		c.add(&c.apply, peer, "down", &change{Interface: peerIntf.Name}, 0)
+		c.add(&c.revert, n, "up", &change{Interface: intf.Name}, 0)
+		c.add(&c.revert, peer, "up", &change{Interface: peerIntf.Name}, 0)
+	case ACLDrop:
+		acl := &change{Interface: intf.Name, Prefix: prefix, Name: fmt.Sprintf("kne-fault-%d", c.index)}
+		c.add(&c.apply, n, "acl", acl, 0)
+		c.add(&c.revert, n, "unacl", acl, 0)
+	case MTUMismatch:
+		c.add(&c.apply, n, "mtu", &change{Interface: intf.Name, MTU: f.MTU}, 0)
+		c.add(&c.revert, n, "mtu", &change{Interface: intf.Name, MTU: c.mtu(n.Name, intf.Key)}, 0)
+	case InterfaceFlap:
+		for i := 0; i < f.Count; i++ {
+			c.add(&c.apply, n, "down", &change{Interface: intf.Name}, f.Interval)
+			c.add(&c.apply, n, "up", &change{Interface: intf.Name}, f.Interval)
+		}
+		c.add(&c.revert, n, "up", &change{Interface: intf.Name}, 0)
+	}
+	return c.err
+}
+
+// add adds the action rendering the template name with ch on n to actions.
+// The first error is kept in c.err, and no more actions are added after it.
+func (c *compiler) add(actions *[]*Action, n *addressing.Node, name string, ch *change, wait time.Duration) {
+	if c.err != nil {
+		return
+	}
+	t := Templates[n.Vendor].Lookup(name)
+	if t == nil {
+		c.err = fmt.Errorf("not supported for vendor %v of node %q", n.Vendor, n.Name)
+		return
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, ch); err != nil {
+		c.err = err
+		return
+	}
+	*actions = append(*actions, &Action{
+		Node:   n.Name,
+		Vendor: n.Vendor,
+		Config: strings.TrimSpace(b.String()) + "\n",
+		Wait:   wait,
+	})
+}
+
+// mtu returns the MTU of the interface key of the named node in the
+// topology, or 0 if not set.
+func (c *compiler) mtu(name, key string) uint32 {
+	for _, n := range c.topo.GetNodes() {
+		if n.GetName() == name {
+			return n.GetInterfaces()[key].GetMtu()
+		}
+	}
+	return 0
+}
+
+// route returns the route of n to prefix, or nil.
+func route(n *addressing.Node, prefix netip.Prefix) *addressing.Route {
+	if n == nil || !prefix.IsValid() {
+		return nil
+	}
+	for _, r := range n.Routes {
+		if r.Prefix == prefix {
+			return r
+		}
+	}
+	return nil
+}
+
+func routeChange(r *addressing.Route) *change {
+	return &change{Interface: r.Interface.Name, Prefix: r.Prefix, NextHop: r.NextHop}
+}
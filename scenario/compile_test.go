@@ -0,0 +1,239 @@
+package scenario
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+// line returns a topology of nodes in a line, r1-r2-r3-r4, with r5 attached
+// to r3.
+func line() *tpb.Topology {
+	return &tpb.Topology{
+		Name: "line",
+		Nodes: []*tpb.Node{
+			{Name: "r1", Vendor: tpb.Vendor_NOKIA},
+			{Name: "r2", Vendor: tpb.Vendor_ARISTA},
+			{Name: "r3", Vendor: tpb.Vendor_JUNIPER, Interfaces: map[string]*tpb.Interface{
+				"eth1": {Name: "et-0/0/0", Mtu: 9000},
+				"eth2": {Name: "et-0/0/1"},
+				"eth3": {Name: "et-0/0/2"},
+			}},
+			{Name: "r4", Vendor: tpb.Vendor_HOST},
+			{Name: "r5", Vendor: tpb.Vendor_FRR},
+			{Name: "r6", Vendor: tpb.Vendor_OPENCONFIG},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "e1-1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r2", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+			{ANode: "r3", AInt: "eth2", ZNode: "r4", ZInt: "eth1"},
+			{ANode: "r3", AInt: "eth3", ZNode: "r5", ZInt: "eth1"},
+			{ANode: "r5", AInt: "eth2", ZNode: "r6", ZInt: "eth1"},
+		},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	egress := []*Route{{Destination: "r4", Prefixes: []string{"0.0.0.0/0"}}}
+	tests := []struct {
+		desc       string
+		routes     []*Route
+		fault      *Fault
+		wantApply  []*Action
+		wantRevert []*Action
+	}{{
+		desc:   "routing loop replacing a route",
+		routes: egress,
+		fault:  &Fault{Kind: RoutingLoop, Node: "r2", Via: "r1", Prefix: "0.0.0.0/0"},
+		wantApply: []*Action{
+			{Node: "r2", Vendor: tpb.Vendor_ARISTA, Config: "no ip route 0.0.0.0/0 192.168.0.3\n"},
+			{Node: "r2", Vendor: tpb.Vendor_ARISTA, Config: "ip route 0.0.0.0/0 192.168.0.0\n"},
+		},
+		wantRevert: []*Action{
+			{Node: "r2", Vendor: tpb.Vendor_ARISTA, Config: "no ip route 0.0.0.0/0 192.168.0.0\n"},
+			{Node: "r2", Vendor: tpb.Vendor_ARISTA, Config: "ip route 0.0.0.0/0 192.168.0.3\n"},
+		},
+	}, {
+		desc:  "routing loop on a loopback",
+		fault: &Fault{Kind: RoutingLoop, Node: "r3", Via: "r2", Prefix: "10.255.0.4/32"},
+		wantApply: []*Action{
+			{Node: "r3", Vendor: tpb.Vendor_JUNIPER, Config: "routing-options {\n    static {\n        delete: route 10.255.0.4/32;\n    }\n}\n"},
+			{Node: "r3", Vendor: tpb.Vendor_JUNIPER, Config: "routing-options {\n    static {\n        route 10.255.0.4/32 next-hop 192.168.0.2;\n    }\n}\n"},
+		},
+		wantRevert: []*Action{
+			{Node: "r3", Vendor: tpb.Vendor_JUNIPER, Config: "routing-options {\n    static {\n        delete: route 10.255.0.4/32;\n    }\n}\n"},
+			{Node: "r3", Vendor: tpb.Vendor_JUNIPER, Config: "routing-options {\n    static {\n        route 10.255.0.4/32 next-hop 192.168.0.5;\n    }\n}\n"},
+		},
+	}, {
+		desc:  "blackhole",
+		fault: &Fault{Kind: Blackhole, Node: "r4", Prefix: "2001:db8:0:ff::1/128"},
+		wantApply: []*Action{
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip -6 route del 2001:db8:0:ff::1/128 via 2001:db8:0:1::4 dev eth1\n"},
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip -6 route replace blackhole 2001:db8:0:ff::1/128\n"},
+		},
+		wantRevert: []*Action{
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip -6 route del blackhole 2001:db8:0:ff::1/128\n"},
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip -6 route replace 2001:db8:0:ff::1/128 via 2001:db8:0:1::4 dev eth1\n"},
+		},
+	}, {
+		desc:  "blackhole replacing a route through a next hop group",
+		fault: &Fault{Kind: Blackhole, Node: "r1", Prefix: "10.255.0.2/32"},
+		wantApply: []*Action{
+			{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: `delete / network-instance default static-routes route 10.255.0.2/32
+delete / network-instance default next-hop-groups group kne-10-255-0-2_32
+`},
+			{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: `set / network-instance default static-routes route 10.255.0.2/32 admin-state enable
+set / network-instance default static-routes route 10.255.0.2/32 blackhole
+`},
+		},
+		wantRevert: []*Action{
+			{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: "delete / network-instance default static-routes route 10.255.0.2/32\n"},
+			{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: `set / network-instance default next-hop-groups group kne-10-255-0-2_32 admin-state enable
+set / network-instance default next-hop-groups group kne-10-255-0-2_32 nexthop 1 ip-address 192.168.0.1
+set / network-instance default static-routes route 10.255.0.2/32 admin-state enable
+set / network-instance default static-routes route 10.255.0.2/32 next-hop-group kne-10-255-0-2_32
+`},
+		},
+	}, {
+		desc:  "blackhole without a route",
+		fault: &Fault{Kind: Blackhole, Node: "r5", Prefix: "0.0.0.0/0"},
+		wantApply: []*Action{
+			{Node: "r5", Vendor: tpb.Vendor_FRR, Config: "ip route 0.0.0.0/0 blackhole\n"},
+		},
+		wantRevert: []*Action{
+			{Node: "r5", Vendor: tpb.Vendor_FRR, Config: "no ip route 0.0.0.0/0 blackhole\n"},
+		},
+	}, {
+		desc:  "link down",
+		fault: &Fault{Kind: LinkDown, Node: "r2", Interface: "eth1"},
+		wantApply: []*Action{
+			{Node: "r2", Vendor: tpb.Vendor_ARISTA, Config: "interface Ethernet1\n   shutdown\n"},
+			{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: "set / interface ethernet-1/1 admin-state disable\n"},
+		},
+		wantRevert: []*Action{
+			{Node: "r2", Vendor: tpb.Vendor_ARISTA, Config: "interface Ethernet1\n   no shutdown\n"},
+			{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: "set / interface ethernet-1/1 admin-state enable\n"},
+		},
+	}, {
+		desc:  "acl drop",
+		fault: &Fault{Kind: ACLDrop, Node: "r1", Interface: "e1-1", Prefix: "10.255.0.1/32"},
+		wantApply: []*Action{{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: `set / acl ipv4-filter kne-fault-0 entry 10 match destination-ip prefix 10.255.0.1/32
+set / acl ipv4-filter kne-fault-0 entry 10 action drop
+set / acl ipv4-filter kne-fault-0 entry 20 action accept
+set / interface ethernet-1/1 subinterface 0 acl input ipv4-filter [ kne-fault-0 ]
+`}},
+		wantRevert: []*Action{{Node: "r1", Vendor: tpb.Vendor_NOKIA, Config: `delete / interface ethernet-1/1 subinterface 0 acl input ipv4-filter
+delete / acl ipv4-filter kne-fault-0
+`}},
+	}, {
+		desc:  "mtu mismatch",
+		fault: &Fault{Kind: MTUMismatch, Node: "r3", Interface: "eth1", MTU: 1400},
+		wantApply: []*Action{
+			{Node: "r3", Vendor: tpb.Vendor_JUNIPER, Config: "interfaces {\n    et-0/0/0 {\n        mtu 1400;\n    }\n}\n"},
+		},
+		wantRevert: []*Action{
+			{Node: "r3", Vendor: tpb.Vendor_JUNIPER, Config: "interfaces {\n    et-0/0/0 {\n        mtu 9000;\n    }\n}\n"},
+		},
+	}, {
+		desc:  "interface flap",
+		fault: &Fault{Kind: InterfaceFlap, Node: "r4", Interface: "eth1", Count: 2, Interval: time.Second},
+		wantApply: []*Action{
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip link set eth1 down\n", Wait: time.Second},
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip link set eth1 up\n", Wait: time.Second},
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip link set eth1 down\n", Wait: time.Second},
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip link set eth1 up\n", Wait: time.Second},
+		},
+		wantRevert: []*Action{
+			{Node: "r4", Vendor: tpb.Vendor_HOST, Config: "ip link set eth1 up\n"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := &Scenario{Name: tt.desc, Routes: tt.routes, Faults: []*Fault{tt.fault}}
+			p, err := Compile(s, line())
+			if err != nil {
+				t.Fatalf("Compile() failed: %v", err)
+			}
+			if s := cmp.Diff(tt.wantApply, p.Apply); s != "" {
+				t.Errorf("Compile() unexpected apply actions (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.wantRevert, p.Revert); s != "" {
+				t.Errorf("Compile() unexpected revert actions (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestCompileRevertOrder(t *testing.T) {
+	s := &Scenario{Name: "two", Faults: []*Fault{
+		{Kind: LinkDown, Node: "r3", Interface: "eth2"},
+		{Kind: Blackhole, Node: "r5", Prefix: "0.0.0.0/0"},
+	}}
+	p, err := Compile(s, line())
+	if err != nil {
+		t.Fatalf("Compile() failed: %v", err)
+	}
+	var got []string
+	for _, a := range p.Revert {
+		got = append(got, a.Node)
+	}
+	if s := cmp.Diff([]string{"r5", "r3", "r4"}, got); s != "" {
+		t.Errorf("Compile() unexpected revert order (-want +got):\n%s", s)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		routes []*Route
+		fault  *Fault
+		want   string
+	}{{
+		desc:  "unknown node",
+		fault: &Fault{Kind: Blackhole, Node: "r9", Prefix: "0.0.0.0/0"},
+		want:  `faults[0]: blackhole: node "r9" not found`,
+	}, {
+		desc:  "unsupported vendor",
+		fault: &Fault{Kind: Blackhole, Node: "r6", Prefix: "0.0.0.0/0"},
+		want:  `vendor OPENCONFIG of node "r6" is not supported`,
+	}, {
+		desc:  "unsupported fault",
+		fault: &Fault{Kind: ACLDrop, Node: "r5", Interface: "eth1", Prefix: "0.0.0.0/0"},
+		want:  `faults[0]: acl-drop: not supported for vendor FRR of node "r5"`,
+	}, {
+		desc:  "unsupported peer",
+		fault: &Fault{Kind: LinkDown, Node: "r5", Interface: "eth2"},
+		want:  `vendor OPENCONFIG of node "r6" is not supported`,
+	}, {
+		desc:  "interface not on a link",
+		fault: &Fault{Kind: LinkDown, Node: "r1", Interface: "e1-9"},
+		want:  `interface "e1-9" of node "r1" is not on a link`,
+	}, {
+		desc:  "not a neighbor",
+		fault: &Fault{Kind: RoutingLoop, Node: "r1", Via: "r3", Prefix: "10.255.0.4/32"},
+		want:  `node "r3" is not a neighbor of "r1"`,
+	}, {
+		desc:  "no loop",
+		fault: &Fault{Kind: RoutingLoop, Node: "r3", Via: "r4", Prefix: "10.255.0.4/32"},
+		want:  `node "r4" does not route 10.255.0.4/32 to "r3"`,
+	}, {
+		desc:   "bad route",
+		routes: []*Route{{Destination: "r9"}},
+		want:   `route: node "r9" not found`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := &Scenario{Name: tt.desc, Routes: tt.routes}
+			if tt.fault != nil {
+				s.Faults = []*Fault{tt.fault}
+			}
+			_, err := Compile(s, line())
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Compile() unexpected error: %s", s)
+			}
+		})
+	}
+}
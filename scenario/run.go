@@ -0,0 +1,144 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	log "k8s.io/klog/v2"
+)
+
+// A Target is the running topology a scenario is applied to.  It is
+// implemented by *topo.Manager.
+type Target interface {
+	ConfigPush(ctx context.Context, node string, r io.Reader) error
+	Exec(ctx context.Context, node string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// Apply runs the pre checks of s, applies the faults of s to the topology t
+// running in target, and runs the post checks of s.  If applying a fault
+// fails the faults already applied are left in place and can be removed with
+// Revert.
+func Apply(ctx context.Context, target Target, s *Scenario, t *tpb.Topology) error {
+	p, err := Compile(s, t)
+	if err != nil {
+		return err
+	}
+	if err := RunChecks(ctx, target, s.Checks.Pre); err != nil {
+		return fmt.Errorf("pre check failed before applying: %w", err)
+	}
+	log.Infof("Applying scenario %q", s.Name)
+	if err := run(ctx, target, p.Apply); err != nil {
+		return err
+	}
+	if err := RunChecks(ctx, target, s.Checks.Post); err != nil {
+		return fmt.Errorf("post check failed after applying: %w", err)
+	}
+	return nil
+}
+
+// Revert reverts the faults of s applied to the topology t running in target
+// and runs the pre checks of s.  Every action is attempted, even if some
+// fail, so a partially applied scenario can be reverted.
+func Revert(ctx context.Context, target Target, s *Scenario, t *tpb.Topology) error {
+	p, err := Compile(s, t)
+	if err != nil {
+		return err
+	}
+	log.Infof("Reverting scenario %q", s.Name)
+	var errs []string
+	for _, a := range p.Revert {
+		if err := a.Run(ctx, target); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to revert: %s", strings.Join(errs, "; "))
+	}
+	if err := RunChecks(ctx, target, s.Checks.Pre); err != nil {
+		return fmt.Errorf("pre check failed after reverting: %w", err)
+	}
+	return nil
+}
+
+func run(ctx context.Context, target Target, actions []*Action) error {
+	for _, a := range actions {
+		if err := a.Run(ctx, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run makes the change of a on target and then waits for a.Wait.
+func (a *Action) Run(ctx context.Context, target Target) error {
+	log.V(1).Infof("Changing configuration of node %q:\n%s", a.Node, a.Config)
+	var err error
+	var out bytes.Buffer
+	switch a.Vendor {
+	case tpb.Vendor_HOST:
+		err = target.Exec(ctx, a.Node, []string{"sh", "-c", a.Config}, nil, &out, &out)
+	case tpb.Vendor_FRR:
+		cmd := []string{"vtysh", "-c", "configure terminal"}
+		for _, line := range strings.Split(strings.TrimSpace(a.Config), "\n") {
+			cmd = append(cmd, "-c", line)
+		}
+		err = target.Exec(ctx, a.Node, cmd, nil, &out, &out)
+	default:
+		err = target.ConfigPush(ctx, a.Node, strings.NewReader(a.Config))
+	}
+	if err != nil {
+		if out.Len() > 0 {
+			return fmt.Errorf("failed to change configuration of node %q: %w: %s", a.Node, err, strings.TrimSpace(out.String()))
+		}
+		return fmt.Errorf("failed to change configuration of node %q: %w", a.Node, err)
+	}
+	if a.Wait == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(a.Wait):
+		return nil
+	}
+}
+
+// RunChecks runs checks on target and returns an error describing the first
+// check that does not pass.
+func RunChecks(ctx context.Context, target Target, checks []*Check) error {
+	for _, c := range checks {
+		var out bytes.Buffer
+		err := target.Exec(ctx, c.Node, c.Command, nil, &out, &out)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && !c.Fail:
+			return fmt.Errorf("%v: %w: %s", c, err, strings.TrimSpace(out.String()))
+		case err == nil && c.Fail:
+			return fmt.Errorf("%v: succeeded, want failure", c)
+		case !strings.Contains(out.String(), c.Contains):
+			return fmt.Errorf("%v: output does not contain %q: %s", c, c.Contains, strings.TrimSpace(out.String()))
+		}
+		log.Infof("Check passed: %v", c)
+	}
+	return nil
+}
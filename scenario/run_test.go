@@ -0,0 +1,180 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+)
+
+// fakeTarget records the changes made to it.  Commands are answered from
+// output, keyed by the command line, and fail if the output starts with
+// "error".
+type fakeTarget struct {
+	log     []string
+	output  map[string]string
+	pushErr error
+}
+
+func (f *fakeTarget) ConfigPush(_ context.Context, node string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.log = append(f.log, fmt.Sprintf("push %s: %s", node, strings.TrimSpace(string(b))))
+	return f.pushErr
+}
+
+func (f *fakeTarget) Exec(_ context.Context, node string, cmd []string, _ io.Reader, stdout, _ io.Writer) error {
+	line := strings.Join(cmd, " ")
+	f.log = append(f.log, fmt.Sprintf("exec %s: %s", node, line))
+	out := f.output[line]
+	fmt.Fprint(stdout, out)
+	if strings.HasPrefix(out, "error") {
+		return fmt.Errorf("command terminated with exit code 1")
+	}
+	return nil
+}
+
+func TestApplyRevert(t *testing.T) {
+	s := &Scenario{
+		Name: "test",
+		Faults: []*Fault{
+			{Kind: Blackhole, Node: "r5", Prefix: "0.0.0.0/0"},
+			{Kind: LinkDown, Node: "r3", Interface: "eth2"},
+		},
+		Checks: Checks{
+			Pre:  []*Check{{Node: "r4", Command: []string{"ping", "r1"}, Contains: "1 received"}},
+			Post: []*Check{{Node: "r4", Command: []string{"ping", "r5"}, Fail: true}},
+		},
+	}
+	target := &fakeTarget{output: map[string]string{
+		"ping r1": "1 received",
+		"ping r5": "error: 0 received",
+	}}
+	if err := Apply(context.Background(), target, s, line()); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	want := []string{
+		"exec r4: ping r1",
+		"exec r5: vtysh -c configure terminal -c ip route 0.0.0.0/0 blackhole",
+		"push r3: interfaces {\n    et-0/0/1 {\n        disable;\n    }\n}",
+		"exec r4: sh -c ip link set eth1 down\n",
+		"exec r4: ping r5",
+	}
+	if s := cmp.Diff(want, target.log); s != "" {
+		t.Errorf("Apply() unexpected changes (-want +got):\n%s", s)
+	}
+
+	target.log = nil
+	if err := Revert(context.Background(), target, s, line()); err != nil {
+		t.Fatalf("Revert() failed: %v", err)
+	}
+	want = []string{
+		"push r3: interfaces {\n    et-0/0/1 {\n        delete: disable;\n    }\n}",
+		"exec r4: sh -c ip link set eth1 up\n",
+		"exec r5: vtysh -c configure terminal -c no ip route 0.0.0.0/0 blackhole",
+		"exec r4: ping r1",
+	}
+	if s := cmp.Diff(want, target.log); s != "" {
+		t.Errorf("Revert() unexpected changes (-want +got):\n%s", s)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	s := &Scenario{
+		Name:   "test",
+		Faults: []*Fault{{Kind: LinkDown, Node: "r2", Interface: "eth1"}},
+		Checks: Checks{
+			Pre:  []*Check{{Node: "r4", Command: []string{"ping", "r1"}}},
+			Post: []*Check{{Node: "r4", Command: []string{"ping", "r1"}, Fail: true}},
+		},
+	}
+	tests := []struct {
+		desc    string
+		target  *fakeTarget
+		scen    *Scenario
+		want    string
+		wantLog int
+	}{{
+		desc:    "pre check fails",
+		target:  &fakeTarget{output: map[string]string{"ping r1": "error: unreachable"}},
+		want:    `pre check failed before applying: r4: ["ping" "r1"]: command terminated with exit code 1: error: unreachable`,
+		wantLog: 1,
+	}, {
+		desc:    "post check fails",
+		target:  &fakeTarget{},
+		want:    `post check failed after applying: r4: ["ping" "r1"]: succeeded, want failure`,
+		wantLog: 4,
+	}, {
+		desc:    "push fails",
+		target:  &fakeTarget{pushErr: fmt.Errorf("commit failed")},
+		want:    `failed to change configuration of node "r2": commit failed`,
+		wantLog: 2,
+	}, {
+		desc:   "compile fails",
+		target: &fakeTarget{},
+		scen:   &Scenario{Name: "bad", Faults: []*Fault{{Kind: LinkDown, Node: "r9", Interface: "eth1"}}},
+		want:   `node "r9" not found`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			scen := s
+			if tt.scen != nil {
+				scen = tt.scen
+			}
+			err := Apply(context.Background(), tt.target, scen, line())
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Apply() unexpected error: %s", s)
+			}
+			if got := len(tt.target.log); got != tt.wantLog {
+				t.Errorf("Apply() made %d changes and checks, want %d: %q", got, tt.wantLog, tt.target.log)
+			}
+		})
+	}
+}
+
+func TestRevertContinues(t *testing.T) {
+	s := &Scenario{
+		Name:   "test",
+		Faults: []*Fault{{Kind: LinkDown, Node: "r2", Interface: "eth1"}},
+	}
+	target := &fakeTarget{pushErr: fmt.Errorf("commit failed")}
+	err := Revert(context.Background(), target, s, line())
+	if s := errdiff.Substring(err, `failed to revert: failed to change configuration of node "r2": commit failed; failed to change configuration of node "r1": commit failed`); s != "" {
+		t.Errorf("Revert() unexpected error: %s", s)
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	target := &fakeTarget{output: map[string]string{"ping": "0 received"}}
+	tests := []struct {
+		desc  string
+		check *Check
+		want  string
+	}{
+		{"contains", &Check{Node: "r1", Command: []string{"ping"}, Contains: "0 received"}, ""},
+		{"does not contain", &Check{Node: "r1", Command: []string{"ping"}, Contains: "1 received"}, `output does not contain "1 received": 0 received`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := RunChecks(context.Background(), target, []*Check{tt.check})
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("RunChecks() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestRunWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := &Action{Node: "r1", Config: "x", Wait: 1 << 40}
+	if err := a.Run(ctx, &fakeTarget{}); err != context.Canceled {
+		t.Errorf("Run() got error %v, want %v", err, context.Canceled)
+	}
+}
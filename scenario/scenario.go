@@ -0,0 +1,261 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scenario injects faults, such as routing loops and links going
+// down, into a running topology and reverts them.
+//
+// A scenario is a YAML file naming the faults and the checks to run around
+// them:
+//
+//	name: transient-loop
+//	routes:
+//	- destination: egress
+//	  prefixes: [0.0.0.0/0]
+//	faults:
+//	- kind: routing-loop
+//	  node: r2
+//	  via: r1
+//	  prefix: 0.0.0.0/0
+//	checks:
+//	  pre:
+//	  - node: r1
+//	    command: [ping, -c1, 10.255.0.4]
+//	  post:
+//	  - node: r1
+//	    command: [ping, -c1, 10.255.0.4]
+//	    fail: true
+//
+// The faults are compiled into configuration changes, rendered for the
+// vendor of each node, using the addresses and static routes of the
+// topology's addressing plan: the plan of package addressing with the default
+// pools, the loopbacks of all nodes routed, and the routes of the scenario.
+package scenario
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the kind of a fault.
+type Kind string
+
+const (
+	// RoutingLoop routes prefix on node to its neighbor via, which routes
+	// it back.
+	RoutingLoop = Kind("routing-loop")
+	// Blackhole discards the traffic to prefix on node.
+	Blackhole = Kind("blackhole")
+	// LinkDown disables both ends of the link of interface on node.
+	LinkDown = Kind("link-down")
+	// ACLDrop drops the traffic to prefix received on interface of node.
+	ACLDrop = Kind("acl-drop")
+	// MTUMismatch sets the MTU of interface on node, and not on its peer.
+	MTUMismatch = Kind("mtu-mismatch")
+	// InterfaceFlap disables and enables interface on node count times,
+	// every interval.
+	InterfaceFlap = Kind("interface-flap")
+)
+
+// Kinds are the kinds of faults, in the order they are listed.
+var Kinds = []Kind{RoutingLoop, Blackhole, LinkDown, ACLDrop, MTUMismatch, InterfaceFlap}
+
+// descriptions are the descriptions of the kinds of faults.
+var descriptions = map[Kind]string{
+	RoutingLoop:   "route prefix on node to its neighbor via, which routes it back",
+	Blackhole:     "discard the traffic to prefix on node",
+	LinkDown:      "disable both ends of the link of interface on node",
+	ACLDrop:       "drop the traffic to prefix received on interface of node",
+	MTUMismatch:   "set the MTU of interface on node to mtu, but not on its peer",
+	InterfaceFlap: "disable and enable interface on node count times, every interval",
+}
+
+// Description returns a description of the kind of fault k and its
+// parameters.
+func (k Kind) Description() string {
+	return descriptions[k]
+}
+
+// A Scenario is a named set of faults and the checks run around them.
+type Scenario struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Routes are the routes of the topology in addition to those between
+	// the loopbacks of all nodes.
+	Routes []*Route `yaml:"routes,omitempty"`
+	Faults []*Fault `yaml:"faults"`
+	Checks Checks   `yaml:"checks,omitempty"`
+}
+
+// A Route routes prefixes to a destination node along shortest paths.  With
+// no prefixes the loopbacks of the destination are routed.
+type Route struct {
+	Destination string   `yaml:"destination"`
+	Prefixes    []string `yaml:"prefixes,omitempty"`
+}
+
+// A Fault is a fault injected into a node.  The parameters used depend on
+// the kind of the fault.
+type Fault struct {
+	Kind Kind   `yaml:"kind" json:"kind"`
+	Node string `yaml:"node" json:"node"`
+	// Interface is the key of the interface in the node's interface map.
+	Interface string        `yaml:"interface,omitempty" json:"interface,omitempty"`
+	Prefix    string        `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	Via       string        `yaml:"via,omitempty" json:"via,omitempty"`
+	MTU       uint32        `yaml:"mtu,omitempty" json:"mtu,omitempty"`
+	Count     int           `yaml:"count,omitempty" json:"count,omitempty"`
+	Interval  time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+func (f *Fault) String() string {
+	s := fmt.Sprintf("%s on %s", f.Kind, f.Node)
+	if f.Interface != "" {
+		s += " " + f.Interface
+	}
+	if f.Prefix != "" {
+		s += " " + f.Prefix
+	}
+	if f.Via != "" {
+		s += " via " + f.Via
+	}
+	return s
+}
+
+// Checks are commands run on nodes to verify the effect of a scenario.  The
+// pre checks are run before the faults are applied and after they are
+// reverted, and the post checks after they are applied.
+type Checks struct {
+	Pre  []*Check `yaml:"pre,omitempty"`
+	Post []*Check `yaml:"post,omitempty"`
+}
+
+// A Check is a command run on a node.  The check passes if the command
+// succeeds, or fails if Fail is set, and its output contains Contains.
+type Check struct {
+	Node     string   `yaml:"node"`
+	Command  []string `yaml:"command"`
+	Fail     bool     `yaml:"fail,omitempty"`
+	Contains string   `yaml:"contains,omitempty"`
+}
+
+func (c *Check) String() string {
+	return fmt.Sprintf("%s: %q", c.Node, c.Command)
+}
+
+// Load returns the scenario in the YAML file at path.
+func Load(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse returns the scenario in the YAML b.  Unknown fields are an error.
+func Parse(b []byte) (*Scenario, error) {
+	s := &Scenario{}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(s); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// validate checks the parameters of the faults that do not depend on the
+// topology.
+func (s *Scenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario has no name")
+	}
+	for _, r := range s.Routes {
+		if r.Destination == "" {
+			return fmt.Errorf("route has no destination")
+		}
+		for _, p := range r.Prefixes {
+			if _, err := netip.ParsePrefix(p); err != nil {
+				return fmt.Errorf("route to %s: %w", r.Destination, err)
+			}
+		}
+	}
+	for i, f := range s.Faults {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("faults[%d]: %w", i, err)
+		}
+	}
+	for _, checks := range [][]*Check{s.Checks.Pre, s.Checks.Post} {
+		for _, c := range checks {
+			if c.Node == "" || len(c.Command) == 0 {
+				return fmt.Errorf("check must have a node and a command")
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Fault) validate() error {
+	if _, ok := descriptions[f.Kind]; !ok {
+		return fmt.Errorf("unknown kind %q", f.Kind)
+	}
+	if f.Node == "" {
+		return fmt.Errorf("%s: no node", f.Kind)
+	}
+	var need []string
+	switch f.Kind {
+	case RoutingLoop:
+		need = []string{"prefix", "via"}
+	case Blackhole:
+		need = []string{"prefix"}
+	case LinkDown:
+		need = []string{"interface"}
+	case ACLDrop:
+		need = []string{"interface", "prefix"}
+	case MTUMismatch:
+		need = []string{"interface", "mtu"}
+	case InterfaceFlap:
+		need = []string{"interface", "count", "interval"}
+	}
+	set := map[string]bool{
+		"interface": f.Interface != "",
+		"prefix":    f.Prefix != "",
+		"via":       f.Via != "",
+		"mtu":       f.MTU != 0,
+		"count":     f.Count > 0,
+		"interval":  f.Interval > 0,
+	}
+	for _, p := range need {
+		if !set[p] {
+			return fmt.Errorf("%s: no %s", f.Kind, p)
+		}
+	}
+	if f.Prefix != "" {
+		if _, err := netip.ParsePrefix(f.Prefix); err != nil {
+			return fmt.Errorf("%s: %w", f.Kind, err)
+		}
+	}
+	return nil
+}
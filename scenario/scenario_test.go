@@ -0,0 +1,124 @@
+package scenario
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load(filepath.Join("testdata", "loop.yaml"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := &Scenario{
+		Name:        "loop",
+		Description: "Transient routing loop toward the egress.",
+		Routes: []*Route{{
+			Destination: "r4",
+			Prefixes:    []string{"0.0.0.0/0"},
+		}},
+		Faults: []*Fault{{
+			Kind:   RoutingLoop,
+			Node:   "r2",
+			Via:    "r1",
+			Prefix: "0.0.0.0/0",
+		}, {
+			Kind:      InterfaceFlap,
+			Node:      "r3",
+			Interface: "eth1",
+			Count:     2,
+			Interval:  5 * time.Second,
+		}},
+		Checks: Checks{
+			Pre: []*Check{{
+				Node:     "r1",
+				Command:  []string{"ping", "-c1", "10.255.0.4"},
+				Contains: "1 received",
+			}},
+			Post: []*Check{{
+				Node:    "r1",
+				Command: []string{"ping", "-c1", "10.255.0.4"},
+				Fail:    true,
+			}},
+		},
+	}
+	if s := cmp.Diff(want, s); s != "" {
+		t.Errorf("Load() unexpected scenario (-want +got):\n%s", s)
+	}
+	if _, err := Load(filepath.Join("testdata", "dne.yaml")); err == nil {
+		t.Errorf("Load() of missing file succeeded")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		want string
+	}{{
+		desc: "valid",
+		in: `
+name: valid
+faults:
+- kind: link-down
+  node: r1
+  interface: eth1
+`,
+	}, {
+		desc: "no name",
+		in:   "faults: []",
+		want: "scenario has no name",
+	}, {
+		desc: "unknown field",
+		in:   "name: x\nfault: []",
+		want: "field fault not found",
+	}, {
+		desc: "unknown kind",
+		in:   "name: x\nfaults:\n- kind: meteor\n  node: r1",
+		want: `faults[0]: unknown kind "meteor"`,
+	}, {
+		desc: "no node",
+		in:   "name: x\nfaults:\n- kind: blackhole\n  prefix: 10.0.0.0/8",
+		want: "faults[0]: blackhole: no node",
+	}, {
+		desc: "missing parameter",
+		in:   "name: x\nfaults:\n- kind: routing-loop\n  node: r1\n  prefix: 10.0.0.0/8",
+		want: "faults[0]: routing-loop: no via",
+	}, {
+		desc: "flap without interval",
+		in:   "name: x\nfaults:\n- kind: interface-flap\n  node: r1\n  interface: eth1\n  count: 3",
+		want: "faults[0]: interface-flap: no interval",
+	}, {
+		desc: "bad prefix",
+		in:   "name: x\nfaults:\n- kind: blackhole\n  node: r1\n  prefix: 10.0.0.300/8",
+		want: "faults[0]: blackhole: netip.ParsePrefix",
+	}, {
+		desc: "bad route",
+		in:   "name: x\nroutes:\n- destination: r1\n  prefixes: [default]",
+		want: "route to r1: netip.ParsePrefix",
+	}, {
+		desc: "check without command",
+		in:   "name: x\nchecks:\n  pre:\n  - node: r1",
+		want: "check must have a node and a command",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Parse() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestKinds(t *testing.T) {
+	for _, k := range Kinds {
+		if k.Description() == "" {
+			t.Errorf("Kind %q has no description", k)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry periodically collects telemetry, such as interface and
+// ACL counters, from the gNMI services of the nodes of a running topology
+// into time series.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/kne/gnmiclient"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	log "k8s.io/klog/v2"
+)
+
+// DefaultInterval is the default sample interval.
+const DefaultInterval = 10 * time.Second
+
+// A Sample is the value of a leaf of a node at a time.
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Node  string    `json:"node"`
+	Path  string    `json:"path"`
+	Value string    `json:"value"`
+	// Delta is the change of an integer value, such as a counter, since
+	// the previous sample of the leaf.  It is not set for the first
+	// sample.  A negative delta means the counter was reset.
+	Delta *big.Int `json:"delta,omitempty"`
+}
+
+// Options are the options of a collection.
+type Options struct {
+	// Paths are the gNMI paths subscribed to on each node, such as
+	// /interfaces/interface[name=*]/state/counters.
+	Paths []string
+	// Interval is the sample interval, DefaultInterval if not set.
+	Interval time.Duration
+	// Duration is how long to collect for.  If not set the collection
+	// runs until the context is canceled.
+	Duration time.Duration
+	// Username and Password are the gNMI credentials, if set.
+	Username string
+	Password string
+}
+
+// A Writer writes samples.
+type Writer interface {
+	Write(s *Sample) error
+	// Flush writes any buffered samples.
+	Flush() error
+}
+
+// Collect subscribes to opts.Paths on the gNMI service of each node of
+// topology t, as returned by topo.Manager.Show, and writes the samples
+// received to w until opts.Duration has passed or ctx is canceled.  Nodes
+// without a gNMI service or whose subscription fails are logged and
+// skipped, but an error is returned if no subscription succeeds.
+func Collect(ctx context.Context, t *tpb.Topology, opts *Options, w Writer) error {
+	if len(opts.Paths) == 0 {
+		return fmt.Errorf("no paths to collect")
+	}
+	var paths []*gpb.Path
+	for _, p := range opts.Paths {
+		path, err := gnmiclient.ParsePath(p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if opts.Duration > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	samples := make(chan *Sample)
+	var wg sync.WaitGroup
+	nodes := 0
+	// The subscriptions that succeeded and the errors of those that failed.
+	var mu sync.Mutex
+	succeeded := 0
+	var errs []error
+	for _, n := range t.GetNodes() {
+		addr := gnmiclient.Address(n)
+		if addr == "" {
+			log.Warningf("Node %q has no gNMI service", n.GetName())
+			continue
+		}
+		nodes++
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			err := subscribe(ctx, name, addr, paths, interval, opts, samples)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Warningf("Failed to collect telemetry of node %q: %v", name, err)
+				errs = append(errs, fmt.Errorf("node %q: %w", name, err))
+				return
+			}
+			succeeded++
+		}(n.GetName())
+	}
+	if nodes == 0 {
+		return fmt.Errorf("no node has a gNMI service")
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	d := deltas{}
+	var werr error
+	for s := range samples {
+		if werr != nil {
+			continue
+		}
+		d.set(s)
+		if werr = w.Write(s); werr != nil {
+			// Stop the subscriptions and drain the samples.
+			cancel()
+		}
+	}
+	if werr != nil {
+		return werr
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if succeeded == 0 {
+		return fmt.Errorf("failed to collect telemetry of any node: %w", errors.Join(errs...))
+	}
+	return w.Flush()
+}
+
+// subscribe streams samples of paths from the node name at addr to samples
+// until ctx is canceled.
+func subscribe(ctx context.Context, name, addr string, paths []*gpb.Path, interval time.Duration, opts *Options, samples chan<- *Sample) error {
+	c, closer, err := gnmiclient.Dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closer(); err != nil {
+			log.Warningf("Failed to close gNMI connection to node %q: %v", name, err)
+		}
+	}()
+	sub, err := c.Subscribe(gnmiclient.WithCredentials(ctx, opts.Username, opts.Password))
+	if err != nil {
+		return done(ctx, err)
+	}
+	var subs []*gpb.Subscription
+	for _, p := range paths {
+		subs = append(subs, &gpb.Subscription{
+			Path:           p,
+			Mode:           gpb.SubscriptionMode_SAMPLE,
+			SampleInterval: uint64(interval.Nanoseconds()),
+		})
+	}
+	if err := sub.Send(&gpb.SubscribeRequest{
+		Request: &gpb.SubscribeRequest_Subscribe{
+			Subscribe: &gpb.SubscriptionList{
+				Mode:         gpb.SubscriptionList_STREAM,
+				Subscription: subs,
+			},
+		},
+	}); err != nil {
+		return done(ctx, err)
+	}
+	log.Infof("Collecting telemetry of node %q", name)
+	for {
+		resp, err := sub.Recv()
+		if err != nil {
+			return done(ctx, err)
+		}
+		n := resp.GetUpdate()
+		ts := time.Now()
+		if n.GetTimestamp() != 0 {
+			ts = time.Unix(0, n.GetTimestamp())
+		}
+		for _, u := range n.GetUpdate() {
+			elems := append(append([]*gpb.PathElem{}, n.GetPrefix().GetElem()...), u.GetPath().GetElem()...)
+			path := gnmiclient.PathString(elems)
+			v, err := gnmiclient.Scalar(u.GetVal())
+			if err != nil {
+				log.V(1).Infof("Skipping %s of node %q: %v", path, name, err)
+				continue
+			}
+			select {
+			case samples <- &Sample{Time: ts.UTC(), Node: name, Path: path, Value: v}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// done returns nil if err is the result of ctx being done, or else err.
+func done(ctx context.Context, err error) error {
+	if ctx.Err() != nil && (errors.Is(err, ctx.Err()) || status.Code(err) == codes.Canceled || status.Code(err) == codes.DeadlineExceeded) {
+		return nil
+	}
+	return err
+}
+
+// deltas are the previous integer values of leaves, keyed by node and path.
+type deltas map[[2]string]*big.Int
+
+// set sets the delta of s, if s and the previous sample of its leaf are
+// integers, and records the value of s.
+func (d deltas) set(s *Sample) {
+	key := [2]string{s.Node, s.Path}
+	v, ok := new(big.Int).SetString(s.Value, 10)
+	if !ok {
+		delete(d, key)
+		return
+	}
+	if prev, ok := d[key]; ok {
+		s.Delta = new(big.Int).Sub(v, prev)
+	}
+	d[key] = v
+}
@@ -0,0 +1,197 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/kne/gnmiclient"
+	"github.com/openconfig/kne/gnmiclient/fake"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+// samples is a Writer collecting samples.
+type samples struct {
+	samples  []*Sample
+	writeErr error
+}
+
+func (s *samples) Write(sample *Sample) error {
+	s.samples = append(s.samples, sample)
+	return s.writeErr
+}
+
+func (s *samples) Flush() error { return nil }
+
+func counter(ts int64, intf string, in uint64) *gpb.Notification {
+	return &gpb.Notification{
+		Timestamp: ts,
+		Prefix: &gpb.Path{Elem: []*gpb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": intf}},
+		}},
+		Update: []*gpb.Update{{
+			Path: &gpb.Path{Elem: []*gpb.PathElem{{Name: "state"}, {Name: "counters"}, {Name: "in-pkts"}}},
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: in}},
+		}, {
+			Path: &gpb.Path{Elem: []*gpb.PathElem{{Name: "state"}, {Name: "oper-status"}}},
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "UP"}},
+		}, {
+			Path: &gpb.Path{Elem: []*gpb.PathElem{{Name: "state"}, {Name: "bytes"}}},
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: []byte("x")}},
+		}},
+	}
+}
+
+func gnmiServices(ip string) map[uint32]*tpb.Service {
+	return map[uint32]*tpb.Service{9339: {Name: "gnmi", Outside: 9339, OutsideIp: ip}}
+}
+
+func TestCollect(t *testing.T) {
+	f := &fake.GNMI{
+		Notifications: []*gpb.Notification{
+			counter(1e9, "eth1", 10),
+			counter(2e9, "eth1", 25),
+			counter(3e9, "eth1", 5),
+		},
+	}
+	c := fake.Serve(t, f)
+	origDial := gnmiclient.Dial
+	defer func() { gnmiclient.Dial = origDial }()
+	gnmiclient.Dial = func(_ context.Context, addr string) (gpb.GNMIClient, func() error, error) {
+		if addr != "10.1.1.1:9339" {
+			return nil, nil, fmt.Errorf("connection refused")
+		}
+		return c, func() error { return nil }, nil
+	}
+	topo := &tpb.Topology{
+		Nodes: []*tpb.Node{
+			{Name: "r1", Services: gnmiServices("10.1.1.1")},
+			{Name: "r2", Services: gnmiServices("10.1.1.2")},
+			{Name: "r3"},
+		},
+	}
+	w := &samples{}
+	opts := &Options{
+		Paths:    []string{"/interfaces/interface[name=*]/state"},
+		Interval: time.Second,
+		Duration: 500 * time.Millisecond,
+	}
+	if err := Collect(context.Background(), topo, opts, w); err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	sample := func(sec int64, path, value string, delta int64, hasDelta bool) *Sample {
+		s := &Sample{Time: time.Unix(sec, 0).UTC(), Node: "r1", Path: path, Value: value}
+		if hasDelta {
+			s.Delta = big.NewInt(delta)
+		}
+		return s
+	}
+	const (
+		in   = "/interfaces/interface[name=eth1]/state/counters/in-pkts"
+		oper = "/interfaces/interface[name=eth1]/state/oper-status"
+	)
+	want := []*Sample{
+		sample(1, in, "10", 0, false),
+		sample(1, oper, "UP", 0, false),
+		sample(2, in, "25", 15, true),
+		sample(2, oper, "UP", 0, false),
+		sample(3, in, "5", -20, true),
+		sample(3, oper, "UP", 0, false),
+	}
+	if s := cmp.Diff(want, w.samples, cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 })); s != "" {
+		t.Errorf("Collect() unexpected samples (-want +got):\n%s", s)
+	}
+	req := f.Requests()[0]
+	sub := req.GetSubscribe()
+	if got, want := sub.GetMode(), gpb.SubscriptionList_STREAM; got != want {
+		t.Errorf("Collect() subscribed in mode %v, want %v", got, want)
+	}
+	if got, want := sub.GetSubscription()[0].GetSampleInterval(), uint64(time.Second); got != want {
+		t.Errorf("Collect() subscribed with sample interval %d, want %d", got, want)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	f := &fake.GNMI{Notifications: []*gpb.Notification{counter(1e9, "eth1", 10)}}
+	c := fake.Serve(t, f)
+	origDial := gnmiclient.Dial
+	defer func() { gnmiclient.Dial = origDial }()
+	gnmiclient.Dial = func(_ context.Context, addr string) (gpb.GNMIClient, func() error, error) {
+		if addr == "10.1.1.9:9339" {
+			return nil, nil, fmt.Errorf("connection refused")
+		}
+		return c, func() error { return nil }, nil
+	}
+	topo := &tpb.Topology{Nodes: []*tpb.Node{{Name: "r1", Services: gnmiServices("10.1.1.1")}}}
+	tests := []struct {
+		desc string
+		topo *tpb.Topology
+		opts *Options
+		w    *samples
+		want string
+	}{{
+		desc: "no paths",
+		topo: topo,
+		opts: &Options{},
+		w:    &samples{},
+		want: "no paths",
+	}, {
+		desc: "bad path",
+		topo: topo,
+		opts: &Options{Paths: []string{"/interfaces/interface[name"}},
+		w:    &samples{},
+		want: "unbalanced brackets",
+	}, {
+		desc: "no gnmi",
+		topo: &tpb.Topology{Nodes: []*tpb.Node{{Name: "r1"}}},
+		opts: &Options{Paths: []string{"/interfaces"}},
+		w:    &samples{},
+		want: "no node has a gNMI service",
+	}, {
+		desc: "write fails",
+		topo: topo,
+		opts: &Options{Paths: []string{"/interfaces"}, Duration: 10 * time.Second},
+		w:    &samples{writeErr: fmt.Errorf("disk full")},
+		want: "disk full",
+	}, {
+		desc: "every subscription fails",
+		topo: &tpb.Topology{Nodes: []*tpb.Node{{Name: "r9", Services: gnmiServices("10.1.1.9")}}},
+		opts: &Options{Paths: []string{"/interfaces"}, Duration: 10 * time.Second},
+		w:    &samples{},
+		want: `node "r9": connection refused`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := Collect(context.Background(), tt.topo, tt.opts, tt.w)
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Collect() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	d := deltas{}
+	var got []string
+	for _, s := range []*Sample{
+		{Node: "r1", Path: "/a", Value: "18446744073709551615"},
+		{Node: "r2", Path: "/a", Value: "1"},
+		{Node: "r1", Path: "/a", Value: "0"},
+		{Node: "r1", Path: "/a", Value: "UP"},
+		{Node: "r1", Path: "/a", Value: "3"},
+		{Node: "r1", Path: "/a", Value: "7"},
+	} {
+		d.set(s)
+		got = append(got, fmt.Sprint(s.Delta))
+	}
+	want := []string{"<nil>", "<nil>", "-18446744073709551615", "<nil>", "<nil>", "4"}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("set() unexpected deltas (-want +got):\n%s", s)
+	}
+}
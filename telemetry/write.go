@@ -0,0 +1,94 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Formats are the formats samples can be written in.
+var Formats = []string{"jsonl", "csv"}
+
+// NewWriter returns a Writer writing samples to w in format, one of Formats.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case "jsonl":
+		return NewJSONLWriter(w), nil
+	case "csv":
+		return NewCSVWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, want one of %q", format, Formats)
+}
+
+// JSONLWriter writes samples as JSON, one per line.
+type JSONLWriter struct {
+	w *bufio.Writer
+	e *json.Encoder
+}
+
+// NewJSONLWriter returns a JSONLWriter writing to w.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	bw := bufio.NewWriter(w)
+	return &JSONLWriter{w: bw, e: json.NewEncoder(bw)}
+}
+
+// Write writes s.
+func (w *JSONLWriter) Write(s *Sample) error {
+	return w.e.Encode(s)
+}
+
+// Flush writes any buffered samples.
+func (w *JSONLWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// CSVWriter writes samples as CSV with a header of the columns time, node,
+// path, value and delta.  Times are in RFC 3339 format with nanoseconds and
+// the delta is empty if not set.
+type CSVWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns a CSVWriter writing to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes s, after the header if s is the first sample.
+func (w *CSVWriter) Write(s *Sample) error {
+	if !w.header {
+		if err := w.w.Write([]string{"time", "node", "path", "value", "delta"}); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	var delta string
+	if s.Delta != nil {
+		delta = s.Delta.String()
+	}
+	return w.w.Write([]string{s.Time.Format(time.RFC3339Nano), s.Node, s.Path, s.Value, delta})
+}
+
+// Flush writes any buffered samples.
+func (w *CSVWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+)
+
+func testSamples() []*Sample {
+	return []*Sample{
+		{Time: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), Node: "r1", Path: "/a[name=x,y]", Value: "10"},
+		{Time: time.Date(2023, 5, 1, 12, 0, 10, 500, time.UTC), Node: "r1", Path: "/a[name=x,y]", Value: "15", Delta: big.NewInt(5)},
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr string
+	}{{
+		format: "jsonl",
+		want: `{"time":"2023-05-01T12:00:00Z","node":"r1","path":"/a[name=x,y]","value":"10"}
+{"time":"2023-05-01T12:00:10.0000005Z","node":"r1","path":"/a[name=x,y]","value":"15","delta":5}
+`,
+	}, {
+		format: "csv",
+		want: `time,node,path,value,delta
+2023-05-01T12:00:00Z,r1,"/a[name=x,y]",10,
+2023-05-01T12:00:10.0000005Z,r1,"/a[name=x,y]",15,5
+`,
+	}, {
+		format:  "xml",
+		wantErr: `unknown format "xml"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(&b, tt.format)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("NewWriter() unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			for _, s := range testSamples() {
+				if err := w.Write(s); err != nil {
+					t.Fatalf("Write() failed: %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() failed: %v", err)
+			}
+			if s := cmp.Diff(tt.want, b.String()); s != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testbed derives an Ondatra testbed, and the configuration of the
+// Ondatra KNE binding, from a KNE topology, so tests written against the
+// testbed stay in sync with the topology.
+package testbed
+
+import (
+	"fmt"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	opb "github.com/openconfig/ondatra/proto"
+	"google.golang.org/protobuf/encoding/prototext"
+	"gopkg.in/yaml.v3"
+)
+
+// vendors are the Ondatra vendors of the KNE vendors supported by the
+// Ondatra KNE binding.  Nodes of other vendors are left out of testbeds.
+var vendors = map[tpb.Vendor]opb.Device_Vendor{
+	tpb.Vendor_ARISTA:     opb.Device_ARISTA,
+	tpb.Vendor_CISCO:      opb.Device_CISCO,
+	tpb.Vendor_JUNIPER:    opb.Device_JUNIPER,
+	tpb.Vendor_KEYSIGHT:   opb.Device_IXIA,
+	tpb.Vendor_NOKIA:      opb.Device_NOKIA,
+	tpb.Vendor_OPENCONFIG: opb.Device_OPENCONFIG,
+}
+
+// Role returns the Ondatra role of node n, node.OndatraRoleDUT or
+// node.OndatraRoleATE, or "" if n is not part of testbeds.  The role is the
+// value of the node.OndatraRoleLabel label if set, or else ATE for Keysight
+// nodes and DUT for nodes of other vendors supported by Ondatra.
+func Role(n *tpb.Node) (string, error) {
+	_, supported := vendors[n.GetVendor()]
+	role, ok := n.GetLabels()[node.OndatraRoleLabel]
+	switch {
+	case !ok && !supported:
+		return "", nil
+	case !ok && n.GetVendor() == tpb.Vendor_KEYSIGHT:
+		return node.OndatraRoleATE, nil
+	case !ok:
+		return node.OndatraRoleDUT, nil
+	case role != node.OndatraRoleDUT && role != node.OndatraRoleATE:
+		return "", fmt.Errorf("node %q: bad %s label %q, want %s or %s", n.GetName(), node.OndatraRoleLabel, role, node.OndatraRoleDUT, node.OndatraRoleATE)
+	case !supported:
+		return "", fmt.Errorf("node %q: vendor %v of %s is not supported by Ondatra", n.GetName(), n.GetVendor(), role)
+	}
+	return role, nil
+}
+
+// Generate returns the testbed of topology t.  Each DUT and ATE node of t,
+// as returned by Role, is a device with the id of the node name, its vendor,
+// and the model and OS of the node as the hardware model and software
+// version, if set.  Each link between devices is a link between ports of the
+// devices, numbered port1, port2 and so on in link order.
+func Generate(t *tpb.Topology) (*opb.Testbed, error) {
+	tb := &opb.Testbed{}
+	devices := map[string]*opb.Device{}
+	for _, n := range t.GetNodes() {
+		role, err := Role(n)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			continue
+		}
+		d := &opb.Device{
+			Id:     n.GetName(),
+			Vendor: vendors[n.GetVendor()],
+		}
+		if m := n.GetModel(); m != "" {
+			d.HardwareModelValue = &opb.Device_HardwareModel{HardwareModel: m}
+		}
+		if os := n.GetOs(); os != "" {
+			d.SoftwareVersionValue = &opb.Device_SoftwareVersion{SoftwareVersion: os}
+		}
+		if role == node.OndatraRoleATE {
+			tb.Ates = append(tb.Ates, d)
+		} else {
+			tb.Duts = append(tb.Duts, d)
+		}
+		devices[n.GetName()] = d
+	}
+	for _, l := range t.GetLinks() {
+		a, z := devices[l.GetANode()], devices[l.GetZNode()]
+		if a == nil || z == nil {
+			continue
+		}
+		tb.Links = append(tb.Links, &opb.Link{A: addPort(a), B: addPort(z)})
+	}
+	return tb, nil
+}
+
+// addPort adds the next port to d and returns its name in links.
+func addPort(d *opb.Device) string {
+	id := fmt.Sprintf("port%d", len(d.Ports)+1)
+	d.Ports = append(d.Ports, &opb.Port{Id: id})
+	return d.GetId() + ":" + id
+}
+
+// header names the message of a testbed textproto for editors and tools.
+const header = `# proto-file: github.com/openconfig/ondatra/blob/main/proto/testbed.proto
+# proto-message: ondatra.Testbed
+
+`
+
+// Marshal returns tb as a textproto.
+func Marshal(tb *opb.Testbed) ([]byte, error) {
+	b, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(tb)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(header), b...), nil
+}
+
+// A Binding is the configuration of the Ondatra KNE binding, passed to tests
+// with the --config flag.
+type Binding struct {
+	// Topology is the path of the topology file.
+	Topology string `yaml:"topology"`
+	// Kubecfg is the path of the kubeconfig file, if not the default.
+	Kubecfg   string `yaml:"kubecfg,omitempty"`
+	SkipReset bool   `yaml:"skip_reset,omitempty"`
+	// Credentials are the credentials of the devices, if not those of the
+	// vendors.
+	Credentials *Credentials `yaml:"credentials,omitempty"`
+}
+
+// Credentials are the device credentials of a Binding, in the form read by
+// the binding: the credentials of named nodes, of vendors, and the username
+// and password of all other devices.
+type Credentials struct {
+	Node     map[string]*UserPass `yaml:"node,omitempty"`
+	Vendor   map[string]*UserPass `yaml:"vendor,omitempty"`
+	Username string               `yaml:"username,omitempty"`
+	Password string               `yaml:"password,omitempty"`
+}
+
+// A UserPass is a username and password.
+type UserPass struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Marshal returns b as YAML.
+func (b *Binding) Marshal() ([]byte, error) {
+	return yaml.Marshal(b)
+}
@@ -0,0 +1,154 @@
+package testbed
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/ondatra/knebind"
+	"github.com/openconfig/ondatra/knebind/creds"
+	opb "github.com/openconfig/ondatra/proto"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestGenerate(t *testing.T) {
+	topo := &tpb.Topology{
+		Nodes: []*tpb.Node{
+			{Name: "r1", Vendor: tpb.Vendor_ARISTA, Model: "ceos", Os: "eos"},
+			{Name: "r2", Vendor: tpb.Vendor_NOKIA, Labels: map[string]string{node.OndatraRoleLabel: node.OndatraRoleDUT}},
+			{Name: "otg", Vendor: tpb.Vendor_KEYSIGHT},
+			{Name: "lemming", Vendor: tpb.Vendor_OPENCONFIG, Labels: map[string]string{node.OndatraRoleLabel: node.OndatraRoleATE}},
+			{Name: "h1", Vendor: tpb.Vendor_HOST},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "e1-1"},
+			{ANode: "otg", AInt: "eth1", ZNode: "r1", ZInt: "eth2"},
+			{ANode: "r2", AInt: "e1-2", ZNode: "h1", ZInt: "eth1"},
+			{ANode: "lemming", AInt: "eth1", ZNode: "r2", ZInt: "e1-3"},
+		},
+	}
+	got, err := Generate(topo)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	want := &opb.Testbed{}
+	if err := prototext.Unmarshal([]byte(`
+duts {
+  id: "r1"
+  vendor: ARISTA
+  hardware_model: "ceos"
+  software_version: "eos"
+  ports { id: "port1" }
+  ports { id: "port2" }
+}
+duts {
+  id: "r2"
+  vendor: NOKIA
+  ports { id: "port1" }
+  ports { id: "port2" }
+}
+ates {
+  id: "otg"
+  vendor: IXIA
+  ports { id: "port1" }
+}
+ates {
+  id: "lemming"
+  vendor: OPENCONFIG
+  ports { id: "port1" }
+}
+links { a: "r1:port1" b: "r2:port1" }
+links { a: "otg:port1" b: "r1:port2" }
+links { a: "lemming:port1" b: "r2:port2" }
+`), want); err != nil {
+		t.Fatal(err)
+	}
+	if s := cmp.Diff(want, got, protocmp.Transform()); s != "" {
+		t.Errorf("Generate() unexpected testbed (-want +got):\n%s", s)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		node *tpb.Node
+		want string
+	}{{
+		desc: "bad role",
+		node: &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA, Labels: map[string]string{node.OndatraRoleLabel: "TGEN"}},
+		want: `node "r1": bad ondatra-role label "TGEN"`,
+	}, {
+		desc: "unsupported vendor",
+		node: &tpb.Node{Name: "h1", Vendor: tpb.Vendor_HOST, Labels: map[string]string{node.OndatraRoleLabel: node.OndatraRoleDUT}},
+		want: `node "h1": vendor HOST of DUT is not supported by Ondatra`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Generate(&tpb.Topology{Nodes: []*tpb.Node{tt.node}})
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Generate() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(&opb.Testbed{Duts: []*opb.Device{{Id: "r1"}}})
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "# proto-file: ") {
+		t.Errorf("Marshal() has no header:\n%s", b)
+	}
+	got := &opb.Testbed{}
+	if err := prototext.Unmarshal(b, got); err != nil {
+		t.Fatalf("Marshal() returned bad textproto: %v", err)
+	}
+	if got.GetDuts()[0].GetId() != "r1" {
+		t.Errorf("Marshal() unexpected testbed:\n%s", b)
+	}
+}
+
+func TestBindingMarshal(t *testing.T) {
+	b := &Binding{
+		Topology:  "/topologies/lab.pb.txt",
+		Kubecfg:   "/tmp/kubeconfig",
+		SkipReset: true,
+		Credentials: &Credentials{
+			Node:     map[string]*UserPass{"r1": {Username: "r1user", Password: "r1pass"}},
+			Vendor:   map[string]*UserPass{"NOKIA": {Username: "admin", Password: "NokiaSrl1!"}},
+			Username: "admin",
+			Password: "admin",
+		},
+	}
+	data, err := b.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "kne.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The binding must be able to read what is written.
+	got, err := knebind.ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("Marshal() returned a binding that does not parse: %v", err)
+	}
+	if got.Topology != b.Topology || got.Kubeconfig != b.Kubecfg || !got.SkipReset {
+		t.Errorf("Marshal() returned %+v, want topology %q, kubecfg %q and skip reset", got, b.Topology, b.Kubecfg)
+	}
+	want := &creds.Credentials{
+		Node:    map[string]*creds.UserPass{"r1": {Username: "r1user", Password: "r1pass"}},
+		Vendor:  map[tpb.Vendor]*creds.UserPass{tpb.Vendor_NOKIA: {Username: "admin", Password: "NokiaSrl1!"}},
+		Default: &creds.UserPass{Username: "admin", Password: "admin"},
+	}
+	if s := cmp.Diff(want, got.Credentials); s != "" {
+		t.Errorf("Marshal() unexpected credentials (-want +got):\n%s\n%s", s, data)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	log "k8s.io/klog/v2"
+)
+
+// Forward is a local port forwarded to a service port of a node.
+type Forward struct {
+	// Node is the name of the node.
+	Node string
+	// Service is the name of the service, if any.
+	Service string
+	// Local is the local address, host:port, the service is reachable at.
+	Local string
+	// Port is the inside port of the service on the node.
+	Port uint32
+}
+
+// portForwarder forwards local ports to the ports of a pod.
+type portForwarder interface {
+	ForwardPorts() error
+	GetPorts() ([]portforward.ForwardedPort, error)
+}
+
+// newPortForwarder returns a portForwarder that forwards ports, in the
+// "local:remote" form, on address to the pod.  It is a variable for testing.
+var newPortForwarder = func(rCfg *rest.Config, kClient kubernetes.Interface, namespace, pod, address string, ports []string, stop <-chan struct{}, ready chan struct{}) (portForwarder, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(rCfg)
+	if err != nil {
+		return nil, err
+	}
+	req := kClient.CoreV1().RESTClient().Post().Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+	return portforward.NewOnAddresses(dialer, []string{address}, ports, stop, ready, io.Discard, io.Discard)
+}
+
+// Forward forwards a free local port on address to each TCP service port of
+// the named nodes, or of all nodes if none are named, without going through
+// the cluster ingress.  The forwards are returned once they are ready and last
+// until ctx is canceled.
+func (m *Manager) Forward(ctx context.Context, address string, nodes ...string) ([]*Forward, error) {
+	if len(nodes) == 0 {
+		for name := range m.nodes {
+			nodes = append(nodes, name)
+		}
+		sort.Strings(nodes)
+	}
+	var fwds []*Forward
+	for _, name := range nodes {
+		n, ok := m.nodes[name]
+		if !ok {
+			return nil, fmt.Errorf("node %q not found", name)
+		}
+		f, err := m.forwardNode(ctx, address, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to forward ports of node %q: %w", name, err)
+		}
+		fwds = append(fwds, f...)
+	}
+	return fwds, nil
+}
+
+// forwardNode forwards local ports to the service ports of n.
+func (m *Manager) forwardNode(ctx context.Context, address string, n node.Node) ([]*Forward, error) {
+	services := n.GetProto().GetServices()
+	if len(services) == 0 {
+		return nil, nil
+	}
+	var outside []uint32
+	for k := range services {
+		outside = append(outside, k)
+	}
+	sort.Slice(outside, func(i, j int) bool { return outside[i] < outside[j] })
+	names := map[uint32]string{}
+	var ports []string
+	for _, k := range outside {
+		s := services[k]
+		// Ports are only forwarded over TCP.
+		if p := s.GetProtocol(); p != tpb.Service_TCP && p != tpb.Service_TCP_UDP {
+			continue
+		}
+		// Like the cluster, an unset inside port defaults to the outside port.
+		inside := s.GetInside()
+		if inside == 0 {
+			inside = k
+		}
+		if _, ok := names[inside]; ok {
+			continue
+		}
+		names[inside] = s.GetName()
+		ports = append(ports, fmt.Sprintf("0:%d", inside))
+	}
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	pods, err := n.Pods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods found")
+	}
+	stop, ready := make(chan struct{}), make(chan struct{})
+	pf, err := newPortForwarder(m.rCfg, m.kClient, n.GetNamespace(), pods[0].Name, address, ports, stop, ready)
+	if err != nil {
+		return nil, err
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- pf.ForwardPorts()
+	}()
+	select {
+	case <-ready:
+	case err := <-errCh:
+		if err == nil {
+			err = fmt.Errorf("port forwarding stopped")
+		}
+		return nil, err
+	case <-ctx.Done():
+		close(stop)
+		return nil, ctx.Err()
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stop)
+		case err := <-errCh:
+			if err != nil {
+				log.Warningf("Port forwarding to node %q stopped: %v", n.Name(), err)
+			}
+		}
+	}()
+	fps, err := pf.GetPorts()
+	if err != nil {
+		return nil, err
+	}
+	var fwds []*Forward
+	for _, fp := range fps {
+		fwds = append(fwds, &Forward{
+			Node:    n.Name(),
+			Service: names[uint32(fp.Remote)],
+			Local:   net.JoinHostPort(address, strconv.Itoa(int(fp.Local))),
+			Port:    uint32(fp.Remote),
+		})
+	}
+	return fwds, nil
+}
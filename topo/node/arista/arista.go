@@ -78,6 +78,12 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl.Proto == nil {
 		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
 	}
+	// The services of cEOS nodes are created by the cEOS operator from the
+	// services of the CEosLabDevice, which only has TCP ports
+	// (ServiceConfig.TCPPorts), so other protocols cannot be passed to it.
+	if err := node.TCPServices(nodeImpl.Proto, "the CEosLabDevice of the cEOS operator only has TCP ports"); err != nil {
+		return nil, err
+	}
 	cfg := defaults(nodeImpl.Proto)
 	nodeImpl.Proto = cfg
 	n := &Node{
@@ -73,6 +73,17 @@ func TestNew(t *testing.T) {
 			desc:    "nil pb",
 			nImpl:   &node.Impl{},
 			wantErr: "nodeImpl.Proto cannot be nil",
+		}, {
+			desc: "udp service",
+			nImpl: &node.Impl{
+				Proto: &topopb.Node{
+					Name: "r1",
+					Services: map[uint32]*topopb.Service{
+						514: {Name: "syslog", Protocol: topopb.Service_UDP},
+					},
+				},
+			},
+			wantErr: "protocol UDP not supported, only TCP: the CEosLabDevice of the cEOS operator only has TCP ports",
 		}, {
 			desc: "invalid eth intfs 1",
 			nImpl: &node.Impl{
@@ -143,24 +143,8 @@ func (n *Node) Create(ctx context.Context) error {
 				},
 			}},
 			TerminationGracePeriodSeconds: pointer.Int64(0),
-			NodeSelector:                  map[string]string{},
-			Affinity: &corev1.Affinity{
-				PodAntiAffinity: &corev1.PodAntiAffinity{
-					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
-						Weight: 100,
-						PodAffinityTerm: corev1.PodAffinityTerm{
-							LabelSelector: &metav1.LabelSelector{
-								MatchExpressions: []metav1.LabelSelectorRequirement{{
-									Key:      "topo",
-									Operator: "In",
-									Values:   []string{pb.Name},
-								}},
-							},
-							TopologyKey: "kubernetes.io/hostname",
-						},
-					}},
-				},
-			},
+			NodeSelector:                  n.NodeSelector(),
+			Affinity:                      n.Affinity(),
 		},
 	}
 	if pb.Config.ConfigData != nil {
@@ -0,0 +1,219 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gateway implements GATEWAY nodes, which connect a topology to the
+// cluster network.  A gateway forwards traffic from its links in the
+// topology to its cluster interface, eth0, translating the source addresses
+// to the address of the pod, so nodes of the topology can reach services in
+// the cluster.
+//
+// The addresses of the gateway's interfaces and its routes into the topology
+// are declared in a GatewayConfig in the vendor data of the node:
+//
+//	config: {
+//	  vendor_data: {
+//	    [type.googleapis.com/gateway.GatewayConfig]: {
+//	      addresses: { interface: "eth1" prefix: "192.168.0.1/31" }
+//	      routes: { prefix: "10.0.0.0/8" next_hop: "192.168.0.0" }
+//	      advertise: "10.96.0.0/12"
+//	    }
+//	  }
+//	}
+//
+// The advertised prefixes, 0.0.0.0/0 if none, are routed to the gateway by
+// the other nodes of the topology in addressing plans.
+package gateway
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"text/template"
+
+	gwpb "github.com/openconfig/kne/proto/gateway"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+)
+
+func New(nodeImpl *node.Impl) (node.Node, error) {
+	if nodeImpl == nil {
+		return nil, fmt.Errorf("nodeImpl cannot be nil")
+	}
+	if nodeImpl.Proto == nil {
+		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
+	}
+	cfg, err := Config(nodeImpl.Proto)
+	if err != nil {
+		return nil, fmt.Errorf("node %q: %w", nodeImpl.Proto.GetName(), err)
+	}
+	for _, a := range cfg.GetAddresses() {
+		if _, ok := nodeImpl.Proto.GetInterfaces()[a.GetInterface()]; !ok && a.GetInterface() != "lo" {
+			return nil, fmt.Errorf("node %q: address %s on unknown interface %q", nodeImpl.Proto.GetName(), a.GetPrefix(), a.GetInterface())
+		}
+	}
+	pb, err := defaults(nodeImpl.Proto, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("node %q: %w", nodeImpl.Proto.GetName(), err)
+	}
+	nodeImpl.Proto = pb
+	n := &Node{
+		Impl: nodeImpl,
+	}
+	return n, nil
+}
+
+type Node struct {
+	*node.Impl
+}
+
+// Config returns the GatewayConfig in the vendor data of pb, which is empty
+// if pb has no vendor data.  The addresses, routes and advertised prefixes
+// are checked to be valid prefixes and addresses.
+func Config(pb *tpb.Node) (*gwpb.GatewayConfig, error) {
+	cfg := &gwpb.GatewayConfig{}
+	if vd := pb.GetConfig().GetVendorData(); vd != nil {
+		if err := vd.UnmarshalTo(cfg); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range cfg.GetAddresses() {
+		if a.GetInterface() == "" {
+			return nil, fmt.Errorf("address %q has no interface", a.GetPrefix())
+		}
+		if _, err := netip.ParsePrefix(a.GetPrefix()); err != nil {
+			return nil, fmt.Errorf("address of interface %q: %w", a.GetInterface(), err)
+		}
+	}
+	for _, r := range cfg.GetRoutes() {
+		if _, err := netip.ParsePrefix(r.GetPrefix()); err != nil {
+			return nil, fmt.Errorf("route: %w", err)
+		}
+		if _, err := netip.ParseAddr(r.GetNextHop()); err != nil {
+			return nil, fmt.Errorf("route to %s: %w", r.GetPrefix(), err)
+		}
+	}
+	for _, s := range cfg.GetAdvertise() {
+		if _, err := netip.ParsePrefix(s); err != nil {
+			return nil, fmt.Errorf("advertise: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// DefaultImage is the image of gateway nodes that do not set one.  It is
+// pinned and ships iptables and iproute2, so the gateway does not need to
+// install anything or reach a package mirror when it starts.
+const DefaultImage = "nicolaka/netshoot:v0.11"
+
+// script sets up the gateway and then sleeps.  It waits for the interfaces
+// with addresses to be added by meshnet, fails if the image does not have
+// the tools it needs, and is safe to rerun when the container restarts.
+var script = template.Must(template.New("script").Parse(`set -e
+for tool in ip iptables{{if .IPv6}} ip6tables{{end}}; do
+  command -v $tool >/dev/null || { echo "gateway image does not have $tool" >&2; exit 1; }
+done
+sysctl -w net.ipv4.ip_forward=1
+{{- if .IPv6}}
+sysctl -w net.ipv6.conf.all.forwarding=1
+{{- end}}
+{{- range .Interfaces}}
+until ip link show {{.}} >/dev/null 2>&1; do sleep 1; done
+ip link set {{.}} up
+{{- end}}
+{{- range .Addresses}}
+ip addr replace {{.Prefix}} dev {{.Interface}}
+{{- end}}
+{{- range .Routes}}
+ip {{if .IPv6}}-6 {{end}}route replace {{.Prefix}} via {{.NextHop}}
+{{- end}}
+iptables -t nat -C POSTROUTING -o {{.External}} -j MASQUERADE 2>/dev/null || iptables -t nat -A POSTROUTING -o {{.External}} -j MASQUERADE
+{{- if .IPv6}}
+ip6tables -t nat -C POSTROUTING -o {{.External}} -j MASQUERADE 2>/dev/null || ip6tables -t nat -A POSTROUTING -o {{.External}} -j MASQUERADE
+{{- end}}
+exec sleep 2000000000000
+`))
+
+type route struct {
+	Prefix, NextHop string
+	IPv6            bool
+}
+
+// Script returns the shell script setting up a gateway with cfg.
+func Script(cfg *gwpb.GatewayConfig) (string, error) {
+	data := struct {
+		Interfaces []string
+		Addresses  []*gwpb.Address
+		Routes     []route
+		External   string
+		IPv6       bool
+	}{
+		Addresses: cfg.GetAddresses(),
+		External:  cfg.GetExternalInterface(),
+	}
+	if data.External == "" {
+		data.External = "eth0"
+	}
+	seen := map[string]bool{}
+	for _, a := range cfg.GetAddresses() {
+		if !seen[a.GetInterface()] {
+			seen[a.GetInterface()] = true
+			data.Interfaces = append(data.Interfaces, a.GetInterface())
+		}
+		if p, err := netip.ParsePrefix(a.GetPrefix()); err == nil && p.Addr().Is6() {
+			data.IPv6 = true
+		}
+	}
+	for _, r := range cfg.GetRoutes() {
+		p, err := netip.ParsePrefix(r.GetPrefix())
+		if err != nil {
+			return "", err
+		}
+		data.Routes = append(data.Routes, route{Prefix: p.String(), NextHop: r.GetNextHop(), IPv6: p.Addr().Is6()})
+	}
+	var b bytes.Buffer
+	if err := script.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func defaults(pb *tpb.Node, cfg *gwpb.GatewayConfig) (*tpb.Node, error) {
+	if pb.Config == nil {
+		pb.Config = &tpb.Config{}
+	}
+	if len(pb.GetConfig().GetCommand()) == 0 {
+		s, err := Script(cfg)
+		if err != nil {
+			return nil, err
+		}
+		pb.Config.Command = []string{"/bin/sh", "-c", s}
+	}
+	if pb.Config.EntryCommand == "" {
+		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- sh", pb.Name)
+	}
+	if pb.Config.Image == "" {
+		pb.Config.Image = DefaultImage
+	}
+	if pb.Config.ConfigPath == "" {
+		pb.Config.ConfigPath = "/etc"
+	}
+	if pb.Config.ConfigFile == "" {
+		pb.Config.ConfigFile = "config"
+	}
+	return pb, nil
+}
+
+func init() {
+	node.Vendor(tpb.Vendor_GATEWAY, New)
+}
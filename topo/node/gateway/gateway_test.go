@@ -0,0 +1,225 @@
+package gateway
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	gwpb "github.com/openconfig/kne/proto/gateway"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func vendorData(t *testing.T, m proto.Message) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatalf("anypb.New() failed: %v", err)
+	}
+	return a
+}
+
+func TestNew(t *testing.T) {
+	cfg := &gwpb.GatewayConfig{
+		Addresses: []*gwpb.Address{
+			{Interface: "eth1", Prefix: "192.168.0.1/31"},
+			{Interface: "eth1", Prefix: "2001:db8::1/127"},
+			{Interface: "lo", Prefix: "10.255.0.1/32"},
+		},
+		Routes: []*gwpb.Route{
+			{Prefix: "10.0.0.0/8", NextHop: "192.168.0.0"},
+			{Prefix: "2001:db8:1::/48", NextHop: "2001:db8::"},
+		},
+	}
+	tests := []struct {
+		desc    string
+		nImpl   *node.Impl
+		want    *tpb.Node
+		wantErr string
+	}{{
+		desc:    "nil impl",
+		wantErr: "nodeImpl cannot be nil",
+	}, {
+		desc:    "nil pb",
+		wantErr: "nodeImpl.Proto cannot be nil",
+		nImpl:   &node.Impl{},
+	}, {
+		desc: "empty pb",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{Name: "gw"},
+		},
+		want: &tpb.Node{
+			Name: "gw",
+			Config: &tpb.Config{
+				Command: []string{"/bin/sh", "-c", `set -e
+for tool in ip iptables; do
+  command -v $tool >/dev/null || { echo "gateway image does not have $tool" >&2; exit 1; }
+done
+sysctl -w net.ipv4.ip_forward=1
+iptables -t nat -C POSTROUTING -o eth0 -j MASQUERADE 2>/dev/null || iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE
+exec sleep 2000000000000
+`},
+				EntryCommand: fmt.Sprintf("kubectl exec -it %s -- sh", "gw"),
+				Image:        DefaultImage,
+				ConfigPath:   "/etc",
+				ConfigFile:   "config",
+			},
+		},
+	}, {
+		desc: "addresses and routes",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Name:       "gw",
+				Interfaces: map[string]*tpb.Interface{"eth1": {IntName: "eth1"}},
+				Config:     &tpb.Config{Image: "gateway:latest", VendorData: vendorData(t, cfg)},
+			},
+		},
+		want: &tpb.Node{
+			Name:       "gw",
+			Interfaces: map[string]*tpb.Interface{"eth1": {IntName: "eth1"}},
+			Config: &tpb.Config{
+				Command: []string{"/bin/sh", "-c", `set -e
+for tool in ip iptables ip6tables; do
+  command -v $tool >/dev/null || { echo "gateway image does not have $tool" >&2; exit 1; }
+done
+sysctl -w net.ipv4.ip_forward=1
+sysctl -w net.ipv6.conf.all.forwarding=1
+until ip link show eth1 >/dev/null 2>&1; do sleep 1; done
+ip link set eth1 up
+until ip link show lo >/dev/null 2>&1; do sleep 1; done
+ip link set lo up
+ip addr replace 192.168.0.1/31 dev eth1
+ip addr replace 2001:db8::1/127 dev eth1
+ip addr replace 10.255.0.1/32 dev lo
+ip route replace 10.0.0.0/8 via 192.168.0.0
+ip -6 route replace 2001:db8:1::/48 via 2001:db8::
+iptables -t nat -C POSTROUTING -o eth0 -j MASQUERADE 2>/dev/null || iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE
+ip6tables -t nat -C POSTROUTING -o eth0 -j MASQUERADE 2>/dev/null || ip6tables -t nat -A POSTROUTING -o eth0 -j MASQUERADE
+exec sleep 2000000000000
+`},
+				EntryCommand: fmt.Sprintf("kubectl exec -it %s -- sh", "gw"),
+				Image:        "gateway:latest",
+				ConfigPath:   "/etc",
+				ConfigFile:   "config",
+				VendorData:   vendorData(t, cfg),
+			},
+		},
+	}, {
+		desc: "provided config command",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{
+					Command:    []string{"run"},
+					VendorData: vendorData(t, &gwpb.GatewayConfig{ExternalInterface: "net1"}),
+				},
+			},
+		},
+		want: &tpb.Node{
+			Config: &tpb.Config{
+				Command:      []string{"run"},
+				EntryCommand: fmt.Sprintf("kubectl exec -it %s -- sh", ""),
+				Image:        DefaultImage,
+				ConfigPath:   "/etc",
+				ConfigFile:   "config",
+				VendorData:   vendorData(t, &gwpb.GatewayConfig{ExternalInterface: "net1"}),
+			},
+		},
+	}, {
+		desc: "external interface",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{VendorData: vendorData(t, &gwpb.GatewayConfig{ExternalInterface: "net1"})},
+			},
+		},
+		want: &tpb.Node{
+			Config: &tpb.Config{
+				Command: []string{"/bin/sh", "-c", `set -e
+for tool in ip iptables; do
+  command -v $tool >/dev/null || { echo "gateway image does not have $tool" >&2; exit 1; }
+done
+sysctl -w net.ipv4.ip_forward=1
+iptables -t nat -C POSTROUTING -o net1 -j MASQUERADE 2>/dev/null || iptables -t nat -A POSTROUTING -o net1 -j MASQUERADE
+exec sleep 2000000000000
+`},
+				EntryCommand: fmt.Sprintf("kubectl exec -it %s -- sh", ""),
+				Image:        DefaultImage,
+				ConfigPath:   "/etc",
+				ConfigFile:   "config",
+				VendorData:   vendorData(t, &gwpb.GatewayConfig{ExternalInterface: "net1"}),
+			},
+		},
+	}, {
+		desc: "unknown interface",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Name:   "gw",
+				Config: &tpb.Config{VendorData: vendorData(t, cfg)},
+			},
+		},
+		wantErr: `node "gw": address 192.168.0.1/31 on unknown interface "eth1"`,
+	}, {
+		desc: "bad address",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{VendorData: vendorData(t, &gwpb.GatewayConfig{
+					Addresses: []*gwpb.Address{{Interface: "eth1", Prefix: "192.168.0.1"}},
+				})},
+			},
+		},
+		wantErr: `address of interface "eth1"`,
+	}, {
+		desc: "address without interface",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{VendorData: vendorData(t, &gwpb.GatewayConfig{
+					Addresses: []*gwpb.Address{{Prefix: "192.168.0.1/31"}},
+				})},
+			},
+		},
+		wantErr: `address "192.168.0.1/31" has no interface`,
+	}, {
+		desc: "bad next hop",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{VendorData: vendorData(t, &gwpb.GatewayConfig{
+					Routes: []*gwpb.Route{{Prefix: "10.0.0.0/8", NextHop: "r1"}},
+				})},
+			},
+		},
+		wantErr: "route to 10.0.0.0/8",
+	}, {
+		desc: "bad advertised prefix",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{VendorData: vendorData(t, &gwpb.GatewayConfig{Advertise: []string{"cluster"}})},
+			},
+		},
+		wantErr: "advertise",
+	}, {
+		desc: "wrong vendor data",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Config: &tpb.Config{VendorData: vendorData(t, &gwpb.Route{})},
+			},
+		},
+		wantErr: "mismatched message type",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n, err := New(tt.nImpl)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("New() unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if s := cmp.Diff(tt.want, n.GetProto(), protocmp.Transform()); s != "" {
+				t.Errorf("New() unexpected proto (-want +got):\n%s", s)
+			}
+		})
+	}
+}
@@ -23,6 +23,13 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl.Proto == nil {
 		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
 	}
+	// The services of IxiaTG nodes are the API endpoints of the IxiaTG
+	// operator, which are gRPC and HTTPS servers.  An IxiaTGSvcPort only has
+	// the inside and outside port, and the operator always creates it over
+	// TCP, so other protocols cannot be passed to it.
+	if err := node.TCPServices(nodeImpl.Proto, "the IxiaTG operator only exposes its API endpoints over TCP"); err != nil {
+		return nil, err
+	}
 	cfg := defaults(nodeImpl.Proto)
 	nodeImpl.Proto = cfg
 	n := &Node{
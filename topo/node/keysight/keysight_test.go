@@ -54,6 +54,16 @@ func TestNew(t *testing.T) {
 				"ondatra-role": "ATE",
 			},
 		},
+	}, {
+		desc: "udp service",
+		nImpl: &node.Impl{
+			Proto: &tpb.Node{
+				Services: map[uint32]*tpb.Service{
+					514: {Name: "syslog", Inside: 514, Protocol: tpb.Service_UDP},
+				},
+			},
+		},
+		wantErr: "protocol UDP not supported, only TCP: the IxiaTG operator only exposes its API endpoints over TCP",
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -65,6 +67,11 @@ type ConfigPusher interface {
 	ConfigPush(context.Context, io.Reader) error
 }
 
+// Execer provides an interface for executing commands on nodes.
+type Execer interface {
+	Exec(ctx context.Context, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
 // Resetter provides Reset interface to nodes.
 type Resetter interface {
 	ResetCfg(ctx context.Context) error
@@ -89,6 +96,8 @@ const (
 	OndatraRoleLabel = "ondatra-role"
 	OndatraRoleDUT   = "DUT"
 	OndatraRoleATE   = "ATE"
+
+	hostnameLabel = "kubernetes.io/hostname"
 )
 
 type NewNodeFn func(n *Impl) (Node, error)
@@ -117,6 +126,9 @@ type Impl struct {
 	Proto      *tpb.Node
 	BasePath   string
 	Kubecfg    string
+	// Placement is the placement of the nodes of the topology on the
+	// cluster nodes.
+	Placement *tpb.Placement
 }
 
 // New creates a new node for use in the k8s cluster.  Configure will push the node to
@@ -132,6 +144,67 @@ func New(namespace string, pb *tpb.Node, kClient kubernetes.Interface, rCfg *res
 	})
 }
 
+// SetPlacement sets the placement of the nodes of the topology.
+func (n *Impl) SetPlacement(p *tpb.Placement) {
+	n.Placement = p
+}
+
+// NodeSelector returns the node selector of the pod of the node, selecting
+// the cluster node the node is pinned to, if any.
+func (n *Impl) NodeSelector() map[string]string {
+	sel := map[string]string{}
+	pin := n.Placement.GetPins()[n.Proto.GetName()]
+	if pin == "" {
+		return sel
+	}
+	if k, v, ok := strings.Cut(pin, "="); ok {
+		sel[k] = v
+	} else {
+		sel[hostnameLabel] = pin
+	}
+	return sel
+}
+
+// Affinity returns the affinity of the pod of the node for the placement
+// strategy of the topology.  PACK prefers the cluster nodes already running
+// pods of the topology and SPREAD prefers the others.
+func (n *Impl) Affinity() *corev1.Affinity {
+	term := func(key string, values ...string) []corev1.WeightedPodAffinityTerm {
+		return []corev1.WeightedPodAffinityTerm{{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      key,
+						Operator: "In",
+						Values:   values,
+					}},
+				},
+				TopologyKey: hostnameLabel,
+			},
+		}}
+	}
+	switch n.Placement.GetStrategy() {
+	case tpb.Placement_PACK:
+		return &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: term("topo", n.Namespace),
+			},
+		}
+	case tpb.Placement_SPREAD:
+		return &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: term("topo", n.Namespace),
+			},
+		}
+	}
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: term("topo", n.Proto.GetName()),
+		},
+	}
+}
+
 func (n *Impl) GetProto() *tpb.Node {
 	return n.Proto
 }
@@ -337,24 +410,8 @@ func (n *Impl) CreatePod(ctx context.Context) error {
 				},
 			}},
 			TerminationGracePeriodSeconds: pointer.Int64(0),
-			NodeSelector:                  map[string]string{},
-			Affinity: &corev1.Affinity{
-				PodAntiAffinity: &corev1.PodAntiAffinity{
-					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
-						Weight: 100,
-						PodAffinityTerm: corev1.PodAffinityTerm{
-							LabelSelector: &metav1.LabelSelector{
-								MatchExpressions: []metav1.LabelSelectorRequirement{{
-									Key:      "topo",
-									Operator: "In",
-									Values:   []string{pb.Name},
-								}},
-							},
-							TopologyKey: "kubernetes.io/hostname",
-						},
-					}},
-				},
-			},
+			NodeSelector:                  n.NodeSelector(),
+			Affinity:                      n.Affinity(),
 		},
 	}
 	if pb.Config.ConfigData != nil {
@@ -383,6 +440,37 @@ func (n *Impl) CreatePod(ctx context.Context) error {
 	return nil
 }
 
+// ServiceProtocols returns the protocols of the ports of s.
+func ServiceProtocols(s *tpb.Service) []corev1.Protocol {
+	switch s.GetProtocol() {
+	case tpb.Service_UDP:
+		return []corev1.Protocol{corev1.ProtocolUDP}
+	case tpb.Service_SCTP:
+		return []corev1.Protocol{corev1.ProtocolSCTP}
+	case tpb.Service_TCP_UDP:
+		return []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP}
+	default:
+		return []corev1.Protocol{corev1.ProtocolTCP}
+	}
+}
+
+// TCPServices returns an error if a service of pb uses a protocol other than
+// TCP.  It is used by vendors whose services are created by an operator that
+// has no way to be given the protocol of a port, with reason saying why.
+func TCPServices(pb *tpb.Node, reason string) error {
+	keys := make([]uint32, 0, len(pb.GetServices()))
+	for k := range pb.GetServices() {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, k := range keys {
+		if p := pb.GetServices()[k].GetProtocol(); p != tpb.Service_TCP {
+			return fmt.Errorf("service on port %d of node %q: protocol %s not supported, only TCP: %s", k, pb.GetName(), p, reason)
+		}
+	}
+	return nil
+}
+
 // CreateService creates services for the node based on the underlying proto.
 func (n *Impl) CreateService(ctx context.Context) error {
 	var servicePorts []corev1.ServicePort
@@ -398,20 +486,28 @@ func (n *Impl) CreateService(ctx context.Context) error {
 		if v.Outside != 0 {
 			log.Warningf("Outside should not be set by user. The key is used as the target external port")
 		}
-		sp := corev1.ServicePort{
-			Name:       name,
-			Protocol:   "TCP",
-			Port:       int32(k),
-			TargetPort: intstr.FromInt(int(v.Inside)),
-		}
-		if v.NodePort != 0 {
-			sp.NodePort = int32(v.NodePort)
-		}
-		if v.Outside != 0 {
-			sp.Port = int32(v.Outside)
+		for i, protocol := range ServiceProtocols(v) {
+			sp := corev1.ServicePort{
+				Name:       name,
+				Protocol:   protocol,
+				Port:       int32(k),
+				TargetPort: intstr.FromInt(int(v.Inside)),
+			}
+			// Port names must be unique, so the second protocol of a port is
+			// suffixed with the protocol.
+			if i > 0 {
+				sp.Name = fmt.Sprintf("%s-%s", name, strings.ToLower(string(protocol)))
+			}
+			if v.NodePort != 0 {
+				sp.NodePort = int32(v.NodePort)
+			}
+			if v.Outside != 0 {
+				sp.Port = int32(v.Outside)
+			}
+			servicePorts = append(servicePorts, sp)
 		}
-		servicePorts = append(servicePorts, sp)
 	}
+	dualStack := corev1.IPFamilyPolicyPreferDualStack
 	s := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Service",
@@ -429,6 +525,9 @@ func (n *Impl) CreateService(ctx context.Context) error {
 				"app": n.Name(),
 			},
 			Type: "LoadBalancer",
+			// Dual stack clusters assign the service both an IPv4 and an
+			// IPv6 address, single stack clusters just one.
+			IPFamilyPolicy: &dualStack,
 		},
 	}
 	sS, err := n.KubeClient.CoreV1().Services(n.Namespace).Create(ctx, s, metav1.CreateOptions{})
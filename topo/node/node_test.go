@@ -211,6 +211,7 @@ func TestCreateConfig(t *testing.T) {
 	}
 }
 func TestService(t *testing.T) {
+	dualStack := corev1.IPFamilyPolicyPreferDualStack
 	tests := []struct {
 		desc           string
 		node           *topopb.Node
@@ -254,8 +255,9 @@ func TestService(t *testing.T) {
 					TargetPort: intstr.FromInt(22),
 					NodePort:   0,
 				}},
-				Selector: map[string]string{"app": "dev1"},
-				Type:     "LoadBalancer",
+				Selector:       map[string]string{"app": "dev1"},
+				Type:           "LoadBalancer",
+				IPFamilyPolicy: &dualStack,
 			},
 		}},
 	}, {
@@ -299,8 +301,69 @@ func TestService(t *testing.T) {
 					TargetPort: intstr.FromInt(9339),
 					NodePort:   0,
 				}},
-				Selector: map[string]string{"app": "dev2"},
-				Type:     "LoadBalancer",
+				Selector:       map[string]string{"app": "dev2"},
+				Type:           "LoadBalancer",
+				IPFamilyPolicy: &dualStack,
+			},
+		}},
+	}, {
+		desc: "services with protocols",
+		node: &topopb.Node{
+			Name:   "dev3",
+			Vendor: topopb.Vendor(1001),
+			Services: map[uint32]*topopb.Service{
+				53: {
+					Name:     "dns",
+					Inside:   53,
+					Protocol: topopb.Service_TCP_UDP,
+				},
+				514: {
+					Name:     "syslog",
+					Inside:   514,
+					Protocol: topopb.Service_UDP,
+				},
+				4739: {
+					Inside:   4739,
+					Protocol: topopb.Service_SCTP,
+				},
+			},
+		},
+		kClient: kfake.NewSimpleClientset(),
+		want: []*corev1.Service{{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Service",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "service-dev3",
+				Namespace: "test",
+				Labels:    map[string]string{"pod": "dev3"},
+			},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{
+					Name:       "dns",
+					Protocol:   "TCP",
+					Port:       53,
+					TargetPort: intstr.FromInt(53),
+				}, {
+					Name:       "dns-udp",
+					Protocol:   "UDP",
+					Port:       53,
+					TargetPort: intstr.FromInt(53),
+				}, {
+					Name:       "port-4739",
+					Protocol:   "SCTP",
+					Port:       4739,
+					TargetPort: intstr.FromInt(4739),
+				}, {
+					Name:       "syslog",
+					Protocol:   "UDP",
+					Port:       514,
+					TargetPort: intstr.FromInt(514),
+				}},
+				Selector:       map[string]string{"app": "dev3"},
+				Type:           "LoadBalancer",
+				IPFamilyPolicy: &dualStack,
 			},
 		}},
 	}, {
@@ -357,3 +420,118 @@ func TestService(t *testing.T) {
 		})
 	}
 }
+
+func TestTCPServices(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pb      *topopb.Node
+		wantErr string
+	}{{
+		desc: "no services",
+		pb:   &topopb.Node{Name: "r1"},
+	}, {
+		desc: "tcp",
+		pb: &topopb.Node{
+			Name: "r1",
+			Services: map[uint32]*topopb.Service{
+				22:  {Name: "ssh"},
+				443: {Name: "ssl", Protocol: topopb.Service_TCP},
+			},
+		},
+	}, {
+		desc: "udp",
+		pb: &topopb.Node{
+			Name: "r1",
+			Services: map[uint32]*topopb.Service{
+				22:  {Name: "ssh"},
+				161: {Name: "snmp", Protocol: topopb.Service_UDP},
+				514: {Name: "syslog", Protocol: topopb.Service_TCP_UDP},
+			},
+		},
+		wantErr: `service on port 161 of node "r1": protocol UDP not supported, only TCP: no protocols`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := TCPServices(tt.pb, "no protocols")
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Errorf("TCPServices() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestPlacement(t *testing.T) {
+	term := func(key, value string) []corev1.WeightedPodAffinityTerm {
+		return []corev1.WeightedPodAffinityTerm{{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      key,
+						Operator: "In",
+						Values:   []string{value},
+					}},
+				},
+				TopologyKey: "kubernetes.io/hostname",
+			},
+		}}
+	}
+	tests := []struct {
+		desc         string
+		placement    *topopb.Placement
+		wantAffinity *corev1.Affinity
+		wantSelector map[string]string
+	}{{
+		desc: "default",
+		wantAffinity: &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: term("topo", "r1"),
+			},
+		},
+		wantSelector: map[string]string{},
+	}, {
+		desc:      "pack",
+		placement: &topopb.Placement{Strategy: topopb.Placement_PACK},
+		wantAffinity: &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: term("topo", "test"),
+			},
+		},
+		wantSelector: map[string]string{},
+	}, {
+		desc: "spread pinned to hostname",
+		placement: &topopb.Placement{
+			Strategy: topopb.Placement_SPREAD,
+			Pins:     map[string]string{"r1": "kne-worker2", "r2": "kne-worker"},
+		},
+		wantAffinity: &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: term("topo", "test"),
+			},
+		},
+		wantSelector: map[string]string{"kubernetes.io/hostname": "kne-worker2"},
+	}, {
+		desc: "pinned to label",
+		placement: &topopb.Placement{
+			Pins: map[string]string{"r1": "kne/pool=large"},
+		},
+		wantAffinity: &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: term("topo", "r1"),
+			},
+		},
+		wantSelector: map[string]string{"kne/pool": "large"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := &Impl{Namespace: "test", Proto: &topopb.Node{Name: "r1"}}
+			n.SetPlacement(tt.placement)
+			if s := cmp.Diff(tt.wantAffinity, n.Affinity()); s != "" {
+				t.Errorf("Affinity() unexpected diff (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.wantSelector, n.NodeSelector()); s != "" {
+				t.Errorf("NodeSelector() unexpected diff (-want +got):\n%s", s)
+			}
+		})
+	}
+}
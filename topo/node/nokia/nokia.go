@@ -55,6 +55,11 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	n := &Node{
 		Impl: nodeImpl,
 	}
+	// A node without a cluster config, as when only the defaults of a
+	// topology are wanted, has no client.
+	if n.RestConfig == nil {
+		return n, nil
+	}
 
 	c, err := newSrlinuxClient(n.RestConfig)
 	if err != nil {
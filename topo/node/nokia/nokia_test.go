@@ -206,6 +206,30 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNewClient(t *testing.T) {
+	origNewSrlinuxClient := newSrlinuxClient
+	defer func() { newSrlinuxClient = origNewSrlinuxClient }()
+	var created []*rest.Config
+	newSrlinuxClient = func(c *rest.Config) (ctrlclient.Client, error) {
+		created = append(created, c)
+		return nil, nil
+	}
+	if _, err := New(&node.Impl{Proto: &topopb.Node{Name: "srl"}}); err != nil {
+		t.Fatalf("New() without a cluster config failed: %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("New() without a cluster config created a client")
+	}
+	cfg := &rest.Config{Host: "https://cluster"}
+	if _, err := New(&node.Impl{Proto: &topopb.Node{Name: "srl"}, RestConfig: cfg}); err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if len(created) != 1 || created[0] != cfg {
+		t.Errorf("New() did not create a client for the cluster config")
+	}
+}
+
 func TestGenerateSelfSigned(t *testing.T) {
 	unpatchClient := patchSrlinuxClient()
 	defer unpatchClient()
@@ -54,6 +54,14 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	var cfg *tpb.Node
 	switch nodeImpl.Proto.Model {
 	case modelLemming:
+		// The services of lemming nodes are created by the lemming operator
+		// from the ports of the Lemming, a ServicePort of which only has
+		// the inner and outer port, and the operator always exposes them
+		// over TCP.  Magna nodes create their own services and support all
+		// protocols.
+		if err := node.TCPServices(nodeImpl.Proto, "the lemming operator only exposes ports over TCP"); err != nil {
+			return nil, err
+		}
 		cfg = lemmingDefaults(nodeImpl.Proto)
 	case modelMagna:
 		cfg = magnaDefaults(nodeImpl.Proto)
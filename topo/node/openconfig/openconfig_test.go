@@ -296,6 +296,18 @@ func TestNew(t *testing.T) {
 			},
 		},
 		wantErr: "a model must be specified",
+	}, {
+		desc: "lemming: udp service",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Name:  "test_node",
+				Model: modelLemming,
+				Services: map[uint32]*tpb.Service{
+					514: {Name: "syslog", Inside: 514, Protocol: tpb.Service_UDP},
+				},
+			},
+		},
+		wantErr: "protocol UDP not supported, only TCP: the lemming operator only exposes ports over TCP",
 	}, {
 		desc: "lemming: test defaults",
 		ni: &node.Impl{
@@ -491,6 +503,44 @@ func TestNew(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		desc: "magna: udp service",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Model: modelMagna,
+				Config: &tpb.Config{
+					Command: []string{"do", "run"},
+				},
+				Services: map[uint32]*tpb.Service{
+					514: {Name: "syslog", Inside: 514, Protocol: tpb.Service_UDP},
+				},
+			},
+		},
+		wantPB: &tpb.Node{
+			Model: modelMagna,
+			Config: &tpb.Config{
+				Command:      []string{"do", "run"},
+				EntryCommand: fmt.Sprintf("kubectl exec -it %s -- sh", ""),
+				Image:        "magna:latest",
+			},
+			Labels: map[string]string{
+				"ondatra-role": "ATE",
+				"vendor":       "OPENCONFIG",
+			},
+			Services: map[uint32]*tpb.Service{
+				514: {Name: "syslog", Inside: 514, Protocol: tpb.Service_UDP},
+				40051: {
+					Name:    "grpc",
+					Inside:  40051,
+					Outside: 40051,
+				},
+				50051: {
+					Name:    "gnmi",
+					Inside:  50051,
+					Outside: 50051,
+				},
+			},
+		},
 	}, {
 		desc: "magna: provided config command",
 		ni: &node.Impl{
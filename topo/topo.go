@@ -18,7 +18,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +28,8 @@ import (
 	"github.com/kr/pretty"
 	topologyclientv1 "github.com/networkop/meshnet-cni/api/clientset/v1beta1"
 	topologyv1 "github.com/networkop/meshnet-cni/api/types/v1beta1"
+	"github.com/openconfig/kne/ingress"
+	"github.com/openconfig/kne/metrics"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
@@ -33,6 +37,7 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -42,6 +47,7 @@ import (
 
 	_ "github.com/openconfig/kne/topo/node/arista"
 	_ "github.com/openconfig/kne/topo/node/cisco"
+	_ "github.com/openconfig/kne/topo/node/gateway"
 	_ "github.com/openconfig/kne/topo/node/gobgp"
 	_ "github.com/openconfig/kne/topo/node/host"
 	_ "github.com/openconfig/kne/topo/node/juniper"
@@ -146,9 +152,69 @@ func New(topo *tpb.Topology, opts ...Option) (*Manager, error) {
 	return m, nil
 }
 
+// Images returns the container images used by the nodes of the topology
+// once the vendor defaults have been applied: the image of each node and the
+// image of its init container.  Nodes created by a vendor operator only use
+// the default init container image if they set one.  The images are sorted
+// and unique.
+func (m *Manager) Images() []string {
+	images := map[string]bool{}
+	for _, n := range m.topo.Nodes {
+		if image := n.GetConfig().GetImage(); image != "" {
+			images[image] = true
+		}
+		switch initImage := n.GetConfig().GetInitImage(); {
+		case initImage != "":
+			images[initImage] = true
+		case !operatorNode(n):
+			images[node.DefaultInitContainerImage] = true
+		}
+	}
+	var l []string
+	for image := range images {
+		l = append(l, image)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// operatorNode returns true if the pod of n is created by a vendor operator
+// rather than by KNE.
+func operatorNode(n *tpb.Node) bool {
+	switch n.GetVendor() {
+	case tpb.Vendor_ARISTA, tpb.Vendor_NOKIA, tpb.Vendor_KEYSIGHT:
+		return true
+	case tpb.Vendor_OPENCONFIG:
+		return n.GetModel() == "LEMMING"
+	}
+	return false
+}
+
+// Images returns the container images used by the nodes of topo, as
+// returned by Manager.Images, without connecting to a cluster.  topo is not
+// modified.
+func Images(topo *tpb.Topology) ([]string, error) {
+	if topo == nil {
+		return nil, fmt.Errorf("topology cannot be nil")
+	}
+	// The nodes are loaded without a cluster config, as there may not be a
+	// cluster yet, so vendors only apply their defaults.
+	m := &Manager{
+		topo:  proto.Clone(topo).(*tpb.Topology),
+		nodes: map[string]node.Node{},
+	}
+	if err := m.load(); err != nil {
+		return nil, fmt.Errorf("failed to load topology: %w", err)
+	}
+	return m.Images(), nil
+}
+
 // Create creates the topology in the cluster.
-func (m *Manager) Create(ctx context.Context, timeout time.Duration) error {
+func (m *Manager) Create(ctx context.Context, timeout time.Duration) (rerr error) {
 	log.V(1).Infof("Topology:\n%v", prototext.Format(m.topo))
+	defer func(start time.Time) {
+		metrics.ObserveTopology(metrics.OpCreate, start, rerr)
+	}(time.Now())
 	if err := m.push(ctx); err != nil {
 		return err
 	}
@@ -160,8 +226,14 @@ func (m *Manager) Create(ctx context.Context, timeout time.Duration) error {
 }
 
 // Delete deletes the topology from the cluster.
-func (m *Manager) Delete(ctx context.Context) error {
+func (m *Manager) Delete(ctx context.Context) (rerr error) {
 	log.Infof("Topology:\n%v", prototext.Format(m.topo))
+	defer func(start time.Time) {
+		metrics.ObserveTopology(metrics.OpDelete, start, rerr)
+		if rerr == nil {
+			metrics.DeleteTopology(m.topo.Name)
+		}
+	}(time.Now())
 	if _, err := m.kClient.CoreV1().Namespaces().Get(ctx, m.topo.Name, metav1.GetOptions{}); err != nil {
 		return fmt.Errorf("topology %q does not exist in cluster", m.topo.Name)
 	}
@@ -169,7 +241,10 @@ func (m *Manager) Delete(ctx context.Context) error {
 	// Delete topology nodes
 	for _, n := range m.nodes {
 		// Delete Service for node
-		if err := n.Delete(ctx); err != nil {
+		start := time.Now()
+		err := n.Delete(ctx)
+		m.observeNode(metrics.OpDelete, n.Name(), start, err)
+		if err != nil {
 			log.Warningf("Error deleting node %q: %v", n.Name(), err)
 		}
 	}
@@ -190,6 +265,8 @@ func (m *Manager) Show(ctx context.Context) (*cpb.ShowTopologyResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	var nodeAddr string
+	var nodeAddrRead bool
 	for _, n := range m.topo.Nodes {
 		if len(n.Services) == 0 {
 			n.Services = map[uint32]*tpb.Service{}
@@ -199,7 +276,15 @@ func (m *Manager) Show(ctx context.Context) (*cpb.ShowTopologyResponse, error) {
 			return nil, fmt.Errorf("services for node %s not found", n.Name)
 		}
 		for _, svc := range services {
-			if err := populateServiceMap(svc, n.Services); err != nil {
+			// Without a load balancer the services are reached on their
+			// node ports, if the NodePort ingress is in use.
+			if len(svc.Status.LoadBalancer.Ingress) == 0 && !nodeAddrRead {
+				nodeAddrRead = true
+				if nodeAddr, _, err = ingress.NodePortAddress(ctx, m.kClient); err != nil {
+					log.Warningf("Failed to find node port address: %v", err)
+				}
+			}
+			if err := populateServiceMap(svc, nodeAddr, n.Services); err != nil {
 				return nil, err
 			}
 		}
@@ -209,6 +294,7 @@ func (m *Manager) Show(ctx context.Context) (*cpb.ShowTopologyResponse, error) {
 		phase, _ := n.Status(ctx)
 		stateMap.setNodeState(n.Name(), phase)
 	}
+	metrics.SetNodeStates(m.topo.Name, stateMap.counts())
 	return &cpb.ShowTopologyResponse{
 		State:    stateMap.topologyState(),
 		Topology: m.topo,
@@ -287,17 +373,34 @@ func (m *Manager) load() error {
 		zInt.Uid = int64(uid)
 		uid++
 	}
+	for name := range m.topo.GetPlacement().GetPins() {
+		n, ok := nMap[name]
+		if !ok {
+			return fmt.Errorf("invalid topology: placement pins missing node %q", name)
+		}
+		if operatorNode(n) {
+			return fmt.Errorf("invalid topology: node %q is placed by its vendor operator and cannot be pinned", name)
+		}
+	}
 	for k, n := range nMap {
 		log.Infof("Adding Node: %s:%s", n.Name, n.Vendor)
 		nn, err := node.New(m.topo.Name, n, m.kClient, m.rCfg, m.basePath, m.kubecfg)
 		if err != nil {
 			return fmt.Errorf("failed to load topology: %w", err)
 		}
+		if p, ok := nn.(placer); ok {
+			p.SetPlacement(m.topo.GetPlacement())
+		}
 		m.nodes[k] = nn
 	}
 	return nil
 }
 
+// A placer is a node that honors the placement of the topology.
+type placer interface {
+	SetPlacement(*tpb.Placement)
+}
+
 // setLinkPeer finds the peer pod name and peer interface name for a given interface.
 func setLinkPeer(nodeName string, podName string, link *topologyv1.Link, peerSpecs []*topologyv1.Topology) error {
 	for _, peerSpec := range peerSpecs {
@@ -374,7 +477,10 @@ func (m *Manager) push(ctx context.Context) error {
 
 	log.Infof("Creating Node Pods")
 	for k, n := range m.nodes {
-		if err := n.Create(ctx); err != nil {
+		start := time.Now()
+		err := n.Create(ctx)
+		m.observeNode(metrics.OpCreate, k, start, err)
+		if err != nil {
 			return err
 		}
 		log.Infof("Node %q resource created", k)
@@ -526,7 +632,25 @@ func (m *Manager) ConfigPush(ctx context.Context, nodeName string, r io.Reader)
 	if !ok {
 		return status.Errorf(codes.Unimplemented, "node %q does not implement ConfigPusher interface", nodeName)
 	}
-	return cp.ConfigPush(ctx, r)
+	start := time.Now()
+	err := cp.ConfigPush(ctx, r)
+	m.observeNode(metrics.OpPush, nodeName, start, err)
+	return err
+}
+
+// Exec executes cmd on the provided node, with the provided standard input,
+// output and error. If the node does not fulfill Execer then
+// status.Unimplemented error will be returned.
+func (m *Manager) Exec(ctx context.Context, nodeName string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	n, ok := m.nodes[nodeName]
+	if !ok {
+		return fmt.Errorf("node %q not found", nodeName)
+	}
+	e, ok := n.(node.Execer)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "node %q does not implement Execer interface", nodeName)
+	}
+	return e.Exec(ctx, cmd, stdin, stdout, stderr)
 }
 
 // ResetCfg will reset the config for the provided node. If the node does
@@ -540,7 +664,20 @@ func (m *Manager) ResetCfg(ctx context.Context, nodeName string) error {
 	if !ok {
 		return status.Errorf(codes.Unimplemented, "node %q does not implement Resetter interface", nodeName)
 	}
-	return r.ResetCfg(ctx)
+	start := time.Now()
+	err := r.ResetCfg(ctx)
+	m.observeNode(metrics.OpReset, nodeName, start, err)
+	return err
+}
+
+// observeNode records the duration of operation op on the named node.
+func (m *Manager) observeNode(op metrics.Operation, name string, start time.Time, err error) {
+	for _, n := range m.topo.GetNodes() {
+		if n.GetName() == name {
+			metrics.ObserveNode(op, n.GetVendor().String(), n.GetModel(), start, err)
+			return
+		}
+	}
 }
 
 // GenerateSelfSigned will create self signed certs on the provided node.
@@ -562,16 +699,57 @@ func (m *Manager) GenerateSelfSigned(ctx context.Context, nodeName string) error
 	return c.GenerateSelfSigned(ctx)
 }
 
-// populateServiceMap modifies m to contain the full service info.
-var populateServiceMap = func(s *corev1.Service, m map[uint32]*tpb.Service) error {
+// populateServiceMap modifies m to contain the full service info.  The
+// outside IP is the load balancer IP of s, preferring IPv4, or, if s has no
+// load balancer, nodeAddr, at which the service is reachable on its node
+// port.  The outside IPv6 address is set for dual stack services.  A port
+// exposed over both TCP and UDP has the TCP_UDP protocol.
+var populateServiceMap = func(s *corev1.Service, nodeAddr string, m map[uint32]*tpb.Service) error {
 	if s == nil || m == nil {
 		return fmt.Errorf("service and map must not be nil")
 	}
-	if len(s.Status.LoadBalancer.Ingress) == 0 {
+	outsideIP := nodeAddr
+	var outsideIPv6 string
+	if len(s.Status.LoadBalancer.Ingress) != 0 {
+		outsideIP = s.Status.LoadBalancer.Ingress[0].IP
+		var v4 string
+		for _, ing := range s.Status.LoadBalancer.Ingress {
+			ip := net.ParseIP(ing.IP)
+			switch {
+			case ip == nil:
+			case ip.To4() != nil:
+				if v4 == "" {
+					v4 = ing.IP
+				}
+			case outsideIPv6 == "":
+				outsideIPv6 = ing.IP
+			}
+		}
+		if v4 != "" {
+			outsideIP = v4
+		}
+	}
+	if outsideIP == "" {
 		return fmt.Errorf("service %s has no external loadbalancer configured", s.Name)
 	}
+	protocols := map[uint32]map[corev1.Protocol]bool{}
 	for _, p := range s.Spec.Ports {
+		if len(s.Status.LoadBalancer.Ingress) == 0 && p.NodePort == 0 {
+			return fmt.Errorf("service %s has no external loadbalancer or node port configured", s.Name)
+		}
 		k := uint32(p.Port)
+		if protocols[k] == nil {
+			protocols[k] = map[corev1.Protocol]bool{}
+		}
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		protocols[k][protocol] = true
+		if len(protocols[k]) > 1 {
+			// The other protocols of a port share the first one's service.
+			continue
+		}
 		service, ok := m[k]
 		if !ok {
 			service = &tpb.Service{
@@ -586,7 +764,20 @@ var populateServiceMap = func(s *corev1.Service, m map[uint32]*tpb.Service) erro
 		service.Inside = uint32(p.TargetPort.IntVal)
 		service.NodePort = uint32(p.NodePort)
 		service.InsideIp = s.Spec.ClusterIP
-		service.OutsideIp = s.Status.LoadBalancer.Ingress[0].IP
+		service.OutsideIp = outsideIP
+		service.OutsideIpv6 = outsideIPv6
+	}
+	for k, ps := range protocols {
+		switch {
+		case ps[corev1.ProtocolTCP] && ps[corev1.ProtocolUDP]:
+			m[k].Protocol = tpb.Service_TCP_UDP
+		case ps[corev1.ProtocolUDP]:
+			m[k].Protocol = tpb.Service_UDP
+		case ps[corev1.ProtocolSCTP]:
+			m[k].Protocol = tpb.Service_SCTP
+		default:
+			m[k].Protocol = tpb.Service_TCP
+		}
 	}
 	return nil
 }
@@ -607,6 +798,18 @@ func (s *stateMap) setNodeState(name string, state node.Status) {
 	s.m[name] = state
 }
 
+// counts returns the number of nodes in each state.
+func (s *stateMap) counts() map[string]int {
+	counts := map[string]int{}
+	if s == nil {
+		return counts
+	}
+	for _, state := range s.m {
+		counts[string(state)]++
+	}
+	return counts
+}
+
 func (s *stateMap) topologyState() cpb.TopologyState {
 	if s == nil || len(s.m) == 0 {
 		return cpb.TopologyState_TOPOLOGY_STATE_UNSPECIFIED
@@ -19,6 +19,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/h-fam/errdiff"
 	tfake "github.com/networkop/meshnet-cni/api/clientset/v1beta1/fake"
 	topologyv1 "github.com/networkop/meshnet-cni/api/types/v1beta1"
+	"github.com/openconfig/kne/ingress"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
@@ -36,9 +39,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
 	kfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	ktest "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/portforward"
 )
 
 func TestLoad(t *testing.T) {
@@ -188,6 +193,34 @@ func TestNew(t *testing.T) {
 			},
 		},
 		wantNodes: []string{"r1", "r2"},
+	}, {
+		desc: "placement pins",
+		topo: &tpb.Topology{
+			Nodes: []*tpb.Node{{Name: "r1", Vendor: tpb.Vendor(1001)}},
+			Placement: &tpb.Placement{
+				Strategy: tpb.Placement_SPREAD,
+				Pins:     map[string]string{"r1": "kne-worker"},
+			},
+		},
+		wantNodes: []string{"r1"},
+	}, {
+		desc: "load err - placement pins missing node",
+		topo: &tpb.Topology{
+			Nodes: []*tpb.Node{{Name: "r1", Vendor: tpb.Vendor(1001)}},
+			Placement: &tpb.Placement{
+				Pins: map[string]string{"r2": "kne-worker"},
+			},
+		},
+		wantErr: "placement pins missing node",
+	}, {
+		desc: "load err - placement pins operator node",
+		topo: &tpb.Topology{
+			Nodes: []*tpb.Node{{Name: "r1", Vendor: tpb.Vendor_NOKIA}},
+			Placement: &tpb.Placement{
+				Pins: map[string]string{"r1": "kne-worker"},
+			},
+		},
+		wantErr: `node "r1" is placed by its vendor operator`,
 	}, {
 		desc:    "nil topo",
 		wantErr: "topology cannot be nil",
@@ -359,6 +392,11 @@ func TestNew(t *testing.T) {
 			if s := cmp.Diff(tt.wantNodes, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); s != "" {
 				t.Errorf("New() nodes unexpected diff (-want +got):\n%s", s)
 			}
+			for name, n := range m.nodes {
+				if got := n.(*configurable).Placement; got != tt.topo.GetPlacement() {
+					t.Errorf("New() node %q placement: got %v, want %v", name, got, tt.topo.GetPlacement())
+				}
+			}
 		})
 	}
 }
@@ -663,12 +701,134 @@ func TestShow(t *testing.T) {
 
 	wantTopoRemapPorts := proto.Clone(topoRemapPorts).(*tpb.Topology)
 
+	wantTopoNodePort := func(addr string) *tpb.Topology {
+		want := proto.Clone(wantTopo).(*tpb.Topology)
+		for _, n := range want.Nodes {
+			for _, s := range n.Services {
+				s.OutsideIp = addr
+			}
+		}
+		return want
+	}
+	// nodePortObjects returns the pods and the services, without load
+	// balancers, of the topology along with extra.
+	nodePortObjects := func(nodePort int32, extra ...runtime.Object) []runtime.Object {
+		objs := []runtime.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		}
+		for _, name := range []string{"r1", "r2"} {
+			objs = append(objs, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				},
+			})
+		}
+		objs = append(objs, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "service-r1", Namespace: "test"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.1.1.1",
+				Type:      "LoadBalancer",
+				Ports: []corev1.ServicePort{
+					{Name: "ssh", Protocol: "TCP", Port: 22, TargetPort: intstr.FromInt(22), NodePort: nodePort},
+				},
+			},
+		}, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "service-r2", Namespace: "test"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.1.1.2",
+				Type:      "LoadBalancer",
+				Ports: []corev1.ServicePort{
+					{Name: "grpc", Protocol: "TCP", Port: 9337, TargetPort: intstr.FromInt(9337), NodePort: 20002},
+					{Name: "gnmi", Protocol: "TCP", Port: 9339, TargetPort: intstr.FromInt(9339), NodePort: 20003},
+				},
+			},
+		})
+		return append(objs, extra...)
+	}
+	// dualStackObjects returns the objects of the topology with services
+	// assigned both IPv4 and IPv6 load balancer addresses.
+	dualStackObjects := func() []runtime.Object {
+		ingress := map[string][]corev1.LoadBalancerIngress{
+			"service-r1": {{IP: "2001:db8::50"}, {IP: "192.168.16.50"}},
+			"service-r2": {{IP: "192.168.16.51"}, {IP: "2001:db8::51"}},
+		}
+		objs := nodePortObjects(20001)
+		for _, o := range objs {
+			if svc, ok := o.(*corev1.Service); ok {
+				svc.Status.LoadBalancer.Ingress = ingress[svc.Name]
+			}
+		}
+		return objs
+	}
+	wantTopoDualStack := proto.Clone(wantTopo).(*tpb.Topology)
+	wantTopoDualStack.Nodes[0].Services[22].OutsideIpv6 = "2001:db8::50"
+	wantTopoDualStack.Nodes[1].Services[9337].OutsideIpv6 = "2001:db8::51"
+	wantTopoDualStack.Nodes[1].Services[9339].OutsideIpv6 = "2001:db8::51"
+	clusterNode := func(name, ip string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeHostName, Address: name},
+					{Type: corev1.NodeInternalIP, Address: ip},
+				},
+			},
+		}
+	}
+	// nodePortIngress returns the config map of the NodePort ingress with
+	// address.
+	nodePortIngress := func(addr string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ingress.ConfigMap, Namespace: ingress.Namespace},
+			Data:       map[string]string{"mode": ingress.NodePort},
+		}
+		if addr != "" {
+			cm.Data["address"] = addr
+		}
+		return cm
+	}
+
 	tests := []struct {
 		desc       string
 		k8sObjects []runtime.Object
 		want       *cpb.ShowTopologyResponse
 		wantErr    string
 	}{{
+		desc:       "dual stack",
+		k8sObjects: dualStackObjects(),
+		want: &cpb.ShowTopologyResponse{
+			State:    cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+			Topology: wantTopoDualStack,
+		},
+	}, {
+		desc:       "node ports",
+		k8sObjects: nodePortObjects(20001, nodePortIngress(""), clusterNode("kne-worker", "172.18.0.3"), clusterNode("kne-control-plane", "172.18.0.2")),
+		want: &cpb.ShowTopologyResponse{
+			State:    cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+			Topology: wantTopoNodePort("172.18.0.2"),
+		},
+	}, {
+		desc:       "node ports with ingress address",
+		k8sObjects: nodePortObjects(20001, clusterNode("kne-control-plane", "172.18.0.2"), nodePortIngress("10.0.0.1")),
+		want: &cpb.ShowTopologyResponse{
+			State:    cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+			Topology: wantTopoNodePort("10.0.0.1"),
+		},
+	}, {
+		desc:       "no load balancer IP without node port ingress",
+		k8sObjects: nodePortObjects(20001, clusterNode("kne-control-plane", "172.18.0.2")),
+		wantErr:    "has no external loadbalancer configured",
+	}, {
+		desc:       "no load balancer or cluster node",
+		k8sObjects: nodePortObjects(20001, nodePortIngress("")),
+		wantErr:    "has no external loadbalancer configured",
+	}, {
+		desc:       "no load balancer or node port",
+		k8sObjects: nodePortObjects(0, nodePortIngress(""), clusterNode("kne-control-plane", "172.18.0.2")),
+		wantErr:    "has no external loadbalancer or node port configured",
+	}, {
 		desc: "success",
 		k8sObjects: []runtime.Object{
 			&corev1.Namespace{
@@ -1093,6 +1253,50 @@ func TestShow(t *testing.T) {
 	}
 }
 
+func TestPopulateServiceMapProtocols(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-r1", Namespace: "test"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.1.1.1",
+			Ports: []corev1.ServicePort{
+				{Name: "ssh", Port: 22, TargetPort: intstr.FromInt(22)},
+				{Name: "dns", Protocol: "TCP", Port: 53, TargetPort: intstr.FromInt(53)},
+				{Name: "dns-udp", Protocol: "UDP", Port: 53, TargetPort: intstr.FromInt(53)},
+				{Name: "syslog", Protocol: "UDP", Port: 514, TargetPort: intstr.FromInt(514)},
+				{Name: "ipfix", Protocol: "SCTP", Port: 4739, TargetPort: intstr.FromInt(4739)},
+			},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "192.168.16.50"}},
+			},
+		},
+	}
+	service := func(name string, port uint32, protocol tpb.Service_Protocol) *tpb.Service {
+		return &tpb.Service{
+			Name:      name,
+			Inside:    port,
+			Outside:   port,
+			InsideIp:  "10.1.1.1",
+			OutsideIp: "192.168.16.50",
+			Protocol:  protocol,
+		}
+	}
+	want := map[uint32]*tpb.Service{
+		22:   service("ssh", 22, tpb.Service_TCP),
+		53:   service("dns", 53, tpb.Service_TCP_UDP),
+		514:  service("syslog", 514, tpb.Service_UDP),
+		4739: service("ipfix", 4739, tpb.Service_SCTP),
+	}
+	got := map[uint32]*tpb.Service{}
+	if err := populateServiceMap(svc, "", got); err != nil {
+		t.Fatalf("populateServiceMap() failed: %v", err)
+	}
+	if s := cmp.Diff(want, got, protocmp.Transform()); s != "" {
+		t.Errorf("populateServiceMap() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
 func TestResources(t *testing.T) {
 	ctx := context.Background()
 	node.Vendor(tpb.Vendor(1005), NewConfigurable)
@@ -1314,6 +1518,51 @@ func TestNodes(t *testing.T) {
 	}
 }
 
+func TestImages(t *testing.T) {
+	topo := &tpb.Topology{
+		Name: "test",
+		Nodes: []*tpb.Node{{
+			Name:   "host",
+			Vendor: tpb.Vendor_HOST,
+		}, {
+			Name:   "srl",
+			Vendor: tpb.Vendor_NOKIA,
+		}, {
+			Name:   "ceos",
+			Vendor: tpb.Vendor_ARISTA,
+			Config: &tpb.Config{
+				Image:     "ceos:4.29",
+				InitImage: "init-wait:latest",
+			},
+		}, {
+			Name:   "host2",
+			Vendor: tpb.Vendor_HOST,
+			Config: &tpb.Config{Image: "alpine:latest"},
+		}},
+	}
+	orig := proto.Clone(topo)
+	got, err := Images(topo)
+	if err != nil {
+		t.Fatalf("Images() failed: %v", err)
+	}
+	want := []string{
+		"alpine:latest",
+		"ceos:4.29",
+		"ghcr.io/nokia/srlinux:latest",
+		"init-wait:latest",
+		node.DefaultInitContainerImage,
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Images() unexpected diff (-want +got):\n%s", s)
+	}
+	if s := cmp.Diff(orig, topo, protocmp.Transform()); s != "" {
+		t.Errorf("Images() modified topology (-want +got):\n%s", s)
+	}
+	if _, err := Images(nil); err == nil {
+		t.Errorf("Images(nil) succeeded")
+	}
+}
+
 func TestConfigPush(t *testing.T) {
 	m := &Manager{
 		nodes: map[string]node.Node{
@@ -1354,6 +1603,71 @@ func TestConfigPush(t *testing.T) {
 	}
 }
 
+type execer struct {
+	*node.Impl
+}
+
+func (e *execer) Exec(_ context.Context, cmd []string, _ io.Reader, stdout, _ io.Writer) error {
+	if cmd[0] == "false" {
+		return fmt.Errorf("command terminated with exit code 1")
+	}
+	fmt.Fprint(stdout, strings.Join(cmd, " "))
+	return nil
+}
+
+// notExecer only has the methods of a node.Node.
+type notExecer struct {
+	node.Node
+}
+
+func TestExec(t *testing.T) {
+	m := &Manager{
+		nodes: map[string]node.Node{
+			"execer":     &execer{},
+			"not_execer": &notExecer{},
+		},
+	}
+	tests := []struct {
+		desc       string
+		name       string
+		cmd        []string
+		wantStdout string
+		wantErr    string
+	}{{
+		desc:       "success",
+		name:       "execer",
+		cmd:        []string{"echo", "hello"},
+		wantStdout: "echo hello",
+	}, {
+		desc:    "command failure",
+		name:    "execer",
+		cmd:     []string{"false"},
+		wantErr: "exit code 1",
+	}, {
+		desc:    "not execer",
+		name:    "not_execer",
+		cmd:     []string{"true"},
+		wantErr: "does not implement Execer interface",
+	}, {
+		desc:    "node not found",
+		name:    "dne",
+		cmd:     []string{"true"},
+		wantErr: "not found",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := m.Exec(context.Background(), tt.name, tt.cmd, nil, &stdout, io.Discard)
+			if s := errdiff.Check(err, tt.wantErr); s != "" {
+				t.Errorf("Exec() unexpected error: %s", s)
+			}
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("Exec() got stdout %q, want %q", got, tt.wantStdout)
+			}
+		})
+	}
+}
+
 func TestResetCfg(t *testing.T) {
 	m := &Manager{
 		nodes: map[string]node.Node{
@@ -1528,3 +1842,162 @@ func TestStateMap(t *testing.T) {
 		})
 	}
 }
+
+type fakePortForwarder struct {
+	ports   []portforward.ForwardedPort
+	stop    <-chan struct{}
+	ready   chan struct{}
+	err     error
+	stopped chan struct{}
+}
+
+func (f *fakePortForwarder) ForwardPorts() error {
+	if f.err != nil {
+		return f.err
+	}
+	close(f.ready)
+	<-f.stop
+	close(f.stopped)
+	return nil
+}
+
+func (f *fakePortForwarder) GetPorts() ([]portforward.ForwardedPort, error) {
+	return f.ports, nil
+}
+
+func TestForward(t *testing.T) {
+	node.Vendor(tpb.Vendor(1006), NewConfigurable)
+	topo := &tpb.Topology{
+		Name: "test",
+		Nodes: []*tpb.Node{{
+			Name:   "r1",
+			Vendor: tpb.Vendor(1006),
+			Services: map[uint32]*tpb.Service{
+				22: {Name: "ssh"},
+			},
+		}, {
+			Name:   "r2",
+			Vendor: tpb.Vendor(1006),
+			Services: map[uint32]*tpb.Service{
+				9337: {Name: "grpc", Inside: 9339},
+				9339: {Name: "gnmi", Inside: 9339},
+				9340: {Name: "gribi"},
+				514:  {Name: "syslog", Protocol: tpb.Service_UDP},
+			},
+		}, {
+			Name:   "r3",
+			Vendor: tpb.Vendor(1006),
+		}},
+	}
+	objs := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r2", Namespace: "test"}},
+	}
+	tests := []struct {
+		desc      string
+		nodes     []string
+		objs      []runtime.Object
+		err       error
+		want      []*Forward
+		wantPorts map[string][]string
+		wantErr   string
+	}{{
+		desc: "all nodes",
+		objs: objs,
+		want: []*Forward{
+			{Node: "r1", Service: "ssh", Local: "localhost:30022", Port: 22},
+			{Node: "r2", Service: "grpc", Local: "localhost:39339", Port: 9339},
+			{Node: "r2", Service: "gribi", Local: "localhost:39340", Port: 9340},
+		},
+		wantPorts: map[string][]string{
+			"r1": {"0:22"},
+			"r2": {"0:9339", "0:9340"},
+		},
+	}, {
+		desc:  "named node",
+		nodes: []string{"r1"},
+		objs:  objs,
+		want: []*Forward{
+			{Node: "r1", Service: "ssh", Local: "localhost:30022", Port: 22},
+		},
+		wantPorts: map[string][]string{
+			"r1": {"0:22"},
+		},
+	}, {
+		desc:    "unknown node",
+		nodes:   []string{"r4"},
+		objs:    objs,
+		wantErr: `node "r4" not found`,
+	}, {
+		desc:    "no pods",
+		nodes:   []string{"r1"},
+		wantErr: `failed to forward ports of node "r1"`,
+	}, {
+		desc:    "forward fails",
+		nodes:   []string{"r1"},
+		objs:    objs,
+		err:     fmt.Errorf("connection refused"),
+		wantErr: "connection refused",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var forwarders []*fakePortForwarder
+			gotPorts := map[string][]string{}
+			origNewPortForwarder := newPortForwarder
+			defer func() {
+				newPortForwarder = origNewPortForwarder
+			}()
+			newPortForwarder = func(_ *rest.Config, _ kubernetes.Interface, namespace, pod, address string, ports []string, stop <-chan struct{}, ready chan struct{}) (portForwarder, error) {
+				if namespace != "test" || address != "localhost" {
+					t.Errorf("newPortForwarder() got namespace %q and address %q, want %q and %q", namespace, address, "test", "localhost")
+				}
+				gotPorts[pod] = ports
+				f := &fakePortForwarder{stop: stop, ready: ready, err: tt.err, stopped: make(chan struct{})}
+				for _, p := range ports {
+					remote, err := strconv.Atoi(strings.TrimPrefix(p, "0:"))
+					if err != nil {
+						t.Fatalf("invalid port %q: %v", p, err)
+					}
+					f.ports = append(f.ports, portforward.ForwardedPort{Local: uint16(30000 + remote%10000), Remote: uint16(remote)})
+				}
+				forwarders = append(forwarders, f)
+				return f, nil
+			}
+			tf, err := tfake.NewSimpleClientset()
+			if err != nil {
+				t.Fatalf("cannot create fake topology clientset: %v", err)
+			}
+			m, err := New(proto.Clone(topo).(*tpb.Topology),
+				WithClusterConfig(&rest.Config{}),
+				WithKubeClient(kfake.NewSimpleClientset(tt.objs...)),
+				WithTopoClient(tf),
+			)
+			if err != nil {
+				t.Fatalf("New() failed to create new topology manager: %v", err)
+			}
+			got, err := m.Forward(ctx, "localhost", tt.nodes...)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Forward() unexpected err: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("Forward() unexpected diff (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.wantPorts, gotPorts); s != "" {
+				t.Errorf("Forward() unexpected forwarded ports diff (-want +got):\n%s", s)
+			}
+			cancel()
+			for _, f := range forwarders {
+				select {
+				case <-f.stopped:
+				case <-time.After(5 * time.Second):
+					t.Errorf("Forward() did not stop forwarding when the context was canceled")
+				}
+			}
+		})
+	}
+}
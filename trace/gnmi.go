@@ -0,0 +1,223 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/kne/gnmiclient"
+	tpb "github.com/openconfig/kne/proto/topo"
+	log "k8s.io/klog/v2"
+)
+
+// Options are the options of reading the forwarding state of nodes.
+type Options struct {
+	// NetworkInstance is the network instance whose AFT is read.  If not
+	// set the instance named default, in any case, is read.
+	NetworkInstance string
+	// Username and Password are sent as gNMI metadata if set.
+	Username string
+	Password string
+}
+
+// Fetch reads the forwarding state of a node from its gNMI client c with a
+// ONCE subscription to its AFT and interface addresses.
+func Fetch(ctx context.Context, c gpb.GNMIClient, opts *Options) (*Table, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	ctx, cancel := context.WithCancel(gnmiclient.WithCredentials(ctx, opts.Username, opts.Password))
+	defer cancel()
+	sub, err := c.Subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instance := opts.NetworkInstance
+	if instance == "" {
+		instance = "*"
+	}
+	var subs []*gpb.Subscription
+	for _, p := range []string{
+		"network-instances/network-instance[name=" + instance + "]/afts",
+		"interfaces/interface/subinterfaces/subinterface/ipv4/addresses/address/state/ip",
+		"interfaces/interface/subinterfaces/subinterface/ipv6/addresses/address/state/ip",
+	} {
+		path, err := gnmiclient.ParsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, &gpb.Subscription{Path: path})
+	}
+	if err := sub.Send(&gpb.SubscribeRequest{
+		Request: &gpb.SubscribeRequest_Subscribe{
+			Subscribe: &gpb.SubscriptionList{
+				Mode:         gpb.SubscriptionList_ONCE,
+				Subscription: subs,
+			},
+		},
+	}); err != nil {
+		return nil, err
+	}
+	t := NewTable()
+	for {
+		resp, err := sub.Recv()
+		if err == io.EOF {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resp.GetSyncResponse() {
+			return t, nil
+		}
+		n := resp.GetUpdate()
+		for _, u := range n.GetUpdate() {
+			elems := append(append([]*gpb.PathElem{}, n.GetPrefix().GetElem()...), u.GetPath().GetElem()...)
+			if err := t.update(elems, u.GetVal(), opts.NetworkInstance); err != nil {
+				return nil, fmt.Errorf("%s: %w", gnmiclient.PathString(elems), err)
+			}
+		}
+	}
+}
+
+// update adds the value v of the leaf at elems to t.  Leaves not needed to
+// trace are ignored.
+func (t *Table) update(elems []*gpb.PathElem, v *gpb.TypedValue, instance string) error {
+	var names []string
+	for _, e := range elems {
+		names = append(names, e.GetName())
+	}
+	key := func(i int, k string) string { return elems[i].GetKey()[k] }
+	path := strings.Join(names, "/")
+	switch {
+	case strings.HasPrefix(path, "interfaces/interface/subinterfaces/subinterface/ipv") && strings.HasSuffix(path, "/addresses/address/state/ip"):
+		s, err := gnmiclient.Scalar(v)
+		if err != nil {
+			return err
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return err
+		}
+		t.Addresses[a] = key(1, "name")
+		return nil
+	case !strings.HasPrefix(path, "network-instances/network-instance/afts/"):
+		return nil
+	}
+	if name := key(1, "name"); instance == "" && !strings.EqualFold(name, "default") || instance != "" && name != instance {
+		return nil
+	}
+	elems, names = elems[3:], names[3:]
+	path = strings.Join(names, "/")
+	switch path {
+	case "ipv4-unicast/ipv4-entry/state/next-hop-group", "ipv6-unicast/ipv6-entry/state/next-hop-group":
+		p, err := netip.ParsePrefix(key(1, "prefix"))
+		if err != nil {
+			return err
+		}
+		id, err := uintValue(v)
+		if err != nil {
+			return err
+		}
+		t.Entries[p.Masked()] = id
+	case "next-hop-groups/next-hop-group/next-hops/next-hop/state/index":
+		id, err := strconv.ParseUint(key(1, "id"), 10, 64)
+		if err != nil {
+			return err
+		}
+		index, err := uintValue(v)
+		if err != nil {
+			return err
+		}
+		t.NextHopGroups[id] = append(t.NextHopGroups[id], index)
+	case "next-hops/next-hop/state/ip-address", "next-hops/next-hop/interface-ref/state/interface", "next-hops/next-hop/interface-ref/state/subinterface":
+		index, err := strconv.ParseUint(key(1, "index"), 10, 64)
+		if err != nil {
+			return err
+		}
+		nh, ok := t.NextHops[index]
+		if !ok {
+			nh = &NextHop{Index: index}
+			t.NextHops[index] = nh
+		}
+		switch names[len(names)-1] {
+		case "ip-address":
+			s, err := gnmiclient.Scalar(v)
+			if err != nil {
+				return err
+			}
+			// Some implementations report directly connected routes with
+			// an unspecified next hop address.
+			if a, err := netip.ParseAddr(s); err == nil && !a.IsUnspecified() {
+				nh.IP = a
+			}
+		case "interface":
+			if nh.Interface, _ = gnmiclient.Scalar(v); nh.Interface == "" {
+				return fmt.Errorf("bad interface %v", v)
+			}
+		case "subinterface":
+			sub, err := uintValue(v)
+			if err != nil {
+				return err
+			}
+			nh.Subinterface = uint32(sub)
+		}
+	}
+	return nil
+}
+
+func uintValue(v *gpb.TypedValue) (uint64, error) {
+	s, err := gnmiclient.Scalar(v)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// FetchAll reads the forwarding state of the nodes of topology t, as returned
+// by topo.Manager.Show, over their gNMI services.  Nodes without a gNMI
+// service or whose state cannot be read are left out, with a warning.
+func FetchAll(ctx context.Context, t *tpb.Topology, opts *Options) map[string]*Table {
+	tables := map[string]*Table{}
+	for _, n := range t.GetNodes() {
+		addr := gnmiclient.Address(n)
+		if addr == "" {
+			log.Warningf("Node %q has no gNMI service", n.GetName())
+			continue
+		}
+		c, closer, err := gnmiclient.Dial(ctx, addr)
+		if err != nil {
+			log.Warningf("Failed to dial gNMI service of node %q at %s: %v", n.GetName(), addr, err)
+			continue
+		}
+		table, err := Fetch(ctx, c, opts)
+		if err := closer(); err != nil {
+			log.Warningf("Failed to close gNMI connection to node %q: %v", n.GetName(), err)
+		}
+		if err != nil {
+			log.Warningf("Failed to read forwarding state of node %q: %v", n.GetName(), err)
+			continue
+		}
+		log.Infof("Read %d routes of node %q", len(table.Entries), n.GetName())
+		tables[n.GetName()] = table
+	}
+	return tables
+}
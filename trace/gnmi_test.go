@@ -0,0 +1,173 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/h-fam/errdiff"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/kne/gnmiclient"
+	"github.com/openconfig/kne/gnmiclient/fake"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+func str(s string) *gpb.TypedValue {
+	return &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: s}}
+}
+
+func uintVal(u uint64) *gpb.TypedValue {
+	return &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: u}}
+}
+
+func jsonVal(s string) *gpb.TypedValue {
+	return &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(s)}}
+}
+
+func parsePath(p string) *gpb.Path {
+	path, err := gnmiclient.ParsePath(p)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+func update(path string, v *gpb.TypedValue) *gpb.Update {
+	return &gpb.Update{Path: parsePath(path), Val: v}
+}
+
+func TestFetch(t *testing.T) {
+	aft := &gpb.Notification{
+		Prefix: parsePath("network-instances/network-instance[name=default]/afts"),
+		Update: []*gpb.Update{
+			update("ipv4-unicast/ipv4-entry[prefix=0.0.0.0/0]/state/next-hop-group", uintVal(1)),
+			update("ipv6-unicast/ipv6-entry[prefix=2001:db8::1/64]/state/next-hop-group", jsonVal("2")),
+			update("next-hop-groups/next-hop-group[id=1]/next-hops/next-hop[index=7]/state/index", uintVal(7)),
+			update("next-hop-groups/next-hop-group[id=2]/next-hops/next-hop[index=8]/state/index", jsonVal("8")),
+			update("next-hops/next-hop[index=7]/state/ip-address", str("192.168.0.1")),
+			update("next-hops/next-hop[index=7]/interface-ref/state/interface", str("ethernet-1/1")),
+			update("next-hops/next-hop[index=7]/interface-ref/state/subinterface", uintVal(0)),
+			update("next-hops/next-hop[index=8]/state/ip-address", jsonVal(`"0.0.0.0"`)),
+			update("next-hops/next-hop[index=8]/interface-ref/state/interface", jsonVal(`"ethernet-1/2"`)),
+			update("next-hops/next-hop[index=8]/interface-ref/state/subinterface", jsonVal("3")),
+			update("next-hops/next-hop[index=8]/state/weight", uintVal(1)),
+		},
+	}
+	other := &gpb.Notification{
+		Prefix: parsePath("network-instances/network-instance[name=mgmt]/afts"),
+		Update: []*gpb.Update{
+			update("ipv4-unicast/ipv4-entry[prefix=172.16.0.0/16]/state/next-hop-group", uintVal(9)),
+		},
+	}
+	addrs := &gpb.Notification{
+		Update: []*gpb.Update{
+			update("interfaces/interface[name=ethernet-1/1]/subinterfaces/subinterface[index=0]/ipv4/addresses/address[ip=192.168.0.0]/state/ip", str("192.168.0.0")),
+			update("interfaces/interface[name=system0]/subinterfaces/subinterface[index=0]/ipv6/addresses/address[ip=2001:db8::1]/state/ip", jsonVal(`"2001:db8::1"`)),
+		},
+	}
+	f := &fake.GNMI{Notifications: []*gpb.Notification{aft, other, addrs}, Sync: true}
+	got, err := Fetch(context.Background(), fake.Serve(t, f), &Options{Username: "admin", Password: "admin"})
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	want := &Table{
+		Entries: map[netip.Prefix]uint64{
+			netip.MustParsePrefix("0.0.0.0/0"):     1,
+			netip.MustParsePrefix("2001:db8::/64"): 2,
+		},
+		NextHopGroups: map[uint64][]uint64{1: {7}, 2: {8}},
+		NextHops: map[uint64]*NextHop{
+			7: {Index: 7, IP: netip.MustParseAddr("192.168.0.1"), Interface: "ethernet-1/1"},
+			8: {Index: 8, Interface: "ethernet-1/2", Subinterface: 3},
+		},
+		Addresses: map[netip.Addr]string{
+			netip.MustParseAddr("192.168.0.0"): "ethernet-1/1",
+			netip.MustParseAddr("2001:db8::1"): "system0",
+		},
+	}
+	if s := cmp.Diff(want, got, cmp.Comparer(func(a, b netip.Addr) bool { return a == b }), cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); s != "" {
+		t.Errorf("Fetch() unexpected table (-want +got):\n%s", s)
+	}
+	if got := f.Usernames(); len(got) != 1 || got[0] != "admin" {
+		t.Errorf("Fetch() sent usernames %q, want %q", got, "admin")
+	}
+	if got, want := gnmiclient.PathString(f.Requests()[0].GetSubscribe().GetSubscription()[0].GetPath().GetElem()), "/network-instances/network-instance[name=*]/afts"; got != want {
+		t.Errorf("Fetch() subscribed to %q, want %q", got, want)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		u    *gpb.Update
+		want string
+	}{{
+		desc: "bad prefix",
+		u:    update("network-instances/network-instance[name=DEFAULT]/afts/ipv4-unicast/ipv4-entry[prefix=foo]/state/next-hop-group", uintVal(1)),
+		want: `netip.ParsePrefix("foo")`,
+	}, {
+		desc: "bad next hop group",
+		u:    update("network-instances/network-instance[name=DEFAULT]/afts/ipv4-unicast/ipv4-entry[prefix=10.0.0.0/8]/state/next-hop-group", str("x")),
+		want: "invalid syntax",
+	}, {
+		desc: "unsupported value",
+		u:    update("interfaces/interface[name=eth1]/subinterfaces/subinterface[index=0]/ipv4/addresses/address[ip=10.0.0.1]/state/ip", &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: []byte("x")}}),
+		want: "unsupported value",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := &fake.GNMI{Notifications: []*gpb.Notification{{Update: []*gpb.Update{tt.u}}}, Sync: true}
+			_, err := Fetch(context.Background(), fake.Serve(t, f), &Options{NetworkInstance: "DEFAULT"})
+			if s := errdiff.Substring(err, tt.want); s != "" {
+				t.Errorf("Fetch() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	f := &fake.GNMI{Sync: true, Notifications: []*gpb.Notification{{
+		Update: []*gpb.Update{
+			update("interfaces/interface[name=eth1]/subinterfaces/subinterface[index=0]/ipv4/addresses/address[ip=10.0.0.1]/state/ip", str("10.0.0.1")),
+		},
+	}}}
+	c := fake.Serve(t, f)
+	origDial := gnmiclient.Dial
+	defer func() { gnmiclient.Dial = origDial }()
+	var dialed []string
+	gnmiclient.Dial = func(_ context.Context, addr string) (gpb.GNMIClient, func() error, error) {
+		dialed = append(dialed, addr)
+		if addr == "10.1.1.3:9339" {
+			return nil, nil, fmt.Errorf("connection refused")
+		}
+		return c, func() error { return nil }, nil
+	}
+	gnmi := func(ip string) map[uint32]*tpb.Service {
+		return map[uint32]*tpb.Service{
+			22:   {Name: "ssh", Outside: 22, OutsideIp: ip},
+			9339: {Name: "gnmi", Outside: 9339, OutsideIp: ip},
+		}
+	}
+	topo := &tpb.Topology{
+		Nodes: []*tpb.Node{
+			{Name: "r1", Services: gnmi("10.1.1.1")},
+			{Name: "r2"},
+			{Name: "r3", Services: gnmi("10.1.1.3")},
+			{Name: "r4", Services: gnmi("2001:db8::4")},
+		},
+	}
+	tables := FetchAll(context.Background(), topo, nil)
+	var got []string
+	for name := range tables {
+		got = append(got, name)
+	}
+	if s := cmp.Diff([]string{"r1", "r4"}, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); s != "" {
+		t.Errorf("FetchAll() unexpected nodes (-want +got):\n%s", s)
+	}
+	if s := cmp.Diff([]string{"10.1.1.1:9339", "10.1.1.3:9339", "[2001:db8::4]:9339"}, dialed); s != "" {
+		t.Errorf("FetchAll() unexpected addresses dialed (-want +got):\n%s", s)
+	}
+}
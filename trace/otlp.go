@@ -0,0 +1,301 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestIDAttribute is the attribute of the x-request-id of a request.  It
+// is the tag Envoy sets on its spans, so the network spans of a request can
+// be joined with those of the service mesh.
+const RequestIDAttribute = "guid:x-request-id"
+
+// DefaultServiceName is the service name of the resource of exported spans.
+const DefaultServiceName = "kne"
+
+// A Request is a request traced through the topology, such as an HTTP
+// request identified by its x-request-id.
+type Request struct {
+	// ID is the x-request-id of the request.
+	ID string
+	// Paths are the paths the request was traced on.
+	Paths []*Result
+	// Start and End are when the request was traced.  The spans of the hops
+	// of a path divide this time in path order; they are not measured.
+	Start, End time.Time
+}
+
+// TraceID returns the OTLP trace id of r: its ID if that is a UUID or 32
+// hex digits, as Envoy generates, or else a hash of its ID.
+func (r *Request) TraceID() string {
+	id := strings.ToLower(strings.ReplaceAll(r.ID, "-", ""))
+	if b, err := hex.DecodeString(id); err == nil && len(b) == 16 {
+		return id
+	}
+	return hash(r.ID)[:32]
+}
+
+// hash returns the hex sha256 of the parts joined by "/".
+func hash(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "/")))
+	return hex.EncodeToString(h[:])
+}
+
+// The OTLP/JSON encoding of traces, as defined by the trace service of the
+// OpenTelemetry protocol.  Integers of 64 bits are strings and ids are hex.
+type (
+	tracesData struct {
+		ResourceSpans []*resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource      `json:"resource"`
+		ScopeSpans []*scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []*keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope   `json:"scope"`
+		Spans []*span `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	span struct {
+		TraceID           string      `json:"traceId"`
+		SpanID            string      `json:"spanId"`
+		ParentSpanID      string      `json:"parentSpanId,omitempty"`
+		Name              string      `json:"name"`
+		Kind              int         `json:"kind"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+		Attributes        []*keyValue `json:"attributes,omitempty"`
+		Events            []*event    `json:"events,omitempty"`
+		Status            *status     `json:"status,omitempty"`
+	}
+	event struct {
+		TimeUnixNano string      `json:"timeUnixNano"`
+		Name         string      `json:"name"`
+		Attributes   []*keyValue `json:"attributes,omitempty"`
+	}
+	status struct {
+		Message string `json:"message,omitempty"`
+		Code    int    `json:"code"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+	}
+)
+
+// Span kinds and status codes.
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func stringAttr(k, v string) *keyValue {
+	return &keyValue{Key: k, Value: anyValue{StringValue: &v}}
+}
+
+func intAttr(k string, v int) *keyValue {
+	s := strconv.Itoa(v)
+	return &keyValue{Key: k, Value: anyValue{IntValue: &s}}
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// An Exporter writes traced requests as OTLP/JSON spans.
+type Exporter struct {
+	// ServiceName is the service.name of the resource of the spans,
+	// DefaultServiceName if not set.
+	ServiceName string
+	// BadInterfaces maps nodes to their interfaces found to be bad, such as
+	// those with TTL expiries.  They are events of the spans of the nodes
+	// on the paths of a request, or else of the span of the request.
+	BadInterfaces map[string][]string
+}
+
+// Write writes the spans of reqs to w as OTLP/JSON.  Each request is a
+// trace with a span for the request, a child span for each path and a
+// child span of the path for each hop, in order.
+func (e *Exporter) Write(w io.Writer, reqs []*Request) error {
+	name := e.ServiceName
+	if name == "" {
+		name = DefaultServiceName
+	}
+	ss := &scopeSpans{Scope: scope{Name: "github.com/openconfig/kne/trace"}, Spans: []*span{}}
+	for _, r := range reqs {
+		ss.Spans = append(ss.Spans, e.spans(r)...)
+	}
+	td := &tracesData{ResourceSpans: []*resourceSpans{{
+		Resource:   resource{Attributes: []*keyValue{stringAttr("service.name", name)}},
+		ScopeSpans: []*scopeSpans{ss},
+	}}}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(td)
+}
+
+// WriteFile writes the spans of reqs to the file path as OTLP/JSON, which
+// collectors and trace viewers can import.
+func (e *Exporter) WriteFile(path string, reqs []*Request) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := e.Write(f, reqs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// spans returns the spans of r.
+func (e *Exporter) spans(r *Request) []*span {
+	start, end := r.Start, r.End
+	if start.IsZero() {
+		start = time.Now()
+	}
+	if end.Before(start) {
+		end = start
+	}
+	traceID := r.TraceID()
+	root := &span{
+		TraceID:           traceID,
+		SpanID:            hash(traceID, "request")[:16],
+		Name:              "network " + r.ID,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: nanos(start),
+		EndTimeUnixNano:   nanos(end),
+		Attributes:        []*keyValue{stringAttr(RequestIDAttribute, r.ID)},
+		Status:            &status{Code: statusOK},
+	}
+	spans := []*span{root}
+	onPath := map[string]bool{}
+	for i, p := range r.Paths {
+		ps := &span{
+			TraceID:           traceID,
+			SpanID:            hash(traceID, "path", strconv.Itoa(i))[:16],
+			ParentSpanID:      root.SpanID,
+			Name:              fmt.Sprintf("path %s -> %s", p.Source, p.Destination),
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: nanos(start),
+			EndTimeUnixNano:   nanos(end),
+			Attributes: []*keyValue{
+				stringAttr("kne.source", p.Source),
+				stringAttr("kne.destination", p.Destination),
+			},
+			Status: &status{Code: statusOK},
+		}
+		if p.Status != "" {
+			ps.Attributes = append(ps.Attributes, stringAttr("kne.trace.status", string(p.Status)))
+		}
+		if p.Status != "" && p.Status != Delivered {
+			ps.Status = &status{Code: statusError, Message: p.Reason}
+			root.Status = &status{Code: statusError, Message: fmt.Sprintf("path %d: %s", i, p.Status)}
+		}
+		spans = append(spans, ps)
+		var step time.Duration
+		if len(p.Path) > 0 {
+			step = end.Sub(start) / time.Duration(len(p.Path))
+		}
+		for j, h := range p.Path {
+			hs := &span{
+				TraceID:           traceID,
+				SpanID:            hash(traceID, "path", strconv.Itoa(i), strconv.Itoa(j))[:16],
+				ParentSpanID:      ps.SpanID,
+				Name:              h.Node,
+				Kind:              spanKindInternal,
+				StartTimeUnixNano: nanos(start.Add(time.Duration(j) * step)),
+				EndTimeUnixNano:   nanos(start.Add(time.Duration(j+1) * step)),
+				Attributes:        hopAttributes(j, h),
+			}
+			hs.Events = e.events(h.Node, hs.EndTimeUnixNano)
+			if len(hs.Events) > 0 {
+				hs.Status = &status{Code: statusError, Message: "bad interfaces"}
+			}
+			onPath[h.Node] = true
+			spans = append(spans, hs)
+		}
+	}
+	var others []string
+	for n := range e.BadInterfaces {
+		if !onPath[n] {
+			others = append(others, n)
+		}
+	}
+	sort.Strings(others)
+	for _, n := range others {
+		root.Events = append(root.Events, e.events(n, root.EndTimeUnixNano)...)
+	}
+	return spans
+}
+
+// hopAttributes returns the attributes of the span of the i'th hop h.
+func hopAttributes(i int, h *Hop) []*keyValue {
+	attrs := []*keyValue{
+		stringAttr("kne.node", h.Node),
+		intAttr("kne.hop", i),
+	}
+	for _, a := range []struct{ k, v string }{
+		{"kne.interface.ingress", h.InInterface},
+		{"kne.interface.egress", h.OutInterface},
+		{"kne.prefix", h.Prefix},
+		{"kne.next_hop", h.NextHop},
+	} {
+		if a.v != "" {
+			attrs = append(attrs, stringAttr(a.k, a.v))
+		}
+	}
+	if h.ECMP > 0 {
+		attrs = append(attrs, intAttr("kne.ecmp", h.ECMP))
+	}
+	return attrs
+}
+
+// events returns an event for each bad interface of node at time t.
+func (e *Exporter) events(node, t string) []*event {
+	var events []*event
+	for _, intf := range e.BadInterfaces[node] {
+		events = append(events, &event{
+			TimeUnixNano: t,
+			Name:         "bad interface",
+			Attributes: []*keyValue{
+				stringAttr("kne.node", node),
+				stringAttr("kne.interface", intf),
+			},
+		})
+	}
+	return events
+}
@@ -0,0 +1,190 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// flatSpan is the part of a span compared in tests, with its parent by name.
+type flatSpan struct {
+	Name, Parent string
+	Start, End   string
+	Attrs        map[string]string
+	Events       []string
+	Status       int
+}
+
+func flatten(t *testing.T, b []byte) (string, []*flatSpan) {
+	t.Helper()
+	td := &tracesData{}
+	if err := json.Unmarshal(b, td); err != nil {
+		t.Fatalf("bad OTLP/JSON: %v", err)
+	}
+	if len(td.ResourceSpans) != 1 || len(td.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("got %d resource spans, want 1 with 1 scope", len(td.ResourceSpans))
+	}
+	rs := td.ResourceSpans[0]
+	service := *rs.Resource.Attributes[0].Value.StringValue
+	names := map[string]string{}
+	for _, s := range rs.ScopeSpans[0].Spans {
+		names[s.SpanID] = s.Name
+	}
+	attrs := func(kvs []*keyValue) map[string]string {
+		m := map[string]string{}
+		for _, kv := range kvs {
+			if kv.Value.StringValue != nil {
+				m[kv.Key] = *kv.Value.StringValue
+			} else {
+				m[kv.Key] = "int " + *kv.Value.IntValue
+			}
+		}
+		return m
+	}
+	var spans []*flatSpan
+	for _, s := range rs.ScopeSpans[0].Spans {
+		if len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("span %s: bad ids %q %q", s.Name, s.TraceID, s.SpanID)
+		}
+		fs := &flatSpan{
+			Name:   s.Name,
+			Parent: names[s.ParentSpanID],
+			Start:  s.StartTimeUnixNano,
+			End:    s.EndTimeUnixNano,
+			Attrs:  attrs(s.Attributes),
+		}
+		if s.Status != nil {
+			fs.Status = s.Status.Code
+		}
+		for _, e := range s.Events {
+			a := attrs(e.Attributes)
+			fs.Events = append(fs.Events, e.Name+" "+a["kne.node"]+" "+a["kne.interface"])
+		}
+		spans = append(spans, fs)
+	}
+	return service, spans
+}
+
+func TestTraceID(t *testing.T) {
+	for _, tt := range []struct {
+		id, want string
+	}{
+		{"4bf92f35-77b3-4da6-a3ce-929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"4BF92F3577B34DA6A3CE929D0E0E4736", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"test-request-srl1-0", hash("test-request-srl1-0")[:32]},
+	} {
+		if got := (&Request{ID: tt.id}).TraceID(); got != tt.want {
+			t.Errorf("TraceID(%q) got %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestExporter(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	reqs := []*Request{{
+		ID:    "4bf92f35-77b3-4da6-a3ce-929d0e0e4736",
+		Start: start,
+		End:   start.Add(300 * time.Millisecond),
+		Paths: []*Result{{
+			Source:      "r1",
+			Destination: "10.0.0.2",
+			Status:      Loop,
+			Reason:      `node "r2" is already on the path`,
+			Path: []*Hop{
+				{Node: "r1", Prefix: "10.0.0.0/8", NextHop: "192.168.0.1", OutInterface: "eth1", ECMP: 2},
+				{Node: "r2", InInterface: "eth1", OutInterface: "eth2"},
+			},
+		}},
+	}}
+	e := &Exporter{BadInterfaces: map[string][]string{"r2": {"eth2"}, "r9": {"eth1", "eth3"}}}
+	var buf bytes.Buffer
+	if err := e.Write(&buf, reqs); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	service, got := flatten(t, buf.Bytes())
+	if service != DefaultServiceName {
+		t.Errorf("Write() got service %q, want %q", service, DefaultServiceName)
+	}
+	want := []*flatSpan{{
+		Name:   "network 4bf92f35-77b3-4da6-a3ce-929d0e0e4736",
+		Start:  "1700000000000000000",
+		End:    "1700000000300000000",
+		Attrs:  map[string]string{RequestIDAttribute: "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"},
+		Events: []string{"bad interface r9 eth1", "bad interface r9 eth3"},
+		Status: statusError,
+	}, {
+		Name:   "path r1 -> 10.0.0.2",
+		Parent: "network 4bf92f35-77b3-4da6-a3ce-929d0e0e4736",
+		Start:  "1700000000000000000",
+		End:    "1700000000300000000",
+		Attrs:  map[string]string{"kne.source": "r1", "kne.destination": "10.0.0.2", "kne.trace.status": "loop"},
+		Status: statusError,
+	}, {
+		Name:   "r1",
+		Parent: "path r1 -> 10.0.0.2",
+		Start:  "1700000000000000000",
+		End:    "1700000000150000000",
+		Attrs: map[string]string{
+			"kne.node":             "r1",
+			"kne.hop":              "int 0",
+			"kne.interface.egress": "eth1",
+			"kne.prefix":           "10.0.0.0/8",
+			"kne.next_hop":         "192.168.0.1",
+			"kne.ecmp":             "int 2",
+		},
+	}, {
+		Name:   "r2",
+		Parent: "path r1 -> 10.0.0.2",
+		Start:  "1700000000150000000",
+		End:    "1700000000300000000",
+		Attrs: map[string]string{
+			"kne.node":              "r2",
+			"kne.hop":               "int 1",
+			"kne.interface.ingress": "eth1",
+			"kne.interface.egress":  "eth2",
+		},
+		Events: []string{"bad interface r2 eth2"},
+		Status: statusError,
+	}}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("Write() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestExporterWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	reqs := []*Request{
+		{ID: "a", Paths: []*Result{{Source: "r1", Destination: "10.0.0.2", Status: Delivered, Path: []*Hop{{Node: "r1"}}}}},
+		{ID: "b"},
+	}
+	e := &Exporter{ServiceName: "wtf-network"}
+	if err := e.WriteFile(path, reqs); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, got := flatten(t, b)
+	if service != "wtf-network" {
+		t.Errorf("WriteFile() got service %q, want wtf-network", service)
+	}
+	var names []string
+	for _, s := range got {
+		names = append(names, s.Name)
+		if s.Status == statusError {
+			t.Errorf("WriteFile() span %s has error status", s.Name)
+		}
+	}
+	if s := cmp.Diff([]string{"network a", "path r1 -> 10.0.0.2", "r1", "network b"}, names); s != "" {
+		t.Errorf("WriteFile() unexpected spans (-want +got):\n%s", s)
+	}
+	if err := e.WriteFile(filepath.Join(path, "bad"), reqs); err == nil {
+		t.Errorf("WriteFile() to a bad path succeeded")
+	}
+}
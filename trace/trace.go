@@ -0,0 +1,254 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trace traces the forwarding path of packets through a running
+// topology hop by hop, using the abstract forwarding tables (AFTs) of the
+// nodes read over gNMI.
+//
+// At each node the route to the destination is found by longest prefix match
+// on the AFT and the packet is followed to the node owning the address of
+// the next hop, or else to the peer of the outgoing interface in the links of
+// the topology.  The trace ends when the packet reaches a node owning the
+// destination, revisits a node, has no route, or leaves the topology.
+package trace
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/openconfig/kne/addressing"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+// Status is how a trace ended.
+type Status string
+
+const (
+	// Delivered means the last node owns the destination.
+	Delivered = Status("delivered")
+	// Loop means the last node is already on the path.
+	Loop = Status("loop")
+	// Blackhole means the last node has no route to the destination, or
+	// discards the traffic to it.
+	Blackhole = Status("blackhole")
+	// Exited means the last node forwards the traffic out of the
+	// topology.
+	Exited = Status("exited")
+	// Unknown means the forwarding state of the last node could not be
+	// read.
+	Unknown = Status("unknown")
+)
+
+// maxResolve is the maximum depth of recursive next hop resolution.
+const maxResolve = 8
+
+// A NextHop is a next hop in an AFT.
+type NextHop struct {
+	Index uint64
+	// IP is the address of the next hop.  It is not set for directly
+	// connected routes.
+	IP           netip.Addr
+	Interface    string
+	Subinterface uint32
+}
+
+// A Table is the forwarding state of a node.
+type Table struct {
+	// Entries maps the prefixes of the IPv4 and IPv6 entries of the AFT
+	// to their next hop groups.
+	Entries map[netip.Prefix]uint64
+	// NextHopGroups maps the id of each next hop group to the indices of
+	// its next hops.
+	NextHopGroups map[uint64][]uint64
+	NextHops      map[uint64]*NextHop
+	// Addresses maps the addresses of the node to their interfaces.
+	Addresses map[netip.Addr]string
+}
+
+// NewTable returns an empty Table.
+func NewTable() *Table {
+	return &Table{
+		Entries:       map[netip.Prefix]uint64{},
+		NextHopGroups: map[uint64][]uint64{},
+		NextHops:      map[uint64]*NextHop{},
+		Addresses:     map[netip.Addr]string{},
+	}
+}
+
+// Lookup returns the longest prefix matching dst and the next hops of its
+// route, in the order of their indices.  ok is false if no prefix matches.
+// Next hops missing from the table are left out, so a route with no next
+// hops discards the traffic.
+func (t *Table) Lookup(dst netip.Addr) (prefix netip.Prefix, nhs []*NextHop, ok bool) {
+	for p := range t.Entries {
+		if p.Contains(dst) && (!ok || p.Bits() > prefix.Bits()) {
+			prefix, ok = p, true
+		}
+	}
+	if !ok {
+		return prefix, nil, false
+	}
+	indices := append([]uint64{}, t.NextHopGroups[t.Entries[prefix]]...)
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	for _, i := range indices {
+		if nh, ok := t.NextHops[i]; ok {
+			nhs = append(nhs, nh)
+		}
+	}
+	return prefix, nhs, true
+}
+
+// resolve returns the outgoing interface of nh, looking up the route to the
+// address of nh if nh has no interface.
+func (t *Table) resolve(nh *NextHop) (intf string, sub uint32) {
+	for i := 0; i < maxResolve && nh != nil; i++ {
+		if nh.Interface != "" {
+			return nh.Interface, nh.Subinterface
+		}
+		if !nh.IP.IsValid() {
+			return "", 0
+		}
+		_, nhs, _ := t.Lookup(nh.IP)
+		if len(nhs) == 0 {
+			return "", 0
+		}
+		nh = nhs[0]
+	}
+	return "", 0
+}
+
+// A Hop is a node on the path.
+type Hop struct {
+	Node string `json:"node"`
+	// InInterface is the interface the packet is received on.  It is not
+	// set for the source.
+	InInterface string `json:"in_interface,omitempty"`
+	// Prefix is the prefix of the route used.
+	Prefix string `json:"prefix,omitempty"`
+	// NextHop is the address of the next hop, if any.
+	NextHop string `json:"next_hop,omitempty"`
+	// OutInterface is the interface the packet is sent on.
+	OutInterface string `json:"out_interface,omitempty"`
+	// ECMP is the number of next hops of the route, if more than one.
+	// The packet is followed through the next hop with the lowest index.
+	ECMP int `json:"ecmp,omitempty"`
+}
+
+// A Result is the path traced to a destination.
+type Result struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Status      Status `json:"status"`
+	// Reason describes why the trace ended, unless it was delivered.
+	Reason string `json:"reason,omitempty"`
+	Path   []*Hop `json:"path"`
+}
+
+// end is an end of a link.
+type end struct {
+	node, intf string
+}
+
+// Trace traces the path of packets from node src to dst through topology t
+// using the tables of its nodes.  Nodes without a table end the trace with
+// status Unknown.
+func Trace(t *tpb.Topology, tables map[string]*Table, src string, dst netip.Addr) (*Result, error) {
+	nodes := map[string]*tpb.Node{}
+	for _, n := range t.GetNodes() {
+		nodes[n.GetName()] = n
+	}
+	if _, ok := nodes[src]; !ok {
+		return nil, fmt.Errorf("node %q not found", src)
+	}
+	// Links are keyed by both the names and the keys of their interfaces,
+	// as the AFTs use names.
+	links := map[end]end{}
+	owners := map[netip.Addr]end{}
+	for _, l := range t.GetLinks() {
+		a := end{l.GetANode(), l.GetAInt()}
+		z := end{l.GetZNode(), l.GetZInt()}
+		an := end{a.node, addressing.InterfaceName(nodes[a.node], a.intf)}
+		zn := end{z.node, addressing.InterfaceName(nodes[z.node], z.intf)}
+		for _, e := range []end{a, an} {
+			links[e] = zn
+		}
+		for _, e := range []end{z, zn} {
+			links[e] = an
+		}
+	}
+	for name, t := range tables {
+		for a, intf := range t.Addresses {
+			owners[a] = end{name, intf}
+		}
+	}
+
+	r := &Result{Source: src, Destination: dst.String()}
+	visited := map[string]bool{}
+	hop := &Hop{Node: src}
+	for {
+		r.Path = append(r.Path, hop)
+		if visited[hop.Node] {
+			r.Status, r.Reason = Loop, fmt.Sprintf("node %q is already on the path", hop.Node)
+			return r, nil
+		}
+		visited[hop.Node] = true
+		table, ok := tables[hop.Node]
+		if !ok {
+			r.Status, r.Reason = Unknown, fmt.Sprintf("no forwarding state for node %q", hop.Node)
+			return r, nil
+		}
+		if _, ok := table.Addresses[dst]; ok {
+			r.Status = Delivered
+			return r, nil
+		}
+		prefix, nhs, ok := table.Lookup(dst)
+		if !ok {
+			r.Status, r.Reason = Blackhole, fmt.Sprintf("node %q has no route to %s", hop.Node, dst)
+			return r, nil
+		}
+		hop.Prefix = prefix.String()
+		if len(nhs) == 0 {
+			r.Status, r.Reason = Blackhole, fmt.Sprintf("node %q discards traffic to %s", hop.Node, prefix)
+			return r, nil
+		}
+		if len(nhs) > 1 {
+			hop.ECMP = len(nhs)
+		}
+		nh := nhs[0]
+		if nh.IP.IsValid() {
+			hop.NextHop = nh.IP.String()
+		}
+		intf, sub := table.resolve(nh)
+		if intf != "" {
+			hop.OutInterface = intf
+			if sub != 0 {
+				hop.OutInterface = fmt.Sprintf("%s.%d", intf, sub)
+			}
+		}
+		next := &Hop{}
+		if o, ok := owners[nh.IP]; ok && o.node != hop.Node {
+			next.Node, next.InInterface = o.node, o.intf
+		} else if peer, ok := links[end{hop.Node, intf}]; ok {
+			next.Node, next.InInterface = peer.node, peer.intf
+		} else if intf == "" {
+			r.Status, r.Reason = Blackhole, fmt.Sprintf("node %q cannot resolve next hop %s", hop.Node, nh.IP)
+			return r, nil
+		} else {
+			r.Status, r.Reason = Exited, fmt.Sprintf("node %q forwards out of the topology on %s", hop.Node, hop.OutInterface)
+			return r, nil
+		}
+		hop = next
+	}
+}
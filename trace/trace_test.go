@@ -0,0 +1,258 @@
+package trace
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+)
+
+// line returns a topology of three nodes in a line, r1-r2-r3, with r3 also
+// linked to an egress outside of the topology.
+func line() *tpb.Topology {
+	return &tpb.Topology{
+		Name: "line",
+		Nodes: []*tpb.Node{
+			{Name: "r1", Vendor: tpb.Vendor_NOKIA},
+			{Name: "r2", Vendor: tpb.Vendor_ARISTA},
+			{Name: "r3", Vendor: tpb.Vendor_HOST},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "e1-1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r2", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+		},
+	}
+}
+
+type route struct {
+	prefix string
+	nhs    []*NextHop
+}
+
+// table returns a table with addresses and routes.  Next hops are indexed
+// in order across routes.
+func table(addrs map[string]string, routes ...route) *Table {
+	t := NewTable()
+	for a, intf := range addrs {
+		t.Addresses[netip.MustParseAddr(a)] = intf
+	}
+	var index uint64
+	for i, r := range routes {
+		id := uint64(i + 1)
+		t.Entries[netip.MustParsePrefix(r.prefix)] = id
+		t.NextHopGroups[id] = []uint64{}
+		for _, nh := range r.nhs {
+			index++
+			nh.Index = index
+			t.NextHops[index] = nh
+			t.NextHopGroups[id] = append(t.NextHopGroups[id], index)
+		}
+	}
+	return t
+}
+
+func nh(ip, intf string) *NextHop {
+	nh := &NextHop{Interface: intf}
+	if ip != "" {
+		nh.IP = netip.MustParseAddr(ip)
+	}
+	return nh
+}
+
+func tables() map[string]*Table {
+	return map[string]*Table{
+		"r1": table(map[string]string{"192.168.0.0": "ethernet-1/1", "10.0.0.1": "system0"},
+			route{"192.168.0.0/31", []*NextHop{nh("", "ethernet-1/1")}},
+			route{"0.0.0.0/0", []*NextHop{nh("192.168.0.1", "")}},
+		),
+		"r2": table(map[string]string{"192.168.0.1": "Ethernet1", "192.168.0.2": "Ethernet2", "10.0.0.2": "Loopback0"},
+			route{"192.168.0.0/31", []*NextHop{nh("", "Ethernet1")}},
+			route{"192.168.0.2/31", []*NextHop{nh("", "Ethernet2")}},
+			route{"10.0.0.1/32", []*NextHop{nh("192.168.0.0", "Ethernet1")}},
+			route{"10.0.0.3/32", []*NextHop{nh("192.168.0.3", "Ethernet2"), nh("192.168.0.3", "Ethernet2")}},
+			route{"10.1.0.0/16", nil},
+			route{"10.2.0.0/16", []*NextHop{nh("192.168.0.0", "Ethernet1")}},
+			route{"10.3.0.0/16", []*NextHop{nh("", "Ethernet3")}},
+			route{"10.4.0.0/16", []*NextHop{nh("172.16.0.1", "")}},
+			route{"10.5.0.0/16", []*NextHop{nh("", "Ethernet2")}},
+		),
+		"r3": table(map[string]string{"192.168.0.3": "eth1", "10.0.0.3": "lo"},
+			route{"0.0.0.0/0", []*NextHop{nh("192.168.0.2", "eth1")}},
+		),
+	}
+}
+
+func TestTrace(t *testing.T) {
+	noR3 := tables()
+	delete(noR3, "r3")
+	tests := []struct {
+		desc    string
+		tables  map[string]*Table
+		src     string
+		dst     string
+		want    *Result
+		wantErr string
+	}{{
+		desc:   "delivered",
+		tables: tables(),
+		src:    "r1",
+		dst:    "10.0.0.3",
+		want: &Result{
+			Source:      "r1",
+			Destination: "10.0.0.3",
+			Status:      Delivered,
+			Path: []*Hop{
+				{Node: "r1", Prefix: "0.0.0.0/0", NextHop: "192.168.0.1", OutInterface: "ethernet-1/1"},
+				{Node: "r2", InInterface: "Ethernet1", Prefix: "10.0.0.3/32", NextHop: "192.168.0.3", OutInterface: "Ethernet2", ECMP: 2},
+				{Node: "r3", InInterface: "eth1"},
+			},
+		},
+	}, {
+		desc:   "source",
+		tables: tables(),
+		src:    "r2",
+		dst:    "10.0.0.2",
+		want: &Result{
+			Source:      "r2",
+			Destination: "10.0.0.2",
+			Status:      Delivered,
+			Path:        []*Hop{{Node: "r2"}},
+		},
+	}, {
+		desc:   "loop",
+		tables: tables(),
+		src:    "r1",
+		dst:    "10.2.0.1",
+		want: &Result{
+			Source:      "r1",
+			Destination: "10.2.0.1",
+			Status:      Loop,
+			Reason:      `node "r1" is already on the path`,
+			Path: []*Hop{
+				{Node: "r1", Prefix: "0.0.0.0/0", NextHop: "192.168.0.1", OutInterface: "ethernet-1/1"},
+				{Node: "r2", InInterface: "Ethernet1", Prefix: "10.2.0.0/16", NextHop: "192.168.0.0", OutInterface: "Ethernet1"},
+				{Node: "r1", InInterface: "ethernet-1/1"},
+			},
+		},
+	}, {
+		desc:   "discarded",
+		tables: tables(),
+		src:    "r2",
+		dst:    "10.1.2.3",
+		want: &Result{
+			Source:      "r2",
+			Destination: "10.1.2.3",
+			Status:      Blackhole,
+			Reason:      `node "r2" discards traffic to 10.1.0.0/16`,
+			Path:        []*Hop{{Node: "r2", Prefix: "10.1.0.0/16"}},
+		},
+	}, {
+		desc:   "no route",
+		tables: tables(),
+		src:    "r2",
+		dst:    "172.16.0.1",
+		want: &Result{
+			Source:      "r2",
+			Destination: "172.16.0.1",
+			Status:      Blackhole,
+			Reason:      `node "r2" has no route to 172.16.0.1`,
+			Path:        []*Hop{{Node: "r2"}},
+		},
+	}, {
+		desc:   "unresolved",
+		tables: tables(),
+		src:    "r2",
+		dst:    "10.4.0.1",
+		want: &Result{
+			Source:      "r2",
+			Destination: "10.4.0.1",
+			Status:      Blackhole,
+			Reason:      `node "r2" cannot resolve next hop 172.16.0.1`,
+			Path:        []*Hop{{Node: "r2", Prefix: "10.4.0.0/16", NextHop: "172.16.0.1"}},
+		},
+	}, {
+		desc:   "exited",
+		tables: tables(),
+		src:    "r2",
+		dst:    "10.3.0.1",
+		want: &Result{
+			Source:      "r2",
+			Destination: "10.3.0.1",
+			Status:      Exited,
+			Reason:      `node "r2" forwards out of the topology on Ethernet3`,
+			Path:        []*Hop{{Node: "r2", Prefix: "10.3.0.0/16", OutInterface: "Ethernet3"}},
+		},
+	}, {
+		desc:   "connected to unknown",
+		tables: noR3,
+		src:    "r2",
+		dst:    "10.5.0.1",
+		want: &Result{
+			Source:      "r2",
+			Destination: "10.5.0.1",
+			Status:      Unknown,
+			Reason:      `no forwarding state for node "r3"`,
+			Path: []*Hop{
+				{Node: "r2", Prefix: "10.5.0.0/16", OutInterface: "Ethernet2"},
+				{Node: "r3", InInterface: "eth1"},
+			},
+		},
+	}, {
+		desc:    "unknown source",
+		tables:  tables(),
+		src:     "r4",
+		dst:     "10.0.0.1",
+		wantErr: `node "r4" not found`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Trace(line(), tt.tables, tt.src, netip.MustParseAddr(tt.dst))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Trace() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("Trace() unexpected result (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tbl := table(nil,
+		route{"10.0.0.0/8", []*NextHop{nh("192.168.0.1", "")}},
+		route{"10.1.0.0/16", []*NextHop{nh("192.168.0.3", ""), nh("192.168.0.5", "")}},
+		route{"192.168.0.0/24", []*NextHop{nh("", "eth1")}},
+	)
+	tbl.NextHopGroups[2] = []uint64{3, 2}
+	tests := []struct {
+		dst        string
+		wantPrefix string
+		wantNHs    []uint64
+		wantIntf   string
+	}{
+		{"10.2.0.1", "10.0.0.0/8", []uint64{1}, "eth1"},
+		{"10.1.0.1", "10.1.0.0/16", []uint64{2, 3}, "eth1"},
+		{"192.168.0.1", "192.168.0.0/24", []uint64{4}, "eth1"},
+		{"172.16.0.1", "", nil, ""},
+	}
+	for _, tt := range tests {
+		prefix, nhs, ok := tbl.Lookup(netip.MustParseAddr(tt.dst))
+		if ok != (tt.wantPrefix != "") || ok && prefix.String() != tt.wantPrefix {
+			t.Errorf("Lookup(%s) got prefix %v, %v, want %q", tt.dst, prefix, ok, tt.wantPrefix)
+		}
+		var got []uint64
+		for _, nh := range nhs {
+			got = append(got, nh.Index)
+		}
+		if s := cmp.Diff(tt.wantNHs, got); s != "" {
+			t.Errorf("Lookup(%s) unexpected next hops (-want +got):\n%s", tt.dst, s)
+		}
+		if len(nhs) > 0 {
+			if intf, _ := tbl.resolve(nhs[0]); intf != tt.wantIntf {
+				t.Errorf("resolve(%v) got interface %q, want %q", nhs[0], intf, tt.wantIntf)
+			}
+		}
+	}
+}
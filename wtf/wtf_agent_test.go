@@ -14,7 +14,9 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/openconfig/kne/trace"
 	"github.com/openconfig/ondatra"
 	"github.com/openconfig/ondatra/gnmi"
 	oc "github.com/openconfig/ondatra/gnmi/oc"
@@ -78,6 +80,9 @@ type RequestOutput struct {
 	TracedIP  string           // for KNE scoping
 	RequestID string           // for Istio scoping
 	Paths     [][]OnPathRouter // for KNE scoping
+	// When the request was traced, for the OTLP spans
+	Start time.Time `json:"-"`
+	End   time.Time `json:"-"`
 }
 
 // Info about all traced requests, for use by KNE and Istio plot scripts
@@ -526,8 +531,11 @@ func getPath(t *testing.T, src_dut *ondatra.DUTDevice, dest_ip string) []OnPathR
 func traceRequest(t *testing.T, src_dut *ondatra.DUTDevice, request_id string, paths [][]OnPathRouter) {
 	// Send a trace request. This doesn't affect scoping in the network (hence don't care about output),
 	// but it's more realistic to send from the router.
+	start := time.Now()
 	curlIstioGateway(t, "WTFTRACE-"+request_id, src_dut)
 	var out RequestOutput
+	out.Start = start
+	out.End = time.Now()
 	out.TracedIP = gateway_ip
 	out.Paths = paths
 	out.RequestID = request_id
@@ -544,4 +552,40 @@ func TestWriteOutput(t *testing.T) {
 	if err := os.WriteFile(outfile, b, 0666); err != nil {
 		t.Fatalf("Error writing output: %v\n", err)
 	}
+	// Also write the network side of the traces as OTLP/JSON spans, to be
+	// joined with the Istio spans of the same x-request-id
+	otlpfile := strings.TrimSuffix(outfile, ".json") + ".otlp.json"
+	if err := writeOTLP(otlpfile, global_output); err != nil {
+		t.Fatalf("Error writing OTLP output: %v\n", err)
+	}
+}
+
+// Convert the output to traced requests and write them as OTLP/JSON spans
+func writeOTLP(path string, output Output) error {
+	exporter := &trace.Exporter{ServiceName: "wtf-kne", BadInterfaces: map[string][]string{}}
+	for _, router := range output.AllRouters {
+		if len(router.BadIfaces) > 0 {
+			exporter.BadInterfaces[router.Name] = router.BadIfaces
+		}
+	}
+	requests := []*trace.Request{}
+	for _, out := range output.Requests {
+		request := &trace.Request{ID: out.RequestID, Start: out.Start, End: out.End}
+		for _, path := range out.Paths {
+			if len(path) == 0 {
+				continue
+			}
+			result := &trace.Result{Source: path[0].Name, Destination: out.TracedIP}
+			for _, router := range path {
+				result.Path = append(result.Path, &trace.Hop{
+					Node:         router.Name,
+					InInterface:  router.InIface.Name,
+					OutInterface: router.OutIface.Name,
+				})
+			}
+			request.Paths = append(request.Paths, result)
+		}
+		requests = append(requests, request)
+	}
+	return exporter.WriteFile(path, requests)
 }